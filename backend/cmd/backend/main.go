@@ -102,7 +102,7 @@ func main() {
 	}
 
 	databaseTxnManager := database.NewTxManager(srv.DB.Pool)
-	balanceUpdater := account.NewBalanceUpdater(queries)
+	balanceUpdater := account.NewBalanceUpdater(queries, databaseTxnManager)
 
 	userModule := user.NewModule(user.Deps{
 		Server:     srv,
@@ -110,14 +110,18 @@ func main() {
 		TxnManager: databaseTxnManager,
 	})
 
-	reconciliationModule := reconciliation.NewReconiliationModule(reconciliation.Deps{
+	reconDeps := reconciliation.Deps{
 		Server:         srv,
 		Queries:        queries,
 		TxnManager:     databaseTxnManager,
 		TaskService:    taskService,
 		BalanceUpdater: balanceUpdater,
 		UserService:    userModule.GetUserService(),
-	})
+	}
+	if globalSvcs != nil && globalSvcs.GeminiService != nil {
+		reconDeps.StatementLLM = globalSvcs.GeminiService
+	}
+	reconciliationModule := reconciliation.NewReconiliationModule(reconDeps)
 
 	investmentModule := investment.NewInvestmentModule(investment.Deps{
 		Server:      srv,
@@ -164,6 +168,7 @@ func main() {
 		Server:     srv,
 		Queries:    queries,
 		AccQueries: queries,
+		TxnManager: databaseTxnManager,
 		UserSvc:    userModule.GetUserService(),
 		TxnSvc:     transactionModule.GetService(),
 		LlmTaskSvc: taskService,
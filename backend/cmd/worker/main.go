@@ -47,7 +47,7 @@ func main() {
 	jobModule := jobs.NewModule(jobs.Deps{Queries: queries})
 
 	txnManager := database.NewTxManager(db.Pool)
-	balanceUpdater := account.NewBalanceUpdater(queries)
+	balanceUpdater := account.NewBalanceUpdater(queries, txnManager)
 
 	userModule := user.NewModule(user.Deps{
 		Queries:    queries,
@@ -73,14 +73,18 @@ func main() {
 		AutoLinker:     investmentModule.GetService(),
 	})
 
-	reconModule := reconciliation.NewReconiliationModule(reconciliation.Deps{
+	reconDeps := reconciliation.Deps{
 		Queries:        queries,
 		TxnManager:     txnManager,
 		BalanceUpdater: balanceUpdater,
 		UserService:    userModule.GetUserService(),
-	})
+	}
+	if globalSvcs.GeminiService != nil {
+		reconDeps.StatementLLM = globalSvcs.GeminiService
+	}
+	reconModule := reconciliation.NewReconiliationModule(reconDeps)
 
-	smsLlmService := sms.NewSmsLlmService(queries, globalSvcs.GeminiService, transactionModule.GetService())
+	smsLlmService := sms.NewSmsLlmService(queries, globalSvcs.GeminiService, transactionModule.GetService(), userModule.GetUserService())
 
 	w := worker.New(worker.Deps{
 		JobRepo:       jobModule.GetJobRepository(),
@@ -244,6 +244,49 @@ func (q *Queries) GetAccountsByUserId(ctx context.Context, userID string) ([]Get
 	return items, nil
 }
 
+const listAccountsByNumberSuffix = `-- name: ListAccountsByNumberSuffix :many
+SELECT id, user_id, bank_id, account_number, account_type, account_name, current_balance, is_primary, is_active, created_at, updated_at, deleted_at FROM accounts
+WHERE user_id = $1 AND account_number LIKE '%' || $2::text AND deleted_at IS NULL
+`
+
+type ListAccountsByNumberSuffixParams struct {
+	UserID string
+	Suffix string
+}
+
+func (q *Queries) ListAccountsByNumberSuffix(ctx context.Context, arg ListAccountsByNumberSuffixParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listAccountsByNumberSuffix, arg.UserID, arg.Suffix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Account
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.BankID,
+			&i.AccountNumber,
+			&i.AccountType,
+			&i.AccountName,
+			&i.CurrentBalance,
+			&i.IsPrimary,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts a 
 SET
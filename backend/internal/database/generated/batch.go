@@ -180,6 +180,61 @@ func (b *InsertReconciliationResultBatchBatchResults) Close() error {
 	return b.br.Close()
 }
 
+const insertReconciliationMatchMemberBatch = `-- name: InsertReconciliationMatchMemberBatch :batchexec
+INSERT INTO reconciliation_match_members (
+    reconciliation_id, statement_transaction_id, app_transaction_id, amount
+) VALUES ($1, $2, $3, $4)
+`
+
+type InsertReconciliationMatchMemberBatchBatchResults struct {
+	br     pgx.BatchResults
+	tot    int
+	closed bool
+}
+
+type InsertReconciliationMatchMemberBatchParams struct {
+	ReconciliationID       pgtype.UUID
+	StatementTransactionID pgtype.UUID
+	AppTransactionID       pgtype.UUID
+	Amount                 pgtype.Numeric
+}
+
+func (q *Queries) InsertReconciliationMatchMemberBatch(ctx context.Context, arg []InsertReconciliationMatchMemberBatchParams) *InsertReconciliationMatchMemberBatchBatchResults {
+	batch := &pgx.Batch{}
+	for _, a := range arg {
+		vals := []interface{}{
+			a.ReconciliationID,
+			a.StatementTransactionID,
+			a.AppTransactionID,
+			a.Amount,
+		}
+		batch.Queue(insertReconciliationMatchMemberBatch, vals...)
+	}
+	br := q.db.SendBatch(ctx, batch)
+	return &InsertReconciliationMatchMemberBatchBatchResults{br, len(arg), false}
+}
+
+func (b *InsertReconciliationMatchMemberBatchBatchResults) Exec(f func(int, error)) {
+	defer b.br.Close()
+	for t := 0; t < b.tot; t++ {
+		if b.closed {
+			if f != nil {
+				f(t, ErrBatchAlreadyClosed)
+			}
+			continue
+		}
+		_, err := b.br.Exec()
+		if f != nil {
+			f(t, err)
+		}
+	}
+}
+
+func (b *InsertReconciliationMatchMemberBatchBatchResults) Close() error {
+	b.closed = true
+	return b.br.Close()
+}
+
 const insertStatementTransactionsBatch = `-- name: InsertStatementTransactionsBatch :batchone
 INSERT INTO statement_transactions (
     upload_id, account_id, transaction_date, description, amount, type,
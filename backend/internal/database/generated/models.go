@@ -63,6 +63,8 @@ const (
 	JobTypeBANKRECONCILIATION JobType = "BANK_RECONCILIATION"
 	JobTypeREPORTS            JobType = "REPORTS"
 	JobTypeINVESTMENTAUTOLINK JobType = "INVESTMENT_AUTO_LINK"
+	JobTypeLLMSMSPARSE        JobType = "LLM_SMS_PARSE"
+	JobTypeRECONCALIBRATION   JobType = "RECON_CALIBRATION"
 )
 
 func (e *JobType) Scan(src interface{}) error {
@@ -151,6 +153,7 @@ const (
 	ReconciliationResultTypeNOTINSTATEMENT      ReconciliationResultType = "NOT_IN_STATEMENT"
 	ReconciliationResultTypeMANUALLYMATCHED     ReconciliationResultType = "MANUALLY_MATCHED"
 	ReconciliationResultTypeCASHTRANSACTION     ReconciliationResultType = "CASH_TRANSACTION"
+	ReconciliationResultTypeSPLITMATCH          ReconciliationResultType = "SPLIT_MATCH"
 )
 
 func (e *ReconciliationResultType) Scan(src interface{}) error {
@@ -431,6 +434,22 @@ type BankStatementUpload struct {
 	// Array of parsing errors: [{"row": 23, "error": "Invalid date", "data": {...}}]
 	ParsingErrors []byte
 	JobID         pgtype.UUID
+	// Running balance on the last statement row, when the statement has a balance column
+	ClosingBalance pgtype.Numeric
+	// closing_balance minus accounts.current_balance at parse time
+	BalanceDrift pgtype.Numeric
+	// Statement rows the reconciliation job has persisted results for so far
+	ProcessedRows pgtype.Int4
+	// Date-ordered chunks the reconciliation job has committed so far
+	ProcessedChunks pgtype.Int4
+	// Billing cycle close date printed on a credit card statement
+	StatementDate pgtype.Date
+	// Date the credit card bill must be paid by
+	PaymentDueDate pgtype.Date
+	// Minimum amount due for the billing cycle
+	MinimumDue pgtype.Numeric
+	// Total amount due (outstanding) for the billing cycle
+	TotalDue pgtype.Numeric
 }
 
 type Category struct {
@@ -514,6 +533,29 @@ type Job struct {
 	UpdatedAt      pgtype.Timestamp
 }
 
+// One row per LLM parse credit taken or given back
+type LlmCreditLedger struct {
+	ID     pgtype.UUID
+	UserID string
+	// What the credit paid for: sms_parse, txn_image_parse or statement_parse
+	Feature string
+	// -1 for a reservation, +1 for a refund
+	Delta        int32
+	BalanceAfter int32
+	// SMS or statement upload the credit was spent on, when there is one
+	ReferenceID pgtype.UUID
+	// Reservation a refund gives back
+	RefundOf  pgtype.UUID
+	CreatedAt pgtype.Timestamp
+}
+
+// Plans a user can be on, with the LLM parse credits each grants per calendar month
+type LlmPlan struct {
+	Name           string
+	MonthlyCredits int32
+	CreatedAt      pgtype.Timestamp
+}
+
 type Merchant struct {
 	ID                pgtype.UUID
 	Name              string
@@ -561,6 +603,44 @@ type NotificationPreference struct {
 	UpdatedAt        pgtype.Timestamp
 }
 
+// Progress of each reconciliation job run so a retried invocation can resume or no-op
+type ReconciliationJobCheckpoint struct {
+	JobID    string
+	UploadID pgtype.UUID
+	// STARTED: nothing committed yet; COMMITTED: auto-creates, balances and results written; COMPLETED: upload marked COMPLETED
+	Stage           string
+	Attempts        int32
+	CreatedTxnIds   []pgtype.UUID
+	CreatedAt       pgtype.Timestamp
+	UpdatedAt       pgtype.Timestamp
+	ChunksCommitted int32
+}
+
+// Every statement row and app transaction taking part in a SPLIT_MATCH result
+type ReconciliationMatchMember struct {
+	ID                     pgtype.UUID
+	ReconciliationID       pgtype.UUID
+	StatementTransactionID pgtype.UUID
+	AppTransactionID       pgtype.UUID
+	Amount                 pgtype.Numeric
+	CreatedAt              pgtype.Timestamp
+}
+
+// Match scoring weights, date window and amount tolerance for a user (account_id NULL) or one of their accounts
+type ReconciliationScoringProfile struct {
+	ID        pgtype.UUID
+	UserID    string
+	AccountID pgtype.UUID
+	Profile   []byte
+	// When false the calibration job only refreshes calibration_stats and leaves profile as the user set it
+	AutoCalibrate bool
+	// What the last calibration learned from the user's accepted and rejected results
+	CalibrationStats []byte
+	CalibratedAt     pgtype.Timestamp
+	CreatedAt        pgtype.Timestamp
+	UpdatedAt        pgtype.Timestamp
+}
+
 type RecurringTransaction struct {
 	ID                    pgtype.UUID
 	UserID                string
@@ -601,6 +681,35 @@ type SmsLog struct {
 	CreatedAt         pgtype.Timestamp
 	LastRetryAt       pgtype.Timestamp
 	UpdatedAt         pgtype.Timestamp
+	// Server-side SMS template that parsed the message, NULL when it was parsed on the device or by the LLM
+	ParsedTemplate pgtype.Text
+	// Available balance the bank printed in the SMS
+	AvailableBalance pgtype.Numeric
+	// Existing transaction this SMS repeats, linked instead of creating another
+	MergedTransactionID pgtype.UUID
+	// Transaction parsed from a merged SMS, kept so the merge can be split back apart
+	ParsedTxn []byte
+	// ID the device gave the message, so a resent batch does not store it twice
+	ClientMessageID pgtype.Text
+}
+
+type SmsTemplate struct {
+	ID     pgtype.UUID
+	UserID string
+	// Sender header without the operator prefix, e.g. HDFCBK
+	Sender  string
+	Pattern string
+	TxnType TxnType
+	// candidate while it is checked against LLM parses, active once it parses SMS in place of the LLM
+	Status string
+	// LLM parses it agreed with while a candidate, plus SMS it parsed once active
+	Hits int32
+	// LLM or device parses of messages it matched that it read differently; an active template is demoted once they pass a threshold
+	Misses     int32
+	LastSeenAt pgtype.Timestamp
+	PromotedAt pgtype.Timestamp
+	CreatedAt  pgtype.Timestamp
+	UpdatedAt  pgtype.Timestamp
 }
 
 type SpendingLimit struct {
@@ -619,6 +728,16 @@ type SpendingLimit struct {
 	UpdatedAt      pgtype.Timestamp
 }
 
+type StatementFormatProfile struct {
+	ID     pgtype.UUID
+	UserID string
+	BankID pgtype.UUID
+	// User-defined column mapping for a bank not covered by the built-in statement profiles
+	Profile   []byte
+	CreatedAt pgtype.Timestamp
+	UpdatedAt pgtype.Timestamp
+}
+
 type StatementTransaction struct {
 	ID              pgtype.UUID
 	UploadID        pgtype.UUID
@@ -640,6 +759,15 @@ type StatementTransaction struct {
 	DeletedAt   pgtype.Timestamptz
 }
 
+// Statement files whose rows the reconciliation job still has to extract; removed once the rows are stored, when the job fails, or a day after upload
+type StatementUploadFile struct {
+	UploadID pgtype.UUID
+	MimeType string
+	// The uploaded file, or the text of a password-protected PDF
+	Content   []byte
+	CreatedAt pgtype.Timestamp
+}
+
 type Transaction struct {
 	ID                   pgtype.UUID
 	UserID               string
@@ -728,6 +856,10 @@ type User struct {
 	// Confidence threshold (0-100) for auto-verification. Default: 70
 	ReconciliationThreshold pgtype.Int4
 	MonthlyBudget           pgtype.Numeric
+	// Plan that sets how many LLM parse credits the user gets each month
+	LlmPlan string
+	// Start of the month llm_parse_credits was last topped up for; NULL until the first LLM parse
+	LlmCreditsResetAt pgtype.Timestamp
 }
 
 type UserNotification struct {
@@ -11,6 +11,67 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addUploadChunkProgress = `-- name: AddUploadChunkProgress :exec
+UPDATE bank_statement_uploads
+SET
+    processed_rows         = processed_rows + $2,
+    processed_chunks       = processed_chunks + 1,
+    matched_transactions   = matched_transactions + $3,
+    unmatched_transactions = unmatched_transactions + $4,
+    missing_transactions   = missing_transactions + $5,
+    updated_at             = NOW()
+WHERE id = $1
+`
+
+type AddUploadChunkProgressParams struct {
+	ID                    pgtype.UUID
+	ProcessedRows         pgtype.Int4
+	MatchedTransactions   pgtype.Int4
+	UnmatchedTransactions pgtype.Int4
+	MissingTransactions   pgtype.Int4
+}
+
+func (q *Queries) AddUploadChunkProgress(ctx context.Context, arg AddUploadChunkProgressParams) error {
+	_, err := q.db.Exec(ctx, addUploadChunkProgress,
+		arg.ID,
+		arg.ProcessedRows,
+		arg.MatchedTransactions,
+		arg.UnmatchedTransactions,
+		arg.MissingTransactions,
+	)
+	return err
+}
+
+const advanceReconciliationJobCheckpoint = `-- name: AdvanceReconciliationJobCheckpoint :execrows
+UPDATE reconciliation_job_checkpoints
+SET chunks_committed = chunks_committed + 1,
+    created_txn_ids  = created_txn_ids || $3::uuid[],
+    stage            = CASE WHEN $4::boolean THEN 'COMMITTED' ELSE stage END,
+    updated_at       = NOW()
+WHERE job_id = $1 AND stage = 'STARTED' AND chunks_committed = $2
+`
+
+type AdvanceReconciliationJobCheckpointParams struct {
+	JobID           string
+	ChunksCommitted int32
+	CreatedTxnIds   []pgtype.UUID
+	Final           bool
+}
+
+// Records one committed chunk, and COMMITTED after the last; matches nothing if another attempt advanced the job first.
+func (q *Queries) AdvanceReconciliationJobCheckpoint(ctx context.Context, arg AdvanceReconciliationJobCheckpointParams) (int64, error) {
+	result, err := q.db.Exec(ctx, advanceReconciliationJobCheckpoint,
+		arg.JobID,
+		arg.ChunksCommitted,
+		arg.CreatedTxnIds,
+		arg.Final,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const bulkUpdateReconciliationResultStatus = `-- name: BulkUpdateReconciliationResultStatus :many
 UPDATE transaction_reconciliation tr
 SET
@@ -23,7 +84,7 @@ WHERE tr.id = ANY($1::uuid[])
   AND tr.upload_id = bsu.id
   AND tr.upload_id = $4
   AND bsu.user_id = $3
-RETURNING tr.id, tr.user_action
+RETURNING tr.id, tr.user_action, tr.reviewed_by, tr.reviewed_at
 `
 
 type BulkUpdateReconciliationResultStatusParams struct {
@@ -36,6 +97,8 @@ type BulkUpdateReconciliationResultStatusParams struct {
 type BulkUpdateReconciliationResultStatusRow struct {
 	ID         pgtype.UUID
 	UserAction pgtype.Text
+	ReviewedBy NullReconciliationActor
+	ReviewedAt pgtype.Timestamp
 }
 
 func (q *Queries) BulkUpdateReconciliationResultStatus(ctx context.Context, arg BulkUpdateReconciliationResultStatusParams) ([]BulkUpdateReconciliationResultStatusRow, error) {
@@ -52,7 +115,12 @@ func (q *Queries) BulkUpdateReconciliationResultStatus(ctx context.Context, arg
 	var items []BulkUpdateReconciliationResultStatusRow
 	for rows.Next() {
 		var i BulkUpdateReconciliationResultStatusRow
-		if err := rows.Scan(&i.ID, &i.UserAction); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserAction,
+			&i.ReviewedBy,
+			&i.ReviewedAt,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -63,10 +131,53 @@ func (q *Queries) BulkUpdateReconciliationResultStatus(ctx context.Context, arg
 	return items, nil
 }
 
+const claimUploadForReprocess = `-- name: ClaimUploadForReprocess :one
+UPDATE bank_statement_uploads u
+SET processing_status = 'UPLOADED', updated_at = NOW()
+WHERE u.id = $1 AND u.user_id = $2
+  AND (u.processing_status IS NULL
+    OR u.processing_status NOT IN ('UPLOADED', 'PROCESSING')
+    OR u.updated_at < NOW() - INTERVAL '1 hour'
+    OR NOT EXISTS (
+      SELECT 1 FROM jobs j
+      WHERE j.job_type = 'BANK_RECONCILIATION'
+        AND j.status IN ('pending', 'processing')
+        AND j.payload->>'upload_id' = u.id::text
+    ))
+RETURNING u.id
+`
+
+type ClaimUploadForReprocessParams struct {
+	ID     pgtype.UUID
+	UserID string
+}
+
+// ClaimUploadForReprocess moves an upload back to UPLOADED unless a reconciliation is
+// still queued or running for it, in which case no row is returned. An upload left
+// UPLOADED or PROCESSING with no live job, or untouched for an hour, can be claimed.
+func (q *Queries) ClaimUploadForReprocess(ctx context.Context, arg ClaimUploadForReprocessParams) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, claimUploadForReprocess, arg.ID, arg.UserID)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const completeReconciliationJobCheckpoint = `-- name: CompleteReconciliationJobCheckpoint :exec
+UPDATE reconciliation_job_checkpoints
+SET stage = 'COMPLETED', updated_at = NOW()
+WHERE job_id = $1
+`
+
+func (q *Queries) CompleteReconciliationJobCheckpoint(ctx context.Context, jobID string) error {
+	_, err := q.db.Exec(ctx, completeReconciliationJobCheckpoint, jobID)
+	return err
+}
+
 const countReconciliationResultsByUploadID = `-- name: CountReconciliationResultsByUploadID :one
 SELECT COUNT(*) FROM transaction_reconciliation tr
-JOIN statement_transactions st ON st.id = tr.statement_transaction_id AND st.deleted_at IS NULL
+LEFT JOIN statement_transactions st ON st.id = tr.statement_transaction_id AND st.deleted_at IS NULL
 WHERE tr.upload_id = $1
+  AND (tr.statement_transaction_id IS NULL OR st.id IS NOT NULL)
 `
 
 func (q *Queries) CountReconciliationResultsByUploadID(ctx context.Context, uploadID pgtype.UUID) (int64, error) {
@@ -88,6 +199,19 @@ func (q *Queries) CountStatementTransactionsByUploadID(ctx context.Context, uplo
 	return count, err
 }
 
+const countStatementTransactionsForProcessing = `-- name: CountStatementTransactionsForProcessing :one
+SELECT COUNT(*) FROM statement_transactions
+WHERE upload_id = $1 AND is_duplicate = false AND deleted_at IS NULL
+  AND transaction_date IS NOT NULL
+`
+
+func (q *Queries) CountStatementTransactionsForProcessing(ctx context.Context, uploadID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countStatementTransactionsForProcessing, uploadID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createBankStatementUpload = `-- name: CreateBankStatementUpload :one
 INSERT INTO bank_statement_uploads (
     user_id, account_id, file_name, file_type, file_size,
@@ -138,6 +262,69 @@ func (q *Queries) DeleteBankStatementUploadByID(ctx context.Context, arg DeleteB
 	return err
 }
 
+const deleteExpiredStatementUploadFiles = `-- name: DeleteExpiredStatementUploadFiles :execrows
+WITH expired AS (
+    DELETE FROM statement_upload_files
+    WHERE created_at < NOW() - INTERVAL '1 day'
+    RETURNING upload_id
+)
+UPDATE bank_statement_uploads u
+SET processing_status = 'FAILED', updated_at = NOW()
+FROM expired
+WHERE u.id = expired.upload_id
+`
+
+// DeleteExpiredStatementUploadFiles removes statement files still waiting to be
+// extracted a day after upload and marks their uploads FAILED.
+func (q *Queries) DeleteExpiredStatementUploadFiles(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredStatementUploadFiles)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteNotInStatementResult = `-- name: DeleteNotInStatementResult :exec
+DELETE FROM transaction_reconciliation
+WHERE upload_id = $1
+  AND app_transaction_id = $2
+  AND result_type = 'NOT_IN_STATEMENT'
+`
+
+type DeleteNotInStatementResultParams struct {
+	UploadID         pgtype.UUID
+	AppTransactionID pgtype.UUID
+}
+
+func (q *Queries) DeleteNotInStatementResult(ctx context.Context, arg DeleteNotInStatementResultParams) error {
+	_, err := q.db.Exec(ctx, deleteNotInStatementResult, arg.UploadID, arg.AppTransactionID)
+	return err
+}
+
+const deleteStatementUploadFile = `-- name: DeleteStatementUploadFile :exec
+DELETE FROM statement_upload_files WHERE upload_id = $1
+`
+
+func (q *Queries) DeleteStatementUploadFile(ctx context.Context, uploadID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteStatementUploadFile, uploadID)
+	return err
+}
+
+const deleteSystemReconciliationResults = `-- name: DeleteSystemReconciliationResults :execrows
+DELETE FROM transaction_reconciliation
+WHERE upload_id = $1
+  AND user_action IS NULL
+  AND result_type <> 'MANUALLY_MATCHED'
+`
+
+func (q *Queries) DeleteSystemReconciliationResults(ctx context.Context, uploadID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteSystemReconciliationResults, uploadID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteTransactionReconciliationByUploadID = `-- name: DeleteTransactionReconciliationByUploadID :exec
 DELETE FROM transaction_reconciliation WHERE upload_id = $1
 `
@@ -148,6 +335,72 @@ func (q *Queries) DeleteTransactionReconciliationByUploadID(ctx context.Context,
 	return err
 }
 
+const findMerchantsByKeys = `-- name: FindMerchantsByKeys :many
+SELECT id, regexp_replace(lower(COALESCE(normalized_name, name)), '[^a-z0-9]', '', 'g')::text AS merchant_key
+FROM merchants
+WHERE regexp_replace(lower(COALESCE(normalized_name, name)), '[^a-z0-9]', '', 'g') = ANY($1::text[])
+`
+
+type FindMerchantsByKeysRow struct {
+	ID          pgtype.UUID
+	MerchantKey string
+}
+
+// Merchants whose alphanumeric-only lowercased name matches one of the narration keys.
+func (q *Queries) FindMerchantsByKeys(ctx context.Context, dollar_1 []string) ([]FindMerchantsByKeysRow, error) {
+	rows, err := q.db.Query(ctx, findMerchantsByKeys, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FindMerchantsByKeysRow
+	for rows.Next() {
+		var i FindMerchantsByKeysRow
+		if err := rows.Scan(&i.ID, &i.MerchantKey); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAccountCurrentBalance = `-- name: GetAccountCurrentBalance :one
+SELECT current_balance FROM accounts
+WHERE id = $1 AND user_id = $2
+`
+
+type GetAccountCurrentBalanceParams struct {
+	ID     pgtype.UUID
+	UserID string
+}
+
+func (q *Queries) GetAccountCurrentBalance(ctx context.Context, arg GetAccountCurrentBalanceParams) (pgtype.Numeric, error) {
+	row := q.db.QueryRow(ctx, getAccountCurrentBalance, arg.ID, arg.UserID)
+	var current_balance pgtype.Numeric
+	err := row.Scan(&current_balance)
+	return current_balance, err
+}
+
+const getAccountType = `-- name: GetAccountType :one
+SELECT account_type FROM accounts
+WHERE id = $1 AND user_id = $2
+`
+
+type GetAccountTypeParams struct {
+	ID     pgtype.UUID
+	UserID string
+}
+
+func (q *Queries) GetAccountType(ctx context.Context, arg GetAccountTypeParams) (string, error) {
+	row := q.db.QueryRow(ctx, getAccountType, arg.ID, arg.UserID)
+	var account_type string
+	err := row.Scan(&account_type)
+	return account_type, err
+}
+
 const getBankStatementUploadByID = `-- name: GetBankStatementUploadByID :one
 SELECT id, user_id, account_id, file_name, upload_status, processing_status,
        statement_period_start, statement_period_end, created_at, updated_at
@@ -191,6 +444,95 @@ func (q *Queries) GetBankStatementUploadByID(ctx context.Context, arg GetBankSta
 	return i, err
 }
 
+const getCardIdentity = `-- name: GetCardIdentity :one
+SELECT a.account_number,
+       COALESCE(b.name, '')::text AS bank_name,
+       COALESCE(b.code, '')::text AS bank_code
+FROM accounts a
+LEFT JOIN banks b ON b.id = a.bank_id
+WHERE a.id = $1 AND a.user_id = $2
+`
+
+type GetCardIdentityParams struct {
+	ID     pgtype.UUID
+	UserID string
+}
+
+type GetCardIdentityRow struct {
+	AccountNumber string
+	BankName      string
+	BankCode      string
+}
+
+// GetCardIdentity returns what a bill payment narration may name a card by: its
+// number and its bank's name and code.
+func (q *Queries) GetCardIdentity(ctx context.Context, arg GetCardIdentityParams) (GetCardIdentityRow, error) {
+	row := q.db.QueryRow(ctx, getCardIdentity, arg.ID, arg.UserID)
+	var i GetCardIdentityRow
+	err := row.Scan(&i.AccountNumber, &i.BankName, &i.BankCode)
+	return i, err
+}
+
+const getReconciliationResultForMatch = `-- name: GetReconciliationResultForMatch :one
+SELECT tr.id, tr.upload_id, tr.result_type, tr.app_transaction_id, bsu.account_id,
+       st.id AS statement_transaction_id, st.transaction_date, st.description,
+       st.amount, st.type, st.reference_number,
+       t.source AS app_source, t.type AS app_type, t.amount AS app_amount,
+       t.deleted_at AS app_deleted_at
+FROM transaction_reconciliation tr
+JOIN bank_statement_uploads bsu ON bsu.id = tr.upload_id
+JOIN statement_transactions st ON st.id = tr.statement_transaction_id AND st.deleted_at IS NULL
+LEFT JOIN transactions t ON t.id = tr.app_transaction_id
+WHERE tr.id = $1
+  AND bsu.user_id = $2
+`
+
+type GetReconciliationResultForMatchParams struct {
+	ID     pgtype.UUID
+	UserID string
+}
+
+type GetReconciliationResultForMatchRow struct {
+	ID                     pgtype.UUID
+	UploadID               pgtype.UUID
+	ResultType             ReconciliationResultType
+	AppTransactionID       pgtype.UUID
+	AccountID              pgtype.UUID
+	StatementTransactionID pgtype.UUID
+	TransactionDate        pgtype.Timestamptz
+	Description            pgtype.Text
+	Amount                 pgtype.Numeric
+	Type                   string
+	ReferenceNumber        pgtype.Text
+	AppSource              NullTransactionSource
+	AppType                NullTxnType
+	AppAmount              pgtype.Numeric
+	AppDeletedAt           pgtype.Timestamp
+}
+
+func (q *Queries) GetReconciliationResultForMatch(ctx context.Context, arg GetReconciliationResultForMatchParams) (GetReconciliationResultForMatchRow, error) {
+	row := q.db.QueryRow(ctx, getReconciliationResultForMatch, arg.ID, arg.UserID)
+	var i GetReconciliationResultForMatchRow
+	err := row.Scan(
+		&i.ID,
+		&i.UploadID,
+		&i.ResultType,
+		&i.AppTransactionID,
+		&i.AccountID,
+		&i.StatementTransactionID,
+		&i.TransactionDate,
+		&i.Description,
+		&i.Amount,
+		&i.Type,
+		&i.ReferenceNumber,
+		&i.AppSource,
+		&i.AppType,
+		&i.AppAmount,
+		&i.AppDeletedAt,
+	)
+	return i, err
+}
+
 const getReconciliationResultsByUploadID = `-- name: GetReconciliationResultsByUploadID :many
 SELECT
     tr.id,
@@ -208,11 +550,18 @@ SELECT
     st.amount            AS stmt_amount,
     st.type              AS stmt_type,
     st.reference_number  AS stmt_reference_number,
-    st.row_number        AS stmt_row_number
+    st.row_number        AS stmt_row_number,
+    t.transaction_date   AS app_date,
+    t.description        AS app_description,
+    t.amount             AS app_amount,
+    t.type               AS app_type,
+    t.source             AS app_source
 FROM transaction_reconciliation tr
-JOIN statement_transactions st ON st.id = tr.statement_transaction_id AND st.deleted_at IS NULL
+LEFT JOIN statement_transactions st ON st.id = tr.statement_transaction_id AND st.deleted_at IS NULL
+LEFT JOIN transactions t ON t.id = tr.app_transaction_id
 WHERE tr.upload_id = $1
-ORDER BY st.transaction_date ASC, tr.result_type ASC
+  AND (tr.statement_transaction_id IS NULL OR st.id IS NOT NULL)
+ORDER BY COALESCE(st.transaction_date, t.transaction_date) ASC, tr.result_type ASC
 LIMIT $2 OFFSET $3
 `
 
@@ -236,9 +585,14 @@ type GetReconciliationResultsByUploadIDRow struct {
 	StmtDate               pgtype.Timestamptz
 	StmtDescription        pgtype.Text
 	StmtAmount             pgtype.Numeric
-	StmtType               string
+	StmtType               pgtype.Text
 	StmtReferenceNumber    pgtype.Text
-	StmtRowNumber          int32
+	StmtRowNumber          pgtype.Int4
+	AppDate                pgtype.Timestamp
+	AppDescription         pgtype.Text
+	AppAmount              pgtype.Numeric
+	AppType                NullTxnType
+	AppSource              NullTransactionSource
 }
 
 func (q *Queries) GetReconciliationResultsByUploadID(ctx context.Context, arg GetReconciliationResultsByUploadIDParams) ([]GetReconciliationResultsByUploadIDRow, error) {
@@ -267,6 +621,79 @@ func (q *Queries) GetReconciliationResultsByUploadID(ctx context.Context, arg Ge
 			&i.StmtType,
 			&i.StmtReferenceNumber,
 			&i.StmtRowNumber,
+			&i.AppDate,
+			&i.AppDescription,
+			&i.AppAmount,
+			&i.AppType,
+			&i.AppSource,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReconciliationReviewTargets = `-- name: GetReconciliationReviewTargets :many
+SELECT tr.id AS reconciliation_id, tr.result_type, tr.statement_transaction_id,
+       t.id AS app_transaction_id, t.account_id, t.to_account_id, t.type, t.amount, t.source,
+       t.reconciliation_status, t.deleted_at, bsu.account_id AS upload_account_id
+FROM transaction_reconciliation tr
+JOIN bank_statement_uploads bsu ON bsu.id = tr.upload_id
+LEFT JOIN reconciliation_match_members rmm
+       ON rmm.reconciliation_id = tr.id AND rmm.app_transaction_id IS NOT NULL
+JOIN transactions t ON t.id = COALESCE(rmm.app_transaction_id, tr.app_transaction_id)
+WHERE tr.id = ANY($1::uuid[])
+  AND tr.upload_id = $2
+  AND bsu.user_id = $3
+`
+
+type GetReconciliationReviewTargetsParams struct {
+	Column1  []pgtype.UUID
+	UploadID pgtype.UUID
+	UserID   string
+}
+
+type GetReconciliationReviewTargetsRow struct {
+	ReconciliationID       pgtype.UUID
+	ResultType             ReconciliationResultType
+	StatementTransactionID pgtype.UUID
+	AppTransactionID       pgtype.UUID
+	AccountID              pgtype.UUID
+	ToAccountID            pgtype.UUID
+	Type                   TxnType
+	Amount                 pgtype.Numeric
+	Source                 NullTransactionSource
+	ReconciliationStatus   NullTransactionReconciliationStatus
+	DeletedAt              pgtype.Timestamp
+	UploadAccountID        pgtype.UUID
+}
+
+func (q *Queries) GetReconciliationReviewTargets(ctx context.Context, arg GetReconciliationReviewTargetsParams) ([]GetReconciliationReviewTargetsRow, error) {
+	rows, err := q.db.Query(ctx, getReconciliationReviewTargets, arg.Column1, arg.UploadID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReconciliationReviewTargetsRow
+	for rows.Next() {
+		var i GetReconciliationReviewTargetsRow
+		if err := rows.Scan(
+			&i.ReconciliationID,
+			&i.ResultType,
+			&i.StatementTransactionID,
+			&i.AppTransactionID,
+			&i.AccountID,
+			&i.ToAccountID,
+			&i.Type,
+			&i.Amount,
+			&i.Source,
+			&i.ReconciliationStatus,
+			&i.DeletedAt,
+			&i.UploadAccountID,
 		); err != nil {
 			return nil, err
 		}
@@ -299,13 +726,26 @@ func (q *Queries) GetStatementDateRange(ctx context.Context, uploadID pgtype.UUI
 }
 
 const getStatementTransactionsForProcessing = `-- name: GetStatementTransactionsForProcessing :many
-SELECT id, upload_id, account_id, transaction_date,
-       description, amount, type, reference_number, raw_row_hash
-FROM statement_transactions
-WHERE upload_id = $1 AND is_duplicate = false AND deleted_at IS NULL
-ORDER BY transaction_date ASC
+SELECT st.id, st.upload_id, st.account_id, st.transaction_date,
+       st.description, st.amount, st.type, st.reference_number, st.raw_row_hash
+FROM statement_transactions st
+WHERE st.upload_id = $1 AND st.is_duplicate = false AND st.deleted_at IS NULL
+  AND st.transaction_date IS NOT NULL
+  AND NOT EXISTS (
+      SELECT 1 FROM transaction_reconciliation tr WHERE tr.statement_transaction_id = st.id
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM reconciliation_match_members m WHERE m.statement_transaction_id = st.id
+  )
+ORDER BY st.transaction_date ASC, st.id ASC
+LIMIT $2
 `
 
+type GetStatementTransactionsForProcessingParams struct {
+	UploadID pgtype.UUID
+	Limit    int32
+}
+
 type GetStatementTransactionsForProcessingRow struct {
 	ID              pgtype.UUID
 	UploadID        pgtype.UUID
@@ -318,8 +758,9 @@ type GetStatementTransactionsForProcessingRow struct {
 	RawRowHash      string
 }
 
-func (q *Queries) GetStatementTransactionsForProcessing(ctx context.Context, uploadID pgtype.UUID) ([]GetStatementTransactionsForProcessingRow, error) {
-	rows, err := q.db.Query(ctx, getStatementTransactionsForProcessing, uploadID)
+// Next date-ordered chunk of dated rows that have no reconciliation result yet.
+func (q *Queries) GetStatementTransactionsForProcessing(ctx context.Context, arg GetStatementTransactionsForProcessingParams) ([]GetStatementTransactionsForProcessingRow, error) {
+	rows, err := q.db.Query(ctx, getStatementTransactionsForProcessing, arg.UploadID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -348,10 +789,36 @@ func (q *Queries) GetStatementTransactionsForProcessing(ctx context.Context, upl
 	return items, nil
 }
 
+const getStatementUploadFile = `-- name: GetStatementUploadFile :one
+SELECT f.mime_type, f.content, u.total_due
+FROM statement_upload_files f
+JOIN bank_statement_uploads u ON u.id = f.upload_id
+WHERE f.upload_id = $1
+`
+
+type GetStatementUploadFileRow struct {
+	MimeType string
+	Content  []byte
+	TotalDue pgtype.Numeric
+}
+
+// GetStatementUploadFile returns the file an upload's rows still have to be extracted
+// from, with the total due read off a card statement's cycle summary.
+func (q *Queries) GetStatementUploadFile(ctx context.Context, uploadID pgtype.UUID) (GetStatementUploadFileRow, error) {
+	row := q.db.QueryRow(ctx, getStatementUploadFile, uploadID)
+	var i GetStatementUploadFileRow
+	err := row.Scan(&i.MimeType, &i.Content, &i.TotalDue)
+	return i, err
+}
+
 const getUploadWithSummary = `-- name: GetUploadWithSummary :one
 SELECT id, user_id, account_id, file_name, upload_status, processing_status,
        statement_period_start, statement_period_end,
        valid_rows, duplicate_rows, error_rows, parsing_errors,
+       closing_balance, balance_drift,
+       matched_transactions, unmatched_transactions, missing_transactions,
+       total_transactions_found, processed_rows, processed_chunks,
+       statement_date, payment_due_date, minimum_due, total_due,
        created_at, updated_at
 FROM bank_statement_uploads
 WHERE id = $1 AND user_id = $2
@@ -363,20 +830,32 @@ type GetUploadWithSummaryParams struct {
 }
 
 type GetUploadWithSummaryRow struct {
-	ID                   pgtype.UUID
-	UserID               string
-	AccountID            pgtype.UUID
-	FileName             string
-	UploadStatus         pgtype.Text
-	ProcessingStatus     NullUploadProcessingStatus
-	StatementPeriodStart pgtype.Date
-	StatementPeriodEnd   pgtype.Date
-	ValidRows            pgtype.Int4
-	DuplicateRows        pgtype.Int4
-	ErrorRows            pgtype.Int4
-	ParsingErrors        []byte
-	CreatedAt            pgtype.Timestamp
-	UpdatedAt            pgtype.Timestamp
+	ID                     pgtype.UUID
+	UserID                 string
+	AccountID              pgtype.UUID
+	FileName               string
+	UploadStatus           pgtype.Text
+	ProcessingStatus       NullUploadProcessingStatus
+	StatementPeriodStart   pgtype.Date
+	StatementPeriodEnd     pgtype.Date
+	ValidRows              pgtype.Int4
+	DuplicateRows          pgtype.Int4
+	ErrorRows              pgtype.Int4
+	ParsingErrors          []byte
+	ClosingBalance         pgtype.Numeric
+	BalanceDrift           pgtype.Numeric
+	MatchedTransactions    pgtype.Int4
+	UnmatchedTransactions  pgtype.Int4
+	MissingTransactions    pgtype.Int4
+	TotalTransactionsFound pgtype.Int4
+	ProcessedRows          pgtype.Int4
+	ProcessedChunks        pgtype.Int4
+	StatementDate          pgtype.Date
+	PaymentDueDate         pgtype.Date
+	MinimumDue             pgtype.Numeric
+	TotalDue               pgtype.Numeric
+	CreatedAt              pgtype.Timestamp
+	UpdatedAt              pgtype.Timestamp
 }
 
 func (q *Queries) GetUploadWithSummary(ctx context.Context, arg GetUploadWithSummaryParams) (GetUploadWithSummaryRow, error) {
@@ -395,12 +874,164 @@ func (q *Queries) GetUploadWithSummary(ctx context.Context, arg GetUploadWithSum
 		&i.DuplicateRows,
 		&i.ErrorRows,
 		&i.ParsingErrors,
+		&i.ClosingBalance,
+		&i.BalanceDrift,
+		&i.MatchedTransactions,
+		&i.UnmatchedTransactions,
+		&i.MissingTransactions,
+		&i.TotalTransactionsFound,
+		&i.ProcessedRows,
+		&i.ProcessedChunks,
+		&i.StatementDate,
+		&i.PaymentDueDate,
+		&i.MinimumDue,
+		&i.TotalDue,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
+const insertReconciliationGroup = `-- name: InsertReconciliationGroup :one
+INSERT INTO transaction_reconciliation (
+    upload_id, statement_transaction_id, app_transaction_id,
+    result_type, confidence_score, match_signals, match_status
+) VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id
+`
+
+type InsertReconciliationGroupParams struct {
+	UploadID               pgtype.UUID
+	StatementTransactionID pgtype.UUID
+	AppTransactionID       pgtype.UUID
+	ResultType             ReconciliationResultType
+	ConfidenceScore        pgtype.Numeric
+	MatchSignals           []byte
+	MatchStatus            string
+}
+
+func (q *Queries) InsertReconciliationGroup(ctx context.Context, arg InsertReconciliationGroupParams) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, insertReconciliationGroup,
+		arg.UploadID,
+		arg.StatementTransactionID,
+		arg.AppTransactionID,
+		arg.ResultType,
+		arg.ConfidenceScore,
+		arg.MatchSignals,
+		arg.MatchStatus,
+	)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const listAccountStatementCoverage = `-- name: ListAccountStatementCoverage :many
+SELECT u.id, u.file_name, u.statement_period_start, u.statement_period_end, u.created_at,
+       MIN(st.transaction_date)::timestamptz AS min_date,
+       MAX(st.transaction_date)::timestamptz AS max_date,
+       COUNT(st.id) AS row_count
+FROM bank_statement_uploads u
+LEFT JOIN statement_transactions st
+       ON st.upload_id = u.id AND st.deleted_at IS NULL AND st.is_duplicate = false
+WHERE u.account_id = $1 AND u.user_id = $2
+GROUP BY u.id
+ORDER BY u.created_at ASC
+`
+
+type ListAccountStatementCoverageParams struct {
+	AccountID pgtype.UUID
+	UserID    string
+}
+
+type ListAccountStatementCoverageRow struct {
+	ID                   pgtype.UUID
+	FileName             string
+	StatementPeriodStart pgtype.Date
+	StatementPeriodEnd   pgtype.Date
+	CreatedAt            pgtype.Timestamp
+	MinDate              pgtype.Timestamptz
+	MaxDate              pgtype.Timestamptz
+	RowCount             int64
+}
+
+// Every upload of an account with its stated period and the span of its rows.
+func (q *Queries) ListAccountStatementCoverage(ctx context.Context, arg ListAccountStatementCoverageParams) ([]ListAccountStatementCoverageRow, error) {
+	rows, err := q.db.Query(ctx, listAccountStatementCoverage, arg.AccountID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAccountStatementCoverageRow
+	for rows.Next() {
+		var i ListAccountStatementCoverageRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FileName,
+			&i.StatementPeriodStart,
+			&i.StatementPeriodEnd,
+			&i.CreatedAt,
+			&i.MinDate,
+			&i.MaxDate,
+			&i.RowCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountStatementRowsInRange = `-- name: ListAccountStatementRowsInRange :many
+SELECT transaction_date, amount, type, reference_number, raw_row_hash
+FROM statement_transactions
+WHERE account_id = $1 AND deleted_at IS NULL AND is_duplicate = false
+  AND transaction_date >= $2 AND transaction_date < $3
+`
+
+type ListAccountStatementRowsInRangeParams struct {
+	AccountID pgtype.UUID
+	FromDate  pgtype.Timestamptz
+	ToDate    pgtype.Timestamptz
+}
+
+type ListAccountStatementRowsInRangeRow struct {
+	TransactionDate pgtype.Timestamptz
+	Amount          pgtype.Numeric
+	Type            string
+	ReferenceNumber pgtype.Text
+	RawRowHash      string
+}
+
+// Rows earlier uploads already hold for an account in a date range, for cross-upload dedupe.
+func (q *Queries) ListAccountStatementRowsInRange(ctx context.Context, arg ListAccountStatementRowsInRangeParams) ([]ListAccountStatementRowsInRangeRow, error) {
+	rows, err := q.db.Query(ctx, listAccountStatementRowsInRange, arg.AccountID, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAccountStatementRowsInRangeRow
+	for rows.Next() {
+		var i ListAccountStatementRowsInRangeRow
+		if err := rows.Scan(
+			&i.TransactionDate,
+			&i.Amount,
+			&i.Type,
+			&i.ReferenceNumber,
+			&i.RawRowHash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listBankStatementUploadsByUser = `-- name: ListBankStatementUploadsByUser :many
 SELECT id, user_id, account_id, file_name, upload_status, processing_status,
        statement_period_start, statement_period_end, created_at
@@ -451,6 +1082,167 @@ func (q *Queries) ListBankStatementUploadsByUser(ctx context.Context, userID str
 	return items, nil
 }
 
+const listCardPaymentAccounts = `-- name: ListCardPaymentAccounts :many
+SELECT a.id, COUNT(t.id)::int AS payments
+FROM accounts a
+LEFT JOIN transactions t
+       ON t.account_id = a.id AND t.to_account_id = $2 AND t.deleted_at IS NULL
+WHERE a.user_id = $1
+  AND a.id <> $2
+  AND LOWER(a.account_type) IN ('savings', 'current')
+  AND a.deleted_at IS NULL
+GROUP BY a.id
+ORDER BY payments DESC, a.id
+`
+
+type ListCardPaymentAccountsParams struct {
+	UserID string
+	ID     pgtype.UUID
+}
+
+type ListCardPaymentAccountsRow struct {
+	ID       pgtype.UUID
+	Payments int32
+}
+
+// ListCardPaymentAccounts lists the user's savings and current accounts with how many
+// transfers each has already made to the card.
+func (q *Queries) ListCardPaymentAccounts(ctx context.Context, arg ListCardPaymentAccountsParams) ([]ListCardPaymentAccountsRow, error) {
+	rows, err := q.db.Query(ctx, listCardPaymentAccounts, arg.UserID, arg.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCardPaymentAccountsRow
+	for rows.Next() {
+		var i ListCardPaymentAccountsRow
+		if err := rows.Scan(&i.ID, &i.Payments); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listClaimedAppTransactionIDs = `-- name: ListClaimedAppTransactionIDs :many
+SELECT tr.app_transaction_id::uuid AS app_transaction_id
+FROM transaction_reconciliation tr
+WHERE tr.upload_id = $1 AND tr.app_transaction_id IS NOT NULL
+UNION
+SELECT m.app_transaction_id::uuid
+FROM reconciliation_match_members m
+JOIN transaction_reconciliation tr ON tr.id = m.reconciliation_id
+WHERE tr.upload_id = $1 AND m.app_transaction_id IS NOT NULL
+`
+
+// App transactions already referenced by a result of this upload, split members included.
+func (q *Queries) ListClaimedAppTransactionIDs(ctx context.Context, uploadID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, listClaimedAppTransactionIDs, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var app_transaction_id pgtype.UUID
+		if err := rows.Scan(&app_transaction_id); err != nil {
+			return nil, err
+		}
+		items = append(items, app_transaction_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDecidedReconciliationLinks = `-- name: ListDecidedReconciliationLinks :many
+SELECT tr.result_type, tr.user_action, tr.statement_transaction_id, tr.app_transaction_id
+FROM transaction_reconciliation tr
+WHERE tr.upload_id = $1
+  AND (tr.user_action IS NOT NULL OR tr.result_type = 'MANUALLY_MATCHED')
+UNION ALL
+SELECT tr.result_type, tr.user_action, rmm.statement_transaction_id, rmm.app_transaction_id
+FROM reconciliation_match_members rmm
+JOIN transaction_reconciliation tr ON tr.id = rmm.reconciliation_id
+WHERE tr.upload_id = $1
+  AND (tr.user_action IS NOT NULL OR tr.result_type = 'MANUALLY_MATCHED')
+`
+
+type ListDecidedReconciliationLinksRow struct {
+	ResultType             ReconciliationResultType
+	UserAction             pgtype.Text
+	StatementTransactionID pgtype.UUID
+	AppTransactionID       pgtype.UUID
+}
+
+func (q *Queries) ListDecidedReconciliationLinks(ctx context.Context, uploadID pgtype.UUID) ([]ListDecidedReconciliationLinksRow, error) {
+	rows, err := q.db.Query(ctx, listDecidedReconciliationLinks, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDecidedReconciliationLinksRow
+	for rows.Next() {
+		var i ListDecidedReconciliationLinksRow
+		if err := rows.Scan(
+			&i.ResultType,
+			&i.UserAction,
+			&i.StatementTransactionID,
+			&i.AppTransactionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReconciliationMatchMembers = `-- name: ListReconciliationMatchMembers :many
+SELECT reconciliation_id, statement_transaction_id, app_transaction_id, amount
+FROM reconciliation_match_members
+WHERE reconciliation_id = ANY($1::uuid[])
+ORDER BY created_at ASC
+`
+
+type ListReconciliationMatchMembersRow struct {
+	ReconciliationID       pgtype.UUID
+	StatementTransactionID pgtype.UUID
+	AppTransactionID       pgtype.UUID
+	Amount                 pgtype.Numeric
+}
+
+func (q *Queries) ListReconciliationMatchMembers(ctx context.Context, dollar_1 []pgtype.UUID) ([]ListReconciliationMatchMembersRow, error) {
+	rows, err := q.db.Query(ctx, listReconciliationMatchMembers, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReconciliationMatchMembersRow
+	for rows.Next() {
+		var i ListReconciliationMatchMembersRow
+		if err := rows.Scan(
+			&i.ReconciliationID,
+			&i.StatementTransactionID,
+			&i.AppTransactionID,
+			&i.Amount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listStatementTransactionsByUploadID = `-- name: ListStatementTransactionsByUploadID :many
 SELECT id, upload_id, account_id, transaction_date, description, amount, type,
        balance, reference_number, raw_row_hash, row_number, is_duplicate
@@ -514,6 +1306,106 @@ func (q *Queries) ListStatementTransactionsByUploadID(ctx context.Context, arg L
 	return items, nil
 }
 
+const listSystemReconciliationResults = `-- name: ListSystemReconciliationResults :many
+SELECT tr.id, tr.result_type, tr.statement_transaction_id, tr.app_transaction_id,
+       t.source AS app_source, t.reconciliation_status AS app_reconciliation_status,
+       t.statement_txn_id AS app_statement_txn_id
+FROM transaction_reconciliation tr
+LEFT JOIN transactions t ON t.id = tr.app_transaction_id AND t.deleted_at IS NULL
+WHERE tr.upload_id = $1
+  AND tr.user_action IS NULL
+  AND tr.result_type <> 'MANUALLY_MATCHED'
+`
+
+type ListSystemReconciliationResultsRow struct {
+	ID                      pgtype.UUID
+	ResultType              ReconciliationResultType
+	StatementTransactionID  pgtype.UUID
+	AppTransactionID        pgtype.UUID
+	AppSource               NullTransactionSource
+	AppReconciliationStatus NullTransactionReconciliationStatus
+	AppStatementTxnID       pgtype.UUID
+}
+
+func (q *Queries) ListSystemReconciliationResults(ctx context.Context, uploadID pgtype.UUID) ([]ListSystemReconciliationResultsRow, error) {
+	rows, err := q.db.Query(ctx, listSystemReconciliationResults, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSystemReconciliationResultsRow
+	for rows.Next() {
+		var i ListSystemReconciliationResultsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ResultType,
+			&i.StatementTransactionID,
+			&i.AppTransactionID,
+			&i.AppSource,
+			&i.AppReconciliationStatus,
+			&i.AppStatementTxnID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resetUploadProgress = `-- name: ResetUploadProgress :exec
+UPDATE bank_statement_uploads
+SET
+    total_transactions_found = $2,
+    matched_transactions     = $3,
+    unmatched_transactions   = $4,
+    missing_transactions     = $5,
+    processed_rows           = $6,
+    processed_chunks         = 0,
+    updated_at               = NOW()
+WHERE id = $1
+`
+
+type ResetUploadProgressParams struct {
+	ID                     pgtype.UUID
+	TotalTransactionsFound pgtype.Int4
+	MatchedTransactions    pgtype.Int4
+	UnmatchedTransactions  pgtype.Int4
+	MissingTransactions    pgtype.Int4
+	ProcessedRows          pgtype.Int4
+}
+
+func (q *Queries) ResetUploadProgress(ctx context.Context, arg ResetUploadProgressParams) error {
+	_, err := q.db.Exec(ctx, resetUploadProgress,
+		arg.ID,
+		arg.TotalTransactionsFound,
+		arg.MatchedTransactions,
+		arg.UnmatchedTransactions,
+		arg.MissingTransactions,
+		arg.ProcessedRows,
+	)
+	return err
+}
+
+const saveStatementUploadFile = `-- name: SaveStatementUploadFile :exec
+INSERT INTO statement_upload_files (upload_id, mime_type, content)
+VALUES ($1, $2, $3)
+ON CONFLICT (upload_id) DO UPDATE SET mime_type = EXCLUDED.mime_type, content = EXCLUDED.content
+`
+
+type SaveStatementUploadFileParams struct {
+	UploadID pgtype.UUID
+	MimeType string
+	Content  []byte
+}
+
+func (q *Queries) SaveStatementUploadFile(ctx context.Context, arg SaveStatementUploadFileParams) error {
+	_, err := q.db.Exec(ctx, saveStatementUploadFile, arg.UploadID, arg.MimeType, arg.Content)
+	return err
+}
+
 const softDeleteStatementTransactionsByUploadID = `-- name: SoftDeleteStatementTransactionsByUploadID :exec
 UPDATE statement_transactions SET deleted_at = NOW() WHERE upload_id = $1 AND deleted_at IS NULL
 `
@@ -524,9 +1416,123 @@ func (q *Queries) SoftDeleteStatementTransactionsByUploadID(ctx context.Context,
 	return err
 }
 
+const startReconciliationJobCheckpoint = `-- name: StartReconciliationJobCheckpoint :one
+INSERT INTO reconciliation_job_checkpoints (job_id, upload_id)
+VALUES ($1, $2)
+ON CONFLICT (job_id) DO UPDATE
+SET attempts = reconciliation_job_checkpoints.attempts + 1, updated_at = NOW()
+RETURNING stage, attempts, chunks_committed, created_txn_ids
+`
+
+type StartReconciliationJobCheckpointParams struct {
+	JobID    string
+	UploadID pgtype.UUID
+}
+
+type StartReconciliationJobCheckpointRow struct {
+	Stage           string
+	Attempts        int32
+	ChunksCommitted int32
+	CreatedTxnIds   []pgtype.UUID
+}
+
+func (q *Queries) StartReconciliationJobCheckpoint(ctx context.Context, arg StartReconciliationJobCheckpointParams) (StartReconciliationJobCheckpointRow, error) {
+	row := q.db.QueryRow(ctx, startReconciliationJobCheckpoint, arg.JobID, arg.UploadID)
+	var i StartReconciliationJobCheckpointRow
+	err := row.Scan(
+		&i.Stage,
+		&i.Attempts,
+		&i.ChunksCommitted,
+		&i.CreatedTxnIds,
+	)
+	return i, err
+}
+
+const updateReconciliationResultManualMatch = `-- name: UpdateReconciliationResultManualMatch :exec
+UPDATE transaction_reconciliation
+SET app_transaction_id = $2,
+    result_type        = 'MANUALLY_MATCHED',
+    confidence_score   = $3,
+    match_signals      = $4,
+    match_status       = 'manually_matched',
+    user_action        = 'accepted',
+    user_action_at     = NOW(),
+    reviewed_by        = 'USER',
+    reviewed_at        = NOW(),
+    updated_at         = NOW()
+WHERE id = $1
+`
+
+type UpdateReconciliationResultManualMatchParams struct {
+	ID               pgtype.UUID
+	AppTransactionID pgtype.UUID
+	ConfidenceScore  pgtype.Numeric
+	MatchSignals     []byte
+}
+
+func (q *Queries) UpdateReconciliationResultManualMatch(ctx context.Context, arg UpdateReconciliationResultManualMatchParams) error {
+	_, err := q.db.Exec(ctx, updateReconciliationResultManualMatch,
+		arg.ID,
+		arg.AppTransactionID,
+		arg.ConfidenceScore,
+		arg.MatchSignals,
+	)
+	return err
+}
+
+const updateUploadBalanceCheck = `-- name: UpdateUploadBalanceCheck :exec
+UPDATE bank_statement_uploads
+SET
+    closing_balance = $2,
+    balance_drift   = $3,
+    updated_at      = NOW()
+WHERE id = $1
+`
+
+type UpdateUploadBalanceCheckParams struct {
+	ID             pgtype.UUID
+	ClosingBalance pgtype.Numeric
+	BalanceDrift   pgtype.Numeric
+}
+
+func (q *Queries) UpdateUploadBalanceCheck(ctx context.Context, arg UpdateUploadBalanceCheckParams) error {
+	_, err := q.db.Exec(ctx, updateUploadBalanceCheck, arg.ID, arg.ClosingBalance, arg.BalanceDrift)
+	return err
+}
+
+const updateUploadCardCycle = `-- name: UpdateUploadCardCycle :exec
+UPDATE bank_statement_uploads
+SET
+    statement_date   = $2,
+    payment_due_date = $3,
+    minimum_due      = $4,
+    total_due        = $5,
+    updated_at       = NOW()
+WHERE id = $1
+`
+
+type UpdateUploadCardCycleParams struct {
+	ID             pgtype.UUID
+	StatementDate  pgtype.Date
+	PaymentDueDate pgtype.Date
+	MinimumDue     pgtype.Numeric
+	TotalDue       pgtype.Numeric
+}
+
+func (q *Queries) UpdateUploadCardCycle(ctx context.Context, arg UpdateUploadCardCycleParams) error {
+	_, err := q.db.Exec(ctx, updateUploadCardCycle,
+		arg.ID,
+		arg.StatementDate,
+		arg.PaymentDueDate,
+		arg.MinimumDue,
+		arg.TotalDue,
+	)
+	return err
+}
+
 const updateUploadProcessingStatus = `-- name: UpdateUploadProcessingStatus :exec
 UPDATE bank_statement_uploads
-SET processing_status = $2, job_id = $3
+SET processing_status = $2, job_id = $3, updated_at = NOW()
 WHERE id = $1
 `
 
@@ -541,6 +1547,36 @@ func (q *Queries) UpdateUploadProcessingStatus(ctx context.Context, arg UpdateUp
 	return err
 }
 
+const updateUploadResultCounts = `-- name: UpdateUploadResultCounts :exec
+UPDATE bank_statement_uploads
+SET
+    total_transactions_found = $2,
+    matched_transactions     = $3,
+    unmatched_transactions   = $4,
+    missing_transactions     = $5,
+    updated_at               = NOW()
+WHERE id = $1
+`
+
+type UpdateUploadResultCountsParams struct {
+	ID                     pgtype.UUID
+	TotalTransactionsFound pgtype.Int4
+	MatchedTransactions    pgtype.Int4
+	UnmatchedTransactions  pgtype.Int4
+	MissingTransactions    pgtype.Int4
+}
+
+func (q *Queries) UpdateUploadResultCounts(ctx context.Context, arg UpdateUploadResultCountsParams) error {
+	_, err := q.db.Exec(ctx, updateUploadResultCounts,
+		arg.ID,
+		arg.TotalTransactionsFound,
+		arg.MatchedTransactions,
+		arg.UnmatchedTransactions,
+		arg.MissingTransactions,
+	)
+	return err
+}
+
 const updateUploadSummary = `-- name: UpdateUploadSummary :exec
 UPDATE bank_statement_uploads
 SET
@@ -20,7 +20,7 @@ INSERT INTO sms_logs (
 ) VALUES (
     $1,$2,$3,$4
 ) 
-RETURNING id, user_id, sender, raw_message, received_at, parsing_status, error_message, retry_count, llm_parsed, llm_parse_attempted, llm_response, created_at, last_retry_at, updated_at
+RETURNING id, user_id, sender, raw_message, received_at, parsing_status, error_message, retry_count, llm_parsed, llm_parse_attempted, llm_response, created_at, last_retry_at, updated_at, parsed_template, available_balance, merged_transaction_id, parsed_txn, client_message_id
 `
 
 type CreateSmsParams struct {
@@ -53,10 +53,90 @@ func (q *Queries) CreateSms(ctx context.Context, arg CreateSmsParams) (SmsLog, e
 		&i.CreatedAt,
 		&i.LastRetryAt,
 		&i.UpdatedAt,
+		&i.ParsedTemplate,
+		&i.AvailableBalance,
+		&i.MergedTransactionID,
+		&i.ParsedTxn,
+		&i.ClientMessageID,
 	)
 	return i, err
 }
 
+const createSmsBatch = `-- name: CreateSmsBatch :many
+INSERT INTO sms_logs (
+    user_id,
+    sender,
+    raw_message,
+    received_at,
+    client_message_id
+)
+SELECT $1::varchar, m.sender, m.raw_message, m.received_at, m.client_message_id
+FROM unnest(
+    $2::varchar[],
+    $3::text[],
+    $4::timestamp[],
+    $5::varchar[]
+) AS m(sender, raw_message, received_at, client_message_id)
+ON CONFLICT (user_id, client_message_id) WHERE client_message_id IS NOT NULL DO NOTHING
+RETURNING id, user_id, sender, raw_message, received_at, parsing_status, error_message, retry_count, llm_parsed, llm_parse_attempted, llm_response, created_at, last_retry_at, updated_at, parsed_template, available_balance, merged_transaction_id, parsed_txn, client_message_id
+`
+
+type CreateSmsBatchParams struct {
+	UserID           string
+	Senders          []string
+	RawMessages      []string
+	ReceivedAts      []pgtype.Timestamp
+	ClientMessageIds []string
+}
+
+// CreateSmsBatch stores a device's backlog in one statement. Messages whose
+// client_message_id is already stored are skipped and not returned.
+func (q *Queries) CreateSmsBatch(ctx context.Context, arg CreateSmsBatchParams) ([]SmsLog, error) {
+	rows, err := q.db.Query(ctx, createSmsBatch,
+		arg.UserID,
+		arg.Senders,
+		arg.RawMessages,
+		arg.ReceivedAts,
+		arg.ClientMessageIds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SmsLog
+	for rows.Next() {
+		var i SmsLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Sender,
+			&i.RawMessage,
+			&i.ReceivedAt,
+			&i.ParsingStatus,
+			&i.ErrorMessage,
+			&i.RetryCount,
+			&i.LlmParsed,
+			&i.LlmParseAttempted,
+			&i.LlmResponse,
+			&i.CreatedAt,
+			&i.LastRetryAt,
+			&i.UpdatedAt,
+			&i.ParsedTemplate,
+			&i.AvailableBalance,
+			&i.MergedTransactionID,
+			&i.ParsedTxn,
+			&i.ClientMessageID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const deleteSms = `-- name: DeleteSms :exec
 DELETE FROM sms_logs WHERE user_id=$1 AND id=$2
 `
@@ -71,8 +151,59 @@ func (q *Queries) DeleteSms(ctx context.Context, arg DeleteSmsParams) error {
 	return err
 }
 
+const findDuplicateSmsTxn = `-- name: FindDuplicateSmsTxn :one
+SELECT id FROM transactions
+WHERE user_id = $1
+  AND type = $2
+  AND account_id = $3
+  AND transaction_date BETWEEN $4 AND $5
+  AND deleted_at IS NULL
+  AND (
+    ($6::text IS NOT NULL AND reference_number = $6::text)
+    OR (
+      amount = $7
+      AND sms_id IS NOT NULL
+      AND ($6::text IS NULL OR NULLIF(reference_number, '') IS NULL)
+    )
+  )
+ORDER BY reference_number IS NOT DISTINCT FROM $6::text DESC,
+         ABS(EXTRACT(EPOCH FROM (transaction_date - $8::timestamp)))
+LIMIT 1
+`
+
+type FindDuplicateSmsTxnParams struct {
+	UserID          string
+	Type            TxnType
+	AccountID       pgtype.UUID
+	FromDate        pgtype.Timestamp
+	ToDate          pgtype.Timestamp
+	ReferenceNumber pgtype.Text
+	Amount          pgtype.Numeric
+	At              pgtype.Timestamp
+}
+
+// FindDuplicateSmsTxn returns the transaction an SMS most likely repeats: one on the
+// same account with the same type inside the window and the same reference number.
+// When either side has no reference number, a transaction created from another SMS
+// with the same amount counts too; reference matches come first, then closest in time.
+func (q *Queries) FindDuplicateSmsTxn(ctx context.Context, arg FindDuplicateSmsTxnParams) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, findDuplicateSmsTxn,
+		arg.UserID,
+		arg.Type,
+		arg.AccountID,
+		arg.FromDate,
+		arg.ToDate,
+		arg.ReferenceNumber,
+		arg.Amount,
+		arg.At,
+	)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
 const getSmsById = `-- name: GetSmsById :one
-SELECT id, user_id, sender, raw_message, received_at, parsing_status, error_message, retry_count, llm_parsed, llm_parse_attempted, llm_response, created_at, last_retry_at, updated_at FROM sms_logs
+SELECT id, user_id, sender, raw_message, received_at, parsing_status, error_message, retry_count, llm_parsed, llm_parse_attempted, llm_response, created_at, last_retry_at, updated_at, parsed_template, available_balance, merged_transaction_id, parsed_txn, client_message_id FROM sms_logs
 WHERE user_id=$1 AND id=$2
 `
 
@@ -99,12 +230,17 @@ func (q *Queries) GetSmsById(ctx context.Context, arg GetSmsByIdParams) (SmsLog,
 		&i.CreatedAt,
 		&i.LastRetryAt,
 		&i.UpdatedAt,
+		&i.ParsedTemplate,
+		&i.AvailableBalance,
+		&i.MergedTransactionID,
+		&i.ParsedTxn,
+		&i.ClientMessageID,
 	)
 	return i, err
 }
 
 const getSmses = `-- name: GetSmses :many
-SELECT id, user_id, sender, raw_message, received_at, parsing_status, error_message, retry_count, llm_parsed, llm_parse_attempted, llm_response, created_at, last_retry_at, updated_at FROM sms_logs 
+SELECT id, user_id, sender, raw_message, received_at, parsing_status, error_message, retry_count, llm_parsed, llm_parse_attempted, llm_response, created_at, last_retry_at, updated_at, parsed_template, available_balance, merged_transaction_id, parsed_txn, client_message_id FROM sms_logs 
 WHERE user_id=$1
 `
 
@@ -132,6 +268,301 @@ func (q *Queries) GetSmses(ctx context.Context, userID string) ([]SmsLog, error)
 			&i.CreatedAt,
 			&i.LastRetryAt,
 			&i.UpdatedAt,
+			&i.ParsedTemplate,
+			&i.AvailableBalance,
+			&i.MergedTransactionID,
+			&i.ParsedTxn,
+			&i.ClientMessageID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSmsesByClientMessageIds = `-- name: GetSmsesByClientMessageIds :many
+SELECT id, user_id, sender, raw_message, received_at, parsing_status, error_message, retry_count, llm_parsed, llm_parse_attempted, llm_response, created_at, last_retry_at, updated_at, parsed_template, available_balance, merged_transaction_id, parsed_txn, client_message_id FROM sms_logs
+WHERE user_id = $1 AND client_message_id = ANY($2::varchar[])
+`
+
+type GetSmsesByClientMessageIdsParams struct {
+	UserID           string
+	ClientMessageIds []string
+}
+
+func (q *Queries) GetSmsesByClientMessageIds(ctx context.Context, arg GetSmsesByClientMessageIdsParams) ([]SmsLog, error) {
+	rows, err := q.db.Query(ctx, getSmsesByClientMessageIds, arg.UserID, arg.ClientMessageIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SmsLog
+	for rows.Next() {
+		var i SmsLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Sender,
+			&i.RawMessage,
+			&i.ReceivedAt,
+			&i.ParsingStatus,
+			&i.ErrorMessage,
+			&i.RetryCount,
+			&i.LlmParsed,
+			&i.LlmParseAttempted,
+			&i.LlmResponse,
+			&i.CreatedAt,
+			&i.LastRetryAt,
+			&i.UpdatedAt,
+			&i.ParsedTemplate,
+			&i.AvailableBalance,
+			&i.MergedTransactionID,
+			&i.ParsedTxn,
+			&i.ClientMessageID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSmsDuplicateCandidates = `-- name: ListSmsDuplicateCandidates :many
+SELECT DISTINCT t.id, t.account_id, t.type, t.amount, t.reference_number, t.transaction_date, t.sms_id
+FROM transactions t
+JOIN unnest(
+    $1::uuid[],
+    $2::timestamp[]
+) AS m(account_id, received_at) ON t.account_id = m.account_id
+WHERE t.user_id = $3
+  AND t.deleted_at IS NULL
+  AND t.transaction_date BETWEEN m.received_at - make_interval(secs => $4::int)
+                             AND m.received_at + make_interval(secs => $4::int)
+ORDER BY t.transaction_date
+`
+
+type ListSmsDuplicateCandidatesParams struct {
+	AccountIds  []pgtype.UUID
+	ReceivedAts []pgtype.Timestamp
+	UserID      string
+	WindowSecs  int32
+}
+
+type ListSmsDuplicateCandidatesRow struct {
+	ID              pgtype.UUID
+	AccountID       pgtype.UUID
+	Type            TxnType
+	Amount          pgtype.Numeric
+	ReferenceNumber pgtype.Text
+	TransactionDate pgtype.Timestamp
+	SmsID           pgtype.UUID
+}
+
+// ListSmsDuplicateCandidates returns the stored transactions a batch of SMS could
+// repeat: those on each message's account within window_secs of when it was received.
+func (q *Queries) ListSmsDuplicateCandidates(ctx context.Context, arg ListSmsDuplicateCandidatesParams) ([]ListSmsDuplicateCandidatesRow, error) {
+	rows, err := q.db.Query(ctx, listSmsDuplicateCandidates,
+		arg.AccountIds,
+		arg.ReceivedAts,
+		arg.UserID,
+		arg.WindowSecs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSmsDuplicateCandidatesRow
+	for rows.Next() {
+		var i ListSmsDuplicateCandidatesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Type,
+			&i.Amount,
+			&i.ReferenceNumber,
+			&i.TransactionDate,
+			&i.SmsID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const mergeSmsIntoTxn = `-- name: MergeSmsIntoTxn :one
+UPDATE sms_logs
+SET merged_transaction_id = $2,
+    parsed_txn            = $3,
+    parsing_status        = $4,
+    updated_at            = NOW()
+WHERE id = $1
+RETURNING id, user_id, sender, raw_message, received_at, parsing_status, error_message, retry_count, llm_parsed, llm_parse_attempted, llm_response, created_at, last_retry_at, updated_at, parsed_template, available_balance, merged_transaction_id, parsed_txn, client_message_id
+`
+
+type MergeSmsIntoTxnParams struct {
+	ID                  pgtype.UUID
+	MergedTransactionID pgtype.UUID
+	ParsedTxn           []byte
+	ParsingStatus       pgtype.Text
+}
+
+func (q *Queries) MergeSmsIntoTxn(ctx context.Context, arg MergeSmsIntoTxnParams) (SmsLog, error) {
+	row := q.db.QueryRow(ctx, mergeSmsIntoTxn,
+		arg.ID,
+		arg.MergedTransactionID,
+		arg.ParsedTxn,
+		arg.ParsingStatus,
+	)
+	var i SmsLog
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Sender,
+		&i.RawMessage,
+		&i.ReceivedAt,
+		&i.ParsingStatus,
+		&i.ErrorMessage,
+		&i.RetryCount,
+		&i.LlmParsed,
+		&i.LlmParseAttempted,
+		&i.LlmResponse,
+		&i.CreatedAt,
+		&i.LastRetryAt,
+		&i.UpdatedAt,
+		&i.ParsedTemplate,
+		&i.AvailableBalance,
+		&i.MergedTransactionID,
+		&i.ParsedTxn,
+		&i.ClientMessageID,
+	)
+	return i, err
+}
+
+const splitSmsMerge = `-- name: SplitSmsMerge :one
+UPDATE sms_logs
+SET merged_transaction_id = NULL,
+    parsed_txn            = NULL,
+    parsing_status        = $3,
+    updated_at            = NOW()
+WHERE id = $1 AND user_id = $2 AND merged_transaction_id IS NOT NULL
+RETURNING id, user_id, sender, raw_message, received_at, parsing_status, error_message, retry_count, llm_parsed, llm_parse_attempted, llm_response, created_at, last_retry_at, updated_at, parsed_template, available_balance, merged_transaction_id, parsed_txn, client_message_id
+`
+
+type SplitSmsMergeParams struct {
+	ID            pgtype.UUID
+	UserID        string
+	ParsingStatus pgtype.Text
+}
+
+// SplitSmsMerge detaches a merged SMS from the transaction it was linked to. It only
+// updates an SMS that is still merged, so a split cannot run twice.
+func (q *Queries) SplitSmsMerge(ctx context.Context, arg SplitSmsMergeParams) (SmsLog, error) {
+	row := q.db.QueryRow(ctx, splitSmsMerge, arg.ID, arg.UserID, arg.ParsingStatus)
+	var i SmsLog
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Sender,
+		&i.RawMessage,
+		&i.ReceivedAt,
+		&i.ParsingStatus,
+		&i.ErrorMessage,
+		&i.RetryCount,
+		&i.LlmParsed,
+		&i.LlmParseAttempted,
+		&i.LlmResponse,
+		&i.CreatedAt,
+		&i.LastRetryAt,
+		&i.UpdatedAt,
+		&i.ParsedTemplate,
+		&i.AvailableBalance,
+		&i.MergedTransactionID,
+		&i.ParsedTxn,
+		&i.ClientMessageID,
+	)
+	return i, err
+}
+
+const updateSmsBatchOutcomes = `-- name: UpdateSmsBatchOutcomes :many
+UPDATE sms_logs s
+SET parsing_status        = COALESCE(NULLIF(o.parsing_status, ''), s.parsing_status),
+    parsed_template       = COALESCE(NULLIF(o.parsed_template, ''), s.parsed_template),
+    available_balance     = COALESCE(o.available_balance, s.available_balance),
+    merged_transaction_id = COALESCE(o.merged_transaction_id, s.merged_transaction_id),
+    parsed_txn            = COALESCE(o.parsed_txn, s.parsed_txn),
+    updated_at            = NOW()
+FROM unnest(
+    $1::uuid[],
+    $2::varchar[],
+    $3::varchar[],
+    $4::numeric[],
+    $5::uuid[],
+    $6::jsonb[]
+) AS o(id, parsing_status, parsed_template, available_balance, merged_transaction_id, parsed_txn)
+WHERE s.id = o.id AND s.user_id = $7
+RETURNING s.id, s.user_id, s.sender, s.raw_message, s.received_at, s.parsing_status, s.error_message, s.retry_count, s.llm_parsed, s.llm_parse_attempted, s.llm_response, s.created_at, s.last_retry_at, s.updated_at, s.parsed_template, s.available_balance, s.merged_transaction_id, s.parsed_txn, s.client_message_id
+`
+
+type UpdateSmsBatchOutcomesParams struct {
+	Ids                  []pgtype.UUID
+	ParsingStatuses      []string
+	ParsedTemplates      []string
+	AvailableBalances    []pgtype.Numeric
+	MergedTransactionIds []pgtype.UUID
+	ParsedTxns           [][]byte
+	UserID               string
+}
+
+// UpdateSmsBatchOutcomes records what processing a batch of stored SMS decided in one
+// statement. Empty or NULL entries keep what the row already has.
+func (q *Queries) UpdateSmsBatchOutcomes(ctx context.Context, arg UpdateSmsBatchOutcomesParams) ([]SmsLog, error) {
+	rows, err := q.db.Query(ctx, updateSmsBatchOutcomes,
+		arg.Ids,
+		arg.ParsingStatuses,
+		arg.ParsedTemplates,
+		arg.AvailableBalances,
+		arg.MergedTransactionIds,
+		arg.ParsedTxns,
+		arg.UserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SmsLog
+	for rows.Next() {
+		var i SmsLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Sender,
+			&i.RawMessage,
+			&i.ReceivedAt,
+			&i.ParsingStatus,
+			&i.ErrorMessage,
+			&i.RetryCount,
+			&i.LlmParsed,
+			&i.LlmParseAttempted,
+			&i.LlmResponse,
+			&i.CreatedAt,
+			&i.LastRetryAt,
+			&i.UpdatedAt,
+			&i.ParsedTemplate,
+			&i.AvailableBalance,
+			&i.MergedTransactionID,
+			&i.ParsedTxn,
+			&i.ClientMessageID,
 		); err != nil {
 			return nil, err
 		}
@@ -152,7 +583,7 @@ SET llm_parse_attempted = $2,
     error_message       = $6,
     updated_at          = NOW()
 WHERE id = $1
-RETURNING id, user_id, sender, raw_message, received_at, parsing_status, error_message, retry_count, llm_parsed, llm_parse_attempted, llm_response, created_at, last_retry_at, updated_at
+RETURNING id, user_id, sender, raw_message, received_at, parsing_status, error_message, retry_count, llm_parsed, llm_parse_attempted, llm_response, created_at, last_retry_at, updated_at, parsed_template, available_balance, merged_transaction_id, parsed_txn, client_message_id
 `
 
 type UpdateSmsLlmResultParams struct {
@@ -189,6 +620,11 @@ func (q *Queries) UpdateSmsLlmResult(ctx context.Context, arg UpdateSmsLlmResult
 		&i.CreatedAt,
 		&i.LastRetryAt,
 		&i.UpdatedAt,
+		&i.ParsedTemplate,
+		&i.AvailableBalance,
+		&i.MergedTransactionID,
+		&i.ParsedTxn,
+		&i.ClientMessageID,
 	)
 	return i, err
 }
@@ -199,7 +635,7 @@ SET parsing_status = $2,
     error_message  = $3,
     updated_at     = NOW()
 WHERE id = $1
-RETURNING id, user_id, sender, raw_message, received_at, parsing_status, error_message, retry_count, llm_parsed, llm_parse_attempted, llm_response, created_at, last_retry_at, updated_at
+RETURNING id, user_id, sender, raw_message, received_at, parsing_status, error_message, retry_count, llm_parsed, llm_parse_attempted, llm_response, created_at, last_retry_at, updated_at, parsed_template, available_balance, merged_transaction_id, parsed_txn, client_message_id
 `
 
 type UpdateSmsParsingStatusParams struct {
@@ -226,6 +662,53 @@ func (q *Queries) UpdateSmsParsingStatus(ctx context.Context, arg UpdateSmsParsi
 		&i.CreatedAt,
 		&i.LastRetryAt,
 		&i.UpdatedAt,
+		&i.ParsedTemplate,
+		&i.AvailableBalance,
+		&i.MergedTransactionID,
+		&i.ParsedTxn,
+		&i.ClientMessageID,
+	)
+	return i, err
+}
+
+const updateSmsTemplateParse = `-- name: UpdateSmsTemplateParse :one
+UPDATE sms_logs
+SET parsed_template   = $2,
+    available_balance = $3,
+    updated_at        = NOW()
+WHERE id = $1
+RETURNING id, user_id, sender, raw_message, received_at, parsing_status, error_message, retry_count, llm_parsed, llm_parse_attempted, llm_response, created_at, last_retry_at, updated_at, parsed_template, available_balance, merged_transaction_id, parsed_txn, client_message_id
+`
+
+type UpdateSmsTemplateParseParams struct {
+	ID               pgtype.UUID
+	ParsedTemplate   pgtype.Text
+	AvailableBalance pgtype.Numeric
+}
+
+func (q *Queries) UpdateSmsTemplateParse(ctx context.Context, arg UpdateSmsTemplateParseParams) (SmsLog, error) {
+	row := q.db.QueryRow(ctx, updateSmsTemplateParse, arg.ID, arg.ParsedTemplate, arg.AvailableBalance)
+	var i SmsLog
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Sender,
+		&i.RawMessage,
+		&i.ReceivedAt,
+		&i.ParsingStatus,
+		&i.ErrorMessage,
+		&i.RetryCount,
+		&i.LlmParsed,
+		&i.LlmParseAttempted,
+		&i.LlmResponse,
+		&i.CreatedAt,
+		&i.LastRetryAt,
+		&i.UpdatedAt,
+		&i.ParsedTemplate,
+		&i.AvailableBalance,
+		&i.MergedTransactionID,
+		&i.ParsedTxn,
+		&i.ClientMessageID,
 	)
 	return i, err
 }
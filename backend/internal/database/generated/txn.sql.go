@@ -90,8 +90,97 @@ func (q *Queries) CreateTxn(ctx context.Context, arg CreateTxnParams) (Transacti
 	return i, err
 }
 
+const discardAutoCreatedTransactions = `-- name: DiscardAutoCreatedTransactions :many
+UPDATE transactions
+SET deleted_at = NOW(),
+    deleted_by = $2
+WHERE id = ANY($1::uuid[])
+  AND source = 'STATEMENT_AUTO'
+  AND deleted_at IS NULL
+RETURNING account_id, to_account_id, type, amount
+`
+
+type DiscardAutoCreatedTransactionsParams struct {
+	Column1   []pgtype.UUID
+	DeletedBy pgtype.Text
+}
+
+type DiscardAutoCreatedTransactionsRow struct {
+	AccountID   pgtype.UUID
+	ToAccountID pgtype.UUID
+	Type        TxnType
+	Amount      pgtype.Numeric
+}
+
+func (q *Queries) DiscardAutoCreatedTransactions(ctx context.Context, arg DiscardAutoCreatedTransactionsParams) ([]DiscardAutoCreatedTransactionsRow, error) {
+	rows, err := q.db.Query(ctx, discardAutoCreatedTransactions, arg.Column1, arg.DeletedBy)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DiscardAutoCreatedTransactionsRow
+	for rows.Next() {
+		var i DiscardAutoCreatedTransactionsRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.ToAccountID,
+			&i.Type,
+			&i.Amount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAppTransactionForMatch = `-- name: GetAppTransactionForMatch :one
+SELECT id, account_id, amount, transaction_date, type, description,
+       reference_number, statement_txn_id
+FROM transactions
+WHERE id = $1
+  AND user_id = $2
+  AND is_cash = false
+  AND deleted_at IS NULL
+`
+
+type GetAppTransactionForMatchParams struct {
+	ID     pgtype.UUID
+	UserID string
+}
+
+type GetAppTransactionForMatchRow struct {
+	ID              pgtype.UUID
+	AccountID       pgtype.UUID
+	Amount          pgtype.Numeric
+	TransactionDate pgtype.Timestamp
+	Type            TxnType
+	Description     pgtype.Text
+	ReferenceNumber pgtype.Text
+	StatementTxnID  pgtype.UUID
+}
+
+func (q *Queries) GetAppTransactionForMatch(ctx context.Context, arg GetAppTransactionForMatchParams) (GetAppTransactionForMatchRow, error) {
+	row := q.db.QueryRow(ctx, getAppTransactionForMatch, arg.ID, arg.UserID)
+	var i GetAppTransactionForMatchRow
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.TransactionDate,
+		&i.Type,
+		&i.Description,
+		&i.ReferenceNumber,
+		&i.StatementTxnID,
+	)
+	return i, err
+}
+
 const getAppTransactionsInDateRange = `-- name: GetAppTransactionsInDateRange :many
-SELECT id, amount, transaction_date, type, description, reference_number
+SELECT id, amount, transaction_date, type, description, reference_number, is_excluded
 FROM transactions
 WHERE account_id = $1
   AND transaction_date BETWEEN $2 AND $3
@@ -113,6 +202,7 @@ type GetAppTransactionsInDateRangeRow struct {
 	Type            TxnType
 	Description     pgtype.Text
 	ReferenceNumber pgtype.Text
+	IsExcluded      pgtype.Bool
 }
 
 func (q *Queries) GetAppTransactionsInDateRange(ctx context.Context, arg GetAppTransactionsInDateRangeParams) ([]GetAppTransactionsInDateRangeRow, error) {
@@ -131,6 +221,7 @@ func (q *Queries) GetAppTransactionsInDateRange(ctx context.Context, arg GetAppT
 			&i.Type,
 			&i.Description,
 			&i.ReferenceNumber,
+			&i.IsExcluded,
 		); err != nil {
 			return nil, err
 		}
@@ -332,6 +423,77 @@ func (q *Queries) HardDeleteTxns(ctx context.Context, arg HardDeleteTxnsParams)
 	return err
 }
 
+const listCardPaymentSources = `-- name: ListCardPaymentSources :many
+SELECT t.id, t.account_id, t.to_account_id, t.amount, t.transaction_date,
+       COALESCE(t.description, '')::text AS description,
+       COALESCE(m.name, '')::text AS payee
+FROM transactions t
+JOIN accounts a ON a.id = t.account_id
+LEFT JOIN merchants m ON m.id = t.merchant_id
+WHERE t.user_id = $1
+  AND t.account_id <> $2
+  AND (t.to_account_id IS NULL OR t.to_account_id = $2)
+  AND t.type = 'DEBIT'
+  AND t.transaction_date BETWEEN $3 AND $4
+  AND t.is_cash = false
+  AND t.deleted_at IS NULL
+  AND LOWER(a.account_type) IN ('savings', 'current')
+ORDER BY t.transaction_date, t.id
+`
+
+type ListCardPaymentSourcesParams struct {
+	UserID            string
+	AccountID         pgtype.UUID
+	TransactionDate   pgtype.Timestamp
+	TransactionDate_2 pgtype.Timestamp
+}
+
+type ListCardPaymentSourcesRow struct {
+	ID              pgtype.UUID
+	AccountID       pgtype.UUID
+	ToAccountID     pgtype.UUID
+	Amount          pgtype.Numeric
+	TransactionDate pgtype.Timestamp
+	Description     string
+	Payee           string
+}
+
+// ListCardPaymentSources returns debits on the user's savings and current accounts that
+// can be the other leg of a card bill payment: not yet a transfer, or already one to this card.
+// The narration and payee are what tell a bill payment from any other debit of the amount.
+func (q *Queries) ListCardPaymentSources(ctx context.Context, arg ListCardPaymentSourcesParams) ([]ListCardPaymentSourcesRow, error) {
+	rows, err := q.db.Query(ctx, listCardPaymentSources,
+		arg.UserID,
+		arg.AccountID,
+		arg.TransactionDate,
+		arg.TransactionDate_2,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCardPaymentSourcesRow
+	for rows.Next() {
+		var i ListCardPaymentSourcesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.TransactionDate,
+			&i.Description,
+			&i.Payee,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const markTransactionAutoVerified = `-- name: MarkTransactionAutoVerified :exec
 UPDATE transactions
 SET reconciliation_status = 'AUTO_VERIFIED',
@@ -351,6 +513,115 @@ func (q *Queries) MarkTransactionAutoVerified(ctx context.Context, arg MarkTrans
 	return err
 }
 
+const markTransactionTransfer = `-- name: MarkTransactionTransfer :one
+UPDATE transactions
+SET to_account_id = $2,
+    updated_at    = NOW()
+WHERE id = $1
+  AND to_account_id IS NULL
+  AND deleted_at IS NULL
+RETURNING id
+`
+
+type MarkTransactionTransferParams struct {
+	ID          pgtype.UUID
+	ToAccountID pgtype.UUID
+}
+
+// MarkTransactionTransfer turns a debit into a transfer to the given account. It
+// returns no row when the transaction already was a transfer.
+func (q *Queries) MarkTransactionTransfer(ctx context.Context, arg MarkTransactionTransferParams) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, markTransactionTransfer, arg.ID, arg.ToAccountID)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const markTransactionUserVerified = `-- name: MarkTransactionUserVerified :exec
+UPDATE transactions
+SET reconciliation_status = 'USER_VERIFIED',
+    reconciled_by         = 'USER',
+    reconciled_at         = NOW(),
+    statement_txn_id      = $2
+WHERE id = $1
+`
+
+type MarkTransactionUserVerifiedParams struct {
+	ID             pgtype.UUID
+	StatementTxnID pgtype.UUID
+}
+
+func (q *Queries) MarkTransactionUserVerified(ctx context.Context, arg MarkTransactionUserVerifiedParams) error {
+	_, err := q.db.Exec(ctx, markTransactionUserVerified, arg.ID, arg.StatementTxnID)
+	return err
+}
+
+const rejectAutoCreatedTransaction = `-- name: RejectAutoCreatedTransaction :execrows
+UPDATE transactions
+SET deleted_at            = NOW(),
+    deleted_by            = $2,
+    reconciliation_status = 'REJECTED',
+    reconciled_by         = 'USER',
+    reconciled_at         = NOW()
+WHERE id = $1
+  AND source = 'STATEMENT_AUTO'
+  AND deleted_at IS NULL
+`
+
+type RejectAutoCreatedTransactionParams struct {
+	ID        pgtype.UUID
+	DeletedBy pgtype.Text
+}
+
+func (q *Queries) RejectAutoCreatedTransaction(ctx context.Context, arg RejectAutoCreatedTransactionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, rejectAutoCreatedTransaction, arg.ID, arg.DeletedBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreAutoCreatedTransaction = `-- name: RestoreAutoCreatedTransaction :execrows
+UPDATE transactions
+SET deleted_at            = NULL,
+    deleted_by            = NULL,
+    reconciliation_status = 'USER_VERIFIED',
+    reconciled_by         = 'USER',
+    reconciled_at         = NOW(),
+    statement_txn_id      = $2
+WHERE id = $1
+  AND source = 'STATEMENT_AUTO'
+  AND deleted_at IS NOT NULL
+`
+
+type RestoreAutoCreatedTransactionParams struct {
+	ID             pgtype.UUID
+	StatementTxnID pgtype.UUID
+}
+
+func (q *Queries) RestoreAutoCreatedTransaction(ctx context.Context, arg RestoreAutoCreatedTransactionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, restoreAutoCreatedTransaction, arg.ID, arg.StatementTxnID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revertTransactionReconciliation = `-- name: RevertTransactionReconciliation :exec
+UPDATE transactions
+SET reconciliation_status = 'UNRECONCILED',
+    reconciled_by         = NULL,
+    reconciled_at         = NULL,
+    statement_txn_id      = NULL
+WHERE id = $1
+  AND deleted_at IS NULL
+`
+
+func (q *Queries) RevertTransactionReconciliation(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, revertTransactionReconciliation, id)
+	return err
+}
+
 const softDeleteTxns = `-- name: SoftDeleteTxns :many
 UPDATE transactions
 SET (deleted_at, deleted_by) = ($1, $2)
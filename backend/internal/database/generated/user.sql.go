@@ -31,7 +31,7 @@ func (q *Queries) AdjustUserLifetimeMetrics(ctx context.Context, arg AdjustUserL
 }
 
 const getAuthUser = `-- name: GetAuthUser :one
-SELECT clerk_id, email, database_url, lifetime_income, lifetime_expense, use_llm_parsing, llm_parse_credits, is_active, created_at, updated_at, transaction_image_parse_attempts, transaction_image_parse_successes, api_key, qr_string, reconciliation_threshold, monthly_budget FROM users WHERE clerk_id=$1
+SELECT clerk_id, email, database_url, lifetime_income, lifetime_expense, use_llm_parsing, llm_parse_credits, is_active, created_at, updated_at, transaction_image_parse_attempts, transaction_image_parse_successes, api_key, qr_string, reconciliation_threshold, monthly_budget, llm_plan, llm_credits_reset_at FROM users WHERE clerk_id=$1
 `
 
 func (q *Queries) GetAuthUser(ctx context.Context, clerkID string) (User, error) {
@@ -54,12 +54,14 @@ func (q *Queries) GetAuthUser(ctx context.Context, clerkID string) (User, error)
 		&i.QrString,
 		&i.ReconciliationThreshold,
 		&i.MonthlyBudget,
+		&i.LlmPlan,
+		&i.LlmCreditsResetAt,
 	)
 	return i, err
 }
 
 const getUserByApiKey = `-- name: GetUserByApiKey :one
-SELECT clerk_id, email, database_url, lifetime_income, lifetime_expense, use_llm_parsing, llm_parse_credits, is_active, created_at, updated_at, transaction_image_parse_attempts, transaction_image_parse_successes, api_key, qr_string, reconciliation_threshold, monthly_budget FROM users WHERE api_key=$1
+SELECT clerk_id, email, database_url, lifetime_income, lifetime_expense, use_llm_parsing, llm_parse_credits, is_active, created_at, updated_at, transaction_image_parse_attempts, transaction_image_parse_successes, api_key, qr_string, reconciliation_threshold, monthly_budget, llm_plan, llm_credits_reset_at FROM users WHERE api_key=$1
 `
 
 func (q *Queries) GetUserByApiKey(ctx context.Context, apiKey pgtype.Text) (User, error) {
@@ -82,6 +84,8 @@ func (q *Queries) GetUserByApiKey(ctx context.Context, apiKey pgtype.Text) (User
 		&i.QrString,
 		&i.ReconciliationThreshold,
 		&i.MonthlyBudget,
+		&i.LlmPlan,
+		&i.LlmCreditsResetAt,
 	)
 	return i, err
 }
@@ -89,7 +93,7 @@ func (q *Queries) GetUserByApiKey(ctx context.Context, apiKey pgtype.Text) (User
 const insertUser = `-- name: InsertUser :one
 INSERT INTO users (email, clerk_id)
 VALUES ($1, $2)
-RETURNING clerk_id, email, database_url, lifetime_income, lifetime_expense, use_llm_parsing, llm_parse_credits, is_active, created_at, updated_at, transaction_image_parse_attempts, transaction_image_parse_successes, api_key, qr_string, reconciliation_threshold, monthly_budget
+RETURNING clerk_id, email, database_url, lifetime_income, lifetime_expense, use_llm_parsing, llm_parse_credits, is_active, created_at, updated_at, transaction_image_parse_attempts, transaction_image_parse_successes, api_key, qr_string, reconciliation_threshold, monthly_budget, llm_plan, llm_credits_reset_at
 `
 
 type InsertUserParams struct {
@@ -117,6 +121,8 @@ func (q *Queries) InsertUser(ctx context.Context, arg InsertUserParams) (User, e
 		&i.QrString,
 		&i.ReconciliationThreshold,
 		&i.MonthlyBudget,
+		&i.LlmPlan,
+		&i.LlmCreditsResetAt,
 	)
 	return i, err
 }
@@ -127,7 +133,7 @@ UPDATE users SET
   database_url=COALESCE($2, database_url),
   lifetime_income=COALESCE($3, lifetime_income),
   lifetime_expense=COALESCE($4, lifetime_expense)
-WHERE clerk_id=$5 RETURNING clerk_id, email, database_url, lifetime_income, lifetime_expense, use_llm_parsing, llm_parse_credits, is_active, created_at, updated_at, transaction_image_parse_attempts, transaction_image_parse_successes, api_key, qr_string, reconciliation_threshold, monthly_budget
+WHERE clerk_id=$5 RETURNING clerk_id, email, database_url, lifetime_income, lifetime_expense, use_llm_parsing, llm_parse_credits, is_active, created_at, updated_at, transaction_image_parse_attempts, transaction_image_parse_successes, api_key, qr_string, reconciliation_threshold, monthly_budget, llm_plan, llm_credits_reset_at
 `
 
 type UpdateUserParams struct {
@@ -164,6 +170,8 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.QrString,
 		&i.ReconciliationThreshold,
 		&i.MonthlyBudget,
+		&i.LlmPlan,
+		&i.LlmCreditsResetAt,
 	)
 	return i, err
 }
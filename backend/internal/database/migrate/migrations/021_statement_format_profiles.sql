@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS statement_format_profiles (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id VARCHAR(255) NOT NULL REFERENCES users(clerk_id) ON DELETE CASCADE,
+  bank_id UUID NOT NULL REFERENCES banks(id) ON DELETE CASCADE,
+  profile JSONB NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (user_id, bank_id)
+);
+
+COMMENT ON COLUMN statement_format_profiles.profile IS 'User-defined column mapping for a bank not covered by the built-in statement profiles';
+
+-- +goose Down
+DROP TABLE IF EXISTS statement_format_profiles;
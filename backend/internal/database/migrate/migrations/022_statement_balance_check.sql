@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE bank_statement_uploads
+  ADD COLUMN IF NOT EXISTS closing_balance DECIMAL(15,2),
+  ADD COLUMN IF NOT EXISTS balance_drift DECIMAL(15,2);
+
+COMMENT ON COLUMN bank_statement_uploads.closing_balance IS 'Running balance on the last statement row, when the statement has a balance column';
+COMMENT ON COLUMN bank_statement_uploads.balance_drift IS 'closing_balance minus accounts.current_balance at parse time';
+
+-- +goose Down
+ALTER TABLE bank_statement_uploads
+  DROP COLUMN IF EXISTS balance_drift,
+  DROP COLUMN IF EXISTS closing_balance;
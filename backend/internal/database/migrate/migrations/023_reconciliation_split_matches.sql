@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TYPE reconciliation_result_type ADD VALUE IF NOT EXISTS 'SPLIT_MATCH';
+
+CREATE TABLE IF NOT EXISTS reconciliation_match_members (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  reconciliation_id UUID NOT NULL REFERENCES transaction_reconciliation(id) ON DELETE CASCADE,
+  statement_transaction_id UUID REFERENCES statement_transactions(id),
+  app_transaction_id UUID REFERENCES transactions(id),
+  amount DECIMAL(15,2) NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  CHECK (statement_transaction_id IS NOT NULL OR app_transaction_id IS NOT NULL)
+);
+
+COMMENT ON TABLE reconciliation_match_members IS 'Every statement row and app transaction taking part in a SPLIT_MATCH result';
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_match_members_reconciliation_id ON reconciliation_match_members(reconciliation_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_reconciliation_match_members_reconciliation_id;
+DROP TABLE IF EXISTS reconciliation_match_members;
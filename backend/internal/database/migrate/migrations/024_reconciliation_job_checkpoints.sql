@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS reconciliation_job_checkpoints (
+  job_id TEXT PRIMARY KEY,
+  upload_id UUID NOT NULL REFERENCES bank_statement_uploads(id) ON DELETE CASCADE,
+  stage TEXT NOT NULL DEFAULT 'STARTED' CHECK (stage IN ('STARTED', 'COMMITTED', 'COMPLETED')),
+  attempts INTEGER NOT NULL DEFAULT 1,
+  created_txn_ids UUID[] NOT NULL DEFAULT '{}',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE reconciliation_job_checkpoints IS 'Progress of each reconciliation job run so a retried invocation can resume or no-op';
+COMMENT ON COLUMN reconciliation_job_checkpoints.stage IS 'STARTED: nothing committed yet; COMMITTED: auto-creates, balances and results written; COMPLETED: upload marked COMPLETED';
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_job_checkpoints_upload_id ON reconciliation_job_checkpoints(upload_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_reconciliation_job_checkpoints_upload_id;
+DROP TABLE IF EXISTS reconciliation_job_checkpoints;
@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE bank_statement_uploads
+  ADD COLUMN IF NOT EXISTS processed_rows INT DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS processed_chunks INT DEFAULT 0;
+
+COMMENT ON COLUMN bank_statement_uploads.processed_rows IS 'Statement rows the reconciliation job has persisted results for so far';
+COMMENT ON COLUMN bank_statement_uploads.processed_chunks IS 'Date-ordered chunks the reconciliation job has committed so far';
+
+ALTER TABLE reconciliation_job_checkpoints
+  ADD COLUMN IF NOT EXISTS chunks_committed INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_match_members_stmt_txn_id ON reconciliation_match_members(statement_transaction_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_reconciliation_match_members_stmt_txn_id;
+
+ALTER TABLE reconciliation_job_checkpoints
+  DROP COLUMN IF EXISTS chunks_committed;
+
+ALTER TABLE bank_statement_uploads
+  DROP COLUMN IF EXISTS processed_chunks,
+  DROP COLUMN IF EXISTS processed_rows;
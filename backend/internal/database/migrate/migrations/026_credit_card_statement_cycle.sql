@@ -0,0 +1,24 @@
+-- +goose Up
+ALTER TABLE bank_statement_uploads
+  ADD COLUMN IF NOT EXISTS statement_date DATE,
+  ADD COLUMN IF NOT EXISTS payment_due_date DATE,
+  ADD COLUMN IF NOT EXISTS minimum_due DECIMAL(15,2),
+  ADD COLUMN IF NOT EXISTS total_due DECIMAL(15,2);
+
+COMMENT ON COLUMN bank_statement_uploads.statement_date IS 'Billing cycle close date printed on a credit card statement';
+COMMENT ON COLUMN bank_statement_uploads.payment_due_date IS 'Date the credit card bill must be paid by';
+COMMENT ON COLUMN bank_statement_uploads.minimum_due IS 'Minimum amount due for the billing cycle';
+COMMENT ON COLUMN bank_statement_uploads.total_due IS 'Total amount due (outstanding) for the billing cycle';
+
+CREATE INDEX IF NOT EXISTS idx_transactions_to_account
+  ON transactions (to_account_id)
+  WHERE to_account_id IS NOT NULL AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_transactions_to_account;
+
+ALTER TABLE bank_statement_uploads
+  DROP COLUMN IF EXISTS total_due,
+  DROP COLUMN IF EXISTS minimum_due,
+  DROP COLUMN IF EXISTS payment_due_date,
+  DROP COLUMN IF EXISTS statement_date;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TYPE job_type ADD VALUE IF NOT EXISTS 'RECON_CALIBRATION';
+
+CREATE TABLE IF NOT EXISTS reconciliation_scoring_profiles (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id VARCHAR(255) NOT NULL REFERENCES users(clerk_id) ON DELETE CASCADE,
+  account_id UUID REFERENCES accounts(id) ON DELETE CASCADE,
+  profile JSONB NOT NULL,
+  auto_calibrate BOOLEAN NOT NULL DEFAULT TRUE,
+  calibration_stats JSONB,
+  calibrated_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE reconciliation_scoring_profiles IS 'Match scoring weights, date window and amount tolerance for a user (account_id NULL) or one of their accounts';
+COMMENT ON COLUMN reconciliation_scoring_profiles.auto_calibrate IS 'When false the calibration job only refreshes calibration_stats and leaves profile as the user set it';
+COMMENT ON COLUMN reconciliation_scoring_profiles.calibration_stats IS 'What the last calibration learned from the user''s accepted and rejected results';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reconciliation_scoring_profiles_user_account
+  ON reconciliation_scoring_profiles (user_id, (COALESCE(account_id, '00000000-0000-0000-0000-000000000000'::uuid)));
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_reconciliation_scoring_profiles_user_account;
+DROP TABLE IF EXISTS reconciliation_scoring_profiles;
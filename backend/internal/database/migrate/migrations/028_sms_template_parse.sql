@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE sms_logs
+  ADD COLUMN IF NOT EXISTS parsed_template VARCHAR(100),
+  ADD COLUMN IF NOT EXISTS available_balance DECIMAL(15,2);
+
+COMMENT ON COLUMN sms_logs.parsed_template IS 'Server-side SMS template that parsed the message, NULL when it was parsed on the device or by the LLM';
+COMMENT ON COLUMN sms_logs.available_balance IS 'Available balance the bank printed in the SMS';
+
+-- +goose Down
+ALTER TABLE sms_logs
+  DROP COLUMN IF EXISTS available_balance,
+  DROP COLUMN IF EXISTS parsed_template;
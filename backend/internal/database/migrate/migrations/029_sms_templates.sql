@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS sms_templates (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id VARCHAR(255) NOT NULL REFERENCES users(clerk_id) ON DELETE CASCADE,
+  sender VARCHAR(50) NOT NULL,
+  pattern TEXT NOT NULL,
+  txn_type txn_type NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'candidate',
+  hits INTEGER NOT NULL DEFAULT 0,
+  misses INTEGER NOT NULL DEFAULT 0,
+  last_seen_at TIMESTAMP,
+  promoted_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE sms_templates IS 'SMS templates learned from the LLM parses of a user''s bank messages';
+COMMENT ON COLUMN sms_templates.sender IS 'Sender header without the operator prefix, e.g. HDFCBK';
+COMMENT ON COLUMN sms_templates.status IS 'candidate while it is checked against LLM parses, active once it parses SMS in place of the LLM';
+COMMENT ON COLUMN sms_templates.hits IS 'LLM parses it agreed with while a candidate, plus SMS it parsed once active';
+COMMENT ON COLUMN sms_templates.misses IS 'LLM parses of messages it matched that it read differently';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sms_templates_user_sender_pattern
+  ON sms_templates (user_id, sender, md5(pattern));
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_sms_templates_user_sender_pattern;
+DROP TABLE IF EXISTS sms_templates;
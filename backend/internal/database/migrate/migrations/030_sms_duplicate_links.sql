@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE sms_logs
+  ADD COLUMN IF NOT EXISTS merged_transaction_id UUID REFERENCES transactions(id) ON DELETE SET NULL,
+  ADD COLUMN IF NOT EXISTS parsed_txn JSONB;
+
+COMMENT ON COLUMN sms_logs.merged_transaction_id IS 'Existing transaction this SMS repeats, linked instead of creating another';
+COMMENT ON COLUMN sms_logs.parsed_txn IS 'Transaction parsed from a merged SMS, kept so the merge can be split back apart';
+
+CREATE INDEX IF NOT EXISTS idx_sms_logs_merged_transaction
+  ON sms_logs (merged_transaction_id)
+  WHERE merged_transaction_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_sms_logs_merged_transaction;
+
+ALTER TABLE sms_logs
+  DROP COLUMN IF EXISTS parsed_txn,
+  DROP COLUMN IF EXISTS merged_transaction_id;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE sms_logs
+  ADD COLUMN IF NOT EXISTS client_message_id VARCHAR(100);
+
+COMMENT ON COLUMN sms_logs.client_message_id IS 'ID the device gave the message, so a resent batch does not store it twice';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sms_logs_user_client_message
+  ON sms_logs (user_id, client_message_id)
+  WHERE client_message_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_sms_logs_user_client_message;
+
+ALTER TABLE sms_logs
+  DROP COLUMN IF EXISTS client_message_id;
@@ -0,0 +1,55 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS llm_plans (
+  name VARCHAR(20) PRIMARY KEY,
+  monthly_credits INTEGER NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE llm_plans IS 'Plans a user can be on, with the LLM parse credits each grants per calendar month';
+
+INSERT INTO llm_plans (name, monthly_credits) VALUES
+  ('free', 50),
+  ('pro', 1000)
+ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS llm_plan VARCHAR(20) NOT NULL DEFAULT 'free' REFERENCES llm_plans(name),
+  ADD COLUMN IF NOT EXISTS llm_credits_reset_at TIMESTAMP;
+
+COMMENT ON COLUMN users.llm_plan IS 'Plan that sets how many LLM parse credits the user gets each month';
+COMMENT ON COLUMN users.llm_credits_reset_at IS 'Start of the month llm_parse_credits was last topped up for; NULL until the first LLM parse';
+
+CREATE TABLE IF NOT EXISTS llm_credit_ledger (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id VARCHAR(255) NOT NULL REFERENCES users(clerk_id) ON DELETE CASCADE,
+  feature VARCHAR(50) NOT NULL,
+  delta INTEGER NOT NULL,
+  balance_after INTEGER NOT NULL,
+  reference_id UUID,
+  refund_of UUID REFERENCES llm_credit_ledger(id) ON DELETE CASCADE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE llm_credit_ledger IS 'One row per LLM parse credit taken or given back';
+COMMENT ON COLUMN llm_credit_ledger.feature IS 'What the credit paid for: sms_parse or txn_image_parse';
+COMMENT ON COLUMN llm_credit_ledger.delta IS '-1 for a reservation, +1 for a refund';
+COMMENT ON COLUMN llm_credit_ledger.reference_id IS 'SMS the credit was spent on, when there is one';
+COMMENT ON COLUMN llm_credit_ledger.refund_of IS 'Reservation a refund gives back';
+
+CREATE INDEX IF NOT EXISTS idx_llm_credit_ledger_user_created
+  ON llm_credit_ledger (user_id, created_at DESC);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_llm_credit_ledger_refund_of
+  ON llm_credit_ledger (refund_of)
+  WHERE refund_of IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_llm_credit_ledger_refund_of;
+DROP INDEX IF EXISTS idx_llm_credit_ledger_user_created;
+DROP TABLE IF EXISTS llm_credit_ledger;
+
+ALTER TABLE users
+  DROP COLUMN IF EXISTS llm_credits_reset_at,
+  DROP COLUMN IF EXISTS llm_plan;
+
+DROP TABLE IF EXISTS llm_plans;
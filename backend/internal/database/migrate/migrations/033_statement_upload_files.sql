@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS statement_upload_files (
+  upload_id UUID PRIMARY KEY REFERENCES bank_statement_uploads(id) ON DELETE CASCADE,
+  mime_type VARCHAR(100) NOT NULL,
+  content BYTEA NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE statement_upload_files IS 'Statement files whose rows the reconciliation job still has to extract; removed once the rows are stored';
+COMMENT ON COLUMN statement_upload_files.content IS 'The uploaded file, or the text of a password-protected PDF';
+
+-- +goose Down
+DROP TABLE IF EXISTS statement_upload_files;
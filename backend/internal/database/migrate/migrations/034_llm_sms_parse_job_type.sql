@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TYPE job_type ADD VALUE IF NOT EXISTS 'LLM_SMS_PARSE';
+
+-- +goose Down
+-- Postgres cannot drop a value from an enum; LLM_SMS_PARSE is left in place.
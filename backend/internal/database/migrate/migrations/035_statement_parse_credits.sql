@@ -0,0 +1,7 @@
+-- +goose Up
+COMMENT ON COLUMN llm_credit_ledger.feature IS 'What the credit paid for: sms_parse, txn_image_parse or statement_parse';
+COMMENT ON COLUMN llm_credit_ledger.reference_id IS 'SMS or statement upload the credit was spent on, when there is one';
+
+-- +goose Down
+COMMENT ON COLUMN llm_credit_ledger.feature IS 'What the credit paid for: sms_parse or txn_image_parse';
+COMMENT ON COLUMN llm_credit_ledger.reference_id IS 'SMS the credit was spent on, when there is one';
@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS idx_statement_upload_files_created_at ON statement_upload_files (created_at);
+
+COMMENT ON TABLE statement_upload_files IS 'Statement files whose rows the reconciliation job still has to extract; removed once the rows are stored, when the job fails, or a day after upload';
+
+-- +goose Down
+COMMENT ON TABLE statement_upload_files IS 'Statement files whose rows the reconciliation job still has to extract; removed once the rows are stored';
+
+DROP INDEX IF EXISTS idx_statement_upload_files_created_at;
@@ -0,0 +1,5 @@
+-- +goose Up
+COMMENT ON COLUMN sms_templates.misses IS 'LLM or device parses of messages it matched that it read differently; an active template is demoted once they pass a threshold';
+
+-- +goose Down
+COMMENT ON COLUMN sms_templates.misses IS 'LLM parses of messages it matched that it read differently';
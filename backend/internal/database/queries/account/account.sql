@@ -54,3 +54,7 @@ WHERE id = sqlc.arg(id)
 SELECT * FROM accounts
 WHERE user_id = $1 AND account_number = $2 AND deleted_at IS NULL
 LIMIT 1;
+
+-- name: ListAccountsByNumberSuffix :many
+SELECT * FROM accounts
+WHERE user_id = $1 AND account_number LIKE '%' || sqlc.arg(suffix)::text AND deleted_at IS NULL;
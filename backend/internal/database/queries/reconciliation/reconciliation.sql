@@ -53,6 +53,10 @@ WHERE id = $1;
 SELECT id, user_id, account_id, file_name, upload_status, processing_status,
        statement_period_start, statement_period_end,
        valid_rows, duplicate_rows, error_rows, parsing_errors,
+       closing_balance, balance_drift,
+       matched_transactions, unmatched_transactions, missing_transactions,
+       total_transactions_found, processed_rows, processed_chunks,
+       statement_date, payment_due_date, minimum_due, total_due,
        created_at, updated_at
 FROM bank_statement_uploads
 WHERE id = $1 AND user_id = $2;
@@ -76,12 +80,26 @@ SELECT
 FROM statement_transactions
 WHERE upload_id = $1 AND is_duplicate = false AND deleted_at IS NULL;
 
+-- Next date-ordered chunk of dated rows that have no reconciliation result yet.
 -- name: GetStatementTransactionsForProcessing :many
-SELECT id, upload_id, account_id, transaction_date,
-       description, amount, type, reference_number, raw_row_hash
-FROM statement_transactions
+SELECT st.id, st.upload_id, st.account_id, st.transaction_date,
+       st.description, st.amount, st.type, st.reference_number, st.raw_row_hash
+FROM statement_transactions st
+WHERE st.upload_id = $1 AND st.is_duplicate = false AND st.deleted_at IS NULL
+  AND st.transaction_date IS NOT NULL
+  AND NOT EXISTS (
+      SELECT 1 FROM transaction_reconciliation tr WHERE tr.statement_transaction_id = st.id
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM reconciliation_match_members m WHERE m.statement_transaction_id = st.id
+  )
+ORDER BY st.transaction_date ASC, st.id ASC
+LIMIT $2;
+
+-- name: CountStatementTransactionsForProcessing :one
+SELECT COUNT(*) FROM statement_transactions
 WHERE upload_id = $1 AND is_duplicate = false AND deleted_at IS NULL
-ORDER BY transaction_date ASC;
+  AND transaction_date IS NOT NULL;
 
 -- name: InsertReconciliationResultBatch :batchexec
 INSERT INTO transaction_reconciliation (
@@ -91,9 +109,27 @@ INSERT INTO transaction_reconciliation (
 
 -- name: UpdateUploadProcessingStatus :exec
 UPDATE bank_statement_uploads
-SET processing_status = $2, job_id = $3
+SET processing_status = $2, job_id = $3, updated_at = NOW()
 WHERE id = $1;
 
+-- ClaimUploadForReprocess moves an upload back to UPLOADED unless a reconciliation is
+-- still queued or running for it, in which case no row is returned. An upload left
+-- UPLOADED or PROCESSING with no live job, or untouched for an hour, can be claimed.
+-- name: ClaimUploadForReprocess :one
+UPDATE bank_statement_uploads u
+SET processing_status = 'UPLOADED', updated_at = NOW()
+WHERE u.id = $1 AND u.user_id = $2
+  AND (u.processing_status IS NULL
+    OR u.processing_status NOT IN ('UPLOADED', 'PROCESSING')
+    OR u.updated_at < NOW() - INTERVAL '1 hour'
+    OR NOT EXISTS (
+      SELECT 1 FROM jobs j
+      WHERE j.job_type = 'BANK_RECONCILIATION'
+        AND j.status IN ('pending', 'processing')
+        AND j.payload->>'upload_id' = u.id::text
+    ))
+RETURNING u.id;
+
 -- name: GetReconciliationResultsByUploadID :many
 SELECT
     tr.id,
@@ -111,17 +147,25 @@ SELECT
     st.amount            AS stmt_amount,
     st.type              AS stmt_type,
     st.reference_number  AS stmt_reference_number,
-    st.row_number        AS stmt_row_number
+    st.row_number        AS stmt_row_number,
+    t.transaction_date   AS app_date,
+    t.description        AS app_description,
+    t.amount             AS app_amount,
+    t.type               AS app_type,
+    t.source             AS app_source
 FROM transaction_reconciliation tr
-JOIN statement_transactions st ON st.id = tr.statement_transaction_id AND st.deleted_at IS NULL
+LEFT JOIN statement_transactions st ON st.id = tr.statement_transaction_id AND st.deleted_at IS NULL
+LEFT JOIN transactions t ON t.id = tr.app_transaction_id
 WHERE tr.upload_id = $1
-ORDER BY st.transaction_date ASC, tr.result_type ASC
+  AND (tr.statement_transaction_id IS NULL OR st.id IS NOT NULL)
+ORDER BY COALESCE(st.transaction_date, t.transaction_date) ASC, tr.result_type ASC
 LIMIT $2 OFFSET $3;
 
 -- name: CountReconciliationResultsByUploadID :one
 SELECT COUNT(*) FROM transaction_reconciliation tr
-JOIN statement_transactions st ON st.id = tr.statement_transaction_id AND st.deleted_at IS NULL
-WHERE tr.upload_id = $1;
+LEFT JOIN statement_transactions st ON st.id = tr.statement_transaction_id AND st.deleted_at IS NULL
+WHERE tr.upload_id = $1
+  AND (tr.statement_transaction_id IS NULL OR st.id IS NOT NULL);
 
 -- name: BulkUpdateReconciliationResultStatus :many
 UPDATE transaction_reconciliation tr
@@ -135,4 +179,267 @@ WHERE tr.id = ANY($1::uuid[])
   AND tr.upload_id = bsu.id
   AND tr.upload_id = $4
   AND bsu.user_id = $3
-RETURNING tr.id, tr.user_action;
+RETURNING tr.id, tr.user_action, tr.reviewed_by, tr.reviewed_at;
+
+-- name: GetAccountCurrentBalance :one
+SELECT current_balance FROM accounts
+WHERE id = $1 AND user_id = $2;
+
+-- name: GetAccountType :one
+SELECT account_type FROM accounts
+WHERE id = $1 AND user_id = $2;
+
+-- GetCardIdentity returns what a bill payment narration may name a card by: its
+-- number and its bank's name and code.
+-- name: GetCardIdentity :one
+SELECT a.account_number,
+       COALESCE(b.name, '')::text AS bank_name,
+       COALESCE(b.code, '')::text AS bank_code
+FROM accounts a
+LEFT JOIN banks b ON b.id = a.bank_id
+WHERE a.id = $1 AND a.user_id = $2;
+
+-- name: UpdateUploadResultCounts :exec
+UPDATE bank_statement_uploads
+SET
+    total_transactions_found = $2,
+    matched_transactions     = $3,
+    unmatched_transactions   = $4,
+    missing_transactions     = $5,
+    updated_at               = NOW()
+WHERE id = $1;
+
+-- name: UpdateUploadBalanceCheck :exec
+UPDATE bank_statement_uploads
+SET
+    closing_balance = $2,
+    balance_drift   = $3,
+    updated_at      = NOW()
+WHERE id = $1;
+
+-- name: SaveStatementUploadFile :exec
+INSERT INTO statement_upload_files (upload_id, mime_type, content)
+VALUES ($1, $2, $3)
+ON CONFLICT (upload_id) DO UPDATE SET mime_type = EXCLUDED.mime_type, content = EXCLUDED.content;
+
+-- GetStatementUploadFile returns the file an upload's rows still have to be extracted
+-- from, with the total due read off a card statement's cycle summary.
+-- name: GetStatementUploadFile :one
+SELECT f.mime_type, f.content, u.total_due
+FROM statement_upload_files f
+JOIN bank_statement_uploads u ON u.id = f.upload_id
+WHERE f.upload_id = $1;
+
+-- name: DeleteStatementUploadFile :exec
+DELETE FROM statement_upload_files WHERE upload_id = $1;
+
+-- DeleteExpiredStatementUploadFiles removes statement files still waiting to be
+-- extracted a day after upload and marks their uploads FAILED.
+-- name: DeleteExpiredStatementUploadFiles :execrows
+WITH expired AS (
+    DELETE FROM statement_upload_files
+    WHERE created_at < NOW() - INTERVAL '1 day'
+    RETURNING upload_id
+)
+UPDATE bank_statement_uploads u
+SET processing_status = 'FAILED', updated_at = NOW()
+FROM expired
+WHERE u.id = expired.upload_id;
+
+-- name: UpdateUploadCardCycle :exec
+UPDATE bank_statement_uploads
+SET
+    statement_date   = $2,
+    payment_due_date = $3,
+    minimum_due      = $4,
+    total_due        = $5,
+    updated_at       = NOW()
+WHERE id = $1;
+
+-- name: InsertReconciliationGroup :one
+INSERT INTO transaction_reconciliation (
+    upload_id, statement_transaction_id, app_transaction_id,
+    result_type, confidence_score, match_signals, match_status
+) VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id;
+
+-- name: InsertReconciliationMatchMemberBatch :batchexec
+INSERT INTO reconciliation_match_members (
+    reconciliation_id, statement_transaction_id, app_transaction_id, amount
+) VALUES ($1, $2, $3, $4);
+
+-- name: ListReconciliationMatchMembers :many
+SELECT reconciliation_id, statement_transaction_id, app_transaction_id, amount
+FROM reconciliation_match_members
+WHERE reconciliation_id = ANY($1::uuid[])
+ORDER BY created_at ASC;
+
+-- name: GetReconciliationReviewTargets :many
+SELECT tr.id AS reconciliation_id, tr.result_type, tr.statement_transaction_id,
+       t.id AS app_transaction_id, t.account_id, t.to_account_id, t.type, t.amount, t.source,
+       t.reconciliation_status, t.deleted_at, bsu.account_id AS upload_account_id
+FROM transaction_reconciliation tr
+JOIN bank_statement_uploads bsu ON bsu.id = tr.upload_id
+LEFT JOIN reconciliation_match_members rmm
+       ON rmm.reconciliation_id = tr.id AND rmm.app_transaction_id IS NOT NULL
+JOIN transactions t ON t.id = COALESCE(rmm.app_transaction_id, tr.app_transaction_id)
+WHERE tr.id = ANY($1::uuid[])
+  AND tr.upload_id = $2
+  AND bsu.user_id = $3;
+
+-- name: GetReconciliationResultForMatch :one
+SELECT tr.id, tr.upload_id, tr.result_type, tr.app_transaction_id, bsu.account_id,
+       st.id AS statement_transaction_id, st.transaction_date, st.description,
+       st.amount, st.type, st.reference_number,
+       t.source AS app_source, t.type AS app_type, t.amount AS app_amount,
+       t.deleted_at AS app_deleted_at
+FROM transaction_reconciliation tr
+JOIN bank_statement_uploads bsu ON bsu.id = tr.upload_id
+JOIN statement_transactions st ON st.id = tr.statement_transaction_id AND st.deleted_at IS NULL
+LEFT JOIN transactions t ON t.id = tr.app_transaction_id
+WHERE tr.id = $1
+  AND bsu.user_id = $2;
+
+-- name: UpdateReconciliationResultManualMatch :exec
+UPDATE transaction_reconciliation
+SET app_transaction_id = $2,
+    result_type        = 'MANUALLY_MATCHED',
+    confidence_score   = $3,
+    match_signals      = $4,
+    match_status       = 'manually_matched',
+    user_action        = 'accepted',
+    user_action_at     = NOW(),
+    reviewed_by        = 'USER',
+    reviewed_at        = NOW(),
+    updated_at         = NOW()
+WHERE id = $1;
+
+-- name: DeleteNotInStatementResult :exec
+DELETE FROM transaction_reconciliation
+WHERE upload_id = $1
+  AND app_transaction_id = $2
+  AND result_type = 'NOT_IN_STATEMENT';
+
+-- name: ListSystemReconciliationResults :many
+SELECT tr.id, tr.result_type, tr.statement_transaction_id, tr.app_transaction_id,
+       t.source AS app_source, t.reconciliation_status AS app_reconciliation_status,
+       t.statement_txn_id AS app_statement_txn_id
+FROM transaction_reconciliation tr
+LEFT JOIN transactions t ON t.id = tr.app_transaction_id AND t.deleted_at IS NULL
+WHERE tr.upload_id = $1
+  AND tr.user_action IS NULL
+  AND tr.result_type <> 'MANUALLY_MATCHED';
+
+-- name: DeleteSystemReconciliationResults :execrows
+DELETE FROM transaction_reconciliation
+WHERE upload_id = $1
+  AND user_action IS NULL
+  AND result_type <> 'MANUALLY_MATCHED';
+
+-- name: ListDecidedReconciliationLinks :many
+SELECT tr.result_type, tr.user_action, tr.statement_transaction_id, tr.app_transaction_id
+FROM transaction_reconciliation tr
+WHERE tr.upload_id = $1
+  AND (tr.user_action IS NOT NULL OR tr.result_type = 'MANUALLY_MATCHED')
+UNION ALL
+SELECT tr.result_type, tr.user_action, rmm.statement_transaction_id, rmm.app_transaction_id
+FROM reconciliation_match_members rmm
+JOIN transaction_reconciliation tr ON tr.id = rmm.reconciliation_id
+WHERE tr.upload_id = $1
+  AND (tr.user_action IS NOT NULL OR tr.result_type = 'MANUALLY_MATCHED');
+
+-- name: StartReconciliationJobCheckpoint :one
+INSERT INTO reconciliation_job_checkpoints (job_id, upload_id)
+VALUES ($1, $2)
+ON CONFLICT (job_id) DO UPDATE
+SET attempts = reconciliation_job_checkpoints.attempts + 1, updated_at = NOW()
+RETURNING stage, attempts, chunks_committed, created_txn_ids;
+
+-- Records one committed chunk, and COMMITTED after the last; matches nothing if another attempt advanced the job first.
+-- name: AdvanceReconciliationJobCheckpoint :execrows
+UPDATE reconciliation_job_checkpoints
+SET chunks_committed = chunks_committed + 1,
+    created_txn_ids  = created_txn_ids || sqlc.arg(created_txn_ids)::uuid[],
+    stage            = CASE WHEN sqlc.arg(final)::boolean THEN 'COMMITTED' ELSE stage END,
+    updated_at       = NOW()
+WHERE job_id = $1 AND stage = 'STARTED' AND chunks_committed = $2;
+
+-- name: CompleteReconciliationJobCheckpoint :exec
+UPDATE reconciliation_job_checkpoints
+SET stage = 'COMPLETED', updated_at = NOW()
+WHERE job_id = $1;
+
+-- App transactions already referenced by a result of this upload, split members included.
+-- name: ListClaimedAppTransactionIDs :many
+SELECT tr.app_transaction_id::uuid AS app_transaction_id
+FROM transaction_reconciliation tr
+WHERE tr.upload_id = $1 AND tr.app_transaction_id IS NOT NULL
+UNION
+SELECT m.app_transaction_id::uuid
+FROM reconciliation_match_members m
+JOIN transaction_reconciliation tr ON tr.id = m.reconciliation_id
+WHERE tr.upload_id = $1 AND m.app_transaction_id IS NOT NULL;
+
+-- name: ResetUploadProgress :exec
+UPDATE bank_statement_uploads
+SET
+    total_transactions_found = $2,
+    matched_transactions     = $3,
+    unmatched_transactions   = $4,
+    missing_transactions     = $5,
+    processed_rows           = $6,
+    processed_chunks         = 0,
+    updated_at               = NOW()
+WHERE id = $1;
+
+-- name: AddUploadChunkProgress :exec
+UPDATE bank_statement_uploads
+SET
+    processed_rows         = processed_rows + $2,
+    processed_chunks       = processed_chunks + 1,
+    matched_transactions   = matched_transactions + $3,
+    unmatched_transactions = unmatched_transactions + $4,
+    missing_transactions   = missing_transactions + $5,
+    updated_at             = NOW()
+WHERE id = $1;
+
+-- Every upload of an account with its stated period and the span of its rows.
+-- name: ListAccountStatementCoverage :many
+SELECT u.id, u.file_name, u.statement_period_start, u.statement_period_end, u.created_at,
+       MIN(st.transaction_date)::timestamptz AS min_date,
+       MAX(st.transaction_date)::timestamptz AS max_date,
+       COUNT(st.id) AS row_count
+FROM bank_statement_uploads u
+LEFT JOIN statement_transactions st
+       ON st.upload_id = u.id AND st.deleted_at IS NULL AND st.is_duplicate = false
+WHERE u.account_id = $1 AND u.user_id = $2
+GROUP BY u.id
+ORDER BY u.created_at ASC;
+
+-- Rows earlier uploads already hold for an account in a date range, for cross-upload dedupe.
+-- name: ListAccountStatementRowsInRange :many
+SELECT transaction_date, amount, type, reference_number, raw_row_hash
+FROM statement_transactions
+WHERE account_id = $1 AND deleted_at IS NULL AND is_duplicate = false
+  AND transaction_date >= sqlc.arg(from_date) AND transaction_date < sqlc.arg(to_date);
+
+-- Merchants whose alphanumeric-only lowercased name matches one of the narration keys.
+-- name: FindMerchantsByKeys :many
+SELECT id, regexp_replace(lower(COALESCE(normalized_name, name)), '[^a-z0-9]', '', 'g')::text AS merchant_key
+FROM merchants
+WHERE regexp_replace(lower(COALESCE(normalized_name, name)), '[^a-z0-9]', '', 'g') = ANY($1::text[]);
+
+-- ListCardPaymentAccounts lists the user's savings and current accounts with how many
+-- transfers each has already made to the card.
+-- name: ListCardPaymentAccounts :many
+SELECT a.id, COUNT(t.id)::int AS payments
+FROM accounts a
+LEFT JOIN transactions t
+       ON t.account_id = a.id AND t.to_account_id = $2 AND t.deleted_at IS NULL
+WHERE a.user_id = $1
+  AND a.id <> $2
+  AND LOWER(a.account_type) IN ('savings', 'current')
+  AND a.deleted_at IS NULL
+GROUP BY a.id
+ORDER BY payments DESC, a.id;
+
@@ -0,0 +1,76 @@
+-- GetScoringProfile returns the account's scoring profile, else the user's default one.
+-- name: GetScoringProfile :one
+SELECT * FROM reconciliation_scoring_profiles
+WHERE user_id = $1 AND (account_id = $2 OR account_id IS NULL)
+ORDER BY account_id NULLS LAST
+LIMIT 1;
+
+-- name: ListScoringProfiles :many
+SELECT * FROM reconciliation_scoring_profiles
+WHERE user_id = $1
+ORDER BY account_id NULLS FIRST, created_at;
+
+-- name: UpsertScoringProfile :one
+INSERT INTO reconciliation_scoring_profiles (
+    user_id,
+    account_id,
+    profile,
+    auto_calibrate
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (user_id, (COALESCE(account_id, '00000000-0000-0000-0000-000000000000'::uuid))) DO UPDATE
+SET profile        = EXCLUDED.profile,
+    auto_calibrate = EXCLUDED.auto_calibrate,
+    updated_at     = NOW()
+RETURNING *;
+
+-- SaveScoringCalibration stores a calibration run. The profile only replaces the
+-- current one when the run learned it and auto_calibrate is on; the stats are always
+-- refreshed.
+-- name: SaveScoringCalibration :one
+INSERT INTO reconciliation_scoring_profiles (
+    user_id,
+    account_id,
+    profile,
+    calibration_stats,
+    calibrated_at
+) VALUES (
+    $1, $2, $3, $4, NOW()
+)
+ON CONFLICT (user_id, (COALESCE(account_id, '00000000-0000-0000-0000-000000000000'::uuid))) DO UPDATE
+SET profile           = CASE WHEN reconciliation_scoring_profiles.auto_calibrate AND sqlc.arg(learned)::boolean
+                             THEN EXCLUDED.profile
+                             ELSE reconciliation_scoring_profiles.profile END,
+    calibration_stats = EXCLUDED.calibration_stats,
+    calibrated_at     = NOW(),
+    updated_at        = NOW()
+RETURNING *;
+
+-- CountUncalibratedDecisions counts the user's review decisions on one-to-one matches
+-- made since their default profile was last calibrated.
+-- name: CountUncalibratedDecisions :one
+SELECT COUNT(*)
+FROM transaction_reconciliation tr
+JOIN bank_statement_uploads bsu ON bsu.id = tr.upload_id
+LEFT JOIN reconciliation_scoring_profiles p ON p.user_id = bsu.user_id AND p.account_id IS NULL
+WHERE bsu.user_id = $1
+  AND tr.reviewed_by = 'USER'
+  AND tr.user_action IN ('accepted', 'rejected')
+  AND tr.result_type IN ('HIGH_CONFIDENCE_MATCH', 'LOW_CONFIDENCE_MATCH', 'MANUALLY_MATCHED')
+  AND tr.match_signals IS NOT NULL
+  AND (p.calibrated_at IS NULL OR tr.user_action_at > p.calibrated_at);
+
+-- ListCalibrationDecisions returns the match signals of the user's most recent review
+-- decisions on one-to-one matches, newest first.
+-- name: ListCalibrationDecisions :many
+SELECT bsu.account_id, tr.user_action, tr.match_signals
+FROM transaction_reconciliation tr
+JOIN bank_statement_uploads bsu ON bsu.id = tr.upload_id
+WHERE bsu.user_id = $1
+  AND tr.reviewed_by = 'USER'
+  AND tr.user_action IN ('accepted', 'rejected')
+  AND tr.result_type IN ('HIGH_CONFIDENCE_MATCH', 'LOW_CONFIDENCE_MATCH', 'MANUALLY_MATCHED')
+  AND tr.match_signals IS NOT NULL
+ORDER BY tr.user_action_at DESC NULLS LAST
+LIMIT $2;
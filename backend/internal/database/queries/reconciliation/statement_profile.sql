@@ -0,0 +1,31 @@
+-- name: GetAccountBank :one
+SELECT a.bank_id, b.code
+FROM accounts a
+JOIN banks b ON b.id = a.bank_id
+WHERE a.id = $1 AND a.user_id = $2;
+
+-- name: GetStatementFormatProfile :one
+SELECT * FROM statement_format_profiles
+WHERE user_id = $1 AND bank_id = $2;
+
+-- name: ListStatementFormatProfiles :many
+SELECT * FROM statement_format_profiles
+WHERE user_id = $1
+ORDER BY updated_at DESC;
+
+-- name: UpsertStatementFormatProfile :one
+INSERT INTO statement_format_profiles (
+    user_id,
+    bank_id,
+    profile
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (user_id, bank_id) DO UPDATE
+SET profile    = EXCLUDED.profile,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteStatementFormatProfile :exec
+DELETE FROM statement_format_profiles
+WHERE user_id = $1 AND bank_id = $2;
@@ -37,4 +37,114 @@ SET llm_parse_attempted = $2,
     error_message       = $6,
     updated_at          = NOW()
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+-- name: UpdateSmsTemplateParse :one
+UPDATE sms_logs
+SET parsed_template   = $2,
+    available_balance = $3,
+    updated_at        = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- FindDuplicateSmsTxn returns the transaction an SMS most likely repeats: one on the
+-- same account with the same type inside the window and the same reference number.
+-- When either side has no reference number, a transaction created from another SMS
+-- with the same amount counts too; reference matches come first, then closest in time.
+-- name: FindDuplicateSmsTxn :one
+SELECT id FROM transactions
+WHERE user_id = sqlc.arg(user_id)
+  AND type = sqlc.arg(type)
+  AND account_id = sqlc.arg(account_id)
+  AND transaction_date BETWEEN sqlc.arg(from_date) AND sqlc.arg(to_date)
+  AND deleted_at IS NULL
+  AND (
+    (sqlc.narg(reference_number)::text IS NOT NULL AND reference_number = sqlc.narg(reference_number)::text)
+    OR (
+      amount = sqlc.arg(amount)
+      AND sms_id IS NOT NULL
+      AND (sqlc.narg(reference_number)::text IS NULL OR NULLIF(reference_number, '') IS NULL)
+    )
+  )
+ORDER BY reference_number IS NOT DISTINCT FROM sqlc.narg(reference_number)::text DESC,
+         ABS(EXTRACT(EPOCH FROM (transaction_date - sqlc.arg(at)::timestamp)))
+LIMIT 1;
+
+-- name: MergeSmsIntoTxn :one
+UPDATE sms_logs
+SET merged_transaction_id = $2,
+    parsed_txn            = $3,
+    parsing_status        = $4,
+    updated_at            = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- SplitSmsMerge detaches a merged SMS from the transaction it was linked to. It only
+-- updates an SMS that is still merged, so a split cannot run twice.
+-- name: SplitSmsMerge :one
+UPDATE sms_logs
+SET merged_transaction_id = NULL,
+    parsed_txn            = NULL,
+    parsing_status        = $3,
+    updated_at            = NOW()
+WHERE id = $1 AND user_id = $2 AND merged_transaction_id IS NOT NULL
+RETURNING *;
+
+-- CreateSmsBatch stores a device's backlog in one statement. Messages whose
+-- client_message_id is already stored are skipped and not returned.
+-- name: CreateSmsBatch :many
+INSERT INTO sms_logs (
+    user_id,
+    sender,
+    raw_message,
+    received_at,
+    client_message_id
+)
+SELECT sqlc.arg(user_id)::varchar, m.sender, m.raw_message, m.received_at, m.client_message_id
+FROM unnest(
+    sqlc.arg(senders)::varchar[],
+    sqlc.arg(raw_messages)::text[],
+    sqlc.arg(received_ats)::timestamp[],
+    sqlc.arg(client_message_ids)::varchar[]
+) AS m(sender, raw_message, received_at, client_message_id)
+ON CONFLICT (user_id, client_message_id) WHERE client_message_id IS NOT NULL DO NOTHING
+RETURNING *;
+
+-- name: GetSmsesByClientMessageIds :many
+SELECT * FROM sms_logs
+WHERE user_id = $1 AND client_message_id = ANY(sqlc.arg(client_message_ids)::varchar[]);
+
+-- ListSmsDuplicateCandidates returns the stored transactions a batch of SMS could
+-- repeat: those on each message's account within window_secs of when it was received.
+-- name: ListSmsDuplicateCandidates :many
+SELECT DISTINCT t.id, t.account_id, t.type, t.amount, t.reference_number, t.transaction_date, t.sms_id
+FROM transactions t
+JOIN unnest(
+    sqlc.arg(account_ids)::uuid[],
+    sqlc.arg(received_ats)::timestamp[]
+) AS m(account_id, received_at) ON t.account_id = m.account_id
+WHERE t.user_id = sqlc.arg(user_id)
+  AND t.deleted_at IS NULL
+  AND t.transaction_date BETWEEN m.received_at - make_interval(secs => sqlc.arg(window_secs)::int)
+                             AND m.received_at + make_interval(secs => sqlc.arg(window_secs)::int)
+ORDER BY t.transaction_date;
+
+-- UpdateSmsBatchOutcomes records what processing a batch of stored SMS decided in one
+-- statement. Empty or NULL entries keep what the row already has.
+-- name: UpdateSmsBatchOutcomes :many
+UPDATE sms_logs s
+SET parsing_status        = COALESCE(NULLIF(o.parsing_status, ''), s.parsing_status),
+    parsed_template       = COALESCE(NULLIF(o.parsed_template, ''), s.parsed_template),
+    available_balance     = COALESCE(o.available_balance, s.available_balance),
+    merged_transaction_id = COALESCE(o.merged_transaction_id, s.merged_transaction_id),
+    parsed_txn            = COALESCE(o.parsed_txn, s.parsed_txn),
+    updated_at            = NOW()
+FROM unnest(
+    sqlc.arg(ids)::uuid[],
+    sqlc.arg(parsing_statuses)::varchar[],
+    sqlc.arg(parsed_templates)::varchar[],
+    sqlc.arg(available_balances)::numeric[],
+    sqlc.arg(merged_transaction_ids)::uuid[],
+    sqlc.arg(parsed_txns)::jsonb[]
+) AS o(id, parsing_status, parsed_template, available_balance, merged_transaction_id, parsed_txn)
+WHERE s.id = o.id AND s.user_id = sqlc.arg(user_id)
+RETURNING s.*;
@@ -0,0 +1,61 @@
+-- name: ListSmsTemplates :many
+SELECT * FROM sms_templates
+WHERE user_id = $1
+ORDER BY sender, status, hits DESC;
+
+-- name: ListSmsTemplatesBySender :many
+SELECT * FROM sms_templates
+WHERE user_id = $1 AND sender = $2
+ORDER BY hits DESC;
+
+-- name: ListActiveSmsTemplates :many
+SELECT * FROM sms_templates
+WHERE user_id = $1 AND sender = $2 AND status = 'active'
+ORDER BY hits DESC;
+
+-- name: CreateSmsTemplate :one
+INSERT INTO sms_templates (
+    user_id,
+    sender,
+    pattern,
+    txn_type,
+    hits,
+    last_seen_at
+) VALUES (
+    $1, $2, $3, $4, 1, NOW()
+)
+ON CONFLICT (user_id, sender, md5(pattern)) DO UPDATE
+SET hits         = sms_templates.hits + 1,
+    last_seen_at = NOW(),
+    updated_at   = NOW()
+RETURNING *;
+
+-- RecordSmsTemplateHit counts parses the template got right, and promotes a candidate
+-- that has reached promote_after hits without a miss.
+-- name: RecordSmsTemplateHit :one
+UPDATE sms_templates
+SET hits         = hits + sqlc.arg(hits)::int,
+    status       = CASE WHEN status = 'candidate' AND misses = 0 AND hits + sqlc.arg(hits)::int >= sqlc.arg(promote_after)::int
+                        THEN 'active' ELSE status END,
+    promoted_at  = CASE WHEN status = 'candidate' AND misses = 0 AND hits + sqlc.arg(hits)::int >= sqlc.arg(promote_after)::int
+                        THEN NOW() ELSE promoted_at END,
+    last_seen_at = NOW(),
+    updated_at   = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- RecordSmsTemplateMiss counts parses the template got wrong, and demotes an active
+-- template back to a candidate once its misses exceed max_misses. Promotion needs a
+-- clean record, so a demoted template stays a candidate.
+-- name: RecordSmsTemplateMiss :one
+UPDATE sms_templates
+SET misses       = misses + sqlc.arg(misses)::int,
+    status       = CASE WHEN status = 'active' AND misses + sqlc.arg(misses)::int > sqlc.arg(max_misses)::int
+                        THEN 'candidate' ELSE status END,
+    last_seen_at = NOW(),
+    updated_at   = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteSmsTemplate :exec
+DELETE FROM sms_templates WHERE id = $1 AND user_id = $2;
@@ -86,7 +86,7 @@ FROM transactions
 WHERE account_id = $1 AND deleted_at IS NULL;
 
 -- name: GetAppTransactionsInDateRange :many
-SELECT id, amount, transaction_date, type, description, reference_number
+SELECT id, amount, transaction_date, type, description, reference_number, is_excluded
 FROM transactions
 WHERE account_id = $1
   AND transaction_date BETWEEN $2 AND $3
@@ -119,3 +119,92 @@ SET reconciliation_status = 'AUTO_VERIFIED',
     reconciled_at         = NOW(),
     statement_txn_id      = $2
 WHERE id = $1;
+
+-- name: DiscardAutoCreatedTransactions :many
+UPDATE transactions
+SET deleted_at = NOW(),
+    deleted_by = $2
+WHERE id = ANY($1::uuid[])
+  AND source = 'STATEMENT_AUTO'
+  AND deleted_at IS NULL
+RETURNING account_id, to_account_id, type, amount;
+
+-- name: GetAppTransactionForMatch :one
+SELECT id, account_id, amount, transaction_date, type, description,
+       reference_number, statement_txn_id
+FROM transactions
+WHERE id = $1
+  AND user_id = $2
+  AND is_cash = false
+  AND deleted_at IS NULL;
+
+-- name: MarkTransactionUserVerified :exec
+UPDATE transactions
+SET reconciliation_status = 'USER_VERIFIED',
+    reconciled_by         = 'USER',
+    reconciled_at         = NOW(),
+    statement_txn_id      = $2
+WHERE id = $1;
+
+-- name: RevertTransactionReconciliation :exec
+UPDATE transactions
+SET reconciliation_status = 'UNRECONCILED',
+    reconciled_by         = NULL,
+    reconciled_at         = NULL,
+    statement_txn_id      = NULL
+WHERE id = $1
+  AND deleted_at IS NULL;
+
+-- name: RejectAutoCreatedTransaction :execrows
+UPDATE transactions
+SET deleted_at            = NOW(),
+    deleted_by            = $2,
+    reconciliation_status = 'REJECTED',
+    reconciled_by         = 'USER',
+    reconciled_at         = NOW()
+WHERE id = $1
+  AND source = 'STATEMENT_AUTO'
+  AND deleted_at IS NULL;
+
+-- name: RestoreAutoCreatedTransaction :execrows
+UPDATE transactions
+SET deleted_at            = NULL,
+    deleted_by            = NULL,
+    reconciliation_status = 'USER_VERIFIED',
+    reconciled_by         = 'USER',
+    reconciled_at         = NOW(),
+    statement_txn_id      = $2
+WHERE id = $1
+  AND source = 'STATEMENT_AUTO'
+  AND deleted_at IS NOT NULL;
+
+-- ListCardPaymentSources returns debits on the user's savings and current accounts that
+-- can be the other leg of a card bill payment: not yet a transfer, or already one to this card.
+-- The narration and payee are what tell a bill payment from any other debit of the amount.
+-- name: ListCardPaymentSources :many
+SELECT t.id, t.account_id, t.to_account_id, t.amount, t.transaction_date,
+       COALESCE(t.description, '')::text AS description,
+       COALESCE(m.name, '')::text AS payee
+FROM transactions t
+JOIN accounts a ON a.id = t.account_id
+LEFT JOIN merchants m ON m.id = t.merchant_id
+WHERE t.user_id = $1
+  AND t.account_id <> $2
+  AND (t.to_account_id IS NULL OR t.to_account_id = $2)
+  AND t.type = 'DEBIT'
+  AND t.transaction_date BETWEEN $3 AND $4
+  AND t.is_cash = false
+  AND t.deleted_at IS NULL
+  AND LOWER(a.account_type) IN ('savings', 'current')
+ORDER BY t.transaction_date, t.id;
+
+-- MarkTransactionTransfer turns a debit into a transfer to the given account. It
+-- returns no row when the transaction already was a transfer.
+-- name: MarkTransactionTransfer :one
+UPDATE transactions
+SET to_account_id = $2,
+    updated_at    = NOW()
+WHERE id = $1
+  AND to_account_id IS NULL
+  AND deleted_at IS NULL
+RETURNING id;
@@ -0,0 +1,82 @@
+-- ReserveLlmCredit takes one LLM parse credit from the user and records it in the
+-- ledger. The first reservation of a month tops the balance back up to the plan's
+-- allowance before taking from it. A reservation for a reference that already has one
+-- not refunded returns that one instead, so a retried job does not pay twice. Nothing
+-- is returned when no credit is left.
+-- name: ReserveLlmCredit :one
+WITH open_reservation AS (
+    SELECT l.id, l.user_id, l.feature, l.delta, l.balance_after, l.reference_id, l.refund_of, l.created_at
+    FROM llm_credit_ledger l
+    WHERE l.user_id = sqlc.arg(user_id)
+      AND l.feature = sqlc.arg(feature)::varchar
+      AND l.reference_id = sqlc.narg(reference_id)::uuid
+      AND l.delta < 0
+      AND NOT EXISTS (SELECT 1 FROM llm_credit_ledger r WHERE r.refund_of = l.id)
+    ORDER BY l.created_at DESC
+    LIMIT 1
+), reserved AS (
+    UPDATE users u
+    SET llm_parse_credits = (CASE
+            WHEN u.llm_credits_reset_at IS NULL OR u.llm_credits_reset_at < date_trunc('month', NOW())
+            THEN p.monthly_credits
+            ELSE COALESCE(u.llm_parse_credits, 0)
+        END) - 1,
+        llm_credits_reset_at = date_trunc('month', NOW())
+    FROM llm_plans p
+    WHERE u.clerk_id = sqlc.arg(user_id)
+      AND p.name = u.llm_plan
+      AND NOT EXISTS (SELECT 1 FROM open_reservation)
+      AND (CASE
+            WHEN u.llm_credits_reset_at IS NULL OR u.llm_credits_reset_at < date_trunc('month', NOW())
+            THEN p.monthly_credits
+            ELSE COALESCE(u.llm_parse_credits, 0)
+        END) > 0
+    RETURNING u.clerk_id, u.llm_parse_credits
+), inserted AS (
+    INSERT INTO llm_credit_ledger (user_id, feature, delta, balance_after, reference_id)
+    SELECT clerk_id, sqlc.arg(feature)::varchar, -1, llm_parse_credits, sqlc.narg(reference_id)::uuid
+    FROM reserved
+    RETURNING id, user_id, feature, delta, balance_after, reference_id, refund_of, created_at
+)
+SELECT id, user_id, feature, delta, balance_after, reference_id, refund_of, created_at FROM inserted
+UNION ALL
+SELECT id, user_id, feature, delta, balance_after, reference_id, refund_of, created_at FROM open_reservation;
+
+-- RefundLlmCredit gives back a reservation whose LLM call failed. A reservation is
+-- refunded once, and only into the month it was taken from; nothing is returned
+-- otherwise.
+-- name: RefundLlmCredit :one
+WITH reservation AS (
+    SELECT l.id, l.user_id, l.feature, l.reference_id, l.created_at FROM llm_credit_ledger l
+    WHERE l.id = sqlc.arg(reservation_id)
+      AND l.user_id = sqlc.arg(user_id)
+      AND l.delta < 0
+      AND NOT EXISTS (SELECT 1 FROM llm_credit_ledger r WHERE r.refund_of = l.id)
+), refunded AS (
+    UPDATE users u
+    SET llm_parse_credits = COALESCE(u.llm_parse_credits, 0) + 1
+    FROM reservation
+    WHERE u.clerk_id = reservation.user_id
+      AND u.llm_credits_reset_at = date_trunc('month', reservation.created_at)
+    RETURNING u.llm_parse_credits
+)
+INSERT INTO llm_credit_ledger (user_id, feature, delta, balance_after, reference_id, refund_of)
+SELECT reservation.user_id, reservation.feature, 1, refunded.llm_parse_credits, reservation.reference_id, reservation.id
+FROM reservation, refunded
+RETURNING *;
+
+-- GetLlmCredits returns the user's plan and the credits left this month, counting the
+-- top-up the month's first reservation will make.
+-- name: GetLlmCredits :one
+SELECT
+    u.llm_plan,
+    p.monthly_credits,
+    (CASE
+        WHEN u.llm_credits_reset_at IS NULL OR u.llm_credits_reset_at < date_trunc('month', NOW())
+        THEN p.monthly_credits
+        ELSE COALESCE(u.llm_parse_credits, 0)
+    END)::int AS remaining,
+    (date_trunc('month', NOW()) + INTERVAL '1 month')::timestamp AS resets_at
+FROM users u
+JOIN llm_plans p ON p.name = u.llm_plan
+WHERE u.clerk_id = $1;
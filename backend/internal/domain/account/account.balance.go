@@ -3,6 +3,7 @@ package account
 import (
 	"context"
 
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/utils"
 	"github.com/google/uuid"
@@ -10,22 +11,34 @@ import (
 
 type BalanceUpdater struct {
 	queries balanceQuerier
+	tm      *database.TxManager
 }
 
-func NewBalanceUpdater(q balanceQuerier) *BalanceUpdater {
-	return &BalanceUpdater{queries: q}
+func NewBalanceUpdater(q balanceQuerier, tm *database.TxManager) *BalanceUpdater {
+	return &BalanceUpdater{queries: q, tm: tm}
+}
+
+// querier joins the caller's transaction when ctx carries one.
+func (b *BalanceUpdater) querier(ctx context.Context) balanceQuerier {
+	if b.tm != nil {
+		if tx := b.tm.GetTx(ctx); tx != nil {
+			return b.queries.WithTx(tx)
+		}
+	}
+	return b.queries
 }
 
 func (b *BalanceUpdater) Apply(ctx context.Context, userID string, accountID uuid.UUID, txnType string, amount float64) error {
 	incomeDelta, expenseDelta, balanceDelta := computeDeltas(txnType, amount)
-	if err := b.queries.AdjustUserLifetimeMetrics(ctx, generated.AdjustUserLifetimeMetricsParams{
+	queries := b.querier(ctx)
+	if err := queries.AdjustUserLifetimeMetrics(ctx, generated.AdjustUserLifetimeMetricsParams{
 		ClerkID:      userID,
 		IncomeDelta:  utils.Float64PtrToNum(&incomeDelta),
 		ExpenseDelta: utils.Float64PtrToNum(&expenseDelta),
 	}); err != nil {
 		return err
 	}
-	return b.queries.AdjustAccountBalance(ctx, generated.AdjustAccountBalanceParams{
+	return queries.AdjustAccountBalance(ctx, generated.AdjustAccountBalanceParams{
 		ID:     utils.UUIDToPgtype(accountID),
 		UserID: userID,
 		Delta:  utils.Float64PtrToNum(&balanceDelta),
@@ -36,14 +49,15 @@ func (b *BalanceUpdater) ApplyBatch(ctx context.Context, userID string, accountI
 	if incomeDelta == 0 && expenseDelta == 0 && balanceDelta == 0 {
 		return nil
 	}
-	if err := b.queries.AdjustUserLifetimeMetrics(ctx, generated.AdjustUserLifetimeMetricsParams{
+	queries := b.querier(ctx)
+	if err := queries.AdjustUserLifetimeMetrics(ctx, generated.AdjustUserLifetimeMetricsParams{
 		ClerkID:      userID,
 		IncomeDelta:  utils.Float64PtrToNum(&incomeDelta),
 		ExpenseDelta: utils.Float64PtrToNum(&expenseDelta),
 	}); err != nil {
 		return err
 	}
-	return b.queries.AdjustAccountBalance(ctx, generated.AdjustAccountBalanceParams{
+	return queries.AdjustAccountBalance(ctx, generated.AdjustAccountBalanceParams{
 		ID:     utils.UUIDToPgtype(accountID),
 		UserID: userID,
 		Delta:  utils.Float64PtrToNum(&balanceDelta),
@@ -4,10 +4,13 @@ import (
 	"context"
 
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
+	"github.com/jackc/pgx/v5"
 )
 
 // balanceQuerier is the narrow slice of generated.Queries that BalanceUpdater needs.
+// WithTx is included so balance changes can join the caller's transaction.
 type balanceQuerier interface {
+	WithTx(tx pgx.Tx) *generated.Queries
 	AdjustUserLifetimeMetrics(ctx context.Context, arg generated.AdjustUserLifetimeMetricsParams) error
 	AdjustAccountBalance(ctx context.Context, arg generated.AdjustAccountBalanceParams) error
 }
@@ -0,0 +1,102 @@
+package reconciliation
+
+import (
+	"math"
+)
+
+// balanceTolerance absorbs paise-level rounding in exported running balances.
+const balanceTolerance = 0.01
+
+// checkRunningBalance walks the statement in chronological order and verifies
+// that each row's balance equals the previous balance plus or minus its amount.
+// Rows without a balance break the chain rather than failing it. Rows flagged as
+// duplicates are still on the statement, so they stay in the chain; they are only
+// kept out of the insert. It returns one ParseError per discontinuity and the
+// closing balance, if the statement has one.
+func checkRunningBalance(rows []ParsedTxns) ([]ParseError, *float64) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	ordered := make([]*ParsedTxns, len(rows))
+	descending := newestFirst(rows)
+	for i := range rows {
+		if descending {
+			ordered[len(rows)-1-i] = &rows[i]
+		} else {
+			ordered[i] = &rows[i]
+		}
+	}
+
+	var mismatches []ParseError
+	var prev *ParsedTxns
+	var closing *float64
+	for _, cur := range ordered {
+		if cur.Balance == nil {
+			prev = nil
+			continue
+		}
+		closing = cur.Balance
+		if prev == nil {
+			prev = cur
+			continue
+		}
+
+		expected := *prev.Balance + signedAmount(*cur)
+		diff := *cur.Balance - expected
+		if math.Abs(diff) > balanceTolerance {
+			cause := "possible missing rows or misparsed amount"
+			if math.Abs(math.Abs(diff)-2*cur.Amount) <= balanceTolerance {
+				cause = "debit/credit direction looks inverted"
+			}
+			mismatches = append(mismatches, ParseError{
+				Row:   int(cur.RowNumber),
+				Error: "running balance mismatch",
+				Data: map[string]interface{}{
+					"previous_row":     prev.RowNumber,
+					"expected_balance": math.Round(expected*100) / 100,
+					"actual_balance":   *cur.Balance,
+					"difference":       math.Round(diff*100) / 100,
+					"cause":            cause,
+				},
+			})
+		}
+		prev = cur
+	}
+	return mismatches, closing
+}
+
+// newestFirst reports whether a statement lists its rows newest first, as some banks
+// export them. The dates cannot tell on a statement that covers one day, so it counts
+// the neighbouring rows whose balances carry on in file order and in reverse, and
+// only falls back to the first and last dates when neither reading wins.
+func newestFirst(rows []ParsedTxns) bool {
+	forward, backward := 0, 0
+	for i := 1; i < len(rows); i++ {
+		a, b := rows[i-1], rows[i]
+		if a.Balance == nil || b.Balance == nil {
+			continue
+		}
+		if math.Abs(*a.Balance+signedAmount(b)-*b.Balance) <= balanceTolerance {
+			forward++
+		}
+		if math.Abs(*b.Balance+signedAmount(a)-*a.Balance) <= balanceTolerance {
+			backward++
+		}
+	}
+	if forward != backward {
+		return backward > forward
+	}
+	return rows[0].TxnDate.After(rows[len(rows)-1].TxnDate)
+}
+
+func isDuplicateRow(t ParsedTxns) bool {
+	return t.IsDuplicate != nil && *t.IsDuplicate
+}
+
+func signedAmount(t ParsedTxns) float64 {
+	if t.Type == DEBIT {
+		return -t.Amount
+	}
+	return t.Amount
+}
@@ -0,0 +1,188 @@
+package reconciliation
+
+import (
+	"testing"
+	"time"
+)
+
+// balanceRow builds a parsed row for balance checks; day is the day of April 2024.
+func balanceRow(num uint32, day int, txnType TxnType, amount float64, balance *float64) ParsedTxns {
+	return ParsedTxns{
+		RowNumber: num,
+		TxnDate:   time.Date(2024, time.April, day, 0, 0, 0, 0, time.UTC),
+		Type:      txnType,
+		Amount:    amount,
+		Balance:   balance,
+	}
+}
+
+func bal(v float64) *float64 { return &v }
+
+func duplicate(r ParsedTxns) ParsedTxns {
+	dup := true
+	r.IsDuplicate = &dup
+	return r
+}
+
+func TestCheckRunningBalance(t *testing.T) {
+	tests := []struct {
+		name          string
+		rows          []ParsedTxns
+		wantErrorRows []int
+		wantCause     string
+		wantClosing   *float64
+	}{
+		{
+			name:        "empty statement",
+			rows:        nil,
+			wantClosing: nil,
+		},
+		{
+			name: "continuous oldest first",
+			rows: []ParsedTxns{
+				balanceRow(1, 1, CREDIT, 1000, bal(1000)),
+				balanceRow(2, 2, DEBIT, 250, bal(750)),
+				balanceRow(3, 3, DEBIT, 50.50, bal(699.50)),
+			},
+			wantClosing: bal(699.50),
+		},
+		{
+			name: "continuous newest first",
+			rows: []ParsedTxns{
+				balanceRow(1, 3, DEBIT, 50.50, bal(699.50)),
+				balanceRow(2, 2, DEBIT, 250, bal(750)),
+				balanceRow(3, 1, CREDIT, 1000, bal(1000)),
+			},
+			wantClosing: bal(699.50),
+		},
+		{
+			name: "single day newest first is read from the balances",
+			rows: []ParsedTxns{
+				balanceRow(1, 1, DEBIT, 10, bal(70)),
+				balanceRow(2, 1, DEBIT, 20, bal(80)),
+				balanceRow(3, 1, CREDIT, 100, bal(100)),
+			},
+			wantClosing: bal(70),
+		},
+		{
+			name: "missing row",
+			rows: []ParsedTxns{
+				balanceRow(1, 1, CREDIT, 1000, bal(1000)),
+				balanceRow(2, 2, DEBIT, 250, bal(700)),
+			},
+			wantErrorRows: []int{2},
+			wantCause:     "possible missing rows or misparsed amount",
+			wantClosing:   bal(700),
+		},
+		{
+			name: "inverted direction",
+			rows: []ParsedTxns{
+				balanceRow(1, 1, CREDIT, 1000, bal(1000)),
+				balanceRow(2, 2, CREDIT, 250, bal(750)),
+			},
+			wantErrorRows: []int{2},
+			wantCause:     "debit/credit direction looks inverted",
+			wantClosing:   bal(750),
+		},
+		{
+			name: "row without a balance breaks the chain",
+			rows: []ParsedTxns{
+				balanceRow(1, 1, CREDIT, 1000, bal(1000)),
+				balanceRow(2, 2, DEBIT, 250, nil),
+				balanceRow(3, 3, DEBIT, 50, bal(700)),
+			},
+			wantClosing: bal(700),
+		},
+		{
+			name: "duplicate row stays in the chain",
+			rows: []ParsedTxns{
+				balanceRow(1, 1, CREDIT, 1000, bal(1000)),
+				duplicate(balanceRow(2, 1, DEBIT, 200, bal(800))),
+				balanceRow(3, 2, DEBIT, 250, bal(550)),
+				balanceRow(4, 3, DEBIT, 50, bal(500)),
+			},
+			wantClosing: bal(500),
+		},
+		{
+			name: "gap after a duplicate row is reported",
+			rows: []ParsedTxns{
+				balanceRow(1, 1, CREDIT, 1000, bal(1000)),
+				duplicate(balanceRow(2, 1, DEBIT, 200, bal(800))),
+				balanceRow(3, 2, DEBIT, 250, bal(750)),
+			},
+			wantErrorRows: []int{3},
+			wantCause:     "possible missing rows or misparsed amount",
+			wantClosing:   bal(750),
+		},
+		{
+			name: "paise rounding is tolerated",
+			rows: []ParsedTxns{
+				balanceRow(1, 1, CREDIT, 100.10, bal(100.10)),
+				balanceRow(2, 2, DEBIT, 0.20, bal(99.90)),
+			},
+			wantClosing: bal(99.90),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mismatches, closing := checkRunningBalance(tt.rows)
+			if len(mismatches) != len(tt.wantErrorRows) {
+				t.Fatalf("got %d mismatches, want %d: %+v", len(mismatches), len(tt.wantErrorRows), mismatches)
+			}
+			for i, m := range mismatches {
+				if m.Row != tt.wantErrorRows[i] {
+					t.Errorf("mismatch %d: Row = %d, want %d", i, m.Row, tt.wantErrorRows[i])
+				}
+				if cause := m.Data["cause"]; cause != tt.wantCause {
+					t.Errorf("mismatch %d: cause = %v, want %q", i, cause, tt.wantCause)
+				}
+			}
+			switch {
+			case tt.wantClosing == nil && closing != nil:
+				t.Errorf("closing = %v, want nil", *closing)
+			case tt.wantClosing != nil && (closing == nil || *closing != *tt.wantClosing):
+				t.Errorf("closing = %v, want %v", closing, *tt.wantClosing)
+			}
+		})
+	}
+}
+
+func TestNewestFirst(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []ParsedTxns
+		want bool
+	}{
+		{
+			name: "balances carry on in file order",
+			rows: []ParsedTxns{
+				balanceRow(1, 1, CREDIT, 100, bal(100)),
+				balanceRow(2, 1, DEBIT, 10, bal(90)),
+			},
+			want: false,
+		},
+		{
+			name: "balances carry on in reverse",
+			rows: []ParsedTxns{
+				balanceRow(1, 1, DEBIT, 10, bal(90)),
+				balanceRow(2, 1, CREDIT, 100, bal(100)),
+			},
+			want: true,
+		},
+		{
+			name: "no balances falls back to dates",
+			rows: []ParsedTxns{
+				balanceRow(1, 5, DEBIT, 10, nil),
+				balanceRow(2, 1, DEBIT, 10, nil),
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newestFirst(tt.rows); got != tt.want {
+				t.Errorf("newestFirst() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
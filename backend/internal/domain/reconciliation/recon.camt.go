@@ -0,0 +1,159 @@
+package reconciliation
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// camtDocument is the slice of an ISO 20022 camt.053 BankToCustomerStatement that
+// reconciliation reads. Tags carry no namespace so every camt.053.001.xx version matches.
+type camtDocument struct {
+	XMLName    xml.Name        `xml:"Document"`
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	Balances []camtBalance `xml:"Bal"`
+	Entries  []camtEntry   `xml:"Ntry"`
+}
+
+type camtBalance struct {
+	Type        string `xml:"Tp>CdOrPrtry>Cd"`
+	Amount      string `xml:"Amt"`
+	CreditDebit string `xml:"CdtDbtInd"`
+}
+
+type camtEntry struct {
+	Ref           string      `xml:"NtryRef"`
+	Amount        string      `xml:"Amt"`
+	CreditDebit   string      `xml:"CdtDbtInd"`
+	Status        camtStatus  `xml:"Sts"`
+	BookingDate   camtDate    `xml:"BookgDt"`
+	ValueDate     camtDate    `xml:"ValDt"`
+	ServicerRef   string      `xml:"AcctSvcrRef"`
+	Details       []camtTxDtl `xml:"NtryDtls>TxDtls"`
+	AdditionalInf string      `xml:"AddtlNtryInf"`
+}
+
+// camtStatus is plain text up to camt.053.001.04 and wrapped in <Cd> from .08 on.
+type camtStatus struct {
+	Text string `xml:",chardata"`
+	Code string `xml:"Cd"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type camtTxDtl struct {
+	ServicerRef string   `xml:"Refs>AcctSvcrRef"`
+	TxID        string   `xml:"Refs>TxId"`
+	EndToEndID  string   `xml:"Refs>EndToEndId"`
+	Debtor      string   `xml:"RltdPties>Dbtr>Nm"`
+	DebtorPty   string   `xml:"RltdPties>Dbtr>Pty>Nm"`
+	Creditor    string   `xml:"RltdPties>Cdtr>Nm"`
+	CreditorPty string   `xml:"RltdPties>Cdtr>Pty>Nm"`
+	Remittance  []string `xml:"RmtInf>Ustrd"`
+}
+
+// parseCamtRows reads the booked entries of every statement in a camt.053 file.
+func parseCamtRows(r io.Reader, accountID uuid.UUID, uploadID uuid.UUID) ([]ParsedTxns, []ParseError, error) {
+	var doc camtDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("not a CAMT.053 file: %w", err)
+	}
+	if len(doc.Statements) == 0 {
+		return nil, nil, fmt.Errorf("no statement found in CAMT.053 file")
+	}
+
+	stmts := make([]nativeStatement, 0, len(doc.Statements))
+	for _, s := range doc.Statements {
+		var st nativeStatement
+		for _, b := range s.Balances {
+			if b.Type != "CLBD" {
+				continue
+			}
+			if v, err := strconv.ParseFloat(strings.TrimSpace(b.Amount), 64); err == nil {
+				if b.CreditDebit == "DBIT" {
+					v = -v
+				}
+				st.Closing = &v
+			}
+		}
+		for _, n := range s.Entries {
+			status := strings.TrimSpace(n.Status.Code)
+			if status == "" {
+				status = strings.TrimSpace(n.Status.Text)
+			}
+			if status != "" && status != "BOOK" {
+				// Pending and informational entries are not on the ledger yet.
+				continue
+			}
+			st.Entries = append(st.Entries, camtEntryToNative(n))
+		}
+		stmts = append(stmts, st)
+	}
+
+	rows, parseErrors := nativeStatementsToRows(nativeFormatCAMT, stmts, accountID, uploadID)
+	return rows, parseErrors, nil
+}
+
+func camtEntryToNative(n camtEntry) nativeEntry {
+	e := nativeEntry{ID: firstNonEmpty(n.ServicerRef, n.Ref)}
+
+	var counterparty string
+	var remittance []string
+	for _, d := range n.Details {
+		if e.ID == "" {
+			e.ID = firstNonEmpty(d.ServicerRef, d.TxID, d.EndToEndID)
+		}
+		if counterparty == "" {
+			if n.CreditDebit == "DBIT" {
+				counterparty = firstNonEmpty(d.Creditor, d.CreditorPty)
+			} else {
+				counterparty = firstNonEmpty(d.Debtor, d.DebtorPty)
+			}
+		}
+		remittance = append(remittance, d.Remittance...)
+	}
+	e.Description = joinNonEmpty(append([]string{n.AdditionalInf, counterparty}, remittance...)...)
+
+	amount, err := strconv.ParseFloat(strings.TrimSpace(n.Amount), 64)
+	if err != nil {
+		e.Invalid, e.InvalidValue = "invalid amount", n.Amount
+		return e
+	}
+	if n.CreditDebit == "DBIT" {
+		amount = -amount
+	}
+	e.Amount = amount
+
+	raw := firstNonEmpty(n.BookingDate.Date, n.BookingDate.DateTime, n.ValueDate.Date, n.ValueDate.DateTime)
+	if len(raw) < 10 {
+		e.Invalid, e.InvalidValue = "invalid transaction date", raw
+		return e
+	}
+	date, err := time.Parse("2006-01-02", raw[:10])
+	if err != nil {
+		e.Invalid, e.InvalidValue = "invalid transaction date", raw
+		return e
+	}
+	e.Date = date
+	return e
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,412 @@
+package reconciliation
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
+	"github.com/google/uuid"
+)
+
+// isCreditCardAccount reports whether an Account.AccountType is a credit card. The
+// column is free text, so "Credit Card", "credit_card" and "CC" all count.
+func isCreditCardAccount(accountType string) bool {
+	t := strings.Join(strings.FieldsFunc(strings.ToLower(accountType), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}), " ")
+	return t == "credit card" || t == "cc" || t == "creditcard"
+}
+
+// Billing-cycle fields a credit card statement prints in its summary box.
+const (
+	cycleStatementDate = "statement_date"
+	cyclePaymentDue    = "payment_due_date"
+	cycleMinimumDue    = "minimum_due"
+	cycleTotalDue      = "total_due"
+)
+
+// cardCycleLabels maps the summary labels card issuers use to the cycle field they hold.
+var cardCycleLabels = map[string]string{
+	"statement date":            cycleStatementDate,
+	"statement generation date": cycleStatementDate,
+	"bill date":                 cycleStatementDate,
+	"billing date":              cycleStatementDate,
+	"payment due date":          cyclePaymentDue,
+	"payment due by":            cyclePaymentDue,
+	"due date":                  cyclePaymentDue,
+	"pay by date":               cyclePaymentDue,
+	"minimum amount due":        cycleMinimumDue,
+	"minimum amount payable":    cycleMinimumDue,
+	"minimum payment due":       cycleMinimumDue,
+	"minimum due":               cycleMinimumDue,
+	"min amount due":            cycleMinimumDue,
+	"min amt due":               cycleMinimumDue,
+	"total amount due":          cycleTotalDue,
+	"total amount payable":      cycleTotalDue,
+	"total payment due":         cycleTotalDue,
+	"total dues":                cycleTotalDue,
+	"total due":                 cycleTotalDue,
+	"total outstanding":         cycleTotalDue,
+}
+
+// maxCycleLabelWords is the longest label in cardCycleLabels, in words.
+const maxCycleLabelWords = 3
+
+// scan picks billing-cycle values out of one row or text line. A label is either
+// followed by its value in the same cell ("Due Date: 05/02/2026") or in the next
+// non-empty cell. The first value found for a field wins.
+func (c *CardCycle) scan(cells []string, dateFormats []string) {
+	for i, cell := range cells {
+		field, value := cardCycleField(cell)
+		if field == "" {
+			continue
+		}
+		for j := i + 1; value == "" && j < len(cells); j++ {
+			value = strings.TrimSpace(cells[j])
+		}
+		if value != "" {
+			c.set(field, value, dateFormats)
+		}
+	}
+}
+
+// cardCycleField returns the cycle field a cell is labelled with and whatever follows
+// the label in the same cell.
+func cardCycleField(cell string) (string, string) {
+	label, value := cell, ""
+	if i := strings.Index(cell, ":"); i >= 0 {
+		label, value = cell[:i], cell[i+1:]
+	}
+	words := strings.Fields(label)
+	for n := min(len(words), maxCycleLabelWords); n > 0; n-- {
+		if field, ok := cardCycleLabels[normalizeCycleLabel(strings.Join(words[:n], " "))]; ok {
+			rest := strings.Join(words[n:], " ")
+			return field, strings.Trim(strings.TrimSpace(rest+" "+value), " :-")
+		}
+	}
+	return "", ""
+}
+
+func normalizeCycleLabel(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}), " ")
+}
+
+func (c *CardCycle) set(field, value string, dateFormats []string) {
+	switch field {
+	case cycleStatementDate, cyclePaymentDue:
+		target := &c.StatementDate
+		if field == cyclePaymentDue {
+			target = &c.PaymentDueDate
+		}
+		if *target != nil {
+			return
+		}
+		if t, err := parseStatementDate([]string{value}, 0, dateFormats); err == nil {
+			*target = &t
+		}
+	case cycleMinimumDue, cycleTotalDue:
+		target := &c.MinimumDue
+		if field == cycleTotalDue {
+			target = &c.TotalDue
+		}
+		if *target != nil {
+			return
+		}
+		if f, err := ParseBalanceValue(value); err == nil {
+			f = math.Abs(f)
+			*target = &f
+		}
+	}
+}
+
+// applyOverrides lets values the user typed in at upload replace the detected ones.
+func (c *CardCycle) applyOverrides(payload *ParseExcelReq) {
+	if payload.StatementDate != nil {
+		c.StatementDate = payload.StatementDate
+	}
+	if payload.PaymentDueDate != nil {
+		c.PaymentDueDate = payload.PaymentDueDate
+	}
+	if payload.MinimumDue != nil {
+		c.MinimumDue = payload.MinimumDue
+	}
+	if payload.TotalDue != nil {
+		c.TotalDue = payload.TotalDue
+	}
+}
+
+func (c *CardCycle) empty() bool {
+	return c.StatementDate == nil && c.PaymentDueDate == nil && c.MinimumDue == nil && c.TotalDue == nil
+}
+
+// parseCardAmount reads a single signed amount column on a card statement, where
+// charges are printed as plain amounts and payments and refunds carry a Cr suffix or
+// a minus sign: the reverse of a savings account export.
+func parseCardAmount(v string) (float64, string, error) {
+	v = strings.TrimSpace(v)
+	upper := strings.ToUpper(v)
+	for _, drCr := range []string{"CR", "DR"} {
+		if strings.HasSuffix(upper, drCr) {
+			amount, err := ParseAmountValue(strings.TrimSpace(v[:len(v)-len(drCr)]))
+			if err != nil {
+				return 0, "", err
+			}
+			return math.Abs(amount), drCr, nil
+		}
+	}
+	amount, err := ParseAmountValue(v)
+	if err != nil {
+		return 0, "", err
+	}
+	if amount < 0 {
+		return -amount, "CR", nil
+	}
+	return amount, "DR", nil
+}
+
+// parseCardBalance reads a card statement balance into the app's ledger sign: what is
+// owed is negative. Issuers print the outstanding as a plain amount, or mark it Dr.
+func parseCardBalance(v string) (float64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(v))
+	if strings.HasSuffix(upper, "CR") || strings.HasSuffix(upper, "DR") {
+		return ParseBalanceValue(v)
+	}
+	b, err := ParseAmountValue(v)
+	if err != nil {
+		return 0, err
+	}
+	return -b, nil
+}
+
+// cardPaymentKeywords are narration phrases issuers print on a bill payment credit.
+var cardPaymentKeywords = []string{
+	"PAYMENT RECEIVED", "PAYMENT RECD", "PAYMENT THANK", "PAYMENT - THANK", "THANK YOU",
+	"BBPS", "AUTOPAY", "AUTO PAY", "AUTO DEBIT", "BILL PAYMENT", "CC PAYMENT",
+	"CARD PAYMENT", "ONLINE PAYMENT",
+}
+
+// isCardBillPayment reports whether a credit on a card statement pays the bill, as
+// opposed to a refund or cashback from a merchant.
+func isCardBillPayment(desc string, narr Narration) bool {
+	upper := strings.ToUpper(strings.Join(strings.Fields(desc), " "))
+	for _, k := range cardPaymentKeywords {
+		if strings.Contains(upper, k) {
+			return true
+		}
+	}
+	switch narr.PaymentMethod {
+	case PaymentMethodNEFT, PaymentMethodIMPS, PaymentMethodRTGS:
+		return true
+	}
+	return false
+}
+
+// cardPaymentWindowDays is how far apart a bill payment may post on the savings and
+// card statements; card issuers credit NEFT and BBPS payments a day or two late.
+const cardPaymentWindowDays = 3
+
+// cardLedger is what a reconciliation job running on a credit card account knows about it.
+type cardLedger struct {
+	// paymentAccountID is the savings or current account the card's bills are paid
+	// from, when there is one to pick.
+	paymentAccountID *uuid.UUID
+	// numberSuffix is the last four digits of the card number.
+	numberSuffix string
+	// bankHints are the words a narration names the card's bank by, such as its code.
+	bankHints []string
+}
+
+// loadCardLedger picks the account a card is paid from: the one that paid it most
+// often, else the user's only savings or current account.
+func (s *ReconService) loadCardLedger(ctx context.Context, userID string, cardID uuid.UUID) (*cardLedger, error) {
+	accounts, err := s.repo.ListCardPaymentAccounts(ctx, userID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	card := &cardLedger{}
+	switch {
+	case len(accounts) > 0 && accounts[0].Payments > 0:
+		card.paymentAccountID = &accounts[0].ID
+	case len(accounts) == 1:
+		card.paymentAccountID = &accounts[0].ID
+	}
+
+	identity, err := s.repo.GetCardIdentity(ctx, cardID, userID)
+	if err != nil {
+		return nil, err
+	}
+	card.setIdentity(*identity)
+	return card, nil
+}
+
+// setIdentity keeps the parts of the card's number and bank name a savings narration
+// is likely to carry.
+func (c *cardLedger) setIdentity(id CardIdentity) {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, id.Number)
+	if len(digits) >= 4 {
+		c.numberSuffix = digits[len(digits)-4:]
+	}
+	hints := []string{strings.ToUpper(strings.TrimSpace(id.BankCode))}
+	if words := strings.Fields(strings.ToUpper(id.BankName)); len(words) > 0 && words[0] != "THE" && words[0] != "BANK" {
+		hints = append(hints, words[0])
+	}
+	for _, h := range hints {
+		if len(h) >= 3 && !slices.Contains(c.bankHints, h) {
+			c.bankHints = append(c.bankHints, h)
+		}
+	}
+}
+
+// namedIn reports whether a savings debit says it pays this card: it already is a
+// transfer to it, or its narration or payee has "CC", "CREDIT CARD", the card number's
+// last four digits or the card's bank.
+func (c *cardLedger) namedIn(src CardPaymentSource) bool {
+	if src.ToAccountID != nil {
+		return true
+	}
+	words := strings.FieldsFunc(strings.ToUpper(src.Description+" "+src.Payee), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if strings.Contains(" "+strings.Join(words, " ")+" ", " CREDIT CARD ") {
+		return true
+	}
+	for _, w := range words {
+		if w == "CC" || w == "CREDITCARD" {
+			return true
+		}
+		if c.numberSuffix != "" && strings.HasSuffix(w, c.numberSuffix) {
+			return true
+		}
+		for _, h := range c.bankHints {
+			if strings.HasPrefix(w, h) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cardPayment is the savings debit a bill payment row is paired with. A pairing the
+// debit's narration does not confirm is only offered for review.
+type cardPayment struct {
+	src       CardPaymentSource
+	confirmed bool
+}
+
+// matchCardPayments pairs bill payment credits on the card statement with the debits on
+// the user's savings and current accounts that already record them, one each, on equal
+// amount and closest date. Debits whose narration names the card are paired first; the
+// rest are left unconfirmed.
+func (s *ReconService) matchCardPayments(ctx context.Context, run *reconRun, rows []StatementTransaction) (map[uuid.UUID]cardPayment, error) {
+	var payments []StatementTransaction
+	var from, to time.Time
+	for _, st := range rows {
+		if st.Type != string(CREDIT) || st.TransactionDate == nil {
+			continue
+		}
+		desc, narr := statementNarration(st)
+		if !isCardBillPayment(desc, narr) {
+			continue
+		}
+		payments = append(payments, st)
+		if from.IsZero() || st.TransactionDate.Before(from) {
+			from = *st.TransactionDate
+		}
+		if st.TransactionDate.After(to) {
+			to = *st.TransactionDate
+		}
+	}
+	if len(payments) == 0 {
+		return nil, nil
+	}
+
+	sources, err := s.repo.ListCardPaymentSources(ctx, run.payload.UserID, run.payload.AccountID,
+		from.AddDate(0, 0, -cardPaymentWindowDays), to.AddDate(0, 0, cardPaymentWindowDays))
+	if err != nil {
+		return nil, err
+	}
+
+	named := make([]bool, len(sources))
+	for j, src := range sources {
+		named[j] = run.card.namedIn(src)
+	}
+
+	type pair struct {
+		stmt, src int
+		days      float64
+	}
+	var pairs []pair
+	for i, st := range payments {
+		for j, src := range sources {
+			if _, claimed := run.claimed[src.ID]; claimed {
+				continue
+			}
+			if math.Abs(src.Amount-st.Amount) > balanceTolerance {
+				continue
+			}
+			days := math.Abs(st.TransactionDate.Sub(src.TransactionDate).Hours()) / 24
+			if days <= cardPaymentWindowDays {
+				pairs = append(pairs, pair{stmt: i, src: j, days: days})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(a, b int) bool {
+		if named[pairs[a].src] != named[pairs[b].src] {
+			return named[pairs[a].src]
+		}
+		return pairs[a].days < pairs[b].days
+	})
+
+	matched := make(map[uuid.UUID]cardPayment, len(pairs))
+	usedSrc := make(map[int]struct{}, len(pairs))
+	for _, p := range pairs {
+		stmtID := payments[p.stmt].ID
+		if _, done := matched[stmtID]; done {
+			continue
+		}
+		if _, used := usedSrc[p.src]; used {
+			continue
+		}
+		matched[stmtID] = cardPayment{src: sources[p.src], confirmed: named[p.src]}
+		usedSrc[p.src] = struct{}{}
+	}
+	return matched, nil
+}
+
+// cardTransferResult links a bill payment row to the savings debit that paid it. An
+// unconfirmed pairing is a low confidence match left pending for the user to accept.
+func cardTransferResult(p ScoringProfile, res ReconciliationResult, st StatementTransaction, payment cardPayment) ReconciliationResult {
+	src := payment.src
+	desc, narr := statementNarration(st)
+	signals, score := scoreMatch(p, st.Amount, desc, narr, *st.TransactionDate, &AppTransaction{
+		ID:              src.ID,
+		Amount:          src.Amount,
+		TransactionDate: src.TransactionDate,
+		Type:            string(generated.TxnTypeDEBIT),
+		AccountID:       src.AccountID,
+	})
+	signals.Transfer = true
+	id := src.ID
+	res.AppTransactionID = &id
+	res.ResultType = string(generated.ReconciliationResultTypeHIGHCONFIDENCEMATCH)
+	res.MatchStatus = "auto_accepted"
+	if !payment.confirmed {
+		res.ResultType = string(generated.ReconciliationResultTypeLOWCONFIDENCEMATCH)
+		res.MatchStatus = "pending"
+	}
+	res.MatchSignals = signals
+	res.ConfidenceScore = float64(score)
+	return res
+}
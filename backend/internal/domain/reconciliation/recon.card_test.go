@@ -0,0 +1,147 @@
+package reconciliation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/tasks"
+	"github.com/google/uuid"
+)
+
+// cardRepo serves the savings debits a card's bill payments may pair with and records
+// the ones made transfers.
+type cardRepo struct {
+	reconRepository
+	sources     []CardPaymentSource
+	transferred []uuid.UUID
+}
+
+func (f *cardRepo) ListCardPaymentSources(context.Context, string, uuid.UUID, time.Time, time.Time) ([]CardPaymentSource, error) {
+	return f.sources, nil
+}
+
+func (f *cardRepo) MarkTransactionTransfer(_ context.Context, txnID, _ uuid.UUID) (bool, error) {
+	f.transferred = append(f.transferred, txnID)
+	return true, nil
+}
+
+func testCardLedger() *cardLedger {
+	card := &cardLedger{}
+	card.setIdentity(CardIdentity{Number: "XXXX XXXX XXXX 4321", BankName: "HDFC Bank", BankCode: "HDFC"})
+	return card
+}
+
+func TestCardLedgerNamedIn(t *testing.T) {
+	card := testCardLedger()
+	cardID := uuid.New()
+	tests := []struct {
+		name string
+		src  CardPaymentSource
+		want bool
+	}{
+		{"credit card in the narration", CardPaymentSource{Description: "BBPS/Credit Card Bill"}, true},
+		{"CC as its own word", CardPaymentSource{Description: "IB BILLPAY CC 99"}, true},
+		{"card number suffix", CardPaymentSource{Description: "NEFT-XX4321-BILL"}, true},
+		{"bank name", CardPaymentSource{Description: "IMPS/HDFCBANK/PAY"}, true},
+		{"payee names the card", CardPaymentSource{Payee: "Credit Card"}, true},
+		{"already a transfer to the card", CardPaymentSource{Description: "rent", ToAccountID: &cardID}, true},
+		{"rent to a landlord", CardPaymentSource{Description: "UPI/RAMESH/RENT"}, false},
+		{"CC inside another word", CardPaymentSource{Description: "ACCOUNT TRANSFER"}, false},
+		{"other card's number", CardPaymentSource{Description: "NEFT-XX9876-BILL"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := card.namedIn(tt.src); got != tt.want {
+				t.Errorf("namedIn(%+v) = %v, want %v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchCardPayments(t *testing.T) {
+	paidOn := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	desc := "PAYMENT RECEIVED - THANK YOU"
+	payment := StatementTransaction{ID: uuid.New(), TransactionDate: &paidOn, Description: &desc, Amount: 5000, Type: string(CREDIT)}
+	named := CardPaymentSource{ID: uuid.New(), Amount: 5000, TransactionDate: paidOn.AddDate(0, 0, -2), Description: "BILLPAY HDFC CREDIT CARD"}
+	unnamed := CardPaymentSource{ID: uuid.New(), Amount: 5000, TransactionDate: paidOn.AddDate(0, 0, -1), Description: "UPI/RAMESH/RENT"}
+
+	tests := []struct {
+		name          string
+		sources       []CardPaymentSource
+		wantSrc       uuid.UUID
+		wantConfirmed bool
+	}{
+		{name: "debit naming the card is linked", sources: []CardPaymentSource{named}, wantSrc: named.ID, wantConfirmed: true},
+		{name: "debit naming the card wins over a closer one", sources: []CardPaymentSource{unnamed, named}, wantSrc: named.ID, wantConfirmed: true},
+		{name: "equal amount alone is only a candidate", sources: []CardPaymentSource{unnamed}, wantSrc: unnamed.ID},
+		{name: "no debit of the amount", sources: []CardPaymentSource{{ID: uuid.New(), Amount: 4999, TransactionDate: paidOn}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &ReconService{repo: &cardRepo{sources: tt.sources}}
+			run := &reconRun{
+				payload: tasks.BankReconciliationPayload{UserID: "user_1", AccountID: uuid.New()},
+				claimed: map[uuid.UUID]struct{}{},
+				card:    testCardLedger(),
+			}
+
+			matched, err := svc.matchCardPayments(context.Background(), run, []StatementTransaction{payment})
+			if err != nil {
+				t.Fatalf("matchCardPayments() error = %v", err)
+			}
+			got, ok := matched[payment.ID]
+			if tt.wantSrc == uuid.Nil {
+				if ok {
+					t.Errorf("paired with %s, want no pairing", got.src.ID)
+				}
+				return
+			}
+			if !ok || got.src.ID != tt.wantSrc || got.confirmed != tt.wantConfirmed {
+				t.Fatalf("paired with %+v, want %s confirmed = %v", got, tt.wantSrc, tt.wantConfirmed)
+			}
+
+			res := cardTransferResult(defaultScoringProfile, ReconciliationResult{}, payment, got)
+			wantType, wantStatus := generated.ReconciliationResultTypeHIGHCONFIDENCEMATCH, "auto_accepted"
+			if !tt.wantConfirmed {
+				wantType, wantStatus = generated.ReconciliationResultTypeLOWCONFIDENCEMATCH, "pending"
+			}
+			if res.ResultType != string(wantType) || res.MatchStatus != wantStatus {
+				t.Errorf("result %s/%s, want %s/%s", res.ResultType, res.MatchStatus, wantType, wantStatus)
+			}
+		})
+	}
+}
+
+func TestApplyReviewDecisionCardPaymentCandidate(t *testing.T) {
+	card, savings, debit := uuid.New(), uuid.New(), uuid.New()
+	candidate := ReviewTxn{
+		ResultType:       string(generated.ReconciliationResultTypeLOWCONFIDENCEMATCH),
+		AppTransactionID: debit,
+		AccountID:        savings,
+		UploadAccountID:  card,
+		Type:             string(generated.TxnTypeDEBIT),
+		Amount:           5000,
+		Source:           "MANUAL",
+	}
+	tests := []struct {
+		action          string
+		wantTransferred int
+	}{
+		{"accepted", 1},
+		{"rejected", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			repo := &cardRepo{}
+			svc := &ReconService{repo: repo}
+			if err := svc.applyReviewDecision(context.Background(), "user_1", tt.action, []ReviewTxn{candidate}); err != nil {
+				t.Fatalf("applyReviewDecision() error = %v", err)
+			}
+			if len(repo.transferred) != tt.wantTransferred {
+				t.Errorf("made %d transfers, want %d", len(repo.transferred), tt.wantTransferred)
+			}
+		})
+	}
+}
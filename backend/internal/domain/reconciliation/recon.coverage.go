@@ -0,0 +1,121 @@
+package reconciliation
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type coverageSpan struct {
+	uploadID uuid.UUID
+	from, to time.Time
+}
+
+// buildCoverage lays every upload's date range on one day-granular timeline and reports
+// the merged covered spans, the gaps between them, and the spans covered by more than
+// one upload. Uploads without a range are listed but take no part.
+func buildCoverage(accountID uuid.UUID, uploads []CoverageUpload) *AccountCoverageRes {
+	res := &AccountCoverageRes{
+		AccountID: accountID,
+		Uploads:   uploads,
+		Covered:   []CoverageRange{},
+		Gaps:      []CoverageRange{},
+		Overlaps:  []CoverageRange{},
+	}
+
+	spans := make([]coverageSpan, 0, len(uploads))
+	for _, u := range uploads {
+		if u.From == nil || u.To == nil {
+			continue
+		}
+		from, to := truncateDay(*u.From), truncateDay(*u.To)
+		if to.Before(from) {
+			from, to = to, from
+		}
+		spans = append(spans, coverageSpan{uploadID: u.UploadID, from: from, to: to})
+	}
+	if len(spans) == 0 {
+		return res
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].from.Equal(spans[j].from) {
+			return spans[i].to.Before(spans[j].to)
+		}
+		return spans[i].from.Before(spans[j].from)
+	})
+
+	cur := CoverageRange{From: spans[0].from, To: spans[0].to}
+	for _, sp := range spans[1:] {
+		if !sp.from.After(cur.To.AddDate(0, 0, 1)) {
+			if sp.to.After(cur.To) {
+				cur.To = sp.to
+			}
+			continue
+		}
+		res.Covered = append(res.Covered, withDays(cur))
+		cur = CoverageRange{From: sp.from, To: sp.to}
+	}
+	res.Covered = append(res.Covered, withDays(cur))
+
+	for i := 1; i < len(res.Covered); i++ {
+		res.Gaps = append(res.Gaps, withDays(CoverageRange{
+			From: res.Covered[i-1].To.AddDate(0, 0, 1),
+			To:   res.Covered[i].From.AddDate(0, 0, -1),
+		}))
+	}
+
+	// Between consecutive boundaries the set of uploads covering a day cannot change.
+	boundarySet := make(map[time.Time]struct{}, len(spans)*2)
+	for _, sp := range spans {
+		boundarySet[sp.from] = struct{}{}
+		boundarySet[sp.to.AddDate(0, 0, 1)] = struct{}{}
+	}
+	boundaries := make([]time.Time, 0, len(boundarySet))
+	for b := range boundarySet {
+		boundaries = append(boundaries, b)
+	}
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i].Before(boundaries[j]) })
+
+	for i := 0; i+1 < len(boundaries); i++ {
+		segFrom, segTo := boundaries[i], boundaries[i+1].AddDate(0, 0, -1)
+		var ids []uuid.UUID
+		for _, sp := range spans {
+			if !sp.from.After(segFrom) && !sp.to.Before(segFrom) {
+				ids = append(ids, sp.uploadID)
+			}
+		}
+		if len(ids) < 2 {
+			continue
+		}
+		if n := len(res.Overlaps); n > 0 && sameUploads(res.Overlaps[n-1].UploadIDs, ids) &&
+			res.Overlaps[n-1].To.AddDate(0, 0, 1).Equal(segFrom) {
+			res.Overlaps[n-1] = withDays(CoverageRange{From: res.Overlaps[n-1].From, To: segTo, UploadIDs: ids})
+			continue
+		}
+		res.Overlaps = append(res.Overlaps, withDays(CoverageRange{From: segFrom, To: segTo, UploadIDs: ids}))
+	}
+	return res
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func withDays(r CoverageRange) CoverageRange {
+	r.Days = int(r.To.Sub(r.From).Hours()/24) + 1
+	return r
+}
+
+func sameUploads(a, b []uuid.UUID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
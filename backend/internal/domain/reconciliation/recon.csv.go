@@ -0,0 +1,135 @@
+package reconciliation
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// csvDelimiters are the separators seen in Indian bank CSV exports, in order of preference.
+var csvDelimiters = []rune{',', ';', '\t', '|'}
+
+// csvSniffLines is how many non-blank lines the delimiter is sniffed from.
+const csvSniffLines = 20
+
+// parseCsvRows streams a CSV statement row by row through the same profile-driven
+// parser as parseXlsxRows.
+func parseCsvRows(r io.Reader, profile StatementProfile, accountID uuid.UUID, uploadID uuid.UUID) ([]ParsedTxns, []ParseError, error) {
+	br := bufio.NewReader(r)
+
+	if bom, err := br.Peek(len(utf8BOM)); err == nil && bytes.Equal(bom, utf8BOM) {
+		if _, err := br.Discard(len(utf8BOM)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	cr := csv.NewReader(br)
+	cr.Comma = sniffCsvDelimiter(br)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.TrimLeadingSpace = true
+
+	// The row parser numbers records; lines[i] is the file line record i+1 starts on,
+	// which differs once a quoted cell spans several lines.
+	var lines []int
+	read := func() ([]string, error) {
+		row, err := cr.Read()
+		var pe *csv.ParseError
+		switch {
+		case err == nil:
+			line, _ := cr.FieldPos(0)
+			lines = append(lines, line)
+		case errors.As(err, &pe):
+			lines = append(lines, pe.StartLine)
+		}
+		return row, err
+	}
+	rows, parseErrors, err := parseStatementRows(read, profile, accountID, uploadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	lineOf := func(record int) int {
+		if record >= 1 && record <= len(lines) {
+			return lines[record-1]
+		}
+		return record
+	}
+	for i := range rows {
+		rows[i].RowNumber = uint32(lineOf(int(rows[i].RowNumber)))
+	}
+	for i := range parseErrors {
+		parseErrors[i].Row = lineOf(parseErrors[i].Row)
+	}
+	return rows, parseErrors, nil
+}
+
+// sniffCsvDelimiter picks the delimiter that splits the most of the first non-blank
+// lines into the same number of fields, more than one, so a preamble of account
+// details above the table does not decide it. Separators inside quoted sections are
+// ignored. A tie goes to the delimiter giving more fields; the default is a comma.
+func sniffCsvDelimiter(br *bufio.Reader) rune {
+	peek, err := br.Peek(br.Size())
+	text := string(peek)
+	if err == nil {
+		// The buffer is full, so its last line may be cut short.
+		if i := strings.LastIndexAny(text, "\r\n"); i >= 0 {
+			text = text[:i]
+		}
+	}
+	var lines []string
+	for _, line := range strings.FieldsFunc(text, func(r rune) bool { return r == '\r' || r == '\n' }) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+		if len(lines) == csvSniffLines {
+			break
+		}
+	}
+
+	best, bestLines, bestFields := ',', 0, 0
+	for _, d := range csvDelimiters {
+		freq := make(map[int]int)
+		for _, line := range lines {
+			if n := csvFieldCount(line, d); n > 1 {
+				freq[n]++
+			}
+		}
+		for fields, count := range freq {
+			if count > bestLines || (count == bestLines && fields > bestFields) {
+				best, bestLines, bestFields = d, count, fields
+			}
+		}
+	}
+	return best
+}
+
+// csvFieldCount counts the fields d splits line into outside quoted sections.
+func csvFieldCount(line string, d rune) int {
+	count, inQuotes := 1, false
+	for _, ch := range line {
+		switch {
+		case ch == '"':
+			inQuotes = !inQuotes
+		case ch == d && !inQuotes:
+			count++
+		}
+	}
+	return count
+}
+
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package reconciliation
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSniffCsvDelimiter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   rune
+	}{
+		{"comma", "Date,Description,Amount,Dr/Cr\n", ','},
+		{"semicolon", "Date;Description;Amount;Dr/Cr\n", ';'},
+		{"tab", "Date\tDescription\tAmount\n", '\t'},
+		{"pipe", "Date|Description|Amount\n", '|'},
+		{"separator inside quotes is ignored", "\"Date, Value\";Description;Amount\n", ';'},
+		{"rows agree with the header", "Date;Amount;Note\n01/04;250;UPI, Swiggy\n02/04;100;Salary\n", ';'},
+		{
+			"preamble above the table",
+			"Statement of account\nAccount No: 1234, Branch: Pune\n\nDate;Description;Amount;Balance\n01/04/2024;UPI, Swiggy;250;9750\n02/04/2024;Salary;50000;59750\n",
+			';',
+		},
+		{"blank lines are skipped", "\n\nDate|Description|Amount\n\n01/04|Rent|100\n", '|'},
+		{"no separator defaults to comma", "Date\n", ','},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sniffCsvDelimiter(bufio.NewReader(strings.NewReader(tt.header)))
+			if got != tt.want {
+				t.Errorf("sniffCsvDelimiter(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCsvRows(t *testing.T) {
+	const header = "Sl. No,Txn Date,Value Date,Description,Chq/Ref No,Amount,Dr/Cr,Balance\n"
+	tests := []struct {
+		name       string
+		csv        string
+		wantRows   []uint32
+		wantTypes  []TxnType
+		wantErrors []int
+	}{
+		{
+			name: "legacy layout",
+			csv: header +
+				"1,01/04/2024,01/04/2024,UPI-SWIGGY,REF1,250.00,DR,9750.00\n" +
+				"2,02/04/2024,02/04/2024,SALARY,REF2,\"50,000.00\",CR,59750.00\n",
+			wantRows:  []uint32{2, 3},
+			wantTypes: []TxnType{DEBIT, CREDIT},
+		},
+		{
+			name: "byte order mark is skipped",
+			csv: "\xEF\xBB\xBF" + header +
+				"1,01/04/2024,01/04/2024,UPI-SWIGGY,REF1,250.00,DR,9750.00\n",
+			wantRows:  []uint32{2},
+			wantTypes: []TxnType{DEBIT},
+		},
+		{
+			name: "quoted cell spanning lines keeps file line numbers",
+			csv: header +
+				"1,01/04/2024,01/04/2024,\"NEFT FROM\nACME CORP\",REF1,100.00,CR,100.00\n" +
+				"2,02/04/2024,02/04/2024,UPI-SWIGGY,REF2,40.00,DR,60.00\n" +
+				"3,03/04/2024,03/04/2024,BAD,REF3,abc,DR,60.00\n",
+			wantRows:   []uint32{2, 4},
+			wantTypes:  []TxnType{CREDIT, DEBIT},
+			wantErrors: []int{5},
+		},
+		{
+			name: "invalid Dr/Cr marker is reported",
+			csv: header +
+				"1,01/04/2024,01/04/2024,UPI,REF1,10.00,XX,10.00\n",
+			wantErrors: []int{2},
+		},
+		{
+			name: "blank lines are skipped",
+			csv: header + "\n" +
+				"1,01/04/2024,01/04/2024,UPI,REF1,10.00,DR,10.00\n\n",
+			wantRows:  []uint32{3},
+			wantTypes: []TxnType{DEBIT},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, parseErrors, err := parseCsvRows(strings.NewReader(tt.csv), legacyStatementProfile, uuid.New(), uuid.New())
+			if err != nil {
+				t.Fatalf("parseCsvRows() error = %v", err)
+			}
+			if len(rows) != len(tt.wantRows) {
+				t.Fatalf("got %d rows, want %d (errors %+v)", len(rows), len(tt.wantRows), parseErrors)
+			}
+			for i, r := range rows {
+				if r.RowNumber != tt.wantRows[i] {
+					t.Errorf("row %d: RowNumber = %d, want %d", i, r.RowNumber, tt.wantRows[i])
+				}
+				if r.Type != tt.wantTypes[i] {
+					t.Errorf("row %d: Type = %s, want %s", i, r.Type, tt.wantTypes[i])
+				}
+			}
+			if len(parseErrors) != len(tt.wantErrors) {
+				t.Fatalf("got %d parse errors, want %d: %+v", len(parseErrors), len(tt.wantErrors), parseErrors)
+			}
+			for i, pe := range parseErrors {
+				if pe.Row != tt.wantErrors[i] {
+					t.Errorf("error %d: Row = %d, want %d", i, pe.Row, tt.wantErrors[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseCsvRowsWithPreamble(t *testing.T) {
+	csv := "Statement of account\n" +
+		"Account No: 1234, Branch: Pune\n" +
+		"\n" +
+		"Sl. No;Txn Date;Value Date;Description;Chq/Ref No;Amount;Dr/Cr;Balance\n" +
+		"1;01/04/2024;01/04/2024;UPI, SWIGGY;REF1;250.00;DR;9750.00\n" +
+		"2;02/04/2024;02/04/2024;SALARY;REF2;50000.00;CR;59750.00\n"
+	rows, parseErrors, err := parseCsvRows(strings.NewReader(csv), autoDetectStatementProfile, uuid.New(), uuid.New())
+	if err != nil {
+		t.Fatalf("parseCsvRows() error = %v", err)
+	}
+	if len(parseErrors) != 0 {
+		t.Errorf("got parse errors %+v, want none", parseErrors)
+	}
+	if len(rows) != 2 || rows[0].RowNumber != 5 || rows[1].RowNumber != 6 {
+		t.Fatalf("got rows %+v, want file lines 5 and 6", rows)
+	}
+	if rows[0].Description == nil || *rows[0].Description != "UPI, SWIGGY" || rows[0].Type != DEBIT {
+		t.Errorf("first row = %+v, want the UPI, SWIGGY debit read whole", rows[0])
+	}
+}
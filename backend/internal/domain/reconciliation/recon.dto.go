@@ -27,6 +27,15 @@ type ParseExcelReq struct {
 	AccountId            uuid.UUID `json:"account_id" form:"account_id" validate:"required"`
 	UserId               string    `json:"user_id" form:"user_id" validate:"required"`
 	FileName             string    `json:"file_name" form:"file_name" validate:"required"`
+	// Password opens an encrypted XLSX or PDF; it is never persisted or echoed back.
+	Password string `json:"-" form:"password"`
+	// Sheet limits an XLSX upload to one sheet; by default every sheet is scanned.
+	Sheet string `json:"sheet,omitempty" form:"sheet"`
+	// Billing-cycle values for a credit card statement; they override what is read off the file.
+	StatementDate  *time.Time `json:"statement_date,omitempty" form:"statement_date"`
+	PaymentDueDate *time.Time `json:"payment_due_date,omitempty" form:"payment_due_date"`
+	MinimumDue     *float64   `json:"minimum_due,omitempty" form:"minimum_due" validate:"omitempty,gte=0"`
+	TotalDue       *float64   `json:"total_due,omitempty" form:"total_due" validate:"omitempty,gte=0"`
 }
 
 func (p *ParseExcelReq) Validate() error {
@@ -41,6 +50,7 @@ type ParsedTxns struct {
 	Amount          float64    `json:"amount"`
 	Type            TxnType    `json:"type"`
 	ReferenceNumber *string    `json:"reference_number"`
+	Balance         *float64   `json:"balance,omitempty"`
 	RawRowHash      *string    `json:"raw_row_hash"`
 	RowNumber       uint32     `json:"row_number"`
 	IsDuplicate     *bool      `json:"is_duplicate"`
@@ -53,11 +63,16 @@ type ParseError struct {
 	Data  map[string]interface{} `json:"data"`
 }
 type UploadSummary struct {
-	TotalRows     int          `json:"total_rows"`
-	DuplicateRows int          `json:"duplicate_rows"`
-	ErrorRows     int          `json:"error_rows"`
-	ValidRows     int          `json:"valid_rows"`
-	Errors        []ParseError `json:"errors"`
+	TotalRows     int `json:"total_rows"`
+	DuplicateRows int `json:"duplicate_rows"`
+	ErrorRows     int `json:"error_rows"`
+	ValidRows     int `json:"valid_rows"`
+	// BalanceMismatches counts running-balance errors; those rows are still imported.
+	BalanceMismatches int `json:"balance_mismatches"`
+	// OverlapDuplicateRows counts rows another upload of the account already holds under a
+	// different narration; they are included in DuplicateRows.
+	OverlapDuplicateRows int          `json:"overlap_duplicate_rows"`
+	Errors               []ParseError `json:"errors"`
 }
 
 type UploadStatementRes struct {
@@ -66,6 +81,19 @@ type UploadStatementRes struct {
 	Status   string        `json:"status"`
 	Summary  UploadSummary `json:"summary"`
 	Txns     []ParsedTxns  `json:"txns"`
+	// ClosingBalance is the statement's last running balance; BalanceDrift is ClosingBalance minus the account's current balance.
+	ClosingBalance *float64 `json:"closing_balance,omitempty"`
+	BalanceDrift   *float64 `json:"balance_drift,omitempty"`
+	// CardCycle is set for credit card statements.
+	CardCycle *CardCycle `json:"card_cycle,omitempty"`
+}
+
+// CardCycle is the billing-cycle summary of a credit card statement.
+type CardCycle struct {
+	StatementDate  *time.Time `json:"statement_date,omitempty"`
+	PaymentDueDate *time.Time `json:"payment_due_date,omitempty"`
+	MinimumDue     *float64   `json:"minimum_due,omitempty"`
+	TotalDue       *float64   `json:"total_due,omitempty"`
 }
 
 // UploadListItem is a single row in the list of bank statement uploads.
@@ -121,6 +149,7 @@ type StatementTransaction struct {
 	Amount          float64    `json:"amount"`
 	Type            string     `json:"type"`
 	ReferenceNumber *string    `json:"reference_number"`
+	Balance         *float64   `json:"balance,omitempty"`
 	RawRowHash      string     `json:"raw_row_hash"`
 	RowNumber       int32      `json:"row_number"`
 	IsDuplicate     *bool      `json:"is_duplicate"`
@@ -128,20 +157,25 @@ type StatementTransaction struct {
 
 // UploadFullDetail is the complete detail for a single upload: metadata, summary counts, parse errors, and all statement transactions.
 type UploadFullDetail struct {
-	ID                   uuid.UUID              `json:"id"`
-	AccountID            uuid.UUID              `json:"account_id"`
-	FileName             string                 `json:"file_name"`
-	UploadStatus         string                 `json:"upload_status"`
-	ProcessingStatus     string                 `json:"processing_status"`
-	StatementPeriodStart *time.Time             `json:"statement_period_start,omitempty"`
-	StatementPeriodEnd   *time.Time             `json:"statement_period_end,omitempty"`
-	ValidRows            int                    `json:"valid_rows"`
-	DuplicateRows        int                    `json:"duplicate_rows"`
-	ErrorRows            int                    `json:"error_rows"`
-	ParsingErrors        []ParseError           `json:"parsing_errors"`
-	Transactions         []StatementTransaction `json:"transactions"`
-	CreatedAt            *time.Time             `json:"created_at,omitempty"`
-	UpdatedAt            *time.Time             `json:"updated_at,omitempty"`
+	ID                    uuid.UUID              `json:"id"`
+	AccountID             uuid.UUID              `json:"account_id"`
+	FileName              string                 `json:"file_name"`
+	UploadStatus          string                 `json:"upload_status"`
+	ProcessingStatus      string                 `json:"processing_status"`
+	StatementPeriodStart  *time.Time             `json:"statement_period_start,omitempty"`
+	StatementPeriodEnd    *time.Time             `json:"statement_period_end,omitempty"`
+	ValidRows             int                    `json:"valid_rows"`
+	DuplicateRows         int                    `json:"duplicate_rows"`
+	ErrorRows             int                    `json:"error_rows"`
+	MatchedTransactions   int                    `json:"matched_transactions"`
+	UnmatchedTransactions int                    `json:"unmatched_transactions"`
+	MissingTransactions   int                    `json:"missing_transactions"`
+	ClosingBalance        *float64               `json:"closing_balance,omitempty"`
+	BalanceDrift          *float64               `json:"balance_drift,omitempty"`
+	ParsingErrors         []ParseError           `json:"parsing_errors"`
+	Transactions          []StatementTransaction `json:"transactions"`
+	CreatedAt             *time.Time             `json:"created_at,omitempty"`
+	UpdatedAt             *time.Time             `json:"updated_at,omitempty"`
 }
 
 // GetUploadDetailReq is used for fetching full upload detail by ID.
@@ -157,24 +191,33 @@ func (r *GetUploadDetailReq) Validate() error {
 
 // UploadFullDetailPaginated is the paginated version of UploadFullDetail.
 type UploadFullDetailPaginated struct {
-	ID                   uuid.UUID              `json:"id"`
-	AccountID            uuid.UUID              `json:"account_id"`
-	FileName             string                 `json:"file_name"`
-	UploadStatus         string                 `json:"upload_status"`
-	ProcessingStatus     string                 `json:"processing_status"`
-	StatementPeriodStart *time.Time             `json:"statement_period_start,omitempty"`
-	StatementPeriodEnd   *time.Time             `json:"statement_period_end,omitempty"`
-	ValidRows            int                    `json:"valid_rows"`
-	DuplicateRows        int                    `json:"duplicate_rows"`
-	ErrorRows            int                    `json:"error_rows"`
-	ParsingErrors        []ParseError           `json:"parsing_errors"`
-	Transactions         []StatementTransaction `json:"transactions"`
-	Total                int64                  `json:"total"`
-	Page                 int32                  `json:"page"`
-	PageSize             int32                  `json:"page_size"`
-	TotalPages           int32                  `json:"total_pages"`
-	CreatedAt            *time.Time             `json:"created_at,omitempty"`
-	UpdatedAt            *time.Time             `json:"updated_at,omitempty"`
+	ID                    uuid.UUID              `json:"id"`
+	AccountID             uuid.UUID              `json:"account_id"`
+	FileName              string                 `json:"file_name"`
+	UploadStatus          string                 `json:"upload_status"`
+	ProcessingStatus      string                 `json:"processing_status"`
+	StatementPeriodStart  *time.Time             `json:"statement_period_start,omitempty"`
+	StatementPeriodEnd    *time.Time             `json:"statement_period_end,omitempty"`
+	ValidRows             int                    `json:"valid_rows"`
+	DuplicateRows         int                    `json:"duplicate_rows"`
+	ErrorRows             int                    `json:"error_rows"`
+	MatchedTransactions   int                    `json:"matched_transactions"`
+	UnmatchedTransactions int                    `json:"unmatched_transactions"`
+	MissingTransactions   int                    `json:"missing_transactions"`
+	TotalTransactions     int                    `json:"total_transactions_found"`
+	ProcessedRows         int                    `json:"processed_rows"`
+	ProcessedChunks       int                    `json:"processed_chunks"`
+	ClosingBalance        *float64               `json:"closing_balance,omitempty"`
+	BalanceDrift          *float64               `json:"balance_drift,omitempty"`
+	CardCycle             *CardCycle             `json:"card_cycle,omitempty"`
+	ParsingErrors         []ParseError           `json:"parsing_errors"`
+	Transactions          []StatementTransaction `json:"transactions"`
+	Total                 int64                  `json:"total"`
+	Page                  int32                  `json:"page"`
+	PageSize              int32                  `json:"page_size"`
+	TotalPages            int32                  `json:"total_pages"`
+	CreatedAt             *time.Time             `json:"created_at,omitempty"`
+	UpdatedAt             *time.Time             `json:"updated_at,omitempty"`
 }
 
 // AppTransaction is a lean in-memory struct fetched for reconciliation matching.
@@ -185,6 +228,9 @@ type AppTransaction struct {
 	Type            string // "DEBIT" / "CREDIT"
 	Description     string
 	ReferenceNumber string
+	IsExcluded      bool
+	AccountID       uuid.UUID  // only set when loaded for a manual match
+	StatementTxnID  *uuid.UUID // only set when loaded for a manual match
 }
 
 // MatchSignals stores the scoring breakdown — persisted as JSONB in match_signals column.
@@ -198,6 +244,10 @@ type MatchSignals struct {
 	AmountScore           int     `json:"amount_score"`
 	DescriptionScore      int     `json:"description_score"`
 	ReferenceScore        int     `json:"reference_score"`
+	PaymentMethod         string  `json:"payment_method,omitempty"`
+	CounterpartyMatch     bool    `json:"counterparty_match"`
+	// Transfer marks a card bill payment linked to the debit on the account that paid it.
+	Transfer bool `json:"transfer,omitempty"`
 }
 
 // ReconciliationResult is a single result row built during the matching phase
@@ -209,7 +259,15 @@ type ReconciliationResult struct {
 	ResultType             string     // generated enum value string
 	ConfidenceScore        float64
 	MatchSignals           MatchSignals
-	MatchStatus            string // "pending" — default user_action
+	MatchStatus            string        // "pending" — default user_action
+	Members                []MatchMember // populated for SPLIT_MATCH only
+}
+
+// MatchMember is one statement row or app transaction inside a SPLIT_MATCH group.
+type MatchMember struct {
+	StatementTransactionID *uuid.UUID `json:"statement_transaction_id,omitempty"`
+	AppTransactionID       *uuid.UUID `json:"app_transaction_id,omitempty"`
+	Amount                 float64    `json:"amount"`
 }
 
 // GetResultsReq is used for fetching reconciliation results for an upload.
@@ -223,6 +281,15 @@ func (r *GetResultsReq) Validate() error {
 	return validator.New().Struct(r)
 }
 
+// ExportReportReq is used for downloading the reconciliation report of an upload.
+type ExportReportReq struct {
+	UploadId uuid.UUID `param:"upload_id" validate:"required"`
+}
+
+func (r *ExportReportReq) Validate() error {
+	return validator.New().Struct(r)
+}
+
 // PaginatedReconciliationResults wraps a page of results with total count metadata.
 type PaginatedReconciliationResults struct {
 	Results    []ReconciliationResultRow `json:"results"`
@@ -249,7 +316,7 @@ func (r *BulkUpdateResultStatusReq) Validate() error {
 type ReconciliationResultRow struct {
 	ID                     uuid.UUID     `json:"id"`
 	UploadID               uuid.UUID     `json:"upload_id"`
-	StatementTransactionID uuid.UUID     `json:"statement_transaction_id"`
+	StatementTransactionID *uuid.UUID    `json:"statement_transaction_id,omitempty"`
 	AppTransactionID       *uuid.UUID    `json:"app_transaction_id,omitempty"`
 	ResultType             string        `json:"result_type"`
 	ConfidenceScore        float64       `json:"confidence_score"`
@@ -264,15 +331,391 @@ type ReconciliationResultRow struct {
 	StmtType            string     `json:"stmt_type"`
 	StmtReferenceNumber *string    `json:"stmt_reference_number,omitempty"`
 	StmtRowNumber       int32      `json:"stmt_row_number"`
+	// App transaction side; the only side present for NOT_IN_STATEMENT results
+	AppDate        *time.Time `json:"app_date,omitempty"`
+	AppDescription *string    `json:"app_description,omitempty"`
+	AppAmount      *float64   `json:"app_amount,omitempty"`
+	AppType        string     `json:"app_type,omitempty"`
+	AppSource      string     `json:"app_source,omitempty"`
+	// Every statement row and app transaction making up a SPLIT_MATCH group
+	Members []MatchMember `json:"members,omitempty"`
 }
 
 // UpdateResultStatusRes is a single updated row returned as part of BulkUpdateResultStatusRes.
 type UpdateResultStatusRes struct {
-	ID         uuid.UUID `json:"id"`
-	UserAction string    `json:"user_action"`
+	ID         uuid.UUID  `json:"id"`
+	UserAction string     `json:"user_action"`
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// ReviewTxn is one app transaction affected by a review decision on a result.
+// SPLIT_MATCH results yield one ReviewTxn per app member.
+type ReviewTxn struct {
+	ResultID               uuid.UUID
+	ResultType             string
+	StatementTransactionID *uuid.UUID
+	AppTransactionID       uuid.UUID
+	AccountID              uuid.UUID
+	ToAccountID            *uuid.UUID
+	UploadAccountID        uuid.UUID
+	Type                   string
+	Amount                 float64
+	Source                 string
+	ReconciliationStatus   string
+	Deleted                bool
 }
 
 // BulkUpdateResultStatusRes is the response after a bulk update.
 type BulkUpdateResultStatusRes struct {
 	Updated []UpdateResultStatusRes `json:"updated"`
 }
+
+// CustomStatementProfile is a user-saved statement layout for one bank.
+type CustomStatementProfile struct {
+	ID        uuid.UUID        `json:"id"`
+	BankId    uuid.UUID        `json:"bank_id"`
+	Profile   StatementProfile `json:"profile"`
+	UpdatedAt *time.Time       `json:"updated_at,omitempty"`
+}
+
+// ListStatementProfilesReq is used for listing built-in and custom statement profiles.
+type ListStatementProfilesReq struct{}
+
+func (ListStatementProfilesReq) Validate() error {
+	return nil
+}
+
+// StatementProfilesRes lists the built-in registry alongside the user's custom mappings.
+type StatementProfilesRes struct {
+	BuiltIn []StatementProfile       `json:"built_in"`
+	Custom  []CustomStatementProfile `json:"custom"`
+}
+
+// SaveStatementProfileReq saves a custom column mapping for a bank.
+type SaveStatementProfileReq struct {
+	BankId        uuid.UUID                    `param:"bank_id" validate:"required"`
+	Name          string                       `json:"name" validate:"required,max=100"`
+	Columns       map[StatementColumn]int      `json:"columns"`
+	Headers       map[StatementColumn][]string `json:"headers"`
+	DateFormats   []string                     `json:"date_formats"`
+	Convention    DrCrConvention               `json:"dr_cr_convention" validate:"required,oneof=INDICATOR_COLUMN SPLIT_COLUMNS SIGNED_AMOUNT"`
+	DebitMarkers  []string                     `json:"debit_markers"`
+	CreditMarkers []string                     `json:"credit_markers"`
+	HeaderRows    int                          `json:"header_rows" validate:"min=0,max=50"`
+	FooterRows    int                          `json:"footer_rows" validate:"min=0,max=50"`
+}
+
+func (r *SaveStatementProfileReq) Validate() error {
+	return validator.New().Struct(r)
+}
+
+// DeleteStatementProfileReq removes the custom column mapping for a bank.
+type DeleteStatementProfileReq struct {
+	BankId uuid.UUID `param:"bank_id" validate:"required"`
+}
+
+func (r *DeleteStatementProfileReq) Validate() error {
+	return validator.New().Struct(r)
+}
+
+// MatchableResult is a reconciliation result loaded with its statement row and the
+// state of the app transaction it currently points at, for manual matching.
+type MatchableResult struct {
+	ID               uuid.UUID
+	UploadID         uuid.UUID
+	AccountID        uuid.UUID
+	ResultType       string
+	Statement        StatementTransaction
+	AppTransactionID *uuid.UUID
+	AppSource        string
+	AppType          string
+	AppAmount        float64
+	AppDeleted       bool
+}
+
+// GetMatchCandidatesReq is used for listing app transactions a result could be linked to.
+type GetMatchCandidatesReq struct {
+	ResultId   uuid.UUID `param:"result_id" validate:"required"`
+	WindowDays int       `query:"window_days" validate:"omitempty,min=1,max=30"`
+	Limit      int       `query:"limit" validate:"omitempty,min=1,max=50"`
+}
+
+func (r *GetMatchCandidatesReq) Validate() error {
+	return validator.New().Struct(r)
+}
+
+// ManualMatchCandidate is an app transaction ranked against a statement row.
+type ManualMatchCandidate struct {
+	AppTransactionID uuid.UUID    `json:"app_transaction_id"`
+	TransactionDate  time.Time    `json:"transaction_date"`
+	Description      string       `json:"description"`
+	Amount           float64      `json:"amount"`
+	Type             string       `json:"type"`
+	ReferenceNumber  string       `json:"reference_number,omitempty"`
+	Score            int          `json:"score"`
+	MatchSignals     MatchSignals `json:"match_signals"`
+}
+
+// MatchCandidatesRes is the ranked candidate list for one reconciliation result.
+type MatchCandidatesRes struct {
+	ResultID               uuid.UUID              `json:"result_id"`
+	StatementTransactionID uuid.UUID              `json:"statement_transaction_id"`
+	WindowDays             int                    `json:"window_days"`
+	Candidates             []ManualMatchCandidate `json:"candidates"`
+}
+
+// ConfirmManualMatchReq links a reconciliation result's statement row to an app transaction.
+type ConfirmManualMatchReq struct {
+	ResultId         uuid.UUID `param:"result_id" validate:"required"`
+	AppTransactionId uuid.UUID `json:"app_transaction_id" validate:"required"`
+}
+
+func (r *ConfirmManualMatchReq) Validate() error {
+	return validator.New().Struct(r)
+}
+
+// ConfirmManualMatchRes is the response after a manual match.
+type ConfirmManualMatchRes struct {
+	ResultID             uuid.UUID    `json:"result_id"`
+	AppTransactionID     uuid.UUID    `json:"app_transaction_id"`
+	ResultType           string       `json:"result_type"`
+	ConfidenceScore      float64      `json:"confidence_score"`
+	MatchSignals         MatchSignals `json:"match_signals"`
+	RemovedTransactionID *uuid.UUID   `json:"removed_transaction_id,omitempty"`
+}
+
+// ReprocessUploadReq re-runs matching for an existing upload, optionally with a
+// different confidence threshold than the user's saved one.
+type ReprocessUploadReq struct {
+	UploadId                uuid.UUID `param:"upload_id" validate:"required"`
+	ReconciliationThreshold *int      `json:"reconciliation_threshold" validate:"omitempty,min=0,max=100"`
+}
+
+func (r *ReprocessUploadReq) Validate() error {
+	return validator.New().Struct(r)
+}
+
+// ReprocessUploadRes summarises what was cleared before the new job was enqueued.
+type ReprocessUploadRes struct {
+	UploadId                uuid.UUID `json:"upload_id"`
+	ProcessingStatus        string    `json:"processing_status"`
+	ReconciliationThreshold int       `json:"reconciliation_threshold"`
+	ClearedResults          int64     `json:"cleared_results"`
+	RevertedTransactions    int       `json:"reverted_transactions"`
+	RemovedTransactions     int       `json:"removed_transactions"`
+}
+
+// SystemResult is a result the matcher produced that the user has not acted on, with
+// the state of the app transaction it points at.
+type SystemResult struct {
+	ID                      uuid.UUID
+	ResultType              string
+	StatementTransactionID  *uuid.UUID
+	AppTransactionID        *uuid.UUID
+	AppSource               string
+	AppReconciliationStatus string
+	AppStatementTxnID       *uuid.UUID
+}
+
+// DecidedLink is a statement row or app transaction covered by a result the user has
+// already acted on; reprocessing leaves these alone.
+type DecidedLink struct {
+	ResultType             string
+	UserAction             string
+	StatementTransactionID *uuid.UUID
+	AppTransactionID       *uuid.UUID
+}
+
+// DiscardedTxn is the balance-relevant part of a removed auto-created transaction.
+type DiscardedTxn struct {
+	AccountID   uuid.UUID
+	ToAccountID *uuid.UUID
+	Type        string
+	Amount      float64
+}
+
+// CardIdentity is what a bill payment narration may name a credit card by.
+type CardIdentity struct {
+	Number   string
+	BankName string
+	BankCode string
+}
+
+// CardPaymentAccount is a savings or current account that can pay a credit card bill.
+type CardPaymentAccount struct {
+	ID       uuid.UUID
+	Payments int
+}
+
+// CardPaymentSource is a debit on a savings or current account that may be the other
+// leg of a card bill payment.
+type CardPaymentSource struct {
+	ID              uuid.UUID
+	AccountID       uuid.UUID
+	ToAccountID     *uuid.UUID
+	Amount          float64
+	TransactionDate time.Time
+	Description     string
+	Payee           string
+}
+
+// JobCheckpoint is how far a reconciliation job got across its attempts.
+type JobCheckpoint struct {
+	Stage           string
+	Attempts        int
+	ChunksCommitted int
+	CreatedTxnIDs   []uuid.UUID
+}
+
+// StatementFile is a stored statement file the reconciliation job extracts the rows of.
+type StatementFile struct {
+	MimeType string
+	Content  []byte
+	// TotalDue is the total due read off a card statement, if any.
+	TotalDue *float64
+}
+
+// GetAccountCoverageReq fetches the statement coverage of one account.
+type GetAccountCoverageReq struct {
+	AccountId uuid.UUID `param:"account_id" validate:"required"`
+}
+
+func (r *GetAccountCoverageReq) Validate() error {
+	return validator.New().Struct(r)
+}
+
+// CoverageUpload is one upload's place on an account's statement timeline. From and To are
+// the stated statement period, or the span of its rows when no period was given.
+type CoverageUpload struct {
+	UploadID  uuid.UUID  `json:"upload_id"`
+	FileName  string     `json:"file_name"`
+	From      *time.Time `json:"from,omitempty"`
+	To        *time.Time `json:"to,omitempty"`
+	RowCount  int        `json:"row_count"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// CoverageRange is an inclusive run of days on an account's statement timeline.
+type CoverageRange struct {
+	From      time.Time   `json:"from"`
+	To        time.Time   `json:"to"`
+	Days      int         `json:"days"`
+	UploadIDs []uuid.UUID `json:"upload_ids,omitempty"`
+}
+
+// AccountCoverageRes shows which days an account's uploads cover, the gaps no upload
+// covers, and the overlaps more than one upload covers.
+type AccountCoverageRes struct {
+	AccountID uuid.UUID        `json:"account_id"`
+	Uploads   []CoverageUpload `json:"uploads"`
+	Covered   []CoverageRange  `json:"covered"`
+	Gaps      []CoverageRange  `json:"gaps"`
+	Overlaps  []CoverageRange  `json:"overlaps"`
+}
+
+// StoredStatementRow is the part of an already imported statement row that cross-upload
+// dedupe compares against.
+type StoredStatementRow struct {
+	TransactionDate time.Time
+	Amount          float64
+	Type            string
+	ReferenceNumber *string
+	RawRowHash      string
+}
+
+// ScoringProfile sets how a statement row is scored against an app transaction. Each
+// weight is the most its signal can add to the confidence score; together they make 100.
+type ScoringProfile struct {
+	// DateWindowDays is how many days apart a pair may be dated and still be matched.
+	DateWindowDays int `json:"date_window_days"`
+	// AmountTolerancePct is the largest amount difference, in percent, that still scores.
+	AmountTolerancePct float64 `json:"amount_tolerance_pct"`
+	DateWeight         int     `json:"date_weight"`
+	AmountWeight       int     `json:"amount_weight"`
+	DescriptionWeight  int     `json:"description_weight"`
+	ReferenceWeight    int     `json:"reference_weight"`
+}
+
+// SignalCalibration is how strongly one match signal was present, from 0 to 1, on the
+// matches the user accepted and on the ones they rejected.
+type SignalCalibration struct {
+	Signal       string  `json:"signal"`
+	AcceptedMean float64 `json:"accepted_mean"`
+	RejectedMean float64 `json:"rejected_mean"`
+	Separation   float64 `json:"separation"`
+}
+
+// CalibrationStats is what a calibration run learned from the user's review decisions.
+type CalibrationStats struct {
+	Accepted int `json:"accepted"`
+	Rejected int `json:"rejected"`
+	// 95th percentile date gap and amount difference among accepted matches.
+	AcceptedDateDiffP95      int                 `json:"accepted_date_diff_p95"`
+	AcceptedAmountDiffPctP95 float64             `json:"accepted_amount_diff_pct_p95"`
+	Signals                  []SignalCalibration `json:"signals"`
+	// Learned is false when there were too few accepted matches and the defaults were
+	// kept; WeightsLearned is false when there were too few rejections to reweigh.
+	Learned        bool `json:"learned"`
+	WeightsLearned bool `json:"weights_learned"`
+}
+
+// CalibrationDecision is one accepted or rejected match a calibration run learns from.
+type CalibrationDecision struct {
+	AccountID uuid.UUID
+	Accepted  bool
+	Signals   MatchSignals
+}
+
+// ScoringProfileRes is a saved scoring profile; AccountID is nil for the user's default.
+type ScoringProfileRes struct {
+	ID               uuid.UUID         `json:"id"`
+	AccountID        *uuid.UUID        `json:"account_id,omitempty"`
+	Profile          ScoringProfile    `json:"profile"`
+	AutoCalibrate    bool              `json:"auto_calibrate"`
+	CalibrationStats *CalibrationStats `json:"calibration_stats,omitempty"`
+	CalibratedAt     *time.Time        `json:"calibrated_at,omitempty"`
+	UpdatedAt        *time.Time        `json:"updated_at,omitempty"`
+}
+
+// ListScoringProfilesReq is used for listing the user's scoring profiles.
+type ListScoringProfilesReq struct{}
+
+func (ListScoringProfilesReq) Validate() error {
+	return nil
+}
+
+// ListScoringProfilesRes returns the built-in profile next to the user's saved ones.
+type ListScoringProfilesRes struct {
+	Default  ScoringProfile      `json:"default"`
+	Profiles []ScoringProfileRes `json:"profiles"`
+}
+
+// SaveScoringProfileReq sets the scoring profile of an account, or the user's default
+// when AccountId is omitted. The four weights must add up to 100.
+type SaveScoringProfileReq struct {
+	AccountId          *uuid.UUID `json:"account_id"`
+	DateWindowDays     int        `json:"date_window_days" validate:"min=0,max=7"`
+	AmountTolerancePct float64    `json:"amount_tolerance_pct" validate:"gte=0,lte=10"`
+	DateWeight         int        `json:"date_weight" validate:"min=0,max=100"`
+	AmountWeight       int        `json:"amount_weight" validate:"min=0,max=100"`
+	DescriptionWeight  int        `json:"description_weight" validate:"min=0,max=100"`
+	ReferenceWeight    int        `json:"reference_weight" validate:"min=0,max=100"`
+	// AutoCalibrate lets the calibration job keep tuning the profile; defaults to true.
+	AutoCalibrate *bool `json:"auto_calibrate"`
+}
+
+func (r *SaveScoringProfileReq) Validate() error {
+	return validator.New().Struct(r)
+}
+
+// RecalibrateScoringReq queues a calibration run for the user's scoring profiles.
+type RecalibrateScoringReq struct{}
+
+func (RecalibrateScoringReq) Validate() error {
+	return nil
+}
+
+type RecalibrateScoringRes struct {
+	Status string `json:"status"`
+}
@@ -68,6 +68,30 @@ func (h *ReconHandler) GetUploadByID(c echo.Context) error {
 	)(c)
 }
 
+// GetAccountCoverage godoc
+// @Summary Get statement coverage for an account
+// @Description Returns the date ranges covered by the account's uploaded statements, the gaps with no statement, and the ranges more than one upload covers
+// @Tags Reconciliation
+// @Produce json
+// @Param account_id path string true "Account ID" format(uuid)
+// @Success 200 {object} AccountCoverageRes
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reconciliation/accounts/{account_id}/coverage [get]
+func (h *ReconHandler) GetAccountCoverage(c echo.Context) error {
+	return handler.Handle(
+		h.base,
+		func(c echo.Context, payload *GetAccountCoverageReq) (*AccountCoverageRes, error) {
+			clerkId := middleware.GetUserID(c)
+			return h.service.GetAccountCoverage(c, payload, clerkId)
+		},
+		http.StatusOK,
+		&GetAccountCoverageReq{},
+	)(c)
+}
+
 // DeleteUpload godoc
 // @Summary Delete bank statement upload
 // @Description Deletes a bank statement upload and all related statement transactions and reconciliation data. Unlinks any app transactions that were linked to this upload.
@@ -142,9 +166,35 @@ func (h *ReconHandler) GetResults(c echo.Context) error {
 	)(c)
 }
 
+// ExportReport godoc
+// @Summary Export the reconciliation report for an upload
+// @Description Downloads an XLSX workbook with the upload's summary counters, matched pairs with confidence and match signals, rows missing in the app, app transactions not in the statement, and parse errors, one sheet each
+// @Tags Reconciliation
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param upload_id path string true "Upload ID" format(uuid)
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reconciliation/uploads/{upload_id}/export [get]
+func (h *ReconHandler) ExportReport(c echo.Context) error {
+	return handler.HandleFile(
+		h.base,
+		func(c echo.Context, payload *ExportReportReq) ([]byte, error) {
+			clerkId := middleware.GetUserID(c)
+			return h.service.ExportReport(c, payload, clerkId)
+		},
+		http.StatusOK,
+		&ExportReportReq{},
+		"reconciliation_report.xlsx",
+		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	)(c)
+}
+
 // BulkUpdateResultStatus godoc
 // @Summary Bulk accept or reject reconciliation results
-// @Description Updates the user_action for one or more reconciliation results. Send a single-element array for a single update.
+// @Description Updates the user_action for one or more reconciliation results and applies it to the linked transactions: accepting marks them USER_VERIFIED, rejecting unlinks them or deletes transactions auto-created from the statement. Send a single-element array for a single update.
 // @Tags Reconciliation
 // @Accept json
 // @Produce json
@@ -166,19 +216,103 @@ func (h *ReconHandler) BulkUpdateResultStatus(c echo.Context) error {
 	)(c)
 }
 
+// GetMatchCandidates godoc
+// @Summary List manual match candidates for a reconciliation result
+// @Description Ranks unreconciled app transactions around the result's statement row by match score. Only LOW_CONFIDENCE_MATCH and MISSING_IN_APP results can be matched manually.
+// @Tags Reconciliation
+// @Produce json
+// @Param result_id path string true "Reconciliation result ID" format(uuid)
+// @Param window_days query int false "Days either side of the statement date to search (default 7, max 30)"
+// @Param limit query int false "Maximum candidates to return (default 10, max 50)"
+// @Success 200 {object} MatchCandidatesRes
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reconciliation/results/{result_id}/candidates [get]
+func (h *ReconHandler) GetMatchCandidates(c echo.Context) error {
+	return handler.Handle(
+		h.base,
+		func(c echo.Context, payload *GetMatchCandidatesReq) (*MatchCandidatesRes, error) {
+			clerkId := middleware.GetUserID(c)
+			return h.service.GetMatchCandidates(c, payload, clerkId)
+		},
+		http.StatusOK,
+		&GetMatchCandidatesReq{},
+	)(c)
+}
+
+// ConfirmManualMatch godoc
+// @Summary Manually match a reconciliation result
+// @Description Links the result's statement row to the chosen app transaction and marks the result MANUALLY_MATCHED. A transaction auto-created for the row is deleted and its balance effect reversed.
+// @Tags Reconciliation
+// @Accept json
+// @Produce json
+// @Param result_id path string true "Reconciliation result ID" format(uuid)
+// @Param body body ConfirmManualMatchReq true "App transaction to link"
+// @Success 200 {object} ConfirmManualMatchRes
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reconciliation/results/{result_id}/match [post]
+func (h *ReconHandler) ConfirmManualMatch(c echo.Context) error {
+	return handler.Handle(
+		h.base,
+		func(c echo.Context, payload *ConfirmManualMatchReq) (*ConfirmManualMatchRes, error) {
+			clerkId := middleware.GetUserID(c)
+			return h.service.ConfirmManualMatch(c, payload, clerkId)
+		},
+		http.StatusOK,
+		&ConfirmManualMatchReq{},
+	)(c)
+}
+
+// ReprocessUpload godoc
+// @Summary Re-run reconciliation for an upload
+// @Description Clears the system-generated results of an upload, un-verifies the transactions they auto-verified, removes the transactions they auto-created (reversing balances) and enqueues a new reconciliation job. Results the user already acted on are kept.
+// @Tags Reconciliation
+// @Accept json
+// @Produce json
+// @Param upload_id path string true "Upload ID" format(uuid)
+// @Param body body ReprocessUploadReq false "Optional threshold override"
+// @Success 202 {object} ReprocessUploadRes
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reconciliation/uploads/{upload_id}/reprocess [post]
+func (h *ReconHandler) ReprocessUpload(c echo.Context) error {
+	return handler.Handle(
+		h.base,
+		func(c echo.Context, payload *ReprocessUploadReq) (*ReprocessUploadRes, error) {
+			clerkId := middleware.GetUserID(c)
+			return h.service.ReprocessUpload(c, payload, clerkId)
+		},
+		http.StatusAccepted,
+		&ReprocessUploadReq{},
+	)(c)
+}
+
 // UploadAndProcessBankStatement godoc
 // @Summary Upload bank statement for reconciliation
-// @Description Uploads a bank statement Excel file and starts reconciliation processing
+// @Description Uploads a bank statement (Excel, CSV, PDF, OFX/QFX, CAMT.053 or MT940) and starts reconciliation processing. A PDF whose layout is not recognised comes back with status EXTRACTING and no rows; the reconciliation job reads its rows with the LLM first.
 // @Tags Reconciliation
 // @Accept multipart/form-data
 // @Produce json
 // @Name UploadAndProcessBankStatement
-// @Param statement formData file true "Bank statement Excel file (.xls, .xlsx)"
+// @Param statement formData file true "Bank statement file (.xlsx, .csv, .pdf, .ofx, .qfx, .xml, .sta, .mt940)"
 // @Param statement_period_start formData string true "Statement period start" format(date-time)
 // @Param statement_period_end formData string true "Statement period end" format(date-time)
 // @Param account_id formData string true "Account ID" format(uuid)
 // @Param user_id formData string true "User ID (Clerk ID)"
 // @Param file_name formData string true "Original file name"
+// @Param password formData string false "Password for an encrypted .xlsx or .pdf; never stored"
+// @Param sheet formData string false "Only parse this sheet; by default every sheet is scanned"
+// @Param statement_date formData string false "Credit card statement date; overrides the one read off the file" format(date-time)
+// @Param payment_due_date formData string false "Credit card payment due date; overrides the one read off the file" format(date-time)
+// @Param minimum_due formData number false "Credit card minimum amount due; overrides the one read off the file"
+// @Param total_due formData number false "Credit card total amount due; overrides the one read off the file"
 // @Success 202 {object} UploadStatementRes
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 401 {object} map[string]string "Unauthorized"
@@ -192,3 +326,138 @@ func (h *ReconHandler) UploadAndProcessBankStatement(c echo.Context) error {
 		&ParseExcelReq{},
 	)(c)
 }
+
+// ListStatementProfiles godoc
+// @Summary List statement format profiles
+// @Description Returns the built-in bank statement layouts and the user's custom column mappings
+// @Tags Reconciliation
+// @Produce json
+// @Success 200 {object} StatementProfilesRes
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reconciliation/profiles [get]
+func (h *ReconHandler) ListStatementProfiles(c echo.Context) error {
+	return handler.Handle(
+		h.base,
+		func(c echo.Context, payload *ListStatementProfilesReq) (*StatementProfilesRes, error) {
+			clerkId := middleware.GetUserID(c)
+			return h.service.ListStatementProfiles(c, payload, clerkId)
+		},
+		http.StatusOK,
+		&ListStatementProfilesReq{},
+	)(c)
+}
+
+// SaveStatementProfile godoc
+// @Summary Save a custom statement format profile
+// @Description Creates or replaces the user's column mapping for a bank whose statement layout is not built in
+// @Tags Reconciliation
+// @Accept json
+// @Produce json
+// @Param bank_id path string true "Bank ID" format(uuid)
+// @Param request body SaveStatementProfileReq true "Column mapping"
+// @Success 200 {object} CustomStatementProfile
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reconciliation/profiles/{bank_id} [put]
+func (h *ReconHandler) SaveStatementProfile(c echo.Context) error {
+	return handler.Handle(
+		h.base,
+		func(c echo.Context, payload *SaveStatementProfileReq) (*CustomStatementProfile, error) {
+			clerkId := middleware.GetUserID(c)
+			return h.service.SaveStatementProfile(c, payload, clerkId)
+		},
+		http.StatusOK,
+		&SaveStatementProfileReq{},
+	)(c)
+}
+
+// DeleteStatementProfile godoc
+// @Summary Delete a custom statement format profile
+// @Description Removes the user's column mapping for a bank; uploads fall back to the built-in profile or auto-detection
+// @Tags Reconciliation
+// @Param bank_id path string true "Bank ID" format(uuid)
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reconciliation/profiles/{bank_id} [delete]
+func (h *ReconHandler) DeleteStatementProfile(c echo.Context) error {
+	return handler.HandleNoContent(
+		h.base,
+		func(c echo.Context, payload *DeleteStatementProfileReq) error {
+			clerkId := middleware.GetUserID(c)
+			return h.service.DeleteStatementProfile(c, payload, clerkId)
+		},
+		http.StatusNoContent,
+		&DeleteStatementProfileReq{},
+	)(c)
+}
+
+// ListScoringProfiles godoc
+// @Summary List match scoring profiles
+// @Description Returns the built-in scoring profile and the user's saved ones, per account or user-wide, with what the last calibration learned from their review decisions
+// @Tags Reconciliation
+// @Produce json
+// @Success 200 {object} ListScoringProfilesRes
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reconciliation/scoring-profiles [get]
+func (h *ReconHandler) ListScoringProfiles(c echo.Context) error {
+	return handler.Handle(
+		h.base,
+		func(c echo.Context, payload *ListScoringProfilesReq) (*ListScoringProfilesRes, error) {
+			clerkId := middleware.GetUserID(c)
+			return h.service.ListScoringProfiles(c, payload, clerkId)
+		},
+		http.StatusOK,
+		&ListScoringProfilesReq{},
+	)(c)
+}
+
+// SaveScoringProfile godoc
+// @Summary Save a match scoring profile
+// @Description Sets the date window, amount tolerance and signal weights used to score matches for an account, or for all of the user's accounts when account_id is omitted. Weights must add up to 100.
+// @Tags Reconciliation
+// @Accept json
+// @Produce json
+// @Param request body SaveScoringProfileReq true "Scoring profile"
+// @Success 200 {object} ScoringProfileRes
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reconciliation/scoring-profiles [put]
+func (h *ReconHandler) SaveScoringProfile(c echo.Context) error {
+	return handler.Handle(
+		h.base,
+		func(c echo.Context, payload *SaveScoringProfileReq) (*ScoringProfileRes, error) {
+			clerkId := middleware.GetUserID(c)
+			return h.service.SaveScoringProfile(c, payload, clerkId)
+		},
+		http.StatusOK,
+		&SaveScoringProfileReq{},
+	)(c)
+}
+
+// RecalibrateScoring godoc
+// @Summary Recalibrate match scoring profiles
+// @Description Queues a background job that relearns the user's scoring profiles from their accepted and rejected reconciliation results
+// @Tags Reconciliation
+// @Produce json
+// @Success 202 {object} RecalibrateScoringRes
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reconciliation/scoring-profiles/recalibrate [post]
+func (h *ReconHandler) RecalibrateScoring(c echo.Context) error {
+	return handler.Handle(
+		h.base,
+		func(c echo.Context, payload *RecalibrateScoringReq) (*RecalibrateScoringRes, error) {
+			clerkId := middleware.GetUserID(c)
+			return h.service.RecalibrateScoring(c, payload, clerkId)
+		},
+		http.StatusAccepted,
+		&RecalibrateScoringReq{},
+	)(c)
+}
@@ -0,0 +1,298 @@
+package reconciliation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/utils"
+)
+
+// exactMatchScore is the confidence given to a same-day, same-amount, same-type pair.
+const exactMatchScore = 95
+
+type matchCandidate struct {
+	stmtIdx int
+	appIdx  int
+	score   int
+	exact   bool
+	signals MatchSignals
+}
+
+// assignedMatch is the app transaction a statement row ended up paired with.
+type assignedMatch struct {
+	appIdx  int
+	score   int
+	exact   bool
+	signals MatchSignals
+}
+
+// assignMatches scores every statement/app pair inside the profile's date window
+// and assigns them greedily by global score, so each app transaction is claimed by
+// at most one statement row. A row that loses its best candidate falls through to
+// its next best one further down the sorted list.
+func assignMatches(stmts []StatementTransaction, appTxns []AppTransaction, p ScoringProfile) map[int]assignedMatch {
+	dateIndex := make(map[string][]int, len(appTxns))
+	for i := range appTxns {
+		key := appTxns[i].TransactionDate.Format("2006-01-02") + "|" + appTxns[i].Type
+		dateIndex[key] = append(dateIndex[key], i)
+	}
+
+	var candidates []matchCandidate
+	for si, st := range stmts {
+		if st.TransactionDate == nil {
+			continue
+		}
+		stmtDesc, narr := statementNarration(st)
+		stmtAmount := fmt.Sprintf("%.2f", st.Amount)
+
+		for dayDelta := -p.DateWindowDays; dayDelta <= p.DateWindowDays; dayDelta++ {
+			key := st.TransactionDate.AddDate(0, 0, dayDelta).Format("2006-01-02") + "|" + st.Type
+			for _, ai := range dateIndex[key] {
+				at := &appTxns[ai]
+				signals, score := scoreMatch(p, st.Amount, stmtDesc, narr, *st.TransactionDate, at)
+				exact := dayDelta == 0 && stmtAmount == fmt.Sprintf("%.2f", at.Amount)
+				if exact && score < exactMatchScore {
+					score = exactMatchScore
+				}
+				if score <= 0 {
+					continue
+				}
+				candidates = append(candidates, matchCandidate{stmtIdx: si, appIdx: ai, score: score, exact: exact, signals: signals})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if a.signals.DateDiffDays != b.signals.DateDiffDays {
+			return a.signals.DateDiffDays < b.signals.DateDiffDays
+		}
+		if a.signals.AmountDiff != b.signals.AmountDiff {
+			return a.signals.AmountDiff < b.signals.AmountDiff
+		}
+		return a.stmtIdx < b.stmtIdx
+	})
+
+	assigned := make(map[int]assignedMatch, len(stmts))
+	claimed := make(map[int]struct{}, len(appTxns))
+	for _, c := range candidates {
+		if _, ok := assigned[c.stmtIdx]; ok {
+			continue
+		}
+		if _, ok := claimed[c.appIdx]; ok {
+			continue
+		}
+		assigned[c.stmtIdx] = assignedMatch{appIdx: c.appIdx, score: c.score, exact: c.exact, signals: c.signals}
+		claimed[c.appIdx] = struct{}{}
+	}
+	return assigned
+}
+
+// maxSplitParts caps how many transactions can be combined to cover a single amount.
+const maxSplitParts = 4
+
+// maxSplitCandidates bounds the subset search per amount; the closest-dated ones are kept.
+const maxSplitCandidates = 12
+
+// splitGroup is a set of statement rows and app transactions whose amounts balance out,
+// either one statement row covered by several app entries or the reverse.
+type splitGroup struct {
+	stmtIdxs []int
+	appIdxs  []int
+	score    int
+	signals  MatchSignals
+}
+
+// findSplitMatches looks for one-to-many matches among whatever assignMatches did not
+// pair on amount: first a statement row whose amount is the sum of 2..maxSplitParts app
+// transactions in its date window, then an app transaction covering several statement
+// rows (e.g. a purchase plus a separately charged fee). Pairs assigned on date alone
+// (no amount score) stay eligible; any such pair touched by a group is dropped from
+// assigned, since an exact sum is stronger evidence than a shared date.
+func findSplitMatches(stmts []StatementTransaction, appTxns []AppTransaction, assigned map[int]assignedMatch, p ScoringProfile) []splitGroup {
+	claimedApp := make(map[int]struct{}, len(assigned))
+	weakByApp := make(map[int]int)
+	for si, m := range assigned {
+		if m.signals.AmountScore == 0 && !m.exact {
+			weakByApp[m.appIdx] = si
+			continue
+		}
+		claimedApp[m.appIdx] = struct{}{}
+	}
+	claimedStmt := make(map[int]struct{}, len(stmts))
+	for si, st := range stmts {
+		if m, ok := assigned[si]; (ok && (m.signals.AmountScore > 0 || m.exact)) || st.TransactionDate == nil {
+			claimedStmt[si] = struct{}{}
+		}
+	}
+	release := func(g splitGroup) {
+		for _, si := range g.stmtIdxs {
+			delete(assigned, si)
+		}
+		for _, ai := range g.appIdxs {
+			if si, ok := weakByApp[ai]; ok {
+				delete(assigned, si)
+			}
+		}
+	}
+
+	var groups []splitGroup
+	for si, st := range stmts {
+		if _, ok := claimedStmt[si]; ok {
+			continue
+		}
+		var cands []int
+		for ai, at := range appTxns {
+			if _, ok := claimedApp[ai]; ok {
+				continue
+			}
+			if at.Type != st.Type || at.Amount <= 0 || at.Amount >= st.Amount {
+				continue
+			}
+			if daysApart(*st.TransactionDate, at.TransactionDate) <= p.DateWindowDays {
+				cands = append(cands, ai)
+			}
+		}
+		cands = closestCandidates(cands, func(ai int) int { return daysApart(*st.TransactionDate, appTxns[ai].TransactionDate) })
+		amounts := make([]int64, len(cands))
+		for k, ai := range cands {
+			amounts[k] = toPaise(appTxns[ai].Amount)
+		}
+		picked := findSubsetSum(amounts, toPaise(st.Amount))
+		if picked == nil {
+			continue
+		}
+		g := splitGroup{stmtIdxs: []int{si}}
+		for _, k := range picked {
+			g.appIdxs = append(g.appIdxs, cands[k])
+			claimedApp[cands[k]] = struct{}{}
+		}
+		claimedStmt[si] = struct{}{}
+		scoreSplitGroup(&g, stmts, appTxns)
+		release(g)
+		groups = append(groups, g)
+	}
+
+	for ai, at := range appTxns {
+		if _, ok := claimedApp[ai]; ok {
+			continue
+		}
+		var cands []int
+		for si, st := range stmts {
+			if _, ok := claimedStmt[si]; ok {
+				continue
+			}
+			if st.Type != at.Type || st.Amount <= 0 || st.Amount >= at.Amount {
+				continue
+			}
+			if daysApart(*st.TransactionDate, at.TransactionDate) <= p.DateWindowDays {
+				cands = append(cands, si)
+			}
+		}
+		cands = closestCandidates(cands, func(si int) int { return daysApart(*stmts[si].TransactionDate, at.TransactionDate) })
+		amounts := make([]int64, len(cands))
+		for k, si := range cands {
+			amounts[k] = toPaise(stmts[si].Amount)
+		}
+		picked := findSubsetSum(amounts, toPaise(at.Amount))
+		if picked == nil {
+			continue
+		}
+		g := splitGroup{appIdxs: []int{ai}}
+		for _, k := range picked {
+			g.stmtIdxs = append(g.stmtIdxs, cands[k])
+			claimedStmt[cands[k]] = struct{}{}
+		}
+		sort.Ints(g.stmtIdxs)
+		claimedApp[ai] = struct{}{}
+		scoreSplitGroup(&g, stmts, appTxns)
+		release(g)
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// scoreSplitGroup rates a group lower than any one-to-one match so it always lands
+// in review: 70 when every part shares the same date, dropping with the date spread.
+func scoreSplitGroup(g *splitGroup, stmts []StatementTransaction, appTxns []AppTransaction) {
+	var maxDiff, pairs int
+	var similarity float64
+	for _, si := range g.stmtIdxs {
+		stmtDesc := ""
+		if stmts[si].Description != nil {
+			stmtDesc = *stmts[si].Description
+		}
+		for _, ai := range g.appIdxs {
+			if d := daysApart(*stmts[si].TransactionDate, appTxns[ai].TransactionDate); d > maxDiff {
+				maxDiff = d
+			}
+			similarity += utils.TokenJaccard(stmtDesc, appTxns[ai].Description)
+			pairs++
+		}
+	}
+	if pairs > 0 {
+		similarity /= float64(pairs)
+	}
+	g.score = 70 - 5*maxDiff
+	g.signals = MatchSignals{
+		DateDiffDays:          maxDiff,
+		DescriptionSimilarity: similarity,
+		AmountScore:           35,
+	}
+}
+
+// findSubsetSum returns the indices of 2..maxSplitParts amounts that add up to target
+// exactly, preferring earlier (closer-dated) entries, or nil when none exist.
+func findSubsetSum(amounts []int64, target int64) []int {
+	var picked []int
+	var dfs func(start int, remaining int64) bool
+	dfs = func(start int, remaining int64) bool {
+		if remaining == 0 {
+			return len(picked) >= 2
+		}
+		if len(picked) == maxSplitParts {
+			return false
+		}
+		for i := start; i < len(amounts); i++ {
+			if amounts[i] > remaining {
+				continue
+			}
+			picked = append(picked, i)
+			if dfs(i+1, remaining-amounts[i]) {
+				return true
+			}
+			picked = picked[:len(picked)-1]
+		}
+		return false
+	}
+	if target <= 0 || !dfs(0, target) {
+		return nil
+	}
+	return picked
+}
+
+// closestCandidates orders candidates by date distance and keeps the nearest maxSplitCandidates.
+func closestCandidates(cands []int, dist func(int) int) []int {
+	sort.SliceStable(cands, func(i, j int) bool { return dist(cands[i]) < dist(cands[j]) })
+	if len(cands) > maxSplitCandidates {
+		cands = cands[:maxSplitCandidates]
+	}
+	return cands
+}
+
+func daysApart(a, b time.Time) int {
+	d := int(a.Sub(b).Hours() / 24)
+	if d < 0 {
+		d = -d
+	}
+	return d
+}
+
+func toPaise(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
@@ -0,0 +1,301 @@
+package reconciliation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, time.April, d, 0, 0, 0, 0, time.UTC)
+}
+
+func stmtTxn(d int, amount float64, txnType, desc string) StatementTransaction {
+	date := day(d)
+	return StatementTransaction{ID: uuid.New(), TransactionDate: &date, Amount: amount, Type: txnType, Description: &desc}
+}
+
+func appTxn(d int, amount float64, txnType, desc string) AppTransaction {
+	return AppTransaction{ID: uuid.New(), TransactionDate: day(d), Amount: amount, Type: txnType, Description: desc}
+}
+
+func TestScoreMatch(t *testing.T) {
+	p := defaultScoringProfile
+	tests := []struct {
+		name        string
+		stmtAmount  float64
+		stmtDesc    string
+		narr        Narration
+		stmtDay     int
+		app         AppTransaction
+		wantDate    int
+		wantAmount  int
+		wantDesc    int
+		wantRef     int
+		wantTotal   int
+		wantCounter bool
+	}{
+		{
+			name:       "everything agrees",
+			stmtAmount: 500,
+			stmtDesc:   "swiggy order",
+			narr:       Narration{Reference: "412345678901"},
+			stmtDay:    10,
+			app:        AppTransaction{TransactionDate: day(10), Amount: 500, Description: "swiggy order", ReferenceNumber: "412345678901"},
+			wantDate:   40, wantAmount: 35, wantDesc: 15, wantRef: 10, wantTotal: 100,
+		},
+		{
+			name:       "one day apart",
+			stmtAmount: 500,
+			stmtDesc:   "alpha",
+			stmtDay:    10,
+			app:        AppTransaction{TransactionDate: day(11), Amount: 500, Description: "beta"},
+			wantDate:   30, wantAmount: 35, wantTotal: 65,
+		},
+		{
+			name:       "outside the date window",
+			stmtAmount: 500,
+			stmtDesc:   "alpha",
+			stmtDay:    10,
+			app:        AppTransaction{TransactionDate: day(13), Amount: 500, Description: "beta"},
+			wantAmount: 35, wantTotal: 35,
+		},
+		{
+			name:       "amount within a third of the tolerance",
+			stmtAmount: 100,
+			stmtDesc:   "alpha",
+			stmtDay:    10,
+			app:        AppTransaction{TransactionDate: day(10), Amount: 99, Description: "beta"},
+			wantDate:   40, wantAmount: 25, wantTotal: 65,
+		},
+		{
+			name:       "amount within the tolerance",
+			stmtAmount: 100,
+			stmtDesc:   "alpha",
+			stmtDay:    10,
+			app:        AppTransaction{TransactionDate: day(10), Amount: 98, Description: "beta"},
+			wantDate:   40, wantAmount: 15, wantTotal: 55,
+		},
+		{
+			name:       "amount beyond the tolerance",
+			stmtAmount: 100,
+			stmtDesc:   "alpha",
+			stmtDay:    10,
+			app:        AppTransaction{TransactionDate: day(10), Amount: 90, Description: "beta"},
+			wantDate:   40, wantTotal: 40,
+		},
+		{
+			name:       "narration counterparty lifts the description score",
+			stmtAmount: 100,
+			stmtDesc:   "UPI/412345678901/RAVI KUMAR/ravi@okaxis",
+			narr:       Narration{Counterparty: "Ravi Kumar"},
+			stmtDay:    10,
+			app:        AppTransaction{TransactionDate: day(10), Amount: 100, Description: "rent to ravi kumar"},
+			wantDate:   40, wantAmount: 35, wantDesc: 10, wantTotal: 85,
+			wantCounter: true,
+		},
+		{
+			name:       "app reference found in the statement description",
+			stmtAmount: 100,
+			stmtDesc:   "NEFT-HDFCN52024041012345-ACME",
+			stmtDay:    10,
+			app:        AppTransaction{TransactionDate: day(10), Amount: 100, Description: "salary", ReferenceNumber: "hdfcn52024041012345"},
+			wantDate:   40, wantAmount: 35, wantRef: 10, wantTotal: 85,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signals, total := scoreMatch(p, tt.stmtAmount, tt.stmtDesc, tt.narr, day(tt.stmtDay), &tt.app)
+			if signals.DateScore != tt.wantDate {
+				t.Errorf("DateScore = %d, want %d", signals.DateScore, tt.wantDate)
+			}
+			if signals.AmountScore != tt.wantAmount {
+				t.Errorf("AmountScore = %d, want %d", signals.AmountScore, tt.wantAmount)
+			}
+			if signals.DescriptionScore != tt.wantDesc {
+				t.Errorf("DescriptionScore = %d, want %d", signals.DescriptionScore, tt.wantDesc)
+			}
+			if signals.ReferenceScore != tt.wantRef {
+				t.Errorf("ReferenceScore = %d, want %d", signals.ReferenceScore, tt.wantRef)
+			}
+			if signals.CounterpartyMatch != tt.wantCounter {
+				t.Errorf("CounterpartyMatch = %v, want %v", signals.CounterpartyMatch, tt.wantCounter)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", total, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestAssignMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		stmts     []StatementTransaction
+		apps      []AppTransaction
+		want      map[int]int
+		wantExact map[int]bool
+	}{
+		{
+			name:      "exact pairs",
+			stmts:     []StatementTransaction{stmtTxn(1, 100, "DEBIT", "a"), stmtTxn(2, 200, "DEBIT", "b")},
+			apps:      []AppTransaction{appTxn(2, 200, "DEBIT", "y"), appTxn(1, 100, "DEBIT", "x")},
+			want:      map[int]int{0: 1, 1: 0},
+			wantExact: map[int]bool{0: true, 1: true},
+		},
+		{
+			name: "a row that loses its best candidate takes its next best",
+			stmts: []StatementTransaction{
+				stmtTxn(2, 100, "DEBIT", "a"),
+				stmtTxn(1, 100, "DEBIT", "b"),
+			},
+			apps: []AppTransaction{appTxn(1, 100, "DEBIT", "x"), appTxn(3, 100, "DEBIT", "y")},
+			// Row 1 pairs exactly with app 0; row 0 is a day from both and gets app 1.
+			want: map[int]int{1: 0, 0: 1},
+		},
+		{
+			name:  "each app transaction is claimed once",
+			stmts: []StatementTransaction{stmtTxn(1, 100, "DEBIT", "a"), stmtTxn(1, 100, "DEBIT", "a")},
+			apps:  []AppTransaction{appTxn(1, 100, "DEBIT", "x")},
+			want:  map[int]int{0: 0},
+		},
+		{
+			name:  "type must agree",
+			stmts: []StatementTransaction{stmtTxn(1, 100, "DEBIT", "a")},
+			apps:  []AppTransaction{appTxn(1, 100, "CREDIT", "x")},
+			want:  map[int]int{},
+		},
+		{
+			name:  "outside the date window",
+			stmts: []StatementTransaction{stmtTxn(1, 100, "DEBIT", "a")},
+			apps:  []AppTransaction{appTxn(5, 100, "DEBIT", "x")},
+			want:  map[int]int{},
+		},
+		{
+			name:  "undated statement row is skipped",
+			stmts: []StatementTransaction{{Amount: 100, Type: "DEBIT"}},
+			apps:  []AppTransaction{appTxn(1, 100, "DEBIT", "x")},
+			want:  map[int]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := assignMatches(tt.stmts, tt.apps, defaultScoringProfile)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d matches, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for si, ai := range tt.want {
+				m, ok := got[si]
+				if !ok || m.appIdx != ai {
+					t.Errorf("statement %d matched app %d (found %v), want %d", si, m.appIdx, ok, ai)
+				}
+				if exact, ok := tt.wantExact[si]; ok && m.exact != exact {
+					t.Errorf("statement %d exact = %v, want %v", si, m.exact, exact)
+				}
+			}
+		})
+	}
+}
+
+func TestFindSubsetSum(t *testing.T) {
+	tests := []struct {
+		name    string
+		amounts []int64
+		target  int64
+		want    []int
+	}{
+		{"two parts", []int64{60000, 40000}, 100000, []int{0, 1}},
+		{"earlier entries win", []int64{300, 700, 200, 500}, 1000, []int{0, 1}},
+		{"a single equal amount is not a split", []int64{1000, 500, 500}, 1000, []int{1, 2}},
+		{"four parts", []int64{100, 200, 300, 400}, 1000, []int{0, 1, 2, 3}},
+		{"more than four parts", []int64{200, 200, 200, 200, 200}, 1000, nil},
+		{"no combination", []int64{300, 300}, 1000, nil},
+		{"zero target", []int64{0, 0}, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findSubsetSum(tt.amounts, tt.target)
+			if !equalInts(got, tt.want) {
+				t.Errorf("findSubsetSum(%v, %d) = %v, want %v", tt.amounts, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindSplitMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		stmts     []StatementTransaction
+		apps      []AppTransaction
+		wantStmts [][]int
+		wantApps  [][]int
+		wantScore []int
+	}{
+		{
+			name:      "statement row covered by several app entries",
+			stmts:     []StatementTransaction{stmtTxn(5, 1000, "DEBIT", "amazon")},
+			apps:      []AppTransaction{appTxn(5, 600, "DEBIT", "amazon"), appTxn(6, 400, "DEBIT", "amazon")},
+			wantStmts: [][]int{{0}},
+			wantApps:  [][]int{{0, 1}},
+			wantScore: []int{65},
+		},
+		{
+			name:      "app entry covering a purchase and its fee",
+			stmts:     []StatementTransaction{stmtTxn(5, 100, "DEBIT", "fee"), stmtTxn(5, 1000, "DEBIT", "flight")},
+			apps:      []AppTransaction{appTxn(5, 1100, "DEBIT", "flight")},
+			wantStmts: [][]int{{0, 1}},
+			wantApps:  [][]int{{0}},
+			wantScore: []int{70},
+		},
+		{
+			name:  "exact pairs are not broken up",
+			stmts: []StatementTransaction{stmtTxn(5, 500, "DEBIT", "a")},
+			apps:  []AppTransaction{appTxn(5, 500, "DEBIT", "a"), appTxn(5, 200, "DEBIT", "b"), appTxn(5, 300, "DEBIT", "c")},
+		},
+		{
+			name:  "parts must share the type",
+			stmts: []StatementTransaction{stmtTxn(5, 1000, "DEBIT", "a")},
+			apps:  []AppTransaction{appTxn(5, 600, "DEBIT", "a"), appTxn(5, 400, "CREDIT", "b")},
+		},
+		{
+			name:  "parts must fall in the date window",
+			stmts: []StatementTransaction{stmtTxn(5, 1000, "DEBIT", "a")},
+			apps:  []AppTransaction{appTxn(5, 600, "DEBIT", "a"), appTxn(9, 400, "DEBIT", "b")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assigned := assignMatches(tt.stmts, tt.apps, defaultScoringProfile)
+			groups := findSplitMatches(tt.stmts, tt.apps, assigned, defaultScoringProfile)
+			if len(groups) != len(tt.wantStmts) {
+				t.Fatalf("got %d groups, want %d: %+v", len(groups), len(tt.wantStmts), groups)
+			}
+			for i, g := range groups {
+				if !equalInts(g.stmtIdxs, tt.wantStmts[i]) || !equalInts(g.appIdxs, tt.wantApps[i]) {
+					t.Errorf("group %d = stmts %v apps %v, want stmts %v apps %v", i, g.stmtIdxs, g.appIdxs, tt.wantStmts[i], tt.wantApps[i])
+				}
+				if g.score != tt.wantScore[i] {
+					t.Errorf("group %d score = %d, want %d", i, g.score, tt.wantScore[i])
+				}
+				for _, si := range g.stmtIdxs {
+					if _, ok := assigned[si]; ok {
+						t.Errorf("statement %d is still assigned one-to-one", si)
+					}
+				}
+			}
+		})
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
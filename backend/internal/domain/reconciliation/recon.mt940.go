@@ -0,0 +1,139 @@
+package reconciliation
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// mt940Tag matches a field tag such as :20:, :60F: or :86: at the start of a line.
+	mt940Tag = regexp.MustCompile(`^:(\d{2}[A-Z]?):`)
+	// mt940Line splits a :61: statement line into value date, optional entry date, debit/credit
+	// mark, amount, customer reference, bank reference and supplementary details.
+	mt940Line = regexp.MustCompile(`(?s)^(\d{6})(\d{4})?(RC|RD|C|D)[A-Z]?(\d+,\d*)[A-Z][A-Z0-9]{3}([^/\n]*)(?://([^\n]*))?(?:\n(.*))?$`)
+	// mt940Balance reads :60F:/:62F: style balances: mark, date, currency and amount.
+	mt940Balance = regexp.MustCompile(`^(C|D)(\d{6})[A-Z]{3}(\d+,\d*)`)
+	// mt940Subfield strips the ?20..?63 subfield markers German banks put in :86:.
+	mt940Subfield = regexp.MustCompile(`\?\d{2}`)
+)
+
+type mt940Field struct {
+	tag   string
+	value string
+}
+
+// parseMt940Rows reads every statement in a SWIFT MT940 file.
+func parseMt940Rows(r io.Reader, accountID uuid.UUID, uploadID uuid.UUID) ([]ParsedTxns, []ParseError, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var fields []mt940Field
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " \r")
+		if m := mt940Tag.FindStringSubmatch(line); m != nil {
+			fields = append(fields, mt940Field{tag: m[1], value: line[len(m[0]):]})
+			continue
+		}
+		// Block delimiters of the SWIFT envelope carry no data.
+		if line == "" || line == "-" || line == "-}" || strings.HasPrefix(line, "{") {
+			continue
+		}
+		if len(fields) > 0 {
+			fields[len(fields)-1].value += "\n" + line
+		}
+	}
+
+	var stmts []nativeStatement
+	var cur *nativeStatement
+	for _, f := range fields {
+		switch f.tag {
+		case "20":
+			stmts = append(stmts, nativeStatement{})
+			cur = &stmts[len(stmts)-1]
+		case "61":
+			if cur != nil {
+				cur.Entries = append(cur.Entries, mt940Entry(f.value))
+			}
+		case "86":
+			if cur != nil && len(cur.Entries) > 0 {
+				last := &cur.Entries[len(cur.Entries)-1]
+				info := strings.ReplaceAll(strings.ReplaceAll(f.value, "\n?", "?"), "\n", " ")
+				info = mt940Subfield.ReplaceAllString(info, " ")
+				last.Description = joinNonEmpty(info, last.Description)
+			}
+		case "62F", "62M":
+			if cur != nil {
+				if b, ok := parseMt940Balance(f.value); ok {
+					cur.Closing = &b
+				}
+			}
+		}
+	}
+	if len(stmts) == 0 {
+		return nil, nil, fmt.Errorf("not an MT940 file: no :20: statement found")
+	}
+
+	rows, parseErrors := nativeStatementsToRows(nativeFormatMT940, stmts, accountID, uploadID)
+	return rows, parseErrors, nil
+}
+
+func mt940Entry(v string) nativeEntry {
+	m := mt940Line.FindStringSubmatch(v)
+	if m == nil {
+		return nativeEntry{Invalid: "invalid statement line", InvalidValue: v}
+	}
+	e := nativeEntry{Description: strings.TrimSpace(m[7])}
+
+	date, err := parseMt940Date(m[1])
+	if err != nil {
+		e.Invalid, e.InvalidValue = "invalid transaction date", m[1]
+		return e
+	}
+	e.Date = date
+
+	amount, err := strconv.ParseFloat(strings.Replace(m[4], ",", ".", 1), 64)
+	if err != nil {
+		e.Invalid, e.InvalidValue = "invalid amount", m[4]
+		return e
+	}
+	// RC reverses a credit, so money leaves the account; RD reverses a debit.
+	if m[3] == "D" || m[3] == "RC" {
+		amount = -amount
+	}
+	e.Amount = amount
+
+	bankRef, customerRef := strings.TrimSpace(m[6]), strings.TrimSpace(m[5])
+	if strings.EqualFold(customerRef, "NONREF") {
+		customerRef = ""
+	}
+	e.ID = firstNonEmpty(bankRef, customerRef)
+	return e
+}
+
+// parseMt940Balance returns the signed amount of a balance field.
+func parseMt940Balance(v string) (float64, bool) {
+	m := mt940Balance.FindStringSubmatch(strings.TrimSpace(v))
+	if m == nil {
+		return 0, false
+	}
+	b, err := strconv.ParseFloat(strings.Replace(m[3], ",", ".", 1), 64)
+	if err != nil {
+		return 0, false
+	}
+	if m[1] == "D" {
+		b = -b
+	}
+	return b, true
+}
+
+func parseMt940Date(v string) (time.Time, error) {
+	return time.Parse("060102", v)
+}
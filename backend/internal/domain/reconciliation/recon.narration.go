@@ -0,0 +1,311 @@
+package reconciliation
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Payment methods a statement narration can reveal, worded like the SMS parser's.
+const (
+	PaymentMethodUPI    = "UPI"
+	PaymentMethodIMPS   = "IMPS"
+	PaymentMethodNEFT   = "NEFT"
+	PaymentMethodRTGS   = "RTGS"
+	PaymentMethodNACH   = "NACH"
+	PaymentMethodCard   = "Debit Card"
+	PaymentMethodATM    = "ATM"
+	PaymentMethodCheque = "Cheque"
+)
+
+// Narration is what ParseNarration pulls out of an Indian bank statement description.
+// Any field may be empty when the narration does not carry it.
+type Narration struct {
+	PaymentMethod string
+	Reference     string // UPI/IMPS RRN, NEFT/RTGS UTR, cheque number
+	Counterparty  string
+	VPA           string
+	Merchant      string
+}
+
+// narrationKeywords maps the leading tokens banks use to the payment method they denote.
+var narrationKeywords = map[string]string{
+	"UPI": PaymentMethodUPI, "UPIOUT": PaymentMethodUPI, "UPIIN": PaymentMethodUPI, "UPIAR": PaymentMethodUPI,
+	"IMPS": PaymentMethodIMPS, "MMT": PaymentMethodIMPS,
+	"NEFT": PaymentMethodNEFT,
+	"RTGS": PaymentMethodRTGS,
+	"ACH":  PaymentMethodNACH, "NACH": PaymentMethodNACH, "ECS": PaymentMethodNACH,
+	"POS": PaymentMethodCard, "PCD": PaymentMethodCard, "ECOM": PaymentMethodCard,
+	"ATM": PaymentMethodATM, "ATW": PaymentMethodATM, "NWD": PaymentMethodATM, "EAW": PaymentMethodATM,
+	"CHQ": PaymentMethodCheque, "CHEQUE": PaymentMethodCheque, "CLG": PaymentMethodCheque, "CLEARING": PaymentMethodCheque,
+}
+
+// narrationNoise are fields that carry no counterparty information.
+var narrationNoise = map[string]struct{}{
+	"DR": {}, "CR": {}, "D": {}, "C": {}, "P2A": {}, "P2M": {}, "P2P": {}, "BY TRANSFER": {}, "TO TRANSFER": {},
+	"TRANSFER": {}, "PAYMENT": {}, "PAY": {}, "NA": {}, "UPI": {}, "SENT": {}, "RECEIVED": {}, "COLLECT": {},
+	"WDL": {}, "CASH WDL": {}, "DEP": {}, "CHQ DEP": {}, "INWARD": {}, "OUTWARD": {}, "REV": {}, "REVERSAL": {},
+	"NO": {}, "CHEQUE NO": {}, "CHQ NO": {}, "TO CLG": {}, "BY CLG": {}, "NEFT CR": {}, "NEFT DR": {},
+	"RTGS CR": {}, "RTGS DR": {}, "IMPS CR": {}, "IMPS DR": {}, "ACH D": {}, "ACH C": {},
+}
+
+// bankCodes are IFSC prefixes that show up as bare fields in UPI and IMPS narrations.
+var bankCodes = map[string]struct{}{
+	"HDFC": {}, "ICIC": {}, "SBIN": {}, "UTIB": {}, "KKBK": {}, "YESB": {}, "PUNB": {}, "BARB": {},
+	"CNRB": {}, "UBIN": {}, "IDIB": {}, "IOBA": {}, "BKID": {}, "INDB": {}, "FDRL": {}, "IDFB": {},
+	"AIRP": {}, "PYTM": {}, "AUBL": {}, "RATN": {}, "SCBL": {}, "CITI": {}, "HSBC": {}, "MAHB": {},
+}
+
+var (
+	vpaPattern      = regexp.MustCompile(`[A-Za-z0-9._]{2,}@[A-Za-z]{2,}`)
+	ifscPattern     = regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`)
+	utrPattern      = regexp.MustCompile(`^[A-Z]{4}[A-Z0-9]\d{6,}$|^[A-Z]\d{3,}$`)
+	maskedCardToken = regexp.MustCompile(`^\d*X+\d*$`)
+)
+
+// ParseNarration recognises the UPI, IMPS, NEFT, RTGS, ACH/NACH, POS, ATM and cheque
+// narrations Indian banks print, e.g. "UPI/412345678901/SWIGGY/swiggy@icici/Payment" or
+// "NEFT-N123-ACME PVT LTD". Unrecognised narrations come back empty.
+func ParseNarration(desc string) Narration {
+	desc = strings.TrimSpace(desc)
+	if desc == "" {
+		return Narration{}
+	}
+	upper := strings.ToUpper(desc)
+	var n Narration
+	for _, tok := range strings.FieldsFunc(upper, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if m, ok := narrationKeywords[tok]; ok {
+			n.PaymentMethod = m
+			break
+		}
+	}
+	if n.PaymentMethod == "" {
+		return n
+	}
+
+	if vpa := vpaPattern.FindString(desc); vpa != "" {
+		n.VPA = strings.ToLower(vpa)
+	}
+
+	fields := strings.FieldsFunc(upper, func(r rune) bool {
+		return r == '/' || r == '-' || r == '*' || r == ':' || r == '|'
+	})
+	for i := range fields {
+		fields[i] = strings.Join(strings.Fields(fields[i]), " ")
+	}
+
+	switch n.PaymentMethod {
+	case PaymentMethodCard, PaymentMethodATM:
+		n.Merchant = cardMerchant(fields)
+		if n.PaymentMethod == PaymentMethodATM {
+			n.Merchant = ""
+		}
+		n.Counterparty = n.Merchant
+		return n
+	}
+
+	for _, f := range fields {
+		if n.Reference == "" {
+			if ref := narrationReference(n.PaymentMethod, f); ref != "" {
+				n.Reference = ref
+				continue
+			}
+		}
+		if n.Counterparty == "" && isNarrationName(f) {
+			n.Counterparty = f
+		}
+	}
+	n.Merchant = n.Counterparty
+	if n.Merchant == "" && n.VPA != "" {
+		n.Merchant = strings.ToUpper(strings.SplitN(n.VPA, "@", 2)[0])
+	}
+	return n
+}
+
+// narrationReference returns f when it has the shape of the reference the method uses.
+func narrationReference(method, f string) string {
+	if strings.Contains(f, " ") {
+		return ""
+	}
+	digits := isAllDigits(f)
+	switch method {
+	case PaymentMethodUPI, PaymentMethodIMPS:
+		if digits && len(f) == 12 {
+			return f
+		}
+	case PaymentMethodNEFT, PaymentMethodRTGS:
+		if ifscPattern.MatchString(f) {
+			return ""
+		}
+		if utrPattern.MatchString(f) || (digits && len(f) >= 6) {
+			return f
+		}
+	case PaymentMethodCheque:
+		if digits && len(f) >= 6 && len(f) <= 10 {
+			return f
+		}
+	case PaymentMethodNACH:
+		if digits && len(f) >= 6 {
+			return f
+		}
+	}
+	return ""
+}
+
+// isNarrationName reports whether a field looks like a person or business name.
+func isNarrationName(f string) bool {
+	if len(f) < 2 || strings.Contains(f, "@") {
+		return false
+	}
+	if _, ok := narrationKeywords[f]; ok {
+		return false
+	}
+	if _, ok := narrationNoise[f]; ok {
+		return false
+	}
+	if _, ok := bankCodes[f]; ok {
+		return false
+	}
+	if ifscPattern.MatchString(f) || maskedCardToken.MatchString(f) {
+		return false
+	}
+	letters, digits := 0, 0
+	for _, r := range f {
+		switch {
+		case unicode.IsLetter(r):
+			letters++
+		case unicode.IsDigit(r):
+			digits++
+		}
+	}
+	return letters >= 2 && digits*2 < letters
+}
+
+// cardMerchant drops the keyword, masked card number and terminal codes from a POS or
+// ATM narration and keeps the words that name the merchant.
+func cardMerchant(fields []string) string {
+	var words []string
+	for _, f := range fields {
+		for _, w := range strings.Fields(f) {
+			if _, ok := narrationKeywords[w]; ok {
+				continue
+			}
+			if maskedCardToken.MatchString(w) || strings.IndexFunc(w, unicode.IsDigit) >= 0 {
+				continue
+			}
+			words = append(words, w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func isAllDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// merchantKey normalises a merchant name for lookup against the merchants table.
+func merchantKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// enrichReferenceNumbers fills a parsed row's ReferenceNumber from its narration when the
+// statement had no reference column for it.
+func enrichReferenceNumbers(rows []ParsedTxns) {
+	for i := range rows {
+		if rows[i].ReferenceNumber != nil && *rows[i].ReferenceNumber != "" {
+			continue
+		}
+		if rows[i].Description == nil {
+			continue
+		}
+		if ref := ParseNarration(*rows[i].Description).Reference; ref != "" {
+			rows[i].ReferenceNumber = &ref
+		}
+	}
+}
+
+// statementNarration returns a statement row's description and its parsed narration. A
+// reference the statement printed in its own column wins over one read from the narration.
+func statementNarration(st StatementTransaction) (string, Narration) {
+	desc := ""
+	if st.Description != nil {
+		desc = *st.Description
+	}
+	narr := ParseNarration(desc)
+	if st.ReferenceNumber != nil && *st.ReferenceNumber != "" {
+		narr.Reference = *st.ReferenceNumber
+	}
+	return desc, narr
+}
+
+// narrationMentions reports whether the app description names the narration's VPA or
+// every word of its counterparty.
+func narrationMentions(narr Narration, appDesc string) bool {
+	if appDesc == "" {
+		return false
+	}
+	lower := strings.ToLower(appDesc)
+	if narr.VPA != "" && strings.Contains(lower, narr.VPA) {
+		return true
+	}
+	words := strings.Fields(strings.ToLower(narr.Counterparty))
+	if len(words) == 0 {
+		return false
+	}
+	for _, w := range words {
+		if !strings.Contains(lower, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// referencesMatch compares references case-insensitively, and also accepts an app
+// reference long enough to be a UTR/RRN appearing inside the statement narration.
+func referencesMatch(stmtRef, stmtDesc, appRef string) bool {
+	appRef = strings.TrimSpace(appRef)
+	if appRef == "" {
+		return false
+	}
+	if stmtRef != "" && strings.EqualFold(stmtRef, appRef) {
+		return true
+	}
+	return len(appRef) >= 6 && strings.Contains(strings.ToUpper(stmtDesc), strings.ToUpper(appRef))
+}
+
+// narrationMerchantKeys collects the distinct merchant keys named by the rows' narrations.
+func narrationMerchantKeys(groups ...[]StatementTransaction) []string {
+	seen := make(map[string]struct{})
+	var keys []string
+	for _, rows := range groups {
+		for _, st := range rows {
+			if st.Description == nil {
+				continue
+			}
+			key := merchantKey(ParseNarration(*st.Description).Merchant)
+			if key == "" {
+				continue
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
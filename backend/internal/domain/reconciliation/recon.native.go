@@ -0,0 +1,138 @@
+package reconciliation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/utils"
+	"github.com/google/uuid"
+)
+
+// Statement formats that carry a bank-assigned ID on every entry.
+const (
+	nativeFormatOFX   = "OFX"
+	nativeFormatCAMT  = "CAMT053"
+	nativeFormatMT940 = "MT940"
+)
+
+// nativeEntry is one booked entry read from an OFX, CAMT.053 or MT940 file.
+// Amount is signed: negative for money leaving the account.
+type nativeEntry struct {
+	ID          string
+	Date        time.Time
+	Amount      float64
+	Description string
+	// Invalid holds why the entry could not be read, with the offending value.
+	Invalid      string
+	InvalidValue string
+}
+
+// nativeStatement groups the entries of one account statement inside a file with the
+// ledger balance the bank reported at its end.
+type nativeStatement struct {
+	Entries []nativeEntry
+	Closing *float64
+}
+
+// nativeStatementsToRows turns parsed statements into ParsedTxns sorted by date. The
+// native ID becomes the reference and the hash key, and each row's Balance is walked
+// back from the statement's closing ledger balance.
+func nativeStatementsToRows(format string, stmts []nativeStatement, accountID, uploadID uuid.UUID) ([]ParsedTxns, []ParseError) {
+	var rows []ParsedTxns
+	var parseErrors []ParseError
+	rowNum := 0
+	for _, st := range stmts {
+		var valid []nativeEntry
+		var nums []int
+		for _, e := range st.Entries {
+			rowNum++
+			switch {
+			case e.Invalid != "":
+				parseErrors = append(parseErrors, ParseError{Row: rowNum, Error: e.Invalid, Data: map[string]interface{}{"value": e.InvalidValue}})
+			case e.Date.IsZero():
+				parseErrors = append(parseErrors, ParseError{Row: rowNum, Error: "invalid transaction date", Data: map[string]interface{}{"id": e.ID}})
+			case e.Amount == 0:
+				parseErrors = append(parseErrors, ParseError{Row: rowNum, Error: "zero amount", Data: map[string]interface{}{"id": e.ID}})
+			default:
+				valid = append(valid, e)
+				nums = append(nums, rowNum)
+			}
+		}
+
+		order := make([]int, len(valid))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool { return valid[order[a]].Date.Before(valid[order[b]].Date) })
+
+		balances := make([]*float64, len(valid))
+		if st.Closing != nil {
+			running := *st.Closing
+			for i := len(order) - 1; i >= 0; i-- {
+				b := math.Round(running*100) / 100
+				balances[order[i]] = &b
+				running -= valid[order[i]].Amount
+			}
+		}
+
+		for _, i := range order {
+			e := valid[i]
+			txnType, drCr := CREDIT, "CR"
+			if e.Amount < 0 {
+				txnType, drCr = DEBIT, "DR"
+			}
+			amount := math.Abs(e.Amount)
+			hash := nativeRowHash(format, e.ID, e.Date, amount, drCr, e.Description)
+			rows = append(rows, ParsedTxns{
+				UploadId:        uploadID,
+				AccountId:       accountID,
+				TxnDate:         e.Date,
+				Description:     utils.PtrString(e.Description),
+				Amount:          amount,
+				Type:            txnType,
+				ReferenceNumber: utils.PtrString(e.ID),
+				Balance:         balances[i],
+				RawRowHash:      &hash,
+				RowNumber:       uint32(nums[i]),
+			})
+		}
+	}
+	return rows, parseErrors
+}
+
+// nativeRowHash keys an entry on its bank-assigned ID, which survives re-downloads with
+// reworded narrations. Entries without one fall back to the spreadsheet row hash.
+func nativeRowHash(format, id string, date time.Time, amount float64, drCr, desc string) string {
+	if id == "" {
+		return RowHash(date, amount, drCr, desc)
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%.2f|%s", format, id, date.Format("2006-01-02"), amount, drCr)))
+	return hex.EncodeToString(sum[:])
+}
+
+// joinNonEmpty joins the trimmed, non-empty parts with single spaces, skipping repeats.
+func joinNonEmpty(parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		p = strings.Join(strings.Fields(p), " ")
+		if p == "" {
+			continue
+		}
+		dup := false
+		for _, o := range out {
+			if strings.EqualFold(o, p) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, " ")
+}
@@ -0,0 +1,179 @@
+package reconciliation
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const ofxFixture = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>INR
+<BANKTRANLIST>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20240402120000[+5.5:IST]<TRNAMT>-250.00<FITID>FIT002<NAME>SWIGGY<MEMO>UPI order &amp; tip</STMTTRN>
+<STMTTRN><TRNTYPE>CREDIT<DTPOSTED>20240401<TRNAMT>50000.00<FITID>FIT001<NAME>SALARY</STMTTRN>
+<STMTTRN><TRNTYPE>DEBIT<DTPOSTED>20240403<TRNAMT>abc<FITID>FIT003</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>59750.00<DTASOF>20240403</LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+const camtFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">
+<BkToCstmrStmt><Stmt>
+<Bal><Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">700.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+<Bal><Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp><Amt Ccy="EUR">1000.00</Amt><CdtDbtInd>CRDT</CdtDbtInd></Bal>
+<Ntry>
+<Amt Ccy="EUR">200.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts><Cd>BOOK</Cd></Sts>
+<BookgDt><Dt>2024-04-05</Dt></BookgDt><AcctSvcrRef>SVC1</AcctSvcrRef>
+<NtryDtls><TxDtls><RltdPties><Cdtr><Nm>ACME</Nm></Cdtr></RltdPties><RmtInf><Ustrd>Invoice 42</Ustrd></RmtInf></TxDtls></NtryDtls>
+</Ntry>
+<Ntry>
+<Amt Ccy="EUR">500.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Sts><Cd>BOOK</Cd></Sts>
+<BookgDt><DtTm>2024-04-04T10:00:00</DtTm></BookgDt>
+<NtryDtls><TxDtls><Refs><EndToEndId>E2E-7</EndToEndId></Refs><RltdPties><Dbtr><Nm>JOHN DOE</Nm></Dbtr></RltdPties></TxDtls></NtryDtls>
+</Ntry>
+<Ntry>
+<Amt Ccy="EUR">90.00</Amt><CdtDbtInd>DBIT</CdtDbtInd><Sts><Cd>PDNG</Cd></Sts>
+<BookgDt><Dt>2024-04-06</Dt></BookgDt><AcctSvcrRef>SVC3</AcctSvcrRef>
+</Ntry>
+</Stmt></BkToCstmrStmt>
+</Document>
+`
+
+const mt940Fixture = `{1:F01BANKDEFFXXXX0000000000}{2:O940}{4:
+:20:STMT1
+:25:12345678/123
+:28C:1/1
+:60F:C240401EUR1000,00
+:61:2404010401D250,00NTRFNONREF//BREF1
+:86:?20Rent April
+?32LANDLORD
+:61:240402C1000,00NTRFCUST2
+:86:Salary
+:61:garbage
+:62F:C240402EUR1750,00
+-}
+`
+
+func TestParseNativeStatements(t *testing.T) {
+	type wantRow struct {
+		ref     string
+		date    string
+		amount  float64
+		txnType TxnType
+		desc    string
+		balance float64
+	}
+	tests := []struct {
+		name       string
+		parse      func(io.Reader, uuid.UUID, uuid.UUID) ([]ParsedTxns, []ParseError, error)
+		file       string
+		want       []wantRow
+		wantErrors []int
+	}{
+		{
+			name:  "OFX",
+			parse: parseOfxRows,
+			file:  ofxFixture,
+			want: []wantRow{
+				{"FIT001", "2024-04-01", 50000, CREDIT, "SALARY", 60000},
+				{"FIT002", "2024-04-02", 250, DEBIT, "SWIGGY UPI order & tip", 59750},
+			},
+			wantErrors: []int{3},
+		},
+		{
+			name:  "CAMT.053",
+			parse: parseCamtRows,
+			file:  camtFixture,
+			want: []wantRow{
+				{"E2E-7", "2024-04-04", 500, CREDIT, "JOHN DOE", 1200},
+				{"SVC1", "2024-04-05", 200, DEBIT, "ACME Invoice 42", 1000},
+			},
+		},
+		{
+			name:  "MT940",
+			parse: parseMt940Rows,
+			file:  mt940Fixture,
+			want: []wantRow{
+				{"BREF1", "2024-04-01", 250, DEBIT, "Rent April LANDLORD", 750},
+				{"CUST2", "2024-04-02", 1000, CREDIT, "Salary", 1750},
+			},
+			wantErrors: []int{3},
+		},
+	}
+	accountID := uuid.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, parseErrors, err := tt.parse(strings.NewReader(tt.file), accountID, uuid.Nil)
+			if err != nil {
+				t.Fatalf("parse error = %v", err)
+			}
+			if len(rows) != len(tt.want) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(tt.want))
+			}
+			for i, w := range tt.want {
+				r := rows[i]
+				if r.AccountId != accountID || r.ReferenceNumber == nil || *r.ReferenceNumber != w.ref {
+					t.Errorf("row %d reference = %v on account %s, want %s", i, r.ReferenceNumber, r.AccountId, w.ref)
+				}
+				if r.TxnDate.Format(time.DateOnly) != w.date || r.Amount != w.amount || r.Type != w.txnType {
+					t.Errorf("row %d = %s %v %s, want %s %v %s", i, r.TxnDate.Format(time.DateOnly), r.Amount, r.Type, w.date, w.amount, w.txnType)
+				}
+				if r.Description == nil || *r.Description != w.desc {
+					t.Errorf("row %d description = %v, want %q", i, r.Description, w.desc)
+				}
+				if r.Balance == nil || *r.Balance != w.balance {
+					t.Errorf("row %d balance = %v, want %v", i, r.Balance, w.balance)
+				}
+			}
+			var gotErrors []int
+			for _, pe := range parseErrors {
+				gotErrors = append(gotErrors, pe.Row)
+			}
+			if !slices.Equal(gotErrors, tt.wantErrors) {
+				t.Errorf("parse errors on rows %v, want %v", gotErrors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestParseNativeStatementsRejectsOtherFiles(t *testing.T) {
+	const csv = "Date,Description,Amount\n01/04/2024,Rent,100\n"
+	parsers := map[string]func(io.Reader, uuid.UUID, uuid.UUID) ([]ParsedTxns, []ParseError, error){
+		"OFX":      parseOfxRows,
+		"CAMT.053": parseCamtRows,
+		"MT940":    parseMt940Rows,
+	}
+	for name, parse := range parsers {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := parse(strings.NewReader(csv), uuid.New(), uuid.Nil); err == nil {
+				t.Errorf("%s parser accepted a CSV file", name)
+			}
+		})
+	}
+}
+
+func TestNativeRowHashKeysOnBankID(t *testing.T) {
+	date := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	a := nativeRowHash(nativeFormatOFX, "FIT001", date, 250, "DR", "SWIGGY")
+	b := nativeRowHash(nativeFormatOFX, "FIT001", date, 250, "DR", "SWIGGY BANGALORE")
+	if a != b {
+		t.Error("reworded narration changed the hash of an entry with a bank ID")
+	}
+	if a == nativeRowHash(nativeFormatOFX, "FIT002", date, 250, "DR", "SWIGGY") {
+		t.Error("two bank IDs share a hash")
+	}
+	if nativeRowHash(nativeFormatOFX, "", date, 250, "DR", "SWIGGY") != RowHash(date, 250, "DR", "SWIGGY") {
+		t.Error("entry without a bank ID does not fall back to the row hash")
+	}
+}
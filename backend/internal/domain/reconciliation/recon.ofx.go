@@ -0,0 +1,111 @@
+package reconciliation
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ofxTag matches one tag and the text up to the next tag. OFX 1.x is SGML where leaf
+// elements are never closed, so the same scan reads both 1.x and 2.x (XML) files.
+var ofxTag = regexp.MustCompile(`<(/?)([A-Za-z0-9.]+)>([^<]*)`)
+
+// parseOfxRows reads the bank and credit card statements in an OFX or QFX download.
+func parseOfxRows(r io.Reader, accountID uuid.UUID, uploadID uuid.UUID) ([]ParsedTxns, []ParseError, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	body := string(data)
+	start := strings.Index(strings.ToUpper(body), "<OFX>")
+	if start < 0 {
+		return nil, nil, fmt.Errorf("not an OFX file: missing <OFX> element")
+	}
+
+	var (
+		stmts    []nativeStatement
+		cur      *nativeStatement
+		txn      map[string]string
+		inLedger bool
+	)
+	for _, m := range ofxTag.FindAllStringSubmatch(body[start:], -1) {
+		closing, name, value := m[1] == "/", strings.ToUpper(m[2]), html.UnescapeString(strings.TrimSpace(m[3]))
+		switch {
+		case name == "STMTRS" || name == "CCSTMTRS":
+			if closing {
+				cur = nil
+				continue
+			}
+			stmts = append(stmts, nativeStatement{})
+			cur = &stmts[len(stmts)-1]
+		case name == "STMTTRN":
+			if closing {
+				if txn != nil && cur != nil {
+					cur.Entries = append(cur.Entries, ofxEntry(txn))
+				}
+				txn = nil
+				continue
+			}
+			txn = map[string]string{}
+		case name == "LEDGERBAL":
+			inLedger = !closing
+		case closing:
+		case txn != nil:
+			txn[name] = value
+		case inLedger && name == "BALAMT" && cur != nil:
+			if b, err := parseOfxAmount(value); err == nil {
+				cur.Closing = &b
+			}
+		}
+	}
+	if len(stmts) == 0 {
+		return nil, nil, fmt.Errorf("no bank or credit card statement found in OFX file")
+	}
+
+	rows, parseErrors := nativeStatementsToRows(nativeFormatOFX, stmts, accountID, uploadID)
+	return rows, parseErrors, nil
+}
+
+func ofxEntry(txn map[string]string) nativeEntry {
+	e := nativeEntry{
+		ID:          txn["FITID"],
+		Description: joinNonEmpty(txn["NAME"], txn["PAYEE"], txn["MEMO"]),
+	}
+	amount, err := parseOfxAmount(txn["TRNAMT"])
+	if err != nil {
+		e.Invalid, e.InvalidValue = "invalid amount", txn["TRNAMT"]
+		return e
+	}
+	e.Amount = amount
+	posted := txn["DTPOSTED"]
+	if posted == "" {
+		posted = txn["DTUSER"]
+	}
+	date, err := parseOfxDate(posted)
+	if err != nil {
+		e.Invalid, e.InvalidValue = "invalid transaction date", posted
+		return e
+	}
+	e.Date = date
+	return e
+}
+
+// parseOfxDate reads the date part of YYYYMMDD[HHMMSS[.XXX]][[gmt offset:tz]].
+func parseOfxDate(v string) (time.Time, error) {
+	if len(v) < 8 {
+		return time.Time{}, fmt.Errorf("invalid OFX date %q", v)
+	}
+	return time.Parse("20060102", v[:8])
+}
+
+// parseOfxAmount accepts a signed decimal with either '.' or ',' as the separator.
+func parseOfxAmount(v string) (float64, error) {
+	v = strings.ReplaceAll(strings.TrimSpace(v), ",", ".")
+	return strconv.ParseFloat(strings.TrimPrefix(v, "+"), 64)
+}
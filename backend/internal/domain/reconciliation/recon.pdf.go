@@ -0,0 +1,448 @@
+package reconciliation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/user"
+	aiservices "github.com/KaranMali2001/finance-tracker-v2-backend/internal/services/aiServices"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/tasks"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/utils"
+	"github.com/google/uuid"
+	"github.com/ledongthuc/pdf"
+	"github.com/rs/zerolog"
+)
+
+// pdfChunk is a run of glyphs on one line with no wide gap inside it, usually a table cell.
+type pdfChunk struct {
+	x0, x1 float64
+	text   string
+}
+
+type pdfGlyph struct {
+	x, y, w, size float64
+	s             string
+}
+
+// parsePdfRows extracts the text lines of a PDF statement and lays them on the grid of
+// the transaction table header so the profile-driven parser can read them. A layout it
+// cannot find a table in is returned as a file for the reconciliation job to hand to the
+// LLM statement reader, when one is configured.
+func (s *ReconService) parsePdfRows(r io.Reader, profile StatementProfile, accountID uuid.UUID, password string, log *zerolog.Logger) ([]ParsedTxns, []ParseError, *StatementFile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	lines, err := extractPdfLines(data, password)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// The cycle summary of a card statement sits outside the transaction table.
+	if profile.cycle != nil {
+		for _, line := range lines {
+			texts := make([]string, len(line))
+			for i, c := range line {
+				texts[i] = c.text
+			}
+			profile.cycle.scan(texts, profile.DateFormats)
+		}
+	}
+
+	var rows []ParsedTxns
+	var parseErrors []ParseError
+	pdfProfile := pdfParseProfile(profile)
+	if grid := pdfTableGrid(lines, pdfProfile); len(grid) > 1 {
+		i := 0
+		rows, parseErrors, err = parseStatementRows(func() ([]string, error) {
+			if i >= len(grid) {
+				return nil, io.EOF
+			}
+			i++
+			return grid[i-1], nil
+		}, pdfProfile, accountID, uuid.Nil)
+		if err == nil && len(rows) > 0 && len(parseErrors) <= len(rows) {
+			log.Info().Int("lines", len(lines)).Int("rows", len(rows)).Msg("Parsed PDF statement with the profile layout")
+			return rows, parseErrors, nil, nil
+		}
+	}
+
+	if s.statementLLM == nil {
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if len(rows) == 0 {
+			return nil, nil, nil, fmt.Errorf("could not find a transaction table in the PDF statement")
+		}
+		return rows, parseErrors, nil, nil
+	}
+
+	log.Info().Int("lines", len(lines)).Int("profile_rows", len(rows)).Msg("PDF layout not recognised, leaving it to LLM statement extraction")
+	// The model cannot open an encrypted file, so it gets the text decrypted here instead.
+	if password != "" {
+		return nil, nil, &StatementFile{MimeType: "text/plain", Content: pdfLinesText(lines)}, nil
+	}
+	return nil, nil, &StatementFile{MimeType: "application/pdf", Content: data}, nil
+}
+
+// reserveStatementParse takes the LLM parse credit an upload's statement file will cost
+// to extract, failing with a payment-required error when the user has none left.
+func (s *ReconService) reserveStatementParse(ctx context.Context, userID string, uploadID uuid.UUID) error {
+	if s.userService == nil {
+		return nil
+	}
+	_, err := s.userService.ReserveLlmCredit(ctx, userID, user.LlmFeatureStatementParse, &uploadID)
+	if errors.Is(err, user.ErrLlmCreditsExhausted) {
+		return user.NewLlmCreditsExhaustedError()
+	}
+	return err
+}
+
+// pdfLinesText renders extracted lines one per line with their chunks tab-separated.
+func pdfLinesText(lines [][]pdfChunk) []byte {
+	var b bytes.Buffer
+	for _, line := range lines {
+		for i, c := range line {
+			if i > 0 {
+				b.WriteByte('\t')
+			}
+			b.WriteString(c.text)
+		}
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// extractStatementFile has the LLM statement reader extract the rows of an upload whose
+// file the parsers left to it, and stores them the way an upload stores parsed rows.
+// The read uses the LLM parse credit reserved at upload, given back if it fails. The
+// file is removed in the same transaction as the rows are stored, so a retried job does
+// not read it twice.
+func (s *ReconService) extractStatementFile(ctx context.Context, payload tasks.BankReconciliationPayload, log *zerolog.Logger) error {
+	file, err := s.repo.GetStatementFile(ctx, payload.UploadID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch statement file: %w", err)
+	}
+	if file == nil {
+		return nil
+	}
+	if s.statementLLM == nil {
+		return fmt.Errorf("no LLM statement reader configured to extract upload %s", payload.UploadID)
+	}
+
+	reservation, err := s.userService.ReserveLlmCredit(ctx, payload.UserID, user.LlmFeatureStatementParse, &payload.UploadID)
+	if err != nil {
+		return fmt.Errorf("failed to reserve LLM parse credit: %w", err)
+	}
+	tableRows, err := s.statementLLM.ParseStatementTable(ctx, file.Content, file.MimeType, log)
+	if err != nil {
+		if rerr := s.userService.RefundLlmCredit(context.WithoutCancel(ctx), payload.UserID, reservation.Id); rerr != nil {
+			log.Error().Err(rerr).Msg("[recon] failed to refund LLM parse credit")
+		}
+		return fmt.Errorf("LLM statement extraction failed: %w", err)
+	}
+	rows, parseErrors := llmStatementRows(tableRows, payload.AccountID)
+	accountType, err := s.repo.GetAccountType(ctx, payload.AccountID, payload.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch account type: %w", err)
+	}
+	if isCreditCardAccount(accountType) {
+		// The model copies the outstanding as printed; the ledger keeps what is owed negative.
+		for i := range rows {
+			if rows[i].Balance != nil {
+				b := -*rows[i].Balance
+				rows[i].Balance = &b
+			}
+		}
+	}
+	enrichReferenceNumbers(rows)
+
+	var insertedHashes map[string]struct{}
+	overlapDuplicates := 0
+	err = s.tm.WithTx(ctx, func(ctx context.Context) error {
+		insertedHashes, overlapDuplicates, err = s.insertParsedRows(ctx, payload.UploadID, payload.AccountID, rows, log)
+		if err != nil {
+			return err
+		}
+		return s.repo.DeleteStatementFile(ctx, payload.UploadID)
+	}, log)
+	if err != nil {
+		return fmt.Errorf("failed to store extracted statement rows: %w", err)
+	}
+	s.recordParseSummary(ctx, payload.UploadID, payload.AccountID, payload.UserID, rows, parseErrors, insertedHashes, overlapDuplicates, file.TotalDue, log)
+	log.Info().Int("rows", len(rows)).Int("errors", len(parseErrors)).Msg("[recon] extracted statement rows with the LLM")
+	return nil
+}
+
+// extractPdfLines returns each page's text lines top to bottom, split into chunks.
+func extractPdfLines(data []byte, password string) (lines [][]pdfChunk, err error) {
+	var pw func() string
+	if password != "" {
+		tried := false
+		pw = func() string {
+			if tried {
+				return ""
+			}
+			tried = true
+			return password
+		}
+	}
+	reader, err := pdf.NewReaderEncrypted(bytes.NewReader(data), int64(len(data)), pw)
+	if err != nil {
+		if errors.Is(err, pdf.ErrInvalidPassword) {
+			if password == "" {
+				return nil, errStatementPasswordRequired
+			}
+			return nil, errStatementPasswordIncorrect
+		}
+		return nil, fmt.Errorf("not a readable PDF: %w", err)
+	}
+
+	// The content walker panics on malformed streams instead of returning an error.
+	defer func() {
+		if x := recover(); x != nil {
+			lines, err = nil, fmt.Errorf("malformed PDF: %v", x)
+		}
+	}()
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		var glyphs []pdfGlyph
+		for _, t := range page.Content().Text {
+			glyphs = append(glyphs, pdfGlyph{x: t.X, y: t.Y, w: t.W, size: t.FontSize, s: t.S})
+		}
+		lines = append(lines, pdfPageLines(glyphs)...)
+	}
+	return lines, nil
+}
+
+// pdfPageLines clusters glyphs into baselines and each baseline into chunks. A gap wider
+// than about one character starts a new chunk; a narrower one is a word space.
+func pdfPageLines(glyphs []pdfGlyph) [][]pdfChunk {
+	sort.SliceStable(glyphs, func(i, j int) bool {
+		if math.Abs(glyphs[i].y-glyphs[j].y) > 2 {
+			return glyphs[i].y > glyphs[j].y
+		}
+		return glyphs[i].x < glyphs[j].x
+	})
+
+	var lines [][]pdfChunk
+	for start := 0; start < len(glyphs); {
+		end := start + 1
+		for end < len(glyphs) && math.Abs(glyphs[end].y-glyphs[start].y) <= 2 {
+			end++
+		}
+		line := glyphs[start:end]
+		sort.SliceStable(line, func(i, j int) bool { return line[i].x < line[j].x })
+
+		var chunks []pdfChunk
+		var b strings.Builder
+		var cur *pdfChunk
+		for _, g := range line {
+			size := g.size
+			if size <= 0 {
+				size = 8
+			}
+			if cur != nil {
+				gap := g.x - cur.x1
+				if gap > size*1.2 {
+					cur.text = strings.TrimSpace(b.String())
+					chunks = append(chunks, *cur)
+					cur = nil
+					b.Reset()
+				} else if gap > size*0.2 && !strings.HasSuffix(b.String(), " ") {
+					b.WriteByte(' ')
+				}
+			}
+			if cur == nil {
+				cur = &pdfChunk{x0: g.x}
+			}
+			b.WriteString(g.s)
+			cur.x1 = math.Max(cur.x1, g.x+g.w)
+		}
+		if cur != nil {
+			cur.text = strings.TrimSpace(b.String())
+			chunks = append(chunks, *cur)
+		}
+		if len(chunks) > 0 {
+			lines = append(lines, chunks)
+		}
+		start = end
+	}
+	return lines
+}
+
+// pdfTableGrid finds the transaction table header and returns it followed by every
+// table line with its chunks placed under the header cell they overlap most. Lines
+// without a date continue the previous row's description; anything else outside the
+// table (page headers, totals, footers) is dropped. Headers repeated on later pages
+// re-anchor the columns.
+func pdfTableGrid(lines [][]pdfChunk, profile StatementProfile) [][]string {
+	var grid [][]string
+	var header []pdfChunk
+	var cols map[StatementColumn]int
+	// remap sends a column of the current page's header to its position in the first one.
+	var remap []int
+	for _, line := range lines {
+		texts := make([]string, len(line))
+		for i, c := range line {
+			texts[i] = c.text
+		}
+		if found, _, ok := detectHeaderColumns(texts, profile.Headers, profile.Convention); ok {
+			if header == nil {
+				grid = append(grid, texts)
+				cols = found
+			}
+			header = line
+			remap = make([]int, len(line))
+			for i := range remap {
+				remap[i] = -1
+			}
+			for col, i := range found {
+				if first, ok := cols[col]; ok {
+					remap[i] = first
+				}
+			}
+			continue
+		}
+		if header == nil {
+			continue
+		}
+
+		cells := make([]string, len(grid[0]))
+		for _, c := range line {
+			if i := remap[pdfColumnFor(c, header)]; i >= 0 {
+				cells[i] = strings.TrimSpace(cells[i] + " " + c.text)
+			}
+		}
+		if cells[cols[ColumnDate]] != "" {
+			grid = append(grid, cells)
+			continue
+		}
+		descIdx, hasDesc := cols[ColumnDescription]
+		if !hasDesc || len(grid) < 2 || cells[descIdx] == "" {
+			continue
+		}
+		continuation := true
+		for i, v := range cells {
+			if v != "" && i != descIdx {
+				continuation = false
+				break
+			}
+		}
+		if continuation {
+			prev := grid[len(grid)-1]
+			prev[descIdx] = strings.TrimSpace(prev[descIdx] + " " + cells[descIdx])
+		}
+	}
+	return grid
+}
+
+// pdfColumnFor picks the header cell a chunk overlaps most, or the nearest one when it
+// overlaps none; amounts are right-aligned under headers of a different width.
+func pdfColumnFor(c pdfChunk, header []pdfChunk) int {
+	best, bestOverlap := -1, 0.0
+	for i, h := range header {
+		if overlap := math.Min(c.x1, h.x1) - math.Max(c.x0, h.x0); overlap > bestOverlap {
+			best, bestOverlap = i, overlap
+		}
+	}
+	if best >= 0 {
+		return best
+	}
+	mid := (c.x0 + c.x1) / 2
+	bestDist := math.MaxFloat64
+	for i, h := range header {
+		if d := math.Abs(mid - (h.x0+h.x1)/2); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// pdfParseProfile adapts a profile to the grid pdfTableGrid builds, which always starts
+// with the detected header row: positional column maps and header skips do not apply.
+func pdfParseProfile(profile StatementProfile) StatementProfile {
+	p := autoDetectStatementProfile
+	if len(profile.Headers) > 0 {
+		p.Headers = mergeHeaderAliases(profile.Headers, defaultHeaderAliases)
+	}
+	p.Convention = profile.Convention
+	p.DateFormats = profile.DateFormats
+	p.DebitMarkers = profile.DebitMarkers
+	p.CreditMarkers = profile.CreditMarkers
+	p.cycle = profile.cycle
+	return p
+}
+
+func mergeHeaderAliases(primary, fallback map[StatementColumn][]string) map[StatementColumn][]string {
+	out := make(map[StatementColumn][]string, len(fallback))
+	for col, a := range fallback {
+		out[col] = append(append([]string{}, primary[col]...), a...)
+	}
+	for col, a := range primary {
+		if _, ok := out[col]; !ok {
+			out[col] = a
+		}
+	}
+	return out
+}
+
+// llmStatementRows validates the rows the LLM read off a statement the same way the
+// spreadsheet parser does and reports the ones it cannot use.
+func llmStatementRows(tableRows []aiservices.StatementTableRow, accountID uuid.UUID) ([]ParsedTxns, []ParseError) {
+	var rows []ParsedTxns
+	var parseErrors []ParseError
+	for i, tr := range tableRows {
+		rowNum := i + 1
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(tr.Date))
+		if err != nil {
+			parseErrors = append(parseErrors, ParseError{Row: rowNum, Error: "invalid transaction date", Data: map[string]interface{}{"value": tr.Date}})
+			continue
+		}
+		amount := math.Abs(tr.Amount)
+		if amount == 0 {
+			parseErrors = append(parseErrors, ParseError{Row: rowNum, Error: "invalid amount", Data: map[string]interface{}{"description": tr.Description}})
+			continue
+		}
+		var txnType TxnType
+		var drCr string
+		switch strings.ToUpper(strings.TrimSpace(tr.Type)) {
+		case "DEBIT", "DR", "D":
+			txnType, drCr = DEBIT, "DR"
+		case "CREDIT", "CR", "C":
+			txnType, drCr = CREDIT, "CR"
+		default:
+			parseErrors = append(parseErrors, ParseError{Row: rowNum, Error: "invalid Dr/Cr", Data: map[string]interface{}{"value": tr.Type}})
+			continue
+		}
+		desc := strings.Join(strings.Fields(tr.Description), " ")
+		hash := RowHash(date, amount, drCr, desc)
+		var ref *string
+		if tr.Reference != nil {
+			ref = utils.PtrString(strings.TrimSpace(*tr.Reference))
+		}
+		rows = append(rows, ParsedTxns{
+			AccountId:       accountID,
+			TxnDate:         date,
+			Description:     utils.PtrString(desc),
+			Amount:          amount,
+			Type:            txnType,
+			ReferenceNumber: ref,
+			Balance:         tr.Balance,
+			RawRowHash:      &hash,
+			RowNumber:       uint32(rowNum),
+		})
+	}
+	return rows, parseErrors
+}
@@ -0,0 +1,693 @@
+package reconciliation
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/utils"
+	"github.com/google/uuid"
+)
+
+// StatementColumn names a logical column in a bank statement export.
+type StatementColumn string
+
+const (
+	ColumnDate        StatementColumn = "date"
+	ColumnDescription StatementColumn = "description"
+	ColumnReference   StatementColumn = "reference"
+	ColumnAmount      StatementColumn = "amount"
+	ColumnDrCr        StatementColumn = "dr_cr"
+	ColumnWithdrawal  StatementColumn = "withdrawal"
+	ColumnDeposit     StatementColumn = "deposit"
+	ColumnBalance     StatementColumn = "balance"
+)
+
+// DrCrConvention describes how a statement tells debits apart from credits.
+type DrCrConvention string
+
+const (
+	// DrCrIndicatorColumn is a single amount column plus a Dr/Cr marker column.
+	DrCrIndicatorColumn DrCrConvention = "INDICATOR_COLUMN"
+	// DrCrSplitColumns puts the amount in either a withdrawal or a deposit column.
+	DrCrSplitColumns DrCrConvention = "SPLIT_COLUMNS"
+	// DrCrSignedAmount is a single amount column where negative values are debits.
+	DrCrSignedAmount DrCrConvention = "SIGNED_AMOUNT"
+)
+
+// detectableConventions is the order auto-detection tries conventions in; the
+// most specific header set wins.
+var detectableConventions = []DrCrConvention{DrCrSplitColumns, DrCrIndicatorColumn, DrCrSignedAmount}
+
+const (
+	autoDetectProfileCode = "AUTO"
+	// maxHeaderScanRows bounds how far down the sheet we look for the header row.
+	maxHeaderScanRows = 30
+)
+
+// StatementProfile describes the layout of a bank's statement export.
+// Columns holds zero-based positions; when empty, the header row is located by matching Headers.
+// An empty Convention means it is inferred from whichever header set matches.
+// FooterMarkers are what the first cell of a trailing summary starts with; that row and
+// every one after it are dropped, however many there are.
+type StatementProfile struct {
+	Code          string                       `json:"code"`
+	Name          string                       `json:"name"`
+	Columns       map[StatementColumn]int      `json:"columns,omitempty"`
+	Headers       map[StatementColumn][]string `json:"headers,omitempty"`
+	DateFormats   []string                     `json:"date_formats,omitempty"`
+	Convention    DrCrConvention               `json:"dr_cr_convention"`
+	DebitMarkers  []string                     `json:"debit_markers,omitempty"`
+	CreditMarkers []string                     `json:"credit_markers,omitempty"`
+	HeaderRows    int                          `json:"header_rows"`
+	FooterRows    int                          `json:"footer_rows"`
+	FooterMarkers []string                     `json:"footer_markers,omitempty"`
+
+	// cycle is set when the statement belongs to a credit card. Signed amounts and
+	// balances are then read the card way round, and the billing-cycle summary printed
+	// around the table is collected into it.
+	cycle *CardCycle
+}
+
+// statementColumns fixes the order columns are matched in, so header detection is deterministic.
+var statementColumns = []StatementColumn{
+	ColumnDate, ColumnWithdrawal, ColumnDeposit, ColumnAmount, ColumnDrCr,
+	ColumnBalance, ColumnDescription, ColumnReference,
+}
+
+var defaultHeaderAliases = map[StatementColumn][]string{
+	ColumnDate:        {"transaction date", "txn date", "tran date", "trans date", "posting date", "date"},
+	ColumnDescription: {"description", "narration", "particulars", "transaction remarks", "transaction details", "remarks", "details"},
+	ColumnReference:   {"chq ref no", "chq ref number", "ref no cheque no", "cheque number", "cheque no", "chq no", "chqno", "reference number", "reference no", "ref no", "utr"},
+	ColumnAmount:      {"amount", "transaction amount", "amount inr", "amount rs", "txn amount"},
+	ColumnDrCr:        {"dr cr", "cr dr", "debit credit", "txn type", "transaction type", "type"},
+	ColumnWithdrawal:  {"withdrawal amt", "withdrawal amount", "withdrawal amount inr", "withdrawals", "withdrawal", "debit amount", "debit", "debits", "dr"},
+	ColumnDeposit:     {"deposit amt", "deposit amount", "deposit amount inr", "deposits", "deposit", "credit amount", "credit", "credits", "cr"},
+	ColumnBalance:     {"closing balance", "balance", "balance inr", "running balance", "available balance", "closing bal", "bal"},
+}
+
+var (
+	defaultDebitMarkers  = []string{"DR", "D", "DB", "DEBIT"}
+	defaultCreditMarkers = []string{"CR", "C", "CREDIT"}
+)
+
+// legacyStatementProfile is the fixed layout the parser originally supported
+// (Sl. No, Txn Date, Value Date, Description, Chq/Ref No, Amount, Dr/Cr). It is
+// also the last resort when auto-detection cannot find a header row.
+var legacyStatementProfile = StatementProfile{
+	Code: "LEGACY",
+	Name: "Legacy fixed layout",
+	Columns: map[StatementColumn]int{
+		ColumnDate:        1,
+		ColumnDescription: 3,
+		ColumnReference:   4,
+		ColumnAmount:      5,
+		ColumnDrCr:        6,
+		ColumnBalance:     7,
+	},
+	Convention: DrCrIndicatorColumn,
+	HeaderRows: 1,
+}
+
+var autoDetectStatementProfile = StatementProfile{
+	Code:    autoDetectProfileCode,
+	Name:    "Auto-detect from header row",
+	Headers: defaultHeaderAliases,
+}
+
+var hdfcStatementProfile = StatementProfile{
+	Code: "HDFC",
+	Name: "HDFC Bank",
+	Headers: map[StatementColumn][]string{
+		ColumnDate:        {"date"},
+		ColumnDescription: {"narration"},
+		ColumnReference:   {"chq ref no"},
+		ColumnWithdrawal:  {"withdrawal amt"},
+		ColumnDeposit:     {"deposit amt"},
+		ColumnBalance:     {"closing balance"},
+	},
+	DateFormats:   []string{"02/01/06", "02/01/2006"},
+	Convention:    DrCrSplitColumns,
+	FooterMarkers: []string{"****", "statement summary"},
+}
+
+var sbiStatementProfile = StatementProfile{
+	Code: "SBI",
+	Name: "State Bank of India",
+	Headers: map[StatementColumn][]string{
+		ColumnDate:        {"txn date"},
+		ColumnDescription: {"description"},
+		ColumnReference:   {"ref no cheque no"},
+		ColumnWithdrawal:  {"debit"},
+		ColumnDeposit:     {"credit"},
+		ColumnBalance:     {"balance"},
+	},
+	DateFormats:   []string{"2 Jan 2006", "02 Jan 2006", "02-01-2006"},
+	Convention:    DrCrSplitColumns,
+	FooterMarkers: []string{"**", "this is a computer generated statement"},
+}
+
+var iciciStatementProfile = StatementProfile{
+	Code: "ICICI",
+	Name: "ICICI Bank",
+	Headers: map[StatementColumn][]string{
+		ColumnDate:        {"transaction date"},
+		ColumnDescription: {"transaction remarks"},
+		ColumnReference:   {"cheque number"},
+		ColumnWithdrawal:  {"withdrawal amount inr", "withdrawal amount"},
+		ColumnDeposit:     {"deposit amount inr", "deposit amount"},
+		ColumnBalance:     {"balance inr", "balance"},
+	},
+	DateFormats:   []string{"02/01/2006", "02-01-2006"},
+	Convention:    DrCrSplitColumns,
+	FooterMarkers: []string{"total", "legends used"},
+}
+
+var axisStatementProfile = StatementProfile{
+	Code: "AXIS",
+	Name: "Axis Bank",
+	Headers: map[StatementColumn][]string{
+		ColumnDate:        {"tran date"},
+		ColumnDescription: {"particulars"},
+		ColumnReference:   {"chqno"},
+		ColumnWithdrawal:  {"dr"},
+		ColumnDeposit:     {"cr"},
+		ColumnBalance:     {"bal"},
+	},
+	DateFormats:   []string{"02-01-2006"},
+	Convention:    DrCrSplitColumns,
+	FooterMarkers: []string{"transaction total", "closing balance", "legends", "++++"},
+}
+
+// statementProfiles is the built-in registry keyed by upper-cased Bank.Code.
+var statementProfiles = map[string]StatementProfile{}
+
+func init() {
+	registerStatementProfile(legacyStatementProfile, "LEGACY")
+	registerStatementProfile(hdfcStatementProfile, "HDFC", "HDFCBANK")
+	registerStatementProfile(sbiStatementProfile, "SBI", "SBIN")
+	registerStatementProfile(iciciStatementProfile, "ICICI", "ICIC")
+	registerStatementProfile(axisStatementProfile, "AXIS", "UTIB")
+}
+
+func registerStatementProfile(p StatementProfile, codes ...string) {
+	for _, code := range codes {
+		statementProfiles[strings.ToUpper(code)] = p
+	}
+}
+
+// LookupStatementProfile returns the built-in profile for a bank code, falling back to auto-detection.
+func LookupStatementProfile(bankCode string) StatementProfile {
+	if p, ok := statementProfiles[strings.ToUpper(strings.TrimSpace(bankCode))]; ok {
+		return p
+	}
+	return autoDetectStatementProfile
+}
+
+// BuiltInStatementProfiles lists every distinct registered profile, sorted by code.
+func BuiltInStatementProfiles() []StatementProfile {
+	seen := make(map[string]struct{})
+	out := []StatementProfile{autoDetectStatementProfile}
+	for _, p := range statementProfiles {
+		if _, ok := seen[p.Code]; ok {
+			continue
+		}
+		seen[p.Code] = struct{}{}
+		out = append(out, p)
+	}
+	sort.Slice(out[1:], func(i, j int) bool { return out[i+1].Code < out[j+1].Code })
+	return out
+}
+
+// requiredColumns returns the columns a statement must have for a convention.
+func requiredColumns(c DrCrConvention) []StatementColumn {
+	switch c {
+	case DrCrSplitColumns:
+		return []StatementColumn{ColumnDate, ColumnWithdrawal, ColumnDeposit}
+	case DrCrSignedAmount:
+		return []StatementColumn{ColumnDate, ColumnAmount}
+	default:
+		return []StatementColumn{ColumnDate, ColumnAmount, ColumnDrCr}
+	}
+}
+
+// Validate checks that the profile can locate every column its convention needs.
+func (p StatementProfile) Validate() error {
+	switch p.Convention {
+	case DrCrIndicatorColumn, DrCrSplitColumns, DrCrSignedAmount:
+	default:
+		return fmt.Errorf("unsupported dr_cr_convention: %q", p.Convention)
+	}
+	for _, col := range requiredColumns(p.Convention) {
+		if _, ok := p.Columns[col]; ok {
+			continue
+		}
+		if len(p.Headers[col]) > 0 {
+			continue
+		}
+		return fmt.Errorf("missing column mapping for %q", col)
+	}
+	for col, idx := range p.Columns {
+		if idx < 0 {
+			return fmt.Errorf("column %q must not be negative", col)
+		}
+	}
+	owners := make(map[int]StatementColumn, len(p.Columns))
+	for _, col := range statementColumns {
+		idx, ok := p.Columns[col]
+		if !ok {
+			continue
+		}
+		if other, taken := owners[idx]; taken {
+			return fmt.Errorf("columns %q and %q both map to position %d", other, col, idx)
+		}
+		owners[idx] = col
+	}
+	if p.HeaderRows < 0 || p.FooterRows < 0 {
+		return fmt.Errorf("header_rows and footer_rows must not be negative")
+	}
+	return nil
+}
+
+// normalizeHeader lower-cases a header cell and collapses punctuation so
+// "Chq./Ref.No." and "chq ref no" compare equal.
+func normalizeHeader(v string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(v) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// detectHeaderColumns reports the column positions and convention if row looks like
+// a header row for the given aliases. With an empty convention every detectable
+// convention is tried in order. A row where one cell names two columns is ambiguous
+// and is not taken as the header.
+func detectHeaderColumns(row []string, headers map[StatementColumn][]string, convention DrCrConvention) (map[StatementColumn]int, DrCrConvention, bool) {
+	normalized := make([]string, len(row))
+	for i, cell := range row {
+		normalized[i] = normalizeHeader(cell)
+	}
+
+	cols := make(map[StatementColumn]int)
+	used := make(map[int]struct{})
+	for _, col := range statementColumns {
+		for _, alias := range headers[col] {
+			want := normalizeHeader(alias)
+			found := -1
+			for i, cell := range normalized {
+				if cell == want {
+					found = i
+					break
+				}
+			}
+			if found < 0 {
+				continue
+			}
+			if _, taken := used[found]; taken {
+				return nil, "", false
+			}
+			cols[col] = found
+			used[found] = struct{}{}
+			break
+		}
+	}
+
+	conventions := []DrCrConvention{convention}
+	if convention == "" {
+		conventions = detectableConventions
+	}
+	for _, c := range conventions {
+		matched := true
+		for _, col := range requiredColumns(c) {
+			if _, ok := cols[col]; !ok {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return cols, c, true
+		}
+	}
+	return nil, "", false
+}
+
+// parseStatementDate tries the profile's formats before the generic Excel date parser.
+func parseStatementDate(row []string, col int, formats []string) (time.Time, error) {
+	v := strings.TrimSpace(SafeCell(row, col))
+	for _, layout := range formats {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	return ParseExcelDate(row, col)
+}
+
+func matchesMarker(v string, markers []string) bool {
+	for _, m := range markers {
+		if v == strings.ToUpper(m) {
+			return true
+		}
+	}
+	return false
+}
+
+type numberedRow struct {
+	num   int
+	cells []string
+}
+
+// statementRowParser turns raw sheet rows into ParsedTxns according to a profile.
+// Rows are pushed one at a time so CSV and XLSX sources can both be streamed.
+type statementRowParser struct {
+	profile   StatementProfile
+	accountID uuid.UUID
+	uploadID  uuid.UUID
+
+	columns  map[StatementColumn]int
+	minWidth int
+	scanned  []numberedRow
+	footer   []numberedRow
+	// ended is set once a footer marker row has been seen.
+	ended bool
+
+	out    []ParsedTxns
+	errors []ParseError
+}
+
+func newStatementRowParser(profile StatementProfile, accountID, uploadID uuid.UUID) *statementRowParser {
+	p := &statementRowParser{profile: profile, accountID: accountID, uploadID: uploadID}
+	if len(profile.Columns) > 0 {
+		p.setColumns(profile.Columns, profile.Convention)
+	}
+	return p
+}
+
+func (p *statementRowParser) setColumns(cols map[StatementColumn]int, convention DrCrConvention) {
+	p.columns = cols
+	p.profile.Convention = convention
+	p.minWidth = 0
+	for _, col := range requiredColumns(convention) {
+		// Exports often leave the trailing deposit cell empty, so only the
+		// earlier of the two split columns has to be present.
+		if convention == DrCrSplitColumns && col != ColumnDate {
+			col = ColumnWithdrawal
+			if cols[ColumnDeposit] < cols[ColumnWithdrawal] {
+				col = ColumnDeposit
+			}
+		}
+		if idx := cols[col] + 1; idx > p.minWidth {
+			p.minWidth = idx
+		}
+	}
+}
+
+func (p *statementRowParser) push(num int, cells []string) {
+	if p.profile.cycle != nil {
+		p.profile.cycle.scan(cells, p.profile.DateFormats)
+	}
+	if p.columns == nil {
+		if cols, convention, ok := detectHeaderColumns(cells, p.profile.Headers, p.profile.Convention); ok {
+			p.setColumns(cols, convention)
+			p.scanned = nil
+			return
+		}
+		p.scanned = append(p.scanned, numberedRow{num: num, cells: cells})
+		if len(p.scanned) >= maxHeaderScanRows {
+			_ = p.fallback()
+		}
+		return
+	}
+	if num <= p.profile.HeaderRows && len(p.profile.Columns) > 0 {
+		return
+	}
+	p.enqueue(num, cells)
+}
+
+// fallback handles a profile whose header row never showed up: it retries the
+// buffered rows with the generic aliases and, failing that, the legacy layout.
+func (p *statementRowParser) fallback() error {
+	scanned := p.scanned
+	p.scanned = nil
+	cycle := p.profile.cycle
+
+	if p.profile.Code != autoDetectProfileCode {
+		for i, r := range scanned {
+			cols, convention, ok := detectHeaderColumns(r.cells, defaultHeaderAliases, "")
+			if !ok {
+				continue
+			}
+			p.profile = autoDetectStatementProfile
+			p.profile.cycle = cycle
+			p.setColumns(cols, convention)
+			for _, rest := range scanned[i+1:] {
+				p.enqueue(rest.num, rest.cells)
+			}
+			return nil
+		}
+		return fmt.Errorf("could not find the header row for the %s statement layout", p.profile.Name)
+	}
+
+	p.profile = legacyStatementProfile
+	p.profile.cycle = cycle
+	p.setColumns(legacyStatementProfile.Columns, legacyStatementProfile.Convention)
+	for _, r := range scanned {
+		if r.num <= p.profile.HeaderRows {
+			continue
+		}
+		p.enqueue(r.num, r.cells)
+	}
+	return nil
+}
+
+// enqueue delays each row by FooterRows so trailing summary rows are never parsed,
+// and drops everything from the first footer marker row on.
+func (p *statementRowParser) enqueue(num int, cells []string) {
+	if p.ended || isBlankRow(cells) {
+		return
+	}
+	if p.isFooter(cells) {
+		p.ended = true
+		return
+	}
+	p.footer = append(p.footer, numberedRow{num: num, cells: cells})
+	if len(p.footer) <= p.profile.FooterRows {
+		return
+	}
+	r := p.footer[0]
+	p.footer = p.footer[1:]
+
+	txn, perr := p.parseRow(r.cells, r.num)
+	if perr != nil {
+		p.errors = append(p.errors, *perr)
+		return
+	}
+	p.out = append(p.out, txn)
+}
+
+// isFooter reports whether a row starts the trailing summary: its first non-empty cell
+// begins with one of the profile's footer markers. Only rows after the first
+// transaction count, so a summary printed above the table is left alone.
+func (p *statementRowParser) isFooter(cells []string) bool {
+	if len(p.out) == 0 && len(p.errors) == 0 && len(p.footer) == 0 {
+		return false
+	}
+	for _, cell := range cells {
+		cell = strings.ToLower(strings.TrimSpace(cell))
+		if cell == "" {
+			continue
+		}
+		for _, m := range p.profile.FooterMarkers {
+			if strings.HasPrefix(cell, strings.ToLower(m)) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func (p *statementRowParser) finish() ([]ParsedTxns, []ParseError, error) {
+	if p.columns == nil {
+		if err := p.fallback(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return p.out, p.errors, nil
+}
+
+func (p *statementRowParser) cell(row []string, col StatementColumn) string {
+	idx, ok := p.columns[col]
+	if !ok {
+		return ""
+	}
+	return SafeCell(row, idx)
+}
+
+func (p *statementRowParser) parseRow(row []string, rowNum int) (ParsedTxns, *ParseError) {
+	if len(row) < p.minWidth {
+		return ParsedTxns{}, &ParseError{Row: rowNum, Error: "insufficient columns", Data: map[string]interface{}{"cells": len(row)}}
+	}
+
+	txnDate, err := parseStatementDate(row, p.columns[ColumnDate], p.profile.DateFormats)
+	if err != nil {
+		return ParsedTxns{}, &ParseError{Row: rowNum, Error: "invalid transaction date", Data: map[string]interface{}{"value": p.cell(row, ColumnDate)}}
+	}
+
+	var amount float64
+	var drCr string
+	var perr *ParseError
+	switch p.profile.Convention {
+	case DrCrSplitColumns:
+		amount, drCr, perr = p.splitAmount(row, rowNum)
+	case DrCrSignedAmount:
+		amount, drCr, perr = p.signedAmount(row, rowNum)
+	default:
+		amount, drCr, perr = p.indicatorAmount(row, rowNum)
+	}
+	if perr != nil {
+		return ParsedTxns{}, perr
+	}
+
+	desc := strings.TrimSpace(p.cell(row, ColumnDescription))
+	hash := RowHash(txnDate, amount, drCr, desc)
+	txnType := DEBIT
+	if drCr == "CR" {
+		txnType = CREDIT
+	}
+
+	var balance *float64
+	if _, ok := p.columns[ColumnBalance]; ok {
+		parseBalance := ParseBalanceValue
+		if p.profile.cycle != nil {
+			parseBalance = parseCardBalance
+		}
+		if b, err := parseBalance(p.cell(row, ColumnBalance)); err == nil {
+			balance = &b
+		}
+	}
+
+	return ParsedTxns{
+		UploadId:        p.uploadID,
+		AccountId:       p.accountID,
+		TxnDate:         txnDate,
+		Description:     utils.PtrString(desc),
+		Amount:          amount,
+		Type:            txnType,
+		ReferenceNumber: utils.PtrString(strings.TrimSpace(p.cell(row, ColumnReference))),
+		Balance:         balance,
+		RawRowHash:      &hash,
+		RowNumber:       uint32(rowNum),
+	}, nil
+}
+
+func (p *statementRowParser) indicatorAmount(row []string, rowNum int) (float64, string, *ParseError) {
+	amount, err := ParseExcelAmount(row, p.columns[ColumnAmount])
+	if err != nil {
+		return 0, "", &ParseError{Row: rowNum, Error: "invalid amount", Data: map[string]interface{}{"value": p.cell(row, ColumnAmount)}}
+	}
+
+	debitMarkers, creditMarkers := p.profile.DebitMarkers, p.profile.CreditMarkers
+	if len(debitMarkers) == 0 {
+		debitMarkers = defaultDebitMarkers
+	}
+	if len(creditMarkers) == 0 {
+		creditMarkers = defaultCreditMarkers
+	}
+	marker := strings.ToUpper(strings.Trim(strings.TrimSpace(p.cell(row, ColumnDrCr)), "."))
+	switch {
+	case matchesMarker(marker, debitMarkers):
+		return amount, "DR", nil
+	case matchesMarker(marker, creditMarkers):
+		return amount, "CR", nil
+	default:
+		return 0, "", &ParseError{Row: rowNum, Error: "invalid Dr/Cr", Data: map[string]interface{}{"value": p.cell(row, ColumnDrCr)}}
+	}
+}
+
+func (p *statementRowParser) splitAmount(row []string, rowNum int) (float64, string, *ParseError) {
+	withdrawalRaw := p.cell(row, ColumnWithdrawal)
+	depositRaw := p.cell(row, ColumnDeposit)
+
+	withdrawal, err := parseOptionalAmount(withdrawalRaw)
+	if err != nil {
+		return 0, "", &ParseError{Row: rowNum, Error: "invalid withdrawal amount", Data: map[string]interface{}{"value": withdrawalRaw}}
+	}
+	deposit, err := parseOptionalAmount(depositRaw)
+	if err != nil {
+		return 0, "", &ParseError{Row: rowNum, Error: "invalid deposit amount", Data: map[string]interface{}{"value": depositRaw}}
+	}
+
+	switch {
+	case withdrawal != 0 && deposit != 0:
+		return 0, "", &ParseError{Row: rowNum, Error: "both withdrawal and deposit present", Data: map[string]interface{}{"withdrawal": withdrawalRaw, "deposit": depositRaw}}
+	case withdrawal != 0:
+		return math.Abs(withdrawal), "DR", nil
+	case deposit != 0:
+		return math.Abs(deposit), "CR", nil
+	default:
+		return 0, "", &ParseError{Row: rowNum, Error: "missing withdrawal or deposit amount", Data: map[string]interface{}{"withdrawal": withdrawalRaw, "deposit": depositRaw}}
+	}
+}
+
+func (p *statementRowParser) signedAmount(row []string, rowNum int) (float64, string, *ParseError) {
+	if p.profile.cycle != nil {
+		amount, drCr, err := parseCardAmount(p.cell(row, ColumnAmount))
+		if err != nil || amount == 0 {
+			return 0, "", &ParseError{Row: rowNum, Error: "invalid amount", Data: map[string]interface{}{"value": p.cell(row, ColumnAmount)}}
+		}
+		return amount, drCr, nil
+	}
+	amount, err := ParseExcelAmount(row, p.columns[ColumnAmount])
+	if err != nil || amount == 0 {
+		return 0, "", &ParseError{Row: rowNum, Error: "invalid amount", Data: map[string]interface{}{"value": p.cell(row, ColumnAmount)}}
+	}
+	if amount < 0 {
+		return -amount, "DR", nil
+	}
+	return amount, "CR", nil
+}
+
+// parseOptionalAmount treats empty, dash and zero cells as "no amount".
+func parseOptionalAmount(v string) (float64, error) {
+	v = strings.TrimSpace(v)
+	if v == "" || strings.Trim(v, "-") == "" {
+		return 0, nil
+	}
+	return ParseAmountValue(v)
+}
+
+// rowReader yields the next raw row of a statement; io.EOF ends the sheet.
+type rowReader func() ([]string, error)
+
+// parseStatementRows drives a statementRowParser over any row source.
+func parseStatementRows(next rowReader, profile StatementProfile, accountID, uploadID uuid.UUID) ([]ParsedTxns, []ParseError, error) {
+	p := newStatementRowParser(profile, accountID, uploadID)
+	rowNum := 0
+	for {
+		row, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		rowNum++
+		if err != nil {
+			var pe *csv.ParseError
+			if errors.As(err, &pe) {
+				p.errors = append(p.errors, ParseError{Row: rowNum, Error: "malformed CSV row", Data: map[string]interface{}{"error": pe.Err.Error()}})
+				continue
+			}
+			return nil, nil, err
+		}
+		p.push(rowNum, row)
+	}
+	return p.finish()
+}
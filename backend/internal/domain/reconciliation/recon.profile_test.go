@@ -0,0 +1,407 @@
+package reconciliation
+
+import (
+	"io"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// sliceRows returns a rowReader over fixed rows.
+func sliceRows(rows [][]string) rowReader {
+	i := 0
+	return func() ([]string, error) {
+		if i >= len(rows) {
+			return nil, io.EOF
+		}
+		i++
+		return rows[i-1], nil
+	}
+}
+
+func TestLookupStatementProfile(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"HDFC", "HDFC"},
+		{"hdfcbank", "HDFC"},
+		{" SBIN ", "SBI"},
+		{"UTIB", "AXIS"},
+		{"legacy", "LEGACY"},
+		{"KKBK", autoDetectProfileCode},
+		{"UNKNOWN", autoDetectProfileCode},
+		{"", autoDetectProfileCode},
+	}
+	for _, tt := range tests {
+		if got := LookupStatementProfile(tt.code).Code; got != tt.want {
+			t.Errorf("LookupStatementProfile(%q).Code = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestStatementProfileValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile StatementProfile
+		wantErr bool
+	}{
+		{"built-in legacy", legacyStatementProfile, false},
+		{"built-in split columns", hdfcStatementProfile, false},
+		{
+			name:    "unknown convention",
+			profile: StatementProfile{Convention: "BOTH", Columns: map[StatementColumn]int{ColumnDate: 0}},
+			wantErr: true,
+		},
+		{
+			name:    "missing required column",
+			profile: StatementProfile{Convention: DrCrSignedAmount, Columns: map[StatementColumn]int{ColumnDate: 0}},
+			wantErr: true,
+		},
+		{
+			name: "column found by header",
+			profile: StatementProfile{
+				Convention: DrCrSignedAmount,
+				Columns:    map[StatementColumn]int{ColumnDate: 0},
+				Headers:    map[StatementColumn][]string{ColumnAmount: {"amount"}},
+			},
+		},
+		{
+			name:    "negative column",
+			profile: StatementProfile{Convention: DrCrSignedAmount, Columns: map[StatementColumn]int{ColumnDate: 0, ColumnAmount: -1}},
+			wantErr: true,
+		},
+		{
+			name:    "two columns at one position",
+			profile: StatementProfile{Convention: DrCrSplitColumns, Columns: map[StatementColumn]int{ColumnDate: 0, ColumnWithdrawal: 2, ColumnDeposit: 2}},
+			wantErr: true,
+		},
+		{
+			name:    "negative footer rows",
+			profile: StatementProfile{Convention: DrCrSignedAmount, Columns: map[StatementColumn]int{ColumnDate: 0, ColumnAmount: 1}, FooterRows: -1},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.profile.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNormalizeHeader(t *testing.T) {
+	tests := map[string]string{
+		"Chq./Ref.No.":           "chq ref no",
+		"  Withdrawal Amt.  ":    "withdrawal amt",
+		"Balance (INR)":          "balance inr",
+		"Transaction\tRemarks":   "transaction remarks",
+		"":                       "",
+		"Dr / Cr":                "dr cr",
+		"Deposit Amount (₹)":     "deposit amount",
+		"Closing-Balance":        "closing balance",
+		"Value Dt":               "value dt",
+		"TXN DATE":               "txn date",
+		"Sl. No.":                "sl no",
+		"Ref No./Cheque No.":     "ref no cheque no",
+		"Withdrawal Amount(INR)": "withdrawal amount inr",
+	}
+	for in, want := range tests {
+		if got := normalizeHeader(in); got != want {
+			t.Errorf("normalizeHeader(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestDetectHeaderColumns(t *testing.T) {
+	tests := []struct {
+		name           string
+		row            []string
+		headers        map[StatementColumn][]string
+		convention     DrCrConvention
+		wantOK         bool
+		wantConvention DrCrConvention
+		wantCols       map[StatementColumn]int
+	}{
+		{
+			name:           "HDFC split columns",
+			row:            []string{"Date", "Narration", "Chq./Ref.No.", "Value Dt", "Withdrawal Amt.", "Deposit Amt.", "Closing Balance"},
+			wantOK:         true,
+			wantConvention: DrCrSplitColumns,
+			wantCols:       map[StatementColumn]int{ColumnDate: 0, ColumnDescription: 1, ColumnReference: 2, ColumnWithdrawal: 4, ColumnDeposit: 5, ColumnBalance: 6},
+		},
+		{
+			name:           "indicator column",
+			row:            []string{"Sl. No.", "Txn Date", "Description", "Amount", "Dr / Cr", "Balance"},
+			wantOK:         true,
+			wantConvention: DrCrIndicatorColumn,
+			wantCols:       map[StatementColumn]int{ColumnDate: 1, ColumnDescription: 2, ColumnAmount: 3, ColumnDrCr: 4, ColumnBalance: 5},
+		},
+		{
+			name:           "signed amount",
+			row:            []string{"Posting Date", "Details", "Amount"},
+			wantOK:         true,
+			wantConvention: DrCrSignedAmount,
+			wantCols:       map[StatementColumn]int{ColumnDate: 0, ColumnDescription: 1, ColumnAmount: 2},
+		},
+		{
+			name:       "forced convention the row cannot satisfy",
+			row:        []string{"Posting Date", "Details", "Amount"},
+			convention: DrCrSplitColumns,
+		},
+		{
+			name: "data row",
+			row:  []string{"01/04/2024", "UPI-SWIGGY", "250.00", "DR"},
+		},
+		{
+			name: "one cell names two columns",
+			row:  []string{"Date", "Details", "Amount", "Amount"},
+			headers: map[StatementColumn][]string{
+				ColumnDate:       {"date"},
+				ColumnWithdrawal: {"amount"},
+				ColumnDeposit:    {"amount"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := tt.headers
+			if headers == nil {
+				headers = defaultHeaderAliases
+			}
+			cols, convention, ok := detectHeaderColumns(tt.row, headers, tt.convention)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if convention != tt.wantConvention {
+				t.Errorf("convention = %s, want %s", convention, tt.wantConvention)
+			}
+			for col, idx := range tt.wantCols {
+				if got, found := cols[col]; !found || got != idx {
+					t.Errorf("column %s = %d (found %v), want %d", col, got, found, idx)
+				}
+			}
+		})
+	}
+}
+
+func TestParseStatementRowsProfiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		profile     StatementProfile
+		rows        [][]string
+		wantAmounts []float64
+		wantErr     bool
+	}{
+		{
+			name:    "header found below a preamble",
+			profile: autoDetectStatementProfile,
+			rows: [][]string{
+				{"Account Statement"},
+				{"Account No", "XXXX1234"},
+				{"Txn Date", "Description", "Amount", "Dr/Cr"},
+				{"01/04/2024", "UPI-SWIGGY", "250.00", "DR"},
+			},
+			wantAmounts: []float64{250},
+		},
+		{
+			name:    "bank profile falls back to generic headers",
+			profile: hdfcStatementProfile,
+			rows: [][]string{
+				{"Txn Date", "Description", "Amount", "Dr/Cr"},
+				{"01/04/2024", "UPI-SWIGGY", "250.00", "DR"},
+			},
+			wantAmounts: []float64{250},
+		},
+		{
+			name:    "auto-detect falls back to the legacy layout",
+			profile: autoDetectStatementProfile,
+			rows: [][]string{
+				{"1", "01/04/2024", "01/04/2024", "UPI-SWIGGY", "REF1", "250.00", "DR", "9750.00"},
+				{"2", "02/04/2024", "02/04/2024", "UPI-ZOMATO", "REF2", "100.00", "DR", "9650.00"},
+			},
+			// The legacy layout treats the first row as its header.
+			wantAmounts: []float64{100},
+		},
+		{
+			name: "footer rows are dropped",
+			profile: StatementProfile{
+				Columns:    map[StatementColumn]int{ColumnDate: 0, ColumnAmount: 1},
+				Convention: DrCrSignedAmount,
+				FooterRows: 1,
+			},
+			rows: [][]string{
+				{"01/04/2024", "-250.00"},
+				{"02/04/2024", "100.00"},
+				{"Total", "-150.00"},
+			},
+			wantAmounts: []float64{250, 100},
+		},
+		{
+			name:    "summary after the table ends it",
+			profile: hdfcStatementProfile,
+			rows: [][]string{
+				{"Date", "Narration", "Chq./Ref.No.", "Value Dt", "Withdrawal Amt.", "Deposit Amt.", "Closing Balance"},
+				{"01/04/24", "UPI-SWIGGY", "REF1", "01/04/24", "250.00", "", "9750.00"},
+				{"02/04/24", "SALARY", "REF2", "02/04/24", "", "5000.00", "14750.00"},
+				{"********", "", "", "", "", "", ""},
+				{"STATEMENT SUMMARY :-"},
+				{"Opening Balance", "Dr Count", "Cr Count", "Debits", "Credits", "Closing Bal"},
+				{"10,000.00", "1", "1", "250.00", "5,000.00", "14,750.00"},
+			},
+			wantAmounts: []float64{250, 5000},
+		},
+		{
+			name:    "summary above the table is not a footer",
+			profile: StatementProfile{Code: "TEST", Headers: defaultHeaderAliases, FooterMarkers: []string{"total"}},
+			rows: [][]string{
+				{"Total debits", "250.00"},
+				{"Txn Date", "Description", "Amount", "Dr/Cr"},
+				{"01/04/2024", "UPI-SWIGGY", "250.00", "DR"},
+				{"Total", "", "250.00", ""},
+			},
+			wantAmounts: []float64{250},
+		},
+		{
+			name:    "bank profile with no header row at all",
+			profile: hdfcStatementProfile,
+			rows: [][]string{
+				{"01/04/2024", "UPI-SWIGGY", "250.00", "DR"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, parseErrors, err := parseStatementRows(sliceRows(tt.rows), tt.profile, uuid.New(), uuid.New())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStatementRows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(parseErrors) != 0 {
+				t.Errorf("parse errors %+v, want none", parseErrors)
+			}
+			if len(rows) != len(tt.wantAmounts) {
+				t.Fatalf("got %d rows, want %d", len(rows), len(tt.wantAmounts))
+			}
+			for i, r := range rows {
+				if r.Amount != tt.wantAmounts[i] {
+					t.Errorf("row %d: Amount = %v, want %v", i, r.Amount, tt.wantAmounts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseStatementRowsConventions(t *testing.T) {
+	tests := []struct {
+		name       string
+		convention DrCrConvention
+		columns    map[StatementColumn]int
+		row        []string
+		wantAmount float64
+		wantType   TxnType
+		wantErr    string
+	}{
+		{
+			name:       "withdrawal",
+			convention: DrCrSplitColumns,
+			columns:    map[StatementColumn]int{ColumnDate: 0, ColumnWithdrawal: 1, ColumnDeposit: 2, ColumnBalance: 3},
+			row:        []string{"01/04/2024", "1,250.00", "", "8,750.00"},
+			wantAmount: 1250,
+			wantType:   DEBIT,
+		},
+		{
+			name:       "deposit with a dash in the withdrawal cell",
+			convention: DrCrSplitColumns,
+			columns:    map[StatementColumn]int{ColumnDate: 0, ColumnWithdrawal: 1, ColumnDeposit: 2},
+			row:        []string{"01/04/2024", "-", "300.00"},
+			wantAmount: 300,
+			wantType:   CREDIT,
+		},
+		{
+			name:       "trailing deposit cell left off",
+			convention: DrCrSplitColumns,
+			columns:    map[StatementColumn]int{ColumnDate: 0, ColumnWithdrawal: 1, ColumnDeposit: 2},
+			row:        []string{"01/04/2024", "40.00"},
+			wantAmount: 40,
+			wantType:   DEBIT,
+		},
+		{
+			name:       "both amounts present",
+			convention: DrCrSplitColumns,
+			columns:    map[StatementColumn]int{ColumnDate: 0, ColumnWithdrawal: 1, ColumnDeposit: 2},
+			row:        []string{"01/04/2024", "10.00", "20.00"},
+			wantErr:    "both withdrawal and deposit present",
+		},
+		{
+			name:       "neither amount present",
+			convention: DrCrSplitColumns,
+			columns:    map[StatementColumn]int{ColumnDate: 0, ColumnWithdrawal: 1, ColumnDeposit: 2},
+			row:        []string{"01/04/2024", "0.00", ""},
+			wantErr:    "missing withdrawal or deposit amount",
+		},
+		{
+			name:       "negative signed amount is a debit",
+			convention: DrCrSignedAmount,
+			columns:    map[StatementColumn]int{ColumnDate: 0, ColumnAmount: 1},
+			row:        []string{"01/04/2024", "-99.50"},
+			wantAmount: 99.50,
+			wantType:   DEBIT,
+		},
+		{
+			name:       "accounting negative is a debit",
+			convention: DrCrSignedAmount,
+			columns:    map[StatementColumn]int{ColumnDate: 0, ColumnAmount: 1},
+			row:        []string{"01/04/2024", "(99.50)"},
+			wantAmount: 99.50,
+			wantType:   DEBIT,
+		},
+		{
+			name:       "positive signed amount is a credit",
+			convention: DrCrSignedAmount,
+			columns:    map[StatementColumn]int{ColumnDate: 0, ColumnAmount: 1},
+			row:        []string{"01/04/2024", "1,000"},
+			wantAmount: 1000,
+			wantType:   CREDIT,
+		},
+		{
+			name:       "zero signed amount",
+			convention: DrCrSignedAmount,
+			columns:    map[StatementColumn]int{ColumnDate: 0, ColumnAmount: 1},
+			row:        []string{"01/04/2024", "0.00"},
+			wantErr:    "invalid amount",
+		},
+		{
+			name:       "bad date",
+			convention: DrCrSignedAmount,
+			columns:    map[StatementColumn]int{ColumnDate: 0, ColumnAmount: 1},
+			row:        []string{"not a date", "10"},
+			wantErr:    "invalid transaction date",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := StatementProfile{Columns: tt.columns, Convention: tt.convention}
+			rows, parseErrors, err := parseStatementRows(sliceRows([][]string{tt.row}), profile, uuid.New(), uuid.New())
+			if err != nil {
+				t.Fatalf("parseStatementRows() error = %v", err)
+			}
+			if tt.wantErr != "" {
+				if len(parseErrors) != 1 || parseErrors[0].Error != tt.wantErr {
+					t.Fatalf("parse errors = %+v, want one %q", parseErrors, tt.wantErr)
+				}
+				return
+			}
+			if len(rows) != 1 {
+				t.Fatalf("got %d rows, want 1 (errors %+v)", len(rows), parseErrors)
+			}
+			if rows[0].Amount != tt.wantAmount || rows[0].Type != tt.wantType {
+				t.Errorf("got %v %s, want %v %s", rows[0].Amount, rows[0].Type, tt.wantAmount, tt.wantType)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package reconciliation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/errs"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/xuri/excelize/v2"
+)
+
+// Sheet names of the reconciliation report, in workbook order.
+const (
+	reportSheetSummary        = "Summary"
+	reportSheetMatched        = "Matched"
+	reportSheetMissingInApp   = "Missing in App"
+	reportSheetNotInStatement = "Not in Statement"
+	reportSheetParseErrors    = "Parse Errors"
+)
+
+// reportPageSize is how many results the export reads per query.
+const reportPageSize = 1000
+
+const reportDateLayout = "2006-01-02"
+
+// ExportReport builds the reconciliation report of an upload as an XLSX workbook.
+func (s *ReconService) ExportReport(c echo.Context, payload *ExportReportReq, clerkId string) ([]byte, error) {
+	ctx := c.Request().Context()
+	// A zero page size loads the upload summary without its statement rows.
+	detail, err := s.repo.GetUploadDetail(ctx, payload.UploadId, clerkId, 0, 0)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.NewNotFoundError("Upload not found", false, nil)
+		}
+		return nil, err
+	}
+	results, err := s.listAllResults(ctx, detail)
+	if err != nil {
+		return nil, err
+	}
+	return buildReconReport(detail, results)
+}
+
+func (s *ReconService) listAllResults(ctx context.Context, detail *UploadFullDetailPaginated) ([]ReconciliationResultRow, error) {
+	var results []ReconciliationResultRow
+	for offset := int32(0); ; offset += reportPageSize {
+		page, err := s.repo.GetResultsByUploadID(ctx, detail.ID, reportPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, page.Results...)
+		if len(page.Results) < reportPageSize {
+			return results, nil
+		}
+	}
+}
+
+// buildReconReport writes one sheet for the summary counters, one per result bucket and
+// one for the rows that failed to parse.
+func buildReconReport(detail *UploadFullDetailPaginated, results []ReconciliationResultRow) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName(f.GetSheetName(0), reportSheetSummary); err != nil {
+		return nil, err
+	}
+	for _, sheet := range []string{reportSheetMatched, reportSheetMissingInApp, reportSheetNotInStatement, reportSheetParseErrors} {
+		if _, err := f.NewSheet(sheet); err != nil {
+			return nil, err
+		}
+	}
+	header, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return nil, err
+	}
+
+	var matched, missing, notInStatement [][]interface{}
+	for _, r := range results {
+		switch r.ResultType {
+		case string(generated.ReconciliationResultTypeMISSINGINAPP):
+			missing = append(missing, missingInAppRow(r))
+		case string(generated.ReconciliationResultTypeNOTINSTATEMENT):
+			notInStatement = append(notInStatement, notInStatementRow(r))
+		default:
+			matched = append(matched, matchedRow(r))
+		}
+	}
+	parseErrors := make([][]interface{}, 0, len(detail.ParsingErrors))
+	for _, pe := range detail.ParsingErrors {
+		data, _ := json.Marshal(pe.Data)
+		parseErrors = append(parseErrors, []interface{}{pe.Row, pe.Error, string(data)})
+	}
+
+	sheets := []struct {
+		name    string
+		columns []string
+		rows    [][]interface{}
+	}{
+		{reportSheetSummary, []string{"Field", "Value"}, summaryRows(detail)},
+		{reportSheetMatched, matchedColumns, matched},
+		{reportSheetMissingInApp, missingInAppColumns, missing},
+		{reportSheetNotInStatement, notInStatementColumns, notInStatement},
+		{reportSheetParseErrors, []string{"Row", "Error", "Data"}, parseErrors},
+	}
+	for _, sh := range sheets {
+		if err := writeReportSheet(f, sh.name, header, sh.columns, sh.rows); err != nil {
+			return nil, fmt.Errorf("write %s sheet: %w", sh.name, err)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeReportSheet(f *excelize.File, sheet string, headerStyle int, columns []string, rows [][]interface{}) error {
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+	header := make([]interface{}, len(columns))
+	for i, col := range columns {
+		header[i] = excelize.Cell{StyleID: headerStyle, Value: col}
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return err
+	}
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return err
+		}
+		if err := sw.SetRow(cell, row); err != nil {
+			return err
+		}
+	}
+	return sw.Flush()
+}
+
+func summaryRows(d *UploadFullDetailPaginated) [][]interface{} {
+	rows := [][]interface{}{
+		{"Upload ID", d.ID.String()},
+		{"Account ID", d.AccountID.String()},
+		{"File Name", d.FileName},
+		{"Upload Status", d.UploadStatus},
+		{"Processing Status", d.ProcessingStatus},
+		{"Statement Period Start", reportDate(d.StatementPeriodStart)},
+		{"Statement Period End", reportDate(d.StatementPeriodEnd)},
+		{"Valid Rows", d.ValidRows},
+		{"Duplicate Rows", d.DuplicateRows},
+		{"Error Rows", d.ErrorRows},
+		{"Total Transactions", d.TotalTransactions},
+		{"Matched", d.MatchedTransactions},
+		{"Missing in App", d.UnmatchedTransactions},
+		{"Not in Statement", d.MissingTransactions},
+		{"Closing Balance", reportFloat(d.ClosingBalance)},
+		{"Balance Drift", reportFloat(d.BalanceDrift)},
+	}
+	if cc := d.CardCycle; cc != nil {
+		rows = append(rows,
+			[]interface{}{"Statement Date", reportDate(cc.StatementDate)},
+			[]interface{}{"Payment Due Date", reportDate(cc.PaymentDueDate)},
+			[]interface{}{"Minimum Due", reportFloat(cc.MinimumDue)},
+			[]interface{}{"Total Due", reportFloat(cc.TotalDue)},
+		)
+	}
+	return append(rows, []interface{}{"Generated At", time.Now().UTC().Format(time.RFC3339)})
+}
+
+var matchedColumns = []string{
+	"Result ID", "Result Type", "Match Status", "User Action", "Confidence",
+	"Statement Row", "Statement Date", "Statement Description", "Statement Type", "Statement Amount", "Reference",
+	"App Date", "App Description", "App Type", "App Amount", "App Source", "Split Members",
+	"Date Diff Days", "Amount Diff", "Amount Diff %", "Description Similarity", "Reference Match",
+	"Date Score", "Amount Score", "Description Score", "Reference Score",
+	"Payment Method", "Counterparty Match", "Transfer",
+}
+
+func matchedRow(r ReconciliationResultRow) []interface{} {
+	row := []interface{}{
+		r.ID.String(), r.ResultType, r.MatchStatus, r.UserAction, r.ConfidenceScore,
+		r.StmtRowNumber, reportDate(r.StmtDate), reportString(r.StmtDescription), r.StmtType, r.StmtAmount, reportString(r.StmtReferenceNumber),
+		reportDate(r.AppDate), reportString(r.AppDescription), r.AppType, reportFloat(r.AppAmount), r.AppSource, splitMembers(r.Members),
+	}
+	if ms := r.MatchSignals; ms != nil {
+		return append(row,
+			ms.DateDiffDays, ms.AmountDiff, ms.AmountDiffPct, ms.DescriptionSimilarity, ms.ReferenceMatch,
+			ms.DateScore, ms.AmountScore, ms.DescriptionScore, ms.ReferenceScore,
+			ms.PaymentMethod, ms.CounterpartyMatch, ms.Transfer,
+		)
+	}
+	return row
+}
+
+// splitMembers lists the amounts on each side of a SPLIT_MATCH group.
+func splitMembers(members []MatchMember) string {
+	var stmt, app []string
+	for _, m := range members {
+		amount := fmt.Sprintf("%.2f", m.Amount)
+		if m.StatementTransactionID != nil {
+			stmt = append(stmt, amount)
+		} else {
+			app = append(app, amount)
+		}
+	}
+	if len(stmt) == 0 && len(app) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("statement: %s; app: %s", strings.Join(stmt, ", "), strings.Join(app, ", "))
+}
+
+var missingInAppColumns = []string{
+	"Result ID", "Match Status", "User Action", "Statement Row", "Date", "Description", "Type", "Amount", "Reference", "Auto-created",
+}
+
+func missingInAppRow(r ReconciliationResultRow) []interface{} {
+	return []interface{}{
+		r.ID.String(), r.MatchStatus, r.UserAction, r.StmtRowNumber, reportDate(r.StmtDate),
+		reportString(r.StmtDescription), r.StmtType, r.StmtAmount, reportString(r.StmtReferenceNumber),
+		r.AppTransactionID != nil,
+	}
+}
+
+var notInStatementColumns = []string{
+	"Result ID", "Match Status", "User Action", "Date", "Description", "Type", "Amount", "Source",
+}
+
+func notInStatementRow(r ReconciliationResultRow) []interface{} {
+	return []interface{}{
+		r.ID.String(), r.MatchStatus, r.UserAction, reportDate(r.AppDate),
+		reportString(r.AppDescription), r.AppType, reportFloat(r.AppAmount), r.AppSource,
+	}
+}
+
+func reportDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(reportDateLayout)
+}
+
+func reportString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// reportFloat leaves the cell empty for a missing value rather than writing 0.
+func reportFloat(f *float64) interface{} {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
@@ -0,0 +1,168 @@
+package reconciliation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/errs"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/xuri/excelize/v2"
+)
+
+// reportRepo serves an upload's summary and its results a page at a time.
+type reportRepo struct {
+	reconRepository
+	detail  *UploadFullDetailPaginated
+	results []ReconciliationResultRow
+	pages   int
+}
+
+func (f *reportRepo) GetUploadDetail(context.Context, uuid.UUID, string, int32, int32) (*UploadFullDetailPaginated, error) {
+	if f.detail == nil {
+		return nil, pgx.ErrNoRows
+	}
+	return f.detail, nil
+}
+
+func (f *reportRepo) GetResultsByUploadID(_ context.Context, _ uuid.UUID, limit, offset int32) (*PaginatedReconciliationResults, error) {
+	f.pages++
+	end := min(int(offset+limit), len(f.results))
+	return &PaginatedReconciliationResults{Results: f.results[offset:end]}, nil
+}
+
+func TestExportReport(t *testing.T) {
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	closing := 1250.5
+	stmtID := uuid.New()
+	desc, appDesc := "UPI/SWIGGY/412345678901", "Swiggy"
+	appAmount := 250.0
+
+	results := make([]ReconciliationResultRow, 0, reportPageSize+3)
+	for i := 0; i < reportPageSize; i++ {
+		results = append(results, ReconciliationResultRow{
+			ID: uuid.New(), ResultType: string(generated.ReconciliationResultTypeMISSINGINAPP), MatchStatus: "pending",
+			StmtDate: &day, StmtDescription: &desc, StmtAmount: 100, StmtType: string(DEBIT), StmtRowNumber: int32(i + 2),
+		})
+	}
+	results = append(results,
+		ReconciliationResultRow{
+			ID: uuid.New(), ResultType: string(generated.ReconciliationResultTypeHIGHCONFIDENCEMATCH), MatchStatus: "auto_accepted",
+			ConfidenceScore: 95, StmtDate: &day, StmtDescription: &desc, StmtAmount: 250, StmtType: string(DEBIT),
+			AppDate: &day, AppDescription: &appDesc, AppAmount: &appAmount, AppType: string(DEBIT), AppSource: "SMS",
+			MatchSignals: &MatchSignals{DateDiffDays: 1, DescriptionSimilarity: 0.8, PaymentMethod: "UPI"},
+		},
+		ReconciliationResultRow{
+			ID: uuid.New(), ResultType: string(generated.ReconciliationResultTypeSPLITMATCH), MatchStatus: "pending",
+			Members: []MatchMember{{StatementTransactionID: &stmtID, Amount: 300}, {Amount: 100}, {Amount: 200}},
+		},
+		ReconciliationResultRow{
+			ID: uuid.New(), ResultType: string(generated.ReconciliationResultTypeNOTINSTATEMENT), MatchStatus: "pending",
+			AppDate: &day, AppDescription: &appDesc, AppAmount: &appAmount, AppType: string(DEBIT), AppSource: "MANUAL",
+		},
+	)
+	detail := &UploadFullDetailPaginated{
+		ID:             uuid.New(),
+		FileName:       "march.csv",
+		ClosingBalance: &closing,
+		ParsingErrors:  []ParseError{{Row: 7, Error: "invalid amount", Data: map[string]interface{}{"value": "abc"}}},
+	}
+
+	repo := &reportRepo{detail: detail, results: results}
+	svc := &ReconService{repo: repo}
+	data, err := svc.ExportReport(testEchoContext(), &ExportReportReq{UploadId: detail.ID}, "user_1")
+	if err != nil {
+		t.Fatalf("ExportReport() error = %v", err)
+	}
+	if repo.pages != 2 {
+		t.Errorf("read results in %d pages, want 2", repo.pages)
+	}
+
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("report is not a workbook: %v", err)
+	}
+	defer f.Close()
+	wantSheets := []string{reportSheetSummary, reportSheetMatched, reportSheetMissingInApp, reportSheetNotInStatement, reportSheetParseErrors}
+	if got := f.GetSheetList(); len(got) != len(wantSheets) {
+		t.Fatalf("sheets = %v, want %v", got, wantSheets)
+	}
+	for i, sheet := range f.GetSheetList() {
+		if sheet != wantSheets[i] {
+			t.Errorf("sheet %d = %q, want %q", i, sheet, wantSheets[i])
+		}
+	}
+
+	rows := func(sheet string) [][]string {
+		t.Helper()
+		got, err := f.GetRows(sheet)
+		if err != nil {
+			t.Fatalf("GetRows(%q) error = %v", sheet, err)
+		}
+		return got
+	}
+	for sheet, want := range map[string]int{
+		reportSheetMatched:        2,
+		reportSheetMissingInApp:   reportPageSize,
+		reportSheetNotInStatement: 1,
+		reportSheetParseErrors:    1,
+	} {
+		if got := len(rows(sheet)) - 1; got != want {
+			t.Errorf("%s has %d rows, want %d", sheet, got, want)
+		}
+	}
+
+	cells := []struct {
+		sheet, cell, want string
+	}{
+		{reportSheetMatched, "A1", "Result ID"},
+		{reportSheetMatched, "G2", "2026-03-10"},
+		{reportSheetMatched, "R2", "1"},
+		{reportSheetMatched, "AA2", "UPI"},
+		{reportSheetMatched, "Q3", "statement: 300.00; app: 100.00, 200.00"},
+		{reportSheetMissingInApp, "J2", "FALSE"},
+		{reportSheetNotInStatement, "G2", "250"},
+		{reportSheetParseErrors, "C2", `{"value":"abc"}`},
+	}
+	for _, c := range cells {
+		got, err := f.GetCellValue(c.sheet, c.cell)
+		if err != nil {
+			t.Fatalf("GetCellValue(%q, %q) error = %v", c.sheet, c.cell, err)
+		}
+		if got != c.want {
+			t.Errorf("%s!%s = %q, want %q", c.sheet, c.cell, got, c.want)
+		}
+	}
+
+	summary := make(map[string]string)
+	for _, r := range rows(reportSheetSummary)[1:] {
+		if len(r) > 1 {
+			summary[r[0]] = r[1]
+		} else {
+			summary[r[0]] = ""
+		}
+	}
+	if summary["File Name"] != "march.csv" || summary["Closing Balance"] != "1250.5" {
+		t.Errorf("summary = %v, want the file name and closing balance", summary)
+	}
+	if v, ok := summary["Balance Drift"]; !ok || v != "" {
+		t.Errorf("Balance Drift = %q, want an empty cell for a missing value", v)
+	}
+	if _, ok := summary["Total Due"]; ok {
+		t.Error("summary lists card cycle fields for a non-card upload")
+	}
+}
+
+func TestExportReportUnknownUpload(t *testing.T) {
+	svc := &ReconService{repo: &reportRepo{}}
+	_, err := svc.ExportReport(testEchoContext(), &ExportReportReq{UploadId: uuid.New()}, "user_1")
+	var httpErr *errs.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Status != http.StatusNotFound {
+		t.Fatalf("ExportReport() error = %v, want not found", err)
+	}
+}
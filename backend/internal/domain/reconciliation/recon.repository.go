@@ -228,6 +228,7 @@ func (r *ReconRepository) GetUploadDetail(ctx context.Context, uploadID uuid.UUI
 			Amount:          utils.NumericToFloat64(t.Amount),
 			Type:            t.Type,
 			ReferenceNumber: utils.TextToStringPtr(t.ReferenceNumber),
+			Balance:         utils.NumericToFloat64Ptr(t.Balance),
 			RawRowHash:      t.RawRowHash,
 			RowNumber:       t.RowNumber,
 			IsDuplicate:     isDup,
@@ -243,26 +244,45 @@ func (r *ReconRepository) GetUploadDetail(ctx context.Context, uploadID uuid.UUI
 		page = offset/limit + 1
 	}
 
+	cardCycle := &CardCycle{
+		StatementDate:  utils.DateToTimePtr(row.StatementDate),
+		PaymentDueDate: utils.DateToTimePtr(row.PaymentDueDate),
+		MinimumDue:     utils.NumericToFloat64Ptr(row.MinimumDue),
+		TotalDue:       utils.NumericToFloat64Ptr(row.TotalDue),
+	}
+	if cardCycle.empty() {
+		cardCycle = nil
+	}
+
 	item := rowToUploadListItem(row.ID, row.AccountID, row.FileName, row.UploadStatus, row.ProcessingStatus, row.StatementPeriodStart, row.StatementPeriodEnd, row.CreatedAt)
 	return &UploadFullDetailPaginated{
-		ID:                   item.ID,
-		AccountID:            item.AccountID,
-		FileName:             item.FileName,
-		UploadStatus:         item.UploadStatus,
-		ProcessingStatus:     item.ProcessingStatus,
-		StatementPeriodStart: item.StatementPeriodStart,
-		StatementPeriodEnd:   item.StatementPeriodEnd,
-		ValidRows:            utils.Int4ToInt(row.ValidRows),
-		DuplicateRows:        utils.Int4ToInt(row.DuplicateRows),
-		ErrorRows:            utils.Int4ToInt(row.ErrorRows),
-		ParsingErrors:        parseErrors,
-		Transactions:         txns,
-		Total:                total,
-		Page:                 page,
-		PageSize:             limit,
-		TotalPages:           totalPages,
-		CreatedAt:            item.CreatedAt,
-		UpdatedAt:            utils.TimestampToTimePtr(row.UpdatedAt),
+		ID:                    item.ID,
+		AccountID:             item.AccountID,
+		FileName:              item.FileName,
+		UploadStatus:          item.UploadStatus,
+		ProcessingStatus:      item.ProcessingStatus,
+		StatementPeriodStart:  item.StatementPeriodStart,
+		StatementPeriodEnd:    item.StatementPeriodEnd,
+		ValidRows:             utils.Int4ToInt(row.ValidRows),
+		DuplicateRows:         utils.Int4ToInt(row.DuplicateRows),
+		ErrorRows:             utils.Int4ToInt(row.ErrorRows),
+		MatchedTransactions:   utils.Int4ToInt(row.MatchedTransactions),
+		UnmatchedTransactions: utils.Int4ToInt(row.UnmatchedTransactions),
+		MissingTransactions:   utils.Int4ToInt(row.MissingTransactions),
+		TotalTransactions:     utils.Int4ToInt(row.TotalTransactionsFound),
+		ProcessedRows:         utils.Int4ToInt(row.ProcessedRows),
+		ProcessedChunks:       utils.Int4ToInt(row.ProcessedChunks),
+		ClosingBalance:        utils.NumericToFloat64Ptr(row.ClosingBalance),
+		BalanceDrift:          utils.NumericToFloat64Ptr(row.BalanceDrift),
+		CardCycle:             cardCycle,
+		ParsingErrors:         parseErrors,
+		Transactions:          txns,
+		Total:                 total,
+		Page:                  page,
+		PageSize:              limit,
+		TotalPages:            totalPages,
+		CreatedAt:             item.CreatedAt,
+		UpdatedAt:             utils.TimestampToTimePtr(row.UpdatedAt),
 	}, nil
 }
 
@@ -284,6 +304,255 @@ func (r *ReconRepository) UpdateParseSummary(ctx context.Context, uploadID uuid.
 	})
 }
 
+// GetAccountCurrentBalance returns the account's ledger balance, or nil when it has none.
+func (r *ReconRepository) GetAccountCurrentBalance(ctx context.Context, accountID uuid.UUID, userID string) (*float64, error) {
+	bal, err := r.queries.GetAccountCurrentBalance(ctx, generated.GetAccountCurrentBalanceParams{
+		ID:     utils.UUIDToPgtype(accountID),
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return utils.NumericToFloat64Ptr(bal), nil
+}
+
+func (r *ReconRepository) UpdateUploadBalanceCheck(ctx context.Context, uploadID uuid.UUID, closingBalance, drift *float64) error {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	return queries.UpdateUploadBalanceCheck(ctx, generated.UpdateUploadBalanceCheckParams{
+		ID:             utils.UUIDToPgtype(uploadID),
+		ClosingBalance: utils.Float64PtrToNum(closingBalance),
+		BalanceDrift:   utils.Float64PtrToNum(drift),
+	})
+}
+
+// SaveStatementFile keeps a statement file for the reconciliation job to extract.
+func (r *ReconRepository) SaveStatementFile(ctx context.Context, uploadID uuid.UUID, mimeType string, content []byte) error {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	return queries.SaveStatementUploadFile(ctx, generated.SaveStatementUploadFileParams{
+		UploadID: utils.UUIDToPgtype(uploadID),
+		MimeType: mimeType,
+		Content:  content,
+	})
+}
+
+// GetStatementFile returns the file an upload's rows are still to be extracted from, or
+// nil when there is none.
+func (r *ReconRepository) GetStatementFile(ctx context.Context, uploadID uuid.UUID) (*StatementFile, error) {
+	row, err := r.queries.GetStatementUploadFile(ctx, utils.UUIDToPgtype(uploadID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &StatementFile{
+		MimeType: row.MimeType,
+		Content:  row.Content,
+		TotalDue: utils.NumericToFloat64Ptr(row.TotalDue),
+	}, nil
+}
+
+func (r *ReconRepository) DeleteStatementFile(ctx context.Context, uploadID uuid.UUID) error {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	return queries.DeleteStatementUploadFile(ctx, utils.UUIDToPgtype(uploadID))
+}
+
+// DeleteExpiredStatementFiles drops statement files left unextracted for a day, failing
+// their uploads, and returns how many it dropped.
+func (r *ReconRepository) DeleteExpiredStatementFiles(ctx context.Context) (int64, error) {
+	return r.queries.DeleteExpiredStatementUploadFiles(ctx)
+}
+
+// UpdateUploadCardCycle stores the billing-cycle summary of a credit card statement.
+func (r *ReconRepository) UpdateUploadCardCycle(ctx context.Context, uploadID uuid.UUID, cycle CardCycle) error {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	return queries.UpdateUploadCardCycle(ctx, generated.UpdateUploadCardCycleParams{
+		ID:             utils.UUIDToPgtype(uploadID),
+		StatementDate:  utils.TimePtrToDate(cycle.StatementDate),
+		PaymentDueDate: utils.TimePtrToDate(cycle.PaymentDueDate),
+		MinimumDue:     utils.Float64PtrToNum(cycle.MinimumDue),
+		TotalDue:       utils.Float64PtrToNum(cycle.TotalDue),
+	})
+}
+
+// GetAccountType returns the account's free-text type, such as "Savings" or "Credit Card".
+func (r *ReconRepository) GetAccountType(ctx context.Context, accountID uuid.UUID, userID string) (string, error) {
+	return r.queries.GetAccountType(ctx, generated.GetAccountTypeParams{
+		ID:     utils.UUIDToPgtype(accountID),
+		UserID: userID,
+	})
+}
+
+// GetCardIdentity returns the card's number and its bank's name and code.
+func (r *ReconRepository) GetCardIdentity(ctx context.Context, cardID uuid.UUID, userID string) (*CardIdentity, error) {
+	row, err := r.queries.GetCardIdentity(ctx, generated.GetCardIdentityParams{
+		ID:     utils.UUIDToPgtype(cardID),
+		UserID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CardIdentity{Number: row.AccountNumber, BankName: row.BankName, BankCode: row.BankCode}, nil
+}
+
+// ListCardPaymentAccounts returns the savings and current accounts that can pay a card,
+// the ones that paid it most often first.
+func (r *ReconRepository) ListCardPaymentAccounts(ctx context.Context, userID string, cardID uuid.UUID) ([]CardPaymentAccount, error) {
+	rows, err := r.queries.ListCardPaymentAccounts(ctx, generated.ListCardPaymentAccountsParams{
+		UserID: userID,
+		ID:     utils.UUIDToPgtype(cardID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CardPaymentAccount, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, CardPaymentAccount{ID: utils.UUIDToUUID(row.ID), Payments: int(row.Payments)})
+	}
+	return out, nil
+}
+
+// ListCardPaymentSources returns savings and current account debits between from and to
+// that can be the other leg of a bill payment to the card.
+func (r *ReconRepository) ListCardPaymentSources(ctx context.Context, userID string, cardID uuid.UUID, from, to time.Time) ([]CardPaymentSource, error) {
+	rows, err := r.queries.ListCardPaymentSources(ctx, generated.ListCardPaymentSourcesParams{
+		UserID:            userID,
+		AccountID:         utils.UUIDToPgtype(cardID),
+		TransactionDate:   utils.TimestampToPgtype(from),
+		TransactionDate_2: utils.TimestampToPgtype(to),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CardPaymentSource, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, CardPaymentSource{
+			ID:              utils.UUIDToUUID(row.ID),
+			AccountID:       utils.UUIDToUUID(row.AccountID),
+			ToAccountID:     utils.UUIDToUUIDPtr(row.ToAccountID),
+			Amount:          utils.NumericToFloat64(row.Amount),
+			TransactionDate: row.TransactionDate.Time,
+			Description:     row.Description,
+			Payee:           row.Payee,
+		})
+	}
+	return out, nil
+}
+
+// MarkTransactionTransfer makes a debit a transfer to toAccountID. It reports false when
+// the transaction already was a transfer.
+func (r *ReconRepository) MarkTransactionTransfer(ctx context.Context, txnID, toAccountID uuid.UUID) (bool, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	_, err := queries.MarkTransactionTransfer(ctx, generated.MarkTransactionTransferParams{
+		ID:          utils.UUIDToPgtype(txnID),
+		ToAccountID: utils.UUIDToPgtype(toAccountID),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ResetUploadProgress sets an upload's counters back to where a fresh job run starts:
+// statement rows to process, plus whatever results the user already decided on.
+func (r *ReconRepository) ResetUploadProgress(ctx context.Context, uploadID uuid.UUID, total, matched, unmatched, missing, processed int) error {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	return queries.ResetUploadProgress(ctx, generated.ResetUploadProgressParams{
+		ID:                     utils.UUIDToPgtype(uploadID),
+		TotalTransactionsFound: utils.IntToInt4(total),
+		MatchedTransactions:    utils.IntToInt4(matched),
+		UnmatchedTransactions:  utils.IntToInt4(unmatched),
+		MissingTransactions:    utils.IntToInt4(missing),
+		ProcessedRows:          utils.IntToInt4(processed),
+	})
+}
+
+// AddUploadChunkProgress adds one committed chunk's rows and classifications to the upload's counters.
+func (r *ReconRepository) AddUploadChunkProgress(ctx context.Context, uploadID uuid.UUID, processed, matched, unmatched, missing int) error {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	return queries.AddUploadChunkProgress(ctx, generated.AddUploadChunkProgressParams{
+		ID:                    utils.UUIDToPgtype(uploadID),
+		ProcessedRows:         utils.IntToInt4(processed),
+		MatchedTransactions:   utils.IntToInt4(matched),
+		UnmatchedTransactions: utils.IntToInt4(unmatched),
+		MissingTransactions:   utils.IntToInt4(missing),
+	})
+}
+
+func (r *ReconRepository) CountStatementTransactionsForProcessing(ctx context.Context, uploadID uuid.UUID) (int, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	n, err := queries.CountStatementTransactionsForProcessing(ctx, utils.UUIDToPgtype(uploadID))
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+func (r *ReconRepository) ListClaimedAppTransactionIDs(ctx context.Context, uploadID uuid.UUID) ([]uuid.UUID, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	rows, err := queries.ListClaimedAppTransactionIDs(ctx, utils.UUIDToPgtype(uploadID))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]uuid.UUID, 0, len(rows))
+	for _, id := range rows {
+		out = append(out, utils.UUIDToUUID(id))
+	}
+	return out, nil
+}
+
+// FindMerchantsByKeys maps merchantKey-normalised names to merchant IDs.
+func (r *ReconRepository) FindMerchantsByKeys(ctx context.Context, keys []string) (map[string]uuid.UUID, error) {
+	out := make(map[string]uuid.UUID, len(keys))
+	if len(keys) == 0 {
+		return out, nil
+	}
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	rows, err := queries.FindMerchantsByKeys(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if _, ok := out[row.MerchantKey]; !ok {
+			out[row.MerchantKey] = utils.UUIDToUUID(row.ID)
+		}
+	}
+	return out, nil
+}
+
 var ErrNoDateRange = errors.New("no statement transactions with a valid date found for upload")
 
 func (r *ReconRepository) GetStatementDateRange(ctx context.Context, uploadID uuid.UUID) (minDate, maxDate time.Time, err error) {
@@ -306,12 +575,17 @@ func (r *ReconRepository) GetStatementDateRange(ctx context.Context, uploadID uu
 	return minT, maxT, nil
 }
 
-func (r *ReconRepository) GetStatementTransactionsForProcessing(ctx context.Context, uploadID uuid.UUID) ([]StatementTransaction, error) {
+// GetStatementTransactionsForProcessing returns up to limit dated rows, oldest first,
+// that no reconciliation result covers yet.
+func (r *ReconRepository) GetStatementTransactionsForProcessing(ctx context.Context, uploadID uuid.UUID, limit int32) ([]StatementTransaction, error) {
 	queries := r.queries
 	if tx := r.tm.GetTx(ctx); tx != nil {
 		queries = queries.WithTx(tx)
 	}
-	rows, err := queries.GetStatementTransactionsForProcessing(ctx, utils.UUIDToPgtype(uploadID))
+	rows, err := queries.GetStatementTransactionsForProcessing(ctx, generated.GetStatementTransactionsForProcessingParams{
+		UploadID: utils.UUIDToPgtype(uploadID),
+		Limit:    limit,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -377,6 +651,7 @@ func (r *ReconRepository) GetAppTransactionsInDateRange(ctx context.Context, acc
 			Type:            string(row.Type),
 			Description:     utils.TextToString(row.Description),
 			ReferenceNumber: utils.TextToString(row.ReferenceNumber),
+			IsExcluded:      row.IsExcluded.Valid && row.IsExcluded.Bool,
 		})
 	}
 	return out, nil
@@ -412,12 +687,36 @@ func (r *ReconRepository) InsertReconciliationResults(ctx context.Context, resul
 		queries = queries.WithTx(tx)
 	}
 	args := make([]generated.InsertReconciliationResultBatchParams, 0, len(results))
+	var members []generated.InsertReconciliationMatchMemberBatchParams
 	for _, res := range results {
 		signalsJSON, err := json.Marshal(res.MatchSignals)
 		if err != nil {
 			return err
 		}
 		appTxnID := utils.UUIDPtrToPgtype(res.AppTransactionID)
+		if len(res.Members) > 0 {
+			groupID, err := queries.InsertReconciliationGroup(ctx, generated.InsertReconciliationGroupParams{
+				UploadID:               utils.UUIDToPgtype(res.UploadID),
+				StatementTransactionID: utils.UUIDToPgtype(res.StatementTransactionID),
+				AppTransactionID:       appTxnID,
+				ResultType:             generated.ReconciliationResultType(res.ResultType),
+				ConfidenceScore:        utils.Float64PtrToNum(&res.ConfidenceScore),
+				MatchSignals:           signalsJSON,
+				MatchStatus:            res.MatchStatus,
+			})
+			if err != nil {
+				return err
+			}
+			for _, m := range res.Members {
+				members = append(members, generated.InsertReconciliationMatchMemberBatchParams{
+					ReconciliationID:       groupID,
+					StatementTransactionID: utils.UUIDPtrToPgtype(m.StatementTransactionID),
+					AppTransactionID:       utils.UUIDPtrToPgtype(m.AppTransactionID),
+					Amount:                 utils.Float64PtrToNum(&m.Amount),
+				})
+			}
+			continue
+		}
 		args = append(args, generated.InsertReconciliationResultBatchParams{
 			UploadID:               utils.UUIDToPgtype(res.UploadID),
 			StatementTransactionID: utils.UUIDToPgtype(res.StatementTransactionID),
@@ -429,8 +728,19 @@ func (r *ReconRepository) InsertReconciliationResults(ctx context.Context, resul
 		})
 	}
 	var batchErr error
-	br := queries.InsertReconciliationResultBatch(ctx, args)
-	br.Exec(func(_ int, err error) {
+	if len(args) > 0 {
+		br := queries.InsertReconciliationResultBatch(ctx, args)
+		br.Exec(func(_ int, err error) {
+			if err != nil {
+				batchErr = err
+			}
+		})
+	}
+	if batchErr != nil || len(members) == 0 {
+		return batchErr
+	}
+	mbr := queries.InsertReconciliationMatchMemberBatch(ctx, members)
+	mbr.Exec(func(_ int, err error) {
 		if err != nil {
 			batchErr = err
 		}
@@ -453,6 +763,23 @@ func (r *ReconRepository) UpdateUploadProcessingStatus(ctx context.Context, uplo
 	})
 }
 
+// ClaimUploadForReprocess resets an upload to UPLOADED, reporting false when a
+// reconciliation is already queued or running for it.
+func (r *ReconRepository) ClaimUploadForReprocess(ctx context.Context, uploadID uuid.UUID, clerkId string) (bool, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	_, err := queries.ClaimUploadForReprocess(ctx, generated.ClaimUploadForReprocessParams{
+		ID:     utils.UUIDToPgtype(uploadID),
+		UserID: clerkId,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 func (r *ReconRepository) MarkTransactionAutoVerified(ctx context.Context, txnID, stmtTxnID uuid.UUID) error {
 	queries := r.queries
 	if tx := r.tm.GetTx(ctx); tx != nil {
@@ -499,7 +826,7 @@ func (r *ReconRepository) GetResultsByUploadID(ctx context.Context, uploadID uui
 		out = append(out, ReconciliationResultRow{
 			ID:                     utils.UUIDToUUID(row.ID),
 			UploadID:               utils.UUIDToUUID(row.UploadID),
-			StatementTransactionID: utils.UUIDToUUID(row.StatementTransactionID),
+			StatementTransactionID: utils.UUIDToUUIDPtr(row.StatementTransactionID),
 			AppTransactionID:       appTxnID,
 			ResultType:             string(row.ResultType),
 			ConfidenceScore:        utils.NumericToFloat64(row.ConfidenceScore),
@@ -510,11 +837,19 @@ func (r *ReconRepository) GetResultsByUploadID(ctx context.Context, uploadID uui
 			StmtDate:               stmtDate,
 			StmtDescription:        utils.TextToStringPtr(row.StmtDescription),
 			StmtAmount:             utils.NumericToFloat64(row.StmtAmount),
-			StmtType:               row.StmtType,
+			StmtType:               utils.TextToString(row.StmtType),
 			StmtReferenceNumber:    utils.TextToStringPtr(row.StmtReferenceNumber),
-			StmtRowNumber:          row.StmtRowNumber,
+			StmtRowNumber:          int32(utils.Int4ToInt(row.StmtRowNumber)),
+			AppDate:                utils.TimestampToTimePtr(row.AppDate),
+			AppDescription:         utils.TextToStringPtr(row.AppDescription),
+			AppAmount:              utils.NumericToFloat64Ptr(row.AppAmount),
+			AppType:                string(row.AppType.TxnType),
+			AppSource:              string(row.AppSource.TransactionSource),
 		})
 	}
+	if err := r.attachMatchMembers(ctx, out); err != nil {
+		return nil, err
+	}
 
 	totalPages := int32(1)
 	if limit > 0 && total > 0 {
@@ -534,12 +869,47 @@ func (r *ReconRepository) GetResultsByUploadID(ctx context.Context, uploadID uui
 	}, nil
 }
 
+// attachMatchMembers loads the participants of every SPLIT_MATCH row in the page.
+func (r *ReconRepository) attachMatchMembers(ctx context.Context, rows []ReconciliationResultRow) error {
+	var groupIDs []pgtype.UUID
+	byID := make(map[uuid.UUID]int)
+	for i, row := range rows {
+		if row.ResultType == string(generated.ReconciliationResultTypeSPLITMATCH) {
+			groupIDs = append(groupIDs, utils.UUIDToPgtype(row.ID))
+			byID[row.ID] = i
+		}
+	}
+	if len(groupIDs) == 0 {
+		return nil
+	}
+	members, err := r.queries.ListReconciliationMatchMembers(ctx, groupIDs)
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		i, ok := byID[utils.UUIDToUUID(m.ReconciliationID)]
+		if !ok {
+			continue
+		}
+		rows[i].Members = append(rows[i].Members, MatchMember{
+			StatementTransactionID: utils.UUIDToUUIDPtr(m.StatementTransactionID),
+			AppTransactionID:       utils.UUIDToUUIDPtr(m.AppTransactionID),
+			Amount:                 utils.NumericToFloat64(m.Amount),
+		})
+	}
+	return nil
+}
+
 func (r *ReconRepository) BulkUpdateResultStatus(ctx context.Context, resultIDs []uuid.UUID, userAction string, clerkID string, uploadID uuid.UUID) ([]UpdateResultStatusRes, error) {
 	pgIDs := make([]pgtype.UUID, len(resultIDs))
 	for i, id := range resultIDs {
 		pgIDs[i] = utils.UUIDToPgtype(id)
 	}
-	rows, err := r.queries.BulkUpdateReconciliationResultStatus(ctx, generated.BulkUpdateReconciliationResultStatusParams{
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	rows, err := queries.BulkUpdateReconciliationResultStatus(ctx, generated.BulkUpdateReconciliationResultStatusParams{
 		Column1:    pgIDs,
 		UserAction: utils.StringToPgtypeText(userAction),
 		UserID:     clerkID,
@@ -553,13 +923,101 @@ func (r *ReconRepository) BulkUpdateResultStatus(ctx context.Context, resultIDs
 		out = append(out, UpdateResultStatusRes{
 			ID:         utils.UUIDToUUID(row.ID),
 			UserAction: utils.TextToString(row.UserAction),
+			ReviewedBy: string(row.ReviewedBy.ReconciliationActor),
+			ReviewedAt: utils.TimestampToTimePtr(row.ReviewedAt),
+		})
+	}
+	return out, nil
+}
+
+// GetReviewTargets returns the app transactions behind the given results, one row per
+// transaction, so a review decision can be applied to each of them.
+func (r *ReconRepository) GetReviewTargets(ctx context.Context, resultIDs []uuid.UUID, clerkID string, uploadID uuid.UUID) ([]ReviewTxn, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	pgIDs := make([]pgtype.UUID, len(resultIDs))
+	for i, id := range resultIDs {
+		pgIDs[i] = utils.UUIDToPgtype(id)
+	}
+	rows, err := queries.GetReconciliationReviewTargets(ctx, generated.GetReconciliationReviewTargetsParams{
+		Column1:  pgIDs,
+		UploadID: utils.UUIDToPgtype(uploadID),
+		UserID:   clerkID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ReviewTxn, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, ReviewTxn{
+			ResultID:               utils.UUIDToUUID(row.ReconciliationID),
+			ResultType:             string(row.ResultType),
+			StatementTransactionID: utils.UUIDToUUIDPtr(row.StatementTransactionID),
+			AppTransactionID:       utils.UUIDToUUID(row.AppTransactionID),
+			AccountID:              utils.UUIDToUUID(row.AccountID),
+			ToAccountID:            utils.UUIDToUUIDPtr(row.ToAccountID),
+			UploadAccountID:        utils.UUIDToUUID(row.UploadAccountID),
+			Type:                   string(row.Type),
+			Amount:                 utils.NumericToFloat64(row.Amount),
+			Source:                 string(row.Source.TransactionSource),
+			ReconciliationStatus:   string(row.ReconciliationStatus.TransactionReconciliationStatus),
+			Deleted:                row.DeletedAt.Valid,
 		})
 	}
 	return out, nil
 }
 
+func (r *ReconRepository) MarkTransactionUserVerified(ctx context.Context, txnID uuid.UUID, stmtTxnID *uuid.UUID) error {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	return queries.MarkTransactionUserVerified(ctx, generated.MarkTransactionUserVerifiedParams{
+		ID:             utils.UUIDToPgtype(txnID),
+		StatementTxnID: utils.UUIDPtrToPgtype(stmtTxnID),
+	})
+}
+
+func (r *ReconRepository) RevertTransactionReconciliation(ctx context.Context, txnID uuid.UUID) error {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	return queries.RevertTransactionReconciliation(ctx, utils.UUIDToPgtype(txnID))
+}
+
+// RejectAutoCreatedTransaction soft-deletes a STATEMENT_AUTO transaction and reports
+// whether it was still live, i.e. whether its balance effect needs reversing.
+func (r *ReconRepository) RejectAutoCreatedTransaction(ctx context.Context, txnID uuid.UUID, clerkID string) (bool, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	n, err := queries.RejectAutoCreatedTransaction(ctx, generated.RejectAutoCreatedTransactionParams{
+		ID:        utils.UUIDToPgtype(txnID),
+		DeletedBy: utils.StringToPgtypeText(clerkID),
+	})
+	return n > 0, err
+}
+
+// RestoreAutoCreatedTransaction undoes a previous rejection of a STATEMENT_AUTO transaction
+// and reports whether anything was restored.
+func (r *ReconRepository) RestoreAutoCreatedTransaction(ctx context.Context, txnID uuid.UUID, stmtTxnID *uuid.UUID) (bool, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	n, err := queries.RestoreAutoCreatedTransaction(ctx, generated.RestoreAutoCreatedTransactionParams{
+		ID:             utils.UUIDToPgtype(txnID),
+		StatementTxnID: utils.UUIDPtrToPgtype(stmtTxnID),
+	})
+	return n > 0, err
+}
+
 func parsedTxnToBatchParam(row ParsedTxns) generated.InsertStatementTransactionsBatchParams {
-	isDup := false
+	isDup := row.IsDuplicate != nil && *row.IsDuplicate
 	return generated.InsertStatementTransactionsBatchParams{
 		UploadID:        utils.UUIDToPgtype(row.UploadId),
 		AccountID:       utils.UUIDToPgtype(row.AccountId),
@@ -567,10 +1025,513 @@ func parsedTxnToBatchParam(row ParsedTxns) generated.InsertStatementTransactions
 		Description:     utils.StringPtrToText(row.Description),
 		Amount:          utils.Float64PtrToNum(&row.Amount),
 		Type:            string(row.Type),
-		Balance:         utils.Float64PtrToNum(nil),
+		Balance:         utils.Float64PtrToNum(row.Balance),
 		ReferenceNumber: utils.StringPtrToText(row.ReferenceNumber),
 		RawRowHash:      *row.RawRowHash,
 		RowNumber:       int32(row.RowNumber),
 		IsDuplicate:     utils.ToPgBool(&isDup),
 	}
 }
+
+// GetAccountBank returns the bank ID and bank code of an account owned by the user.
+func (r *ReconRepository) GetAccountBank(ctx context.Context, accountID uuid.UUID, userID string) (uuid.UUID, string, error) {
+	row, err := r.queries.GetAccountBank(ctx, generated.GetAccountBankParams{
+		ID:     utils.UUIDToPgtype(accountID),
+		UserID: userID,
+	})
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	return utils.UUIDToUUID(row.BankID), utils.TextToString(row.Code), nil
+}
+
+// GetCustomStatementProfile returns the user's saved profile for a bank, or nil when none exists.
+func (r *ReconRepository) GetCustomStatementProfile(ctx context.Context, userID string, bankID uuid.UUID) (*StatementProfile, error) {
+	row, err := r.queries.GetStatementFormatProfile(ctx, generated.GetStatementFormatProfileParams{
+		UserID: userID,
+		BankID: utils.UUIDToPgtype(bankID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var profile StatementProfile
+	if err := json.Unmarshal(row.Profile, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *ReconRepository) ListCustomStatementProfiles(ctx context.Context, userID string) ([]CustomStatementProfile, error) {
+	rows, err := r.queries.ListStatementFormatProfiles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CustomStatementProfile, 0, len(rows))
+	for _, row := range rows {
+		item, err := rowToCustomStatementProfile(row)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *item)
+	}
+	return out, nil
+}
+
+func (r *ReconRepository) SaveCustomStatementProfile(ctx context.Context, userID string, bankID uuid.UUID, profile StatementProfile) (*CustomStatementProfile, error) {
+	raw, err := json.Marshal(profile)
+	if err != nil {
+		return nil, err
+	}
+	row, err := r.queries.UpsertStatementFormatProfile(ctx, generated.UpsertStatementFormatProfileParams{
+		UserID:  userID,
+		BankID:  utils.UUIDToPgtype(bankID),
+		Profile: raw,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rowToCustomStatementProfile(row)
+}
+
+func (r *ReconRepository) DeleteCustomStatementProfile(ctx context.Context, userID string, bankID uuid.UUID) error {
+	return r.queries.DeleteStatementFormatProfile(ctx, generated.DeleteStatementFormatProfileParams{
+		UserID: userID,
+		BankID: utils.UUIDToPgtype(bankID),
+	})
+}
+
+func rowToCustomStatementProfile(row generated.StatementFormatProfile) (*CustomStatementProfile, error) {
+	var profile StatementProfile
+	if err := json.Unmarshal(row.Profile, &profile); err != nil {
+		return nil, err
+	}
+	return &CustomStatementProfile{
+		ID:        utils.UUIDToUUID(row.ID),
+		BankId:    utils.UUIDToUUID(row.BankID),
+		Profile:   profile,
+		UpdatedAt: utils.TimestampToTimePtr(row.UpdatedAt),
+	}, nil
+}
+
+// GetResultForMatch loads a result with its statement row for manual matching.
+func (r *ReconRepository) GetResultForMatch(ctx context.Context, resultID uuid.UUID, clerkID string) (*MatchableResult, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	row, err := queries.GetReconciliationResultForMatch(ctx, generated.GetReconciliationResultForMatchParams{
+		ID:     utils.UUIDToPgtype(resultID),
+		UserID: clerkID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var txnDate *time.Time
+	if row.TransactionDate.Valid {
+		txnDate = &row.TransactionDate.Time
+	}
+	return &MatchableResult{
+		ID:         utils.UUIDToUUID(row.ID),
+		UploadID:   utils.UUIDToUUID(row.UploadID),
+		AccountID:  utils.UUIDToUUID(row.AccountID),
+		ResultType: string(row.ResultType),
+		Statement: StatementTransaction{
+			ID:              utils.UUIDToUUID(row.StatementTransactionID),
+			UploadID:        utils.UUIDToUUID(row.UploadID),
+			AccountID:       utils.UUIDToUUID(row.AccountID),
+			TransactionDate: txnDate,
+			Description:     utils.TextToStringPtr(row.Description),
+			Amount:          utils.NumericToFloat64(row.Amount),
+			Type:            row.Type,
+			ReferenceNumber: utils.TextToStringPtr(row.ReferenceNumber),
+		},
+		AppTransactionID: utils.UUIDToUUIDPtr(row.AppTransactionID),
+		AppSource:        string(row.AppSource.TransactionSource),
+		AppType:          string(row.AppType.TxnType),
+		AppAmount:        utils.NumericToFloat64(row.AppAmount),
+		AppDeleted:       row.AppDeletedAt.Valid,
+	}, nil
+}
+
+// GetAppTransactionForMatch loads a live, non-cash transaction owned by the user.
+func (r *ReconRepository) GetAppTransactionForMatch(ctx context.Context, txnID uuid.UUID, clerkID string) (*AppTransaction, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	row, err := queries.GetAppTransactionForMatch(ctx, generated.GetAppTransactionForMatchParams{
+		ID:     utils.UUIDToPgtype(txnID),
+		UserID: clerkID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &AppTransaction{
+		ID:              utils.UUIDToUUID(row.ID),
+		Amount:          utils.NumericToFloat64(row.Amount),
+		TransactionDate: utils.TimestampToTime(row.TransactionDate),
+		Type:            string(row.Type),
+		Description:     utils.TextToString(row.Description),
+		ReferenceNumber: utils.TextToString(row.ReferenceNumber),
+		AccountID:       utils.UUIDToUUID(row.AccountID),
+		StatementTxnID:  utils.UUIDToUUIDPtr(row.StatementTxnID),
+	}, nil
+}
+
+func (r *ReconRepository) UpdateResultManualMatch(ctx context.Context, resultID, appTxnID uuid.UUID, score float64, signals MatchSignals) error {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	signalsJSON, err := json.Marshal(signals)
+	if err != nil {
+		return err
+	}
+	return queries.UpdateReconciliationResultManualMatch(ctx, generated.UpdateReconciliationResultManualMatchParams{
+		ID:               utils.UUIDToPgtype(resultID),
+		AppTransactionID: utils.UUIDToPgtype(appTxnID),
+		ConfidenceScore:  utils.Float64PtrToNum(&score),
+		MatchSignals:     signalsJSON,
+	})
+}
+
+// DeleteNotInStatementResult drops the NOT_IN_STATEMENT flag for a transaction that has
+// since been matched to a row of the same upload.
+func (r *ReconRepository) DeleteNotInStatementResult(ctx context.Context, uploadID, appTxnID uuid.UUID) error {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	return queries.DeleteNotInStatementResult(ctx, generated.DeleteNotInStatementResultParams{
+		UploadID:         utils.UUIDToPgtype(uploadID),
+		AppTransactionID: utils.UUIDToPgtype(appTxnID),
+	})
+}
+
+func (r *ReconRepository) ListSystemResults(ctx context.Context, uploadID uuid.UUID) ([]SystemResult, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	rows, err := queries.ListSystemReconciliationResults(ctx, utils.UUIDToPgtype(uploadID))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SystemResult, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, SystemResult{
+			ID:                      utils.UUIDToUUID(row.ID),
+			ResultType:              string(row.ResultType),
+			StatementTransactionID:  utils.UUIDToUUIDPtr(row.StatementTransactionID),
+			AppTransactionID:        utils.UUIDToUUIDPtr(row.AppTransactionID),
+			AppSource:               string(row.AppSource.TransactionSource),
+			AppReconciliationStatus: string(row.AppReconciliationStatus.TransactionReconciliationStatus),
+			AppStatementTxnID:       utils.UUIDToUUIDPtr(row.AppStatementTxnID),
+		})
+	}
+	return out, nil
+}
+
+func (r *ReconRepository) DeleteSystemResults(ctx context.Context, uploadID uuid.UUID) (int64, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	return queries.DeleteSystemReconciliationResults(ctx, utils.UUIDToPgtype(uploadID))
+}
+
+func (r *ReconRepository) ListDecidedLinks(ctx context.Context, uploadID uuid.UUID) ([]DecidedLink, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	rows, err := queries.ListDecidedReconciliationLinks(ctx, utils.UUIDToPgtype(uploadID))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]DecidedLink, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, DecidedLink{
+			ResultType:             string(row.ResultType),
+			UserAction:             utils.TextToString(row.UserAction),
+			StatementTransactionID: utils.UUIDToUUIDPtr(row.StatementTransactionID),
+			AppTransactionID:       utils.UUIDToUUIDPtr(row.AppTransactionID),
+		})
+	}
+	return out, nil
+}
+
+// DiscardAutoCreatedTransactions soft-deletes STATEMENT_AUTO transactions and returns
+// the ones that were still live so their balance effect can be reversed.
+func (r *ReconRepository) DiscardAutoCreatedTransactions(ctx context.Context, txnIDs []uuid.UUID, clerkID string) ([]DiscardedTxn, error) {
+	if len(txnIDs) == 0 {
+		return nil, nil
+	}
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	pgIDs := make([]pgtype.UUID, len(txnIDs))
+	for i, id := range txnIDs {
+		pgIDs[i] = utils.UUIDToPgtype(id)
+	}
+	rows, err := queries.DiscardAutoCreatedTransactions(ctx, generated.DiscardAutoCreatedTransactionsParams{
+		Column1:   pgIDs,
+		DeletedBy: utils.StringToPgtypeText(clerkID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]DiscardedTxn, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, DiscardedTxn{
+			AccountID:   utils.UUIDToUUID(row.AccountID),
+			ToAccountID: utils.UUIDToUUIDPtr(row.ToAccountID),
+			Type:        string(row.Type),
+			Amount:      utils.NumericToFloat64(row.Amount),
+		})
+	}
+	return out, nil
+}
+
+// StartJobCheckpoint records an attempt for jobID and returns how far earlier attempts got.
+func (r *ReconRepository) StartJobCheckpoint(ctx context.Context, jobID string, uploadID uuid.UUID) (*JobCheckpoint, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	row, err := queries.StartReconciliationJobCheckpoint(ctx, generated.StartReconciliationJobCheckpointParams{
+		JobID:    jobID,
+		UploadID: utils.UUIDToPgtype(uploadID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	createdIDs := make([]uuid.UUID, 0, len(row.CreatedTxnIds))
+	for _, id := range row.CreatedTxnIds {
+		createdIDs = append(createdIDs, utils.UUIDToUUID(id))
+	}
+	return &JobCheckpoint{
+		Stage:           row.Stage,
+		Attempts:        int(row.Attempts),
+		ChunksCommitted: int(row.ChunksCommitted),
+		CreatedTxnIDs:   createdIDs,
+	}, nil
+}
+
+// AdvanceJobCheckpoint records one more committed chunk for jobID, expecting chunksCommitted
+// before it; false means another attempt advanced the job first.
+func (r *ReconRepository) AdvanceJobCheckpoint(ctx context.Context, jobID string, chunksCommitted int, createdIDs []uuid.UUID, final bool) (bool, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	pgIDs := make([]pgtype.UUID, len(createdIDs))
+	for i, id := range createdIDs {
+		pgIDs[i] = utils.UUIDToPgtype(id)
+	}
+	n, err := queries.AdvanceReconciliationJobCheckpoint(ctx, generated.AdvanceReconciliationJobCheckpointParams{
+		JobID:           jobID,
+		ChunksCommitted: int32(chunksCommitted),
+		CreatedTxnIds:   pgIDs,
+		Final:           final,
+	})
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *ReconRepository) CompleteJobCheckpoint(ctx context.Context, jobID string) error {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	return queries.CompleteReconciliationJobCheckpoint(ctx, jobID)
+}
+
+// ListAccountCoverage returns every upload of the account with the date range it covers.
+func (r *ReconRepository) ListAccountCoverage(ctx context.Context, accountID uuid.UUID, userID string) ([]CoverageUpload, error) {
+	rows, err := r.queries.ListAccountStatementCoverage(ctx, generated.ListAccountStatementCoverageParams{
+		AccountID: utils.UUIDToPgtype(accountID),
+		UserID:    userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CoverageUpload, 0, len(rows))
+	for _, row := range rows {
+		item := CoverageUpload{
+			UploadID:  utils.UUIDToUUID(row.ID),
+			FileName:  row.FileName,
+			RowCount:  int(row.RowCount),
+			CreatedAt: utils.TimestampToTimePtr(row.CreatedAt),
+		}
+		if row.StatementPeriodStart.Valid && row.StatementPeriodEnd.Valid {
+			item.From = utils.DateToTimePtr(row.StatementPeriodStart)
+			item.To = utils.DateToTimePtr(row.StatementPeriodEnd)
+		} else if row.MinDate.Valid && row.MaxDate.Valid {
+			from, to := row.MinDate.Time, row.MaxDate.Time
+			item.From, item.To = &from, &to
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// ListStoredStatementRows returns the account's imported, non-duplicate statement rows dated in [from, to).
+func (r *ReconRepository) ListStoredStatementRows(ctx context.Context, accountID uuid.UUID, from, to time.Time) ([]StoredStatementRow, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	rows, err := queries.ListAccountStatementRowsInRange(ctx, generated.ListAccountStatementRowsInRangeParams{
+		AccountID: utils.UUIDToPgtype(accountID),
+		FromDate:  utils.TimeToTimestamptz(from),
+		ToDate:    utils.TimeToTimestamptz(to),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]StoredStatementRow, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, StoredStatementRow{
+			TransactionDate: row.TransactionDate.Time,
+			Amount:          utils.NumericToFloat64(row.Amount),
+			Type:            row.Type,
+			ReferenceNumber: utils.TextToStringPtr(row.ReferenceNumber),
+			RawRowHash:      row.RawRowHash,
+		})
+	}
+	return out, nil
+}
+
+// GetScoringProfile returns the account's scoring profile, else the user's default one,
+// or nil when the user has neither.
+func (r *ReconRepository) GetScoringProfile(ctx context.Context, userID string, accountID uuid.UUID) (*ScoringProfile, error) {
+	row, err := r.queries.GetScoringProfile(ctx, generated.GetScoringProfileParams{
+		UserID:    userID,
+		AccountID: utils.UUIDToPgtype(accountID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var profile ScoringProfile
+	if err := json.Unmarshal(row.Profile, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *ReconRepository) ListScoringProfiles(ctx context.Context, userID string) ([]ScoringProfileRes, error) {
+	rows, err := r.queries.ListScoringProfiles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ScoringProfileRes, 0, len(rows))
+	for _, row := range rows {
+		item, err := rowToScoringProfileRes(row)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *item)
+	}
+	return out, nil
+}
+
+func (r *ReconRepository) SaveScoringProfile(ctx context.Context, userID string, accountID *uuid.UUID, profile ScoringProfile, autoCalibrate bool) (*ScoringProfileRes, error) {
+	raw, err := json.Marshal(profile)
+	if err != nil {
+		return nil, err
+	}
+	row, err := r.queries.UpsertScoringProfile(ctx, generated.UpsertScoringProfileParams{
+		UserID:        userID,
+		AccountID:     utils.UUIDPtrToPgtype(accountID),
+		Profile:       raw,
+		AutoCalibrate: autoCalibrate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rowToScoringProfileRes(row)
+}
+
+// SaveScoringCalibration records a calibration run; the profile is only replaced when
+// the saved one has auto_calibrate on.
+func (r *ReconRepository) SaveScoringCalibration(ctx context.Context, userID string, accountID *uuid.UUID, profile ScoringProfile, stats CalibrationStats) error {
+	rawProfile, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	rawStats, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	_, err = r.queries.SaveScoringCalibration(ctx, generated.SaveScoringCalibrationParams{
+		UserID:           userID,
+		AccountID:        utils.UUIDPtrToPgtype(accountID),
+		Profile:          rawProfile,
+		CalibrationStats: rawStats,
+		Learned:          stats.Learned,
+	})
+	return err
+}
+
+// ListCalibrationDecisions returns up to limit of the user's latest accepted and rejected matches.
+func (r *ReconRepository) ListCalibrationDecisions(ctx context.Context, userID string, limit int32) ([]CalibrationDecision, error) {
+	rows, err := r.queries.ListCalibrationDecisions(ctx, generated.ListCalibrationDecisionsParams{
+		UserID: userID,
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CalibrationDecision, 0, len(rows))
+	for _, row := range rows {
+		var signals MatchSignals
+		if err := json.Unmarshal(row.MatchSignals, &signals); err != nil {
+			continue
+		}
+		out = append(out, CalibrationDecision{
+			AccountID: utils.UUIDToUUID(row.AccountID),
+			Accepted:  utils.TextToString(row.UserAction) == "accepted",
+			Signals:   signals,
+		})
+	}
+	return out, nil
+}
+
+func rowToScoringProfileRes(row generated.ReconciliationScoringProfile) (*ScoringProfileRes, error) {
+	var profile ScoringProfile
+	if err := json.Unmarshal(row.Profile, &profile); err != nil {
+		return nil, err
+	}
+	res := &ScoringProfileRes{
+		ID:            utils.UUIDToUUID(row.ID),
+		AccountID:     utils.UUIDToUUIDPtr(row.AccountID),
+		Profile:       profile,
+		AutoCalibrate: row.AutoCalibrate,
+		CalibratedAt:  utils.TimestampToTimePtr(row.CalibratedAt),
+		UpdatedAt:     utils.TimestampToTimePtr(row.UpdatedAt),
+	}
+	if len(row.CalibrationStats) > 0 {
+		var stats CalibrationStats
+		if err := json.Unmarshal(row.CalibrationStats, &stats); err != nil {
+			return nil, err
+		}
+		res.CalibrationStats = &stats
+	}
+	return res, nil
+}
+
+// CountUncalibratedDecisions returns how many review decisions the user has made since
+// their last calibration.
+func (r *ReconRepository) CountUncalibratedDecisions(ctx context.Context, userID string) (int64, error) {
+	return r.queries.CountUncalibratedDecisions(ctx, userID)
+}
@@ -19,11 +19,13 @@ type Deps struct {
 	TaskService    reconTaskService
 	BalanceUpdater balanceApplier
 	UserService    userThresholdProvider
+	// StatementLLM reads PDF statements whose layout no profile matches; optional.
+	StatementLLM statementTableExtractor
 }
 
 func NewReconiliationModule(deps Deps) *Module {
 	repo := NewReconRepository(deps.Queries, deps.TxnManager)
-	service := NewReconService(repo, deps.TxnManager, deps.TaskService, deps.BalanceUpdater, deps.UserService)
+	service := NewReconService(repo, deps.TxnManager, deps.TaskService, deps.BalanceUpdater, deps.UserService, deps.StatementLLM)
 	handler := NewReconHandler(deps.Server, service)
 
 	return &Module{
@@ -43,6 +45,17 @@ func (m *Module) RegisterRoutes(g *echo.Group) {
 	g.GET("/reconciliation/uploads/:upload_id", m.handler.GetUploadByID, authMiddleware)
 	g.GET("/reconciliation/uploads/:upload_id/detail", m.handler.GetUploadDetail, authMiddleware)
 	g.GET("/reconciliation/uploads/:upload_id/results", m.handler.GetResults, authMiddleware)
+	g.GET("/reconciliation/uploads/:upload_id/export", m.handler.ExportReport, authMiddleware)
+	g.POST("/reconciliation/uploads/:upload_id/reprocess", m.handler.ReprocessUpload, authMiddleware)
 	g.PATCH("/reconciliation/results/status", m.handler.BulkUpdateResultStatus, authMiddleware)
+	g.GET("/reconciliation/results/:result_id/candidates", m.handler.GetMatchCandidates, authMiddleware)
+	g.POST("/reconciliation/results/:result_id/match", m.handler.ConfirmManualMatch, authMiddleware)
 	g.DELETE("/reconciliation/uploads/:upload_id", m.handler.DeleteUpload, authMiddleware)
+	g.GET("/reconciliation/accounts/:account_id/coverage", m.handler.GetAccountCoverage, authMiddleware)
+	g.GET("/reconciliation/profiles", m.handler.ListStatementProfiles, authMiddleware)
+	g.PUT("/reconciliation/profiles/:bank_id", m.handler.SaveStatementProfile, authMiddleware)
+	g.DELETE("/reconciliation/profiles/:bank_id", m.handler.DeleteStatementProfile, authMiddleware)
+	g.GET("/reconciliation/scoring-profiles", m.handler.ListScoringProfiles, authMiddleware)
+	g.PUT("/reconciliation/scoring-profiles", m.handler.SaveScoringProfile, authMiddleware)
+	g.POST("/reconciliation/scoring-profiles/recalibrate", m.handler.RecalibrateScoring, authMiddleware)
 }
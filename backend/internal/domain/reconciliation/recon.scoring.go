@@ -0,0 +1,317 @@
+package reconciliation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/errs"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/middleware"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/tasks"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// defaultScoringProfile is used until the user saves or calibrates one: a two-day
+// window, 1% and 3% amount steps, and date 40, amount 35, description 15, reference 10.
+var defaultScoringProfile = ScoringProfile{
+	DateWindowDays:     2,
+	AmountTolerancePct: 3,
+	DateWeight:         40,
+	AmountWeight:       35,
+	DescriptionWeight:  15,
+	ReferenceWeight:    10,
+}
+
+// Bounds a calibrated profile stays within, matching what SaveScoringProfileReq accepts.
+const (
+	maxScoringWindowDays  = 7
+	maxAmountTolerancePct = 10.0
+)
+
+const (
+	// calibrationMinAccepted is how many accepted matches a calibration needs before it
+	// moves the date window and amount tolerance off the defaults.
+	calibrationMinAccepted = 20
+	// calibrationMinRejected is how many rejections it needs before it reweighs signals.
+	calibrationMinRejected = 5
+	// calibrationMaxDecisions caps how many of the latest decisions are read.
+	calibrationMaxDecisions = 2000
+	// calibrationRequeueDecisions is how many new decisions a review has to bring the
+	// user to before it queues another calibration.
+	calibrationRequeueDecisions = 10
+)
+
+// scoringSignals names the weighted signals in the order ScoringProfile.weights returns them.
+var scoringSignals = [4]string{"date", "amount", "description", "reference"}
+
+func (p ScoringProfile) weights() [4]int {
+	return [4]int{p.DateWeight, p.AmountWeight, p.DescriptionWeight, p.ReferenceWeight}
+}
+
+func (p *ScoringProfile) setWeights(w [4]int) {
+	p.DateWeight, p.AmountWeight, p.DescriptionWeight, p.ReferenceWeight = w[0], w[1], w[2], w[3]
+}
+
+// weightShare is num/den of weight, rounded to the nearest point.
+func weightShare(weight, num, den int) int {
+	return (weight*num + den/2) / den
+}
+
+// scoringProfile returns the profile an account's matches are scored with: the
+// account's own, else the user's default, else the built-in one.
+func (s *ReconService) scoringProfile(ctx context.Context, userID string, accountID uuid.UUID) (ScoringProfile, error) {
+	p, err := s.repo.GetScoringProfile(ctx, userID, accountID)
+	if err != nil {
+		return ScoringProfile{}, err
+	}
+	if p == nil {
+		return defaultScoringProfile, nil
+	}
+	return *p, nil
+}
+
+func (s *ReconService) ListScoringProfiles(c echo.Context, payload *ListScoringProfilesReq, clerkId string) (*ListScoringProfilesRes, error) {
+	profiles, err := s.repo.ListScoringProfiles(c.Request().Context(), clerkId)
+	if err != nil {
+		return nil, err
+	}
+	return &ListScoringProfilesRes{Default: defaultScoringProfile, Profiles: profiles}, nil
+}
+
+func (s *ReconService) SaveScoringProfile(c echo.Context, payload *SaveScoringProfileReq, clerkId string) (*ScoringProfileRes, error) {
+	ctx := c.Request().Context()
+	profile := ScoringProfile{
+		DateWindowDays:     payload.DateWindowDays,
+		AmountTolerancePct: payload.AmountTolerancePct,
+		DateWeight:         payload.DateWeight,
+		AmountWeight:       payload.AmountWeight,
+		DescriptionWeight:  payload.DescriptionWeight,
+		ReferenceWeight:    payload.ReferenceWeight,
+	}
+	if profile.DateWeight+profile.AmountWeight+profile.DescriptionWeight+profile.ReferenceWeight != 100 {
+		return nil, errs.NewBadRequestError("Scoring weights must add up to 100", false, nil, nil, nil)
+	}
+	if payload.AccountId != nil {
+		if _, err := s.repo.GetAccountType(ctx, *payload.AccountId, clerkId); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, errs.NewNotFoundError("Account not found", false, nil)
+			}
+			return nil, err
+		}
+	}
+	autoCalibrate := true
+	if payload.AutoCalibrate != nil {
+		autoCalibrate = *payload.AutoCalibrate
+	}
+	return s.repo.SaveScoringProfile(ctx, clerkId, payload.AccountId, profile, autoCalibrate)
+}
+
+func (s *ReconService) RecalibrateScoring(c echo.Context, payload *RecalibrateScoringReq, clerkId string) (*RecalibrateScoringRes, error) {
+	if s.taskService == nil {
+		return nil, fmt.Errorf("task service not configured")
+	}
+	log := middleware.GetLogger(c)
+	if err := s.taskService.EnqueueReconCalibration(c.Request().Context(), tasks.ReconCalibrationPayload{UserID: clerkId}, log); err != nil {
+		return nil, fmt.Errorf("failed to enqueue calibration task: %w", err)
+	}
+	return &RecalibrateScoringRes{Status: "QUEUED"}, nil
+}
+
+// enqueueCalibration queues a recalibration after the user reviews results, once
+// enough decisions have been made since the last one. A failure is only logged; the
+// next review or an explicit recalibrate retries it.
+func (s *ReconService) enqueueCalibration(ctx context.Context, userID string, log *zerolog.Logger) {
+	if s.taskService == nil {
+		return
+	}
+	pending, err := s.repo.CountUncalibratedDecisions(ctx, userID)
+	if err != nil {
+		log.Warn().Err(err).Msg("[recon] failed to count decisions since the last calibration")
+		return
+	}
+	if pending < calibrationRequeueDecisions {
+		return
+	}
+	if err := s.taskService.EnqueueReconCalibration(ctx, tasks.ReconCalibrationPayload{UserID: userID}, log); err != nil {
+		log.Warn().Err(err).Msg("[recon] failed to enqueue scoring calibration")
+	}
+}
+
+// RunCalibrationJob relearns the user's default scoring profile from all their review
+// decisions, and each account's from that account's own once there are enough of them.
+// While too few decisions are in to learn from, only the default profile's stats are
+// refreshed and its weights are left as they are.
+func (s *ReconService) RunCalibrationJob(ctx context.Context, payload tasks.ReconCalibrationPayload, log *zerolog.Logger) (*CalibrationStats, error) {
+	decisions, err := s.repo.ListCalibrationDecisions(ctx, payload.UserID, calibrationMaxDecisions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch review decisions: %w", err)
+	}
+
+	profile, stats := calibrateScoring(decisions)
+	if err := s.repo.SaveScoringCalibration(ctx, payload.UserID, nil, profile, stats); err != nil {
+		return nil, fmt.Errorf("failed to save scoring calibration: %w", err)
+	}
+
+	byAccount := make(map[uuid.UUID][]CalibrationDecision)
+	for _, d := range decisions {
+		byAccount[d.AccountID] = append(byAccount[d.AccountID], d)
+	}
+	accounts := 0
+	for accountID, ds := range byAccount {
+		accountProfile, accountStats := calibrateScoring(ds)
+		if !accountStats.Learned {
+			continue
+		}
+		if err := s.repo.SaveScoringCalibration(ctx, payload.UserID, &accountID, accountProfile, accountStats); err != nil {
+			return nil, fmt.Errorf("failed to save account scoring calibration: %w", err)
+		}
+		accounts++
+	}
+
+	log.Info().
+		Int("accepted", stats.Accepted).
+		Int("rejected", stats.Rejected).
+		Bool("learned", stats.Learned).
+		Int("accounts_calibrated", accounts).
+		Msg("[recon] scoring calibration complete")
+	return &stats, nil
+}
+
+// calibrateScoring learns a profile from review decisions. The date window and amount
+// tolerance widen to cover 95% of what the user accepted, never narrower than the
+// defaults; each weight moves with how much more present its signal was on accepted
+// matches than on rejected ones, then the weights are rescaled to 100.
+func calibrateScoring(decisions []CalibrationDecision) (ScoringProfile, CalibrationStats) {
+	profile := defaultScoringProfile
+	var stats CalibrationStats
+	var dateDiffs, amountPcts []float64
+	var acceptedSum, rejectedSum [4]float64
+	for _, d := range decisions {
+		// Card bill payments are paired on amount alone and say nothing about scoring.
+		if d.Signals.Transfer {
+			continue
+		}
+		strengths := signalStrengths(d.Signals)
+		if d.Accepted {
+			stats.Accepted++
+			dateDiffs = append(dateDiffs, float64(d.Signals.DateDiffDays))
+			amountPcts = append(amountPcts, d.Signals.AmountDiffPct)
+			for i, v := range strengths {
+				acceptedSum[i] += v
+			}
+		} else {
+			stats.Rejected++
+			for i, v := range strengths {
+				rejectedSum[i] += v
+			}
+		}
+	}
+
+	stats.Signals = make([]SignalCalibration, len(scoringSignals))
+	for i, name := range scoringSignals {
+		sc := SignalCalibration{Signal: name}
+		if stats.Accepted > 0 {
+			sc.AcceptedMean = roundTo(acceptedSum[i]/float64(stats.Accepted), 3)
+		}
+		if stats.Rejected > 0 {
+			sc.RejectedMean = roundTo(rejectedSum[i]/float64(stats.Rejected), 3)
+		}
+		if stats.Accepted > 0 && stats.Rejected > 0 {
+			sc.Separation = roundTo(sc.AcceptedMean-sc.RejectedMean, 3)
+		}
+		stats.Signals[i] = sc
+	}
+	if stats.Accepted > 0 {
+		stats.AcceptedDateDiffP95 = int(math.Ceil(percentile(dateDiffs, 0.95)))
+		stats.AcceptedAmountDiffPctP95 = roundTo(percentile(amountPcts, 0.95), 2)
+	}
+
+	if stats.Accepted < calibrationMinAccepted {
+		return profile, stats
+	}
+	stats.Learned = true
+	profile.DateWindowDays = min(max(stats.AcceptedDateDiffP95, defaultScoringProfile.DateWindowDays), maxScoringWindowDays)
+	// Rounded up to the next half percent so the p95 match itself still scores.
+	tolerance := math.Ceil(stats.AcceptedAmountDiffPctP95*2) / 2
+	profile.AmountTolerancePct = math.Min(math.Max(tolerance, defaultScoringProfile.AmountTolerancePct), maxAmountTolerancePct)
+
+	if stats.Rejected >= calibrationMinRejected {
+		stats.WeightsLearned = true
+		profile.setWeights(reweigh(defaultScoringProfile.weights(), stats.Signals))
+	}
+	return profile, stats
+}
+
+// signalStrengths rates each signal of a match from 0 to 1 off its raw measurements, so
+// decisions scored under different profiles compare alike.
+func signalStrengths(ms MatchSignals) [4]float64 {
+	date := math.Max(0, 1-float64(ms.DateDiffDays)/float64(maxScoringWindowDays+1))
+	amount := 1.0
+	if ms.AmountDiff != 0 {
+		amount = math.Max(0, 1-ms.AmountDiffPct/maxAmountTolerancePct)
+	}
+	description := ms.DescriptionSimilarity
+	if ms.CounterpartyMatch {
+		description = math.Max(description, 2.0/3)
+	}
+	var reference float64
+	if ms.ReferenceMatch {
+		reference = 1
+	}
+	return [4]float64{date, amount, description, reference}
+}
+
+// reweigh scales each base weight by 1 plus its signal's separation, held to half and
+// one and a half times, and rescales the result to add up to 100.
+func reweigh(base [4]int, signals []SignalCalibration) [4]int {
+	var raw [4]float64
+	var sum float64
+	for i := range raw {
+		factor := math.Min(math.Max(1+signals[i].Separation, 0.5), 1.5)
+		raw[i] = float64(base[i]) * factor
+		sum += raw[i]
+	}
+	if sum == 0 {
+		return base
+	}
+
+	// Largest remainder, so the rounded weights still add up to exactly 100.
+	var out [4]int
+	order := make([]int, len(raw))
+	total := 0
+	for i := range raw {
+		raw[i] = raw[i] * 100 / sum
+		out[i] = int(raw[i])
+		total += out[i]
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return raw[order[a]]-float64(out[order[a]]) > raw[order[b]]-float64(out[order[b]])
+	})
+	for k := 0; total < 100; k++ {
+		out[order[k%len(order)]]++
+		total++
+	}
+	return out
+}
+
+// percentile returns the q-th quantile of values by the nearest-rank method.
+func percentile(values []float64, q float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	idx := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[max(idx, 0)]
+}
+
+func roundTo(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
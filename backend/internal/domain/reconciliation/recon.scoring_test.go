@@ -0,0 +1,198 @@
+package reconciliation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/tasks"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// calibrationRepo serves review decisions and records the calibrations saved from them.
+type calibrationRepo struct {
+	reconRepository
+	decisions    []CalibrationDecision
+	uncalibrated int64
+	saved        map[uuid.UUID]CalibrationStats
+}
+
+func newCalibrationRepo(decisions []CalibrationDecision) *calibrationRepo {
+	return &calibrationRepo{decisions: decisions, saved: make(map[uuid.UUID]CalibrationStats)}
+}
+
+func (f *calibrationRepo) ListCalibrationDecisions(context.Context, string, int32) ([]CalibrationDecision, error) {
+	return f.decisions, nil
+}
+
+// SaveScoringCalibration keys the user's default profile under uuid.Nil.
+func (f *calibrationRepo) SaveScoringCalibration(_ context.Context, _ string, accountID *uuid.UUID, _ ScoringProfile, stats CalibrationStats) error {
+	key := uuid.Nil
+	if accountID != nil {
+		key = *accountID
+	}
+	f.saved[key] = stats
+	return nil
+}
+
+func (f *calibrationRepo) CountUncalibratedDecisions(context.Context, string) (int64, error) {
+	return f.uncalibrated, nil
+}
+
+// calibrationTasks counts the calibrations the service queues.
+type calibrationTasks struct {
+	reconTaskService
+	queued int
+}
+
+func (f *calibrationTasks) EnqueueReconCalibration(context.Context, tasks.ReconCalibrationPayload, *zerolog.Logger) error {
+	f.queued++
+	return nil
+}
+
+// decisions builds n review decisions on account, each four days and 4% off its
+// statement row; accepted ones also matched on reference.
+func decisions(account uuid.UUID, accepted bool, n int) []CalibrationDecision {
+	out := make([]CalibrationDecision, n)
+	for i := range out {
+		out[i] = CalibrationDecision{
+			AccountID: account,
+			Accepted:  accepted,
+			Signals:   MatchSignals{DateDiffDays: 4, AmountDiff: 4, AmountDiffPct: 4, ReferenceMatch: accepted},
+		}
+	}
+	return out
+}
+
+func TestCalibrateScoring(t *testing.T) {
+	account := uuid.New()
+	tests := []struct {
+		name        string
+		decisions   []CalibrationDecision
+		wantLearned bool
+		wantWeights bool
+		wantProfile ScoringProfile
+	}{
+		{
+			name:        "too few accepted keeps the defaults",
+			decisions:   decisions(account, true, calibrationMinAccepted-1),
+			wantProfile: defaultScoringProfile,
+		},
+		{
+			name:        "card bill payments do not count",
+			decisions:   append(decisions(account, true, 1), transferDecisions(account, calibrationMinAccepted)...),
+			wantProfile: defaultScoringProfile,
+		},
+		{
+			name:        "enough accepted widens the window and tolerance",
+			decisions:   decisions(account, true, calibrationMinAccepted),
+			wantLearned: true,
+			wantProfile: ScoringProfile{DateWindowDays: 4, AmountTolerancePct: 4, DateWeight: 40, AmountWeight: 35, DescriptionWeight: 15, ReferenceWeight: 10},
+		},
+		{
+			name:        "enough rejected reweighs the signals",
+			decisions:   append(decisions(account, true, calibrationMinAccepted), decisions(account, false, calibrationMinRejected)...),
+			wantLearned: true,
+			wantWeights: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, stats := calibrateScoring(tt.decisions)
+			if stats.Learned != tt.wantLearned || stats.WeightsLearned != tt.wantWeights {
+				t.Fatalf("learned = %v, weights learned = %v; want %v, %v", stats.Learned, stats.WeightsLearned, tt.wantLearned, tt.wantWeights)
+			}
+			w := profile.weights()
+			if w[0]+w[1]+w[2]+w[3] != 100 {
+				t.Errorf("weights %v add up to %d, want 100", w, w[0]+w[1]+w[2]+w[3])
+			}
+			if tt.wantWeights {
+				if profile.ReferenceWeight <= defaultScoringProfile.ReferenceWeight {
+					t.Errorf("reference weight = %d, want it raised above %d since only accepted matches had it", profile.ReferenceWeight, defaultScoringProfile.ReferenceWeight)
+				}
+				return
+			}
+			if profile != tt.wantProfile {
+				t.Errorf("calibrateScoring() = %+v, want %+v", profile, tt.wantProfile)
+			}
+		})
+	}
+}
+
+func transferDecisions(account uuid.UUID, n int) []CalibrationDecision {
+	out := decisions(account, true, n)
+	for i := range out {
+		out[i].Signals.Transfer = true
+	}
+	return out
+}
+
+func TestRunCalibrationJob(t *testing.T) {
+	busy, quiet := uuid.New(), uuid.New()
+	tests := []struct {
+		name         string
+		decisions    []CalibrationDecision
+		wantLearned  bool
+		wantAccounts []uuid.UUID
+	}{
+		{
+			name:      "too few decisions only refreshes the default's stats",
+			decisions: decisions(busy, true, 3),
+		},
+		{
+			name:         "only accounts with enough decisions get their own profile",
+			decisions:    append(decisions(busy, true, calibrationMinAccepted), decisions(quiet, true, 2)...),
+			wantLearned:  true,
+			wantAccounts: []uuid.UUID{busy},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newCalibrationRepo(tt.decisions)
+			svc := &ReconService{repo: repo}
+
+			if _, err := svc.RunCalibrationJob(context.Background(), tasks.ReconCalibrationPayload{UserID: "user_1"}, &testLog); err != nil {
+				t.Fatalf("RunCalibrationJob() error = %v", err)
+			}
+			stats, ok := repo.saved[uuid.Nil]
+			if !ok {
+				t.Fatal("default profile's calibration was not saved")
+			}
+			if stats.Learned != tt.wantLearned {
+				t.Errorf("default profile saved with learned = %v, want %v", stats.Learned, tt.wantLearned)
+			}
+			if len(repo.saved) != len(tt.wantAccounts)+1 {
+				t.Errorf("saved %d calibrations, want the default and %d accounts", len(repo.saved), len(tt.wantAccounts))
+			}
+			for _, id := range tt.wantAccounts {
+				if !repo.saved[id].Learned {
+					t.Errorf("account %s was not calibrated", id)
+				}
+			}
+		})
+	}
+}
+
+func TestEnqueueCalibration(t *testing.T) {
+	tests := []struct {
+		name         string
+		uncalibrated int64
+		wantQueued   int
+	}{
+		{"a few new decisions", calibrationRequeueDecisions - 1, 0},
+		{"enough new decisions", calibrationRequeueDecisions, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newCalibrationRepo(nil)
+			repo.uncalibrated = tt.uncalibrated
+			taskSvc := &calibrationTasks{}
+			svc := &ReconService{repo: repo, taskService: taskSvc}
+
+			svc.enqueueCalibration(context.Background(), "user_1", &testLog)
+			if taskSvc.queued != tt.wantQueued {
+				t.Errorf("queued %d calibrations, want %d", taskSvc.queued, tt.wantQueued)
+			}
+		})
+	}
+}
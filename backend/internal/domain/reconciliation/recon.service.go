@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"mime/multipart"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -17,34 +20,28 @@ import (
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/tasks"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/utils"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog"
-	"github.com/xuri/excelize/v2"
-)
-
-const (
-	colTxnDate     = 1
-	colDescription = 3
-	colChqRefNo    = 4
-	colAmount      = 5
-	colDrCr        = 6
 )
 
 type ReconService struct {
 	repo           reconRepository
-	tm             *database.TxManager
+	tm             txRunner
 	taskService    reconTaskService
 	balanceUpdater balanceApplier
 	userService    userThresholdProvider
+	statementLLM   statementTableExtractor
 }
 
-func NewReconService(repo reconRepository, tm *database.TxManager, taskService reconTaskService, balanceUpdater balanceApplier, userService userThresholdProvider) *ReconService {
+func NewReconService(repo reconRepository, tm *database.TxManager, taskService reconTaskService, balanceUpdater balanceApplier, userService userThresholdProvider, statementLLM statementTableExtractor) *ReconService {
 	return &ReconService{
 		repo:           repo,
 		tm:             tm,
 		taskService:    taskService,
 		balanceUpdater: balanceUpdater,
 		userService:    userService,
+		statementLLM:   statementLLM,
 	}
 }
 
@@ -82,14 +79,417 @@ func (s *ReconService) GetResults(c echo.Context, payload *GetResultsReq, clerkI
 	return s.repo.GetResultsByUploadID(c.Request().Context(), payload.UploadId, pageSize, offset)
 }
 
+// BulkUpdateResultStatus records the user's decision and applies it to the linked app
+// transactions in the same DB transaction: accepting verifies them against the statement
+// row, rejecting unlinks them or, for rows auto-created from the statement, deletes them
+// and reverses their balance effect. The decisions then feed a scoring recalibration.
 func (s *ReconService) BulkUpdateResultStatus(c echo.Context, payload *BulkUpdateResultStatusReq, clerkId string) (*BulkUpdateResultStatusRes, error) {
-	updated, err := s.repo.BulkUpdateResultStatus(c.Request().Context(), payload.ResultIds, payload.UserAction, clerkId, payload.UploadId)
+	if s.tm == nil {
+		return nil, fmt.Errorf("tx manager not configured")
+	}
+	log := middleware.GetLogger(c)
+	var updated []UpdateResultStatusRes
+	err := s.tm.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		targets, err := s.repo.GetReviewTargets(ctx, payload.ResultIds, clerkId, payload.UploadId)
+		if err != nil {
+			return err
+		}
+		updated, err = s.repo.BulkUpdateResultStatus(ctx, payload.ResultIds, payload.UserAction, clerkId, payload.UploadId)
+		if err != nil {
+			return err
+		}
+		return s.applyReviewDecision(ctx, clerkId, payload.UserAction, targets)
+	}, log)
 	if err != nil {
 		return nil, err
 	}
+	s.enqueueCalibration(c.Request().Context(), clerkId, log)
 	return &BulkUpdateResultStatusRes{Updated: updated}, nil
 }
 
+// applyReviewDecision updates each affected app transaction and applies the net balance
+// change per account. Rows created from this statement (MISSING_IN_APP + STATEMENT_AUTO)
+// are deleted on reject and restored on a later accept; an accepted card bill payment
+// candidate becomes a transfer; everything else is only relinked.
+func (s *ReconService) applyReviewDecision(ctx context.Context, clerkId, userAction string, targets []ReviewTxn) error {
+	deltas := make(map[uuid.UUID]*accountDelta)
+	addDelta := func(t ReviewTxn, sign float64) {
+		addTxnDeltas(deltas, t.AccountID, t.ToAccountID, generated.TxnType(t.Type), t.Amount, sign)
+	}
+
+	for _, t := range targets {
+		if t.ResultType == string(generated.ReconciliationResultTypeNOTINSTATEMENT) {
+			continue
+		}
+		autoCreated := t.ResultType == string(generated.ReconciliationResultTypeMISSINGINAPP) &&
+			t.Source == string(generated.TransactionSourceSTATEMENTAUTO)
+		// The savings leg of a card bill payment is verified against its own account's
+		// statement, never against the card's.
+		otherLeg := t.AccountID != t.UploadAccountID
+		if otherLeg && !autoCreated {
+			// An unconfirmed bill payment becomes the transfer to the card once accepted.
+			if userAction == "accepted" && !t.Deleted && t.ToAccountID == nil &&
+				t.ResultType == string(generated.ReconciliationResultTypeLOWCONFIDENCEMATCH) {
+				converted, err := s.repo.MarkTransactionTransfer(ctx, t.AppTransactionID, t.UploadAccountID)
+				if err != nil {
+					return err
+				}
+				if converted {
+					addDelta(t, -1)
+					addTxnDeltas(deltas, t.AccountID, &t.UploadAccountID, generated.TxnType(t.Type), t.Amount, 1)
+				}
+			}
+			continue
+		}
+
+		switch userAction {
+		case "accepted":
+			if t.Deleted {
+				if !autoCreated {
+					continue
+				}
+				restored, err := s.repo.RestoreAutoCreatedTransaction(ctx, t.AppTransactionID, t.StatementTransactionID)
+				if err != nil {
+					return err
+				}
+				if restored {
+					addDelta(t, 1)
+				}
+				continue
+			}
+			if otherLeg {
+				continue
+			}
+			if err := s.repo.MarkTransactionUserVerified(ctx, t.AppTransactionID, t.StatementTransactionID); err != nil {
+				return err
+			}
+
+		case "rejected":
+			if t.Deleted {
+				continue
+			}
+			if autoCreated {
+				rejected, err := s.repo.RejectAutoCreatedTransaction(ctx, t.AppTransactionID, clerkId)
+				if err != nil {
+					return err
+				}
+				if rejected {
+					addDelta(t, -1)
+				}
+				continue
+			}
+			if err := s.repo.RevertTransactionReconciliation(ctx, t.AppTransactionID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.applyAccountDeltas(ctx, clerkId, deltas)
+}
+
+// manualMatchWindowDays is the default ± day range searched for manual match candidates,
+// wider than the default scoring window so late-posted entries still show up.
+const manualMatchWindowDays = 7
+
+// manualMatchCandidateLimit is the default number of candidates returned.
+const manualMatchCandidateLimit = 10
+
+// isManuallyMatchable reports whether a result type can be relinked by the user.
+func isManuallyMatchable(resultType string) bool {
+	switch generated.ReconciliationResultType(resultType) {
+	case generated.ReconciliationResultTypeLOWCONFIDENCEMATCH, generated.ReconciliationResultTypeMISSINGINAPP:
+		return true
+	}
+	return false
+}
+
+// GetMatchCandidates ranks unreconciled app transactions around a result's statement row
+// with scoreMatch so the user can pick the right one.
+func (s *ReconService) GetMatchCandidates(c echo.Context, payload *GetMatchCandidatesReq, clerkId string) (*MatchCandidatesRes, error) {
+	ctx := c.Request().Context()
+	res, err := s.repo.GetResultForMatch(ctx, payload.ResultId, clerkId)
+	if err != nil {
+		return nil, err
+	}
+	if !isManuallyMatchable(res.ResultType) {
+		return nil, errs.NewBadRequestError("Only low-confidence and missing-in-app results can be matched manually", false, nil, nil, nil)
+	}
+	st := res.Statement
+	if st.TransactionDate == nil {
+		return nil, errs.NewBadRequestError("Statement row has no transaction date", false, nil, nil, nil)
+	}
+	window := payload.WindowDays
+	if window <= 0 {
+		window = manualMatchWindowDays
+	}
+	limit := payload.Limit
+	if limit <= 0 {
+		limit = manualMatchCandidateLimit
+	}
+
+	appTxns, err := s.repo.GetAppTransactionsInDateRange(ctx, res.AccountID, st.TransactionDate.AddDate(0, 0, -window), st.TransactionDate.AddDate(0, 0, window))
+	if err != nil {
+		return nil, err
+	}
+	scoring, err := s.scoringProfile(ctx, clerkId, res.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	stmtDesc, narr := statementNarration(st)
+
+	candidates := make([]ManualMatchCandidate, 0, len(appTxns))
+	for i := range appTxns {
+		at := &appTxns[i]
+		if at.IsExcluded || at.Type != st.Type {
+			continue
+		}
+		if res.AppTransactionID != nil && *res.AppTransactionID == at.ID &&
+			res.AppSource == string(generated.TransactionSourceSTATEMENTAUTO) {
+			continue
+		}
+		signals, score := scoreMatch(scoring, st.Amount, stmtDesc, narr, *st.TransactionDate, at)
+		candidates = append(candidates, ManualMatchCandidate{
+			AppTransactionID: at.ID,
+			TransactionDate:  at.TransactionDate,
+			Description:      at.Description,
+			Amount:           at.Amount,
+			Type:             at.Type,
+			ReferenceNumber:  at.ReferenceNumber,
+			Score:            score,
+			MatchSignals:     signals,
+		})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.MatchSignals.DateDiffDays != b.MatchSignals.DateDiffDays {
+			return a.MatchSignals.DateDiffDays < b.MatchSignals.DateDiffDays
+		}
+		return a.MatchSignals.AmountDiff < b.MatchSignals.AmountDiff
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	return &MatchCandidatesRes{
+		ResultID:               res.ID,
+		StatementTransactionID: st.ID,
+		WindowDays:             window,
+		Candidates:             candidates,
+	}, nil
+}
+
+// ConfirmManualMatch links a result's statement row to the chosen app transaction. A
+// transaction auto-created for the row is deleted with its balance effect reversed, a
+// previously suggested one is unlinked, and the result becomes MANUALLY_MATCHED.
+func (s *ReconService) ConfirmManualMatch(c echo.Context, payload *ConfirmManualMatchReq, clerkId string) (*ConfirmManualMatchRes, error) {
+	if s.tm == nil {
+		return nil, fmt.Errorf("tx manager not configured")
+	}
+	log := middleware.GetLogger(c)
+	var out *ConfirmManualMatchRes
+	err := s.tm.WithTx(c.Request().Context(), func(ctx context.Context) error {
+		res, err := s.repo.GetResultForMatch(ctx, payload.ResultId, clerkId)
+		if err != nil {
+			return err
+		}
+		if !isManuallyMatchable(res.ResultType) {
+			return errs.NewBadRequestError("Only low-confidence and missing-in-app results can be matched manually", false, nil, nil, nil)
+		}
+		st := res.Statement
+		if st.TransactionDate == nil {
+			return errs.NewBadRequestError("Statement row has no transaction date", false, nil, nil, nil)
+		}
+
+		at, err := s.repo.GetAppTransactionForMatch(ctx, payload.AppTransactionId, clerkId)
+		if err != nil {
+			return err
+		}
+		if at.AccountID != res.AccountID {
+			return errs.NewBadRequestError("Transaction belongs to a different account than the statement", false, nil, nil, nil)
+		}
+		if at.Type != st.Type {
+			return errs.NewBadRequestError("Transaction type does not match the statement row", false, nil, nil, nil)
+		}
+		if at.StatementTxnID != nil && *at.StatementTxnID != st.ID {
+			return errs.NewBadRequestError("Transaction is already reconciled against another statement row", false, nil, nil, nil)
+		}
+
+		var removed *uuid.UUID
+		if prev := res.AppTransactionID; prev != nil && *prev != at.ID && !res.AppDeleted {
+			autoCreated := res.ResultType == string(generated.ReconciliationResultTypeMISSINGINAPP) &&
+				res.AppSource == string(generated.TransactionSourceSTATEMENTAUTO)
+			if autoCreated {
+				rejected, err := s.repo.RejectAutoCreatedTransaction(ctx, *prev, clerkId)
+				if err != nil {
+					return err
+				}
+				if rejected {
+					removed = prev
+					if s.balanceUpdater != nil {
+						income, expense, balance := txnBalanceDeltas(generated.TxnType(res.AppType), res.AppAmount)
+						if err := s.balanceUpdater.ApplyBatch(ctx, clerkId, res.AccountID, -income, -expense, -balance); err != nil {
+							return err
+						}
+					}
+				}
+			} else if err := s.repo.RevertTransactionReconciliation(ctx, *prev); err != nil {
+				return err
+			}
+		}
+
+		scoring, err := s.scoringProfile(ctx, clerkId, res.AccountID)
+		if err != nil {
+			return err
+		}
+		stmtDesc, narr := statementNarration(st)
+		signals, score := scoreMatch(scoring, st.Amount, stmtDesc, narr, *st.TransactionDate, at)
+
+		if err := s.repo.MarkTransactionUserVerified(ctx, at.ID, &st.ID); err != nil {
+			return err
+		}
+		if err := s.repo.UpdateResultManualMatch(ctx, res.ID, at.ID, float64(score), signals); err != nil {
+			return err
+		}
+		if err := s.repo.DeleteNotInStatementResult(ctx, res.UploadID, at.ID); err != nil {
+			return err
+		}
+
+		out = &ConfirmManualMatchRes{
+			ResultID:             res.ID,
+			AppTransactionID:     at.ID,
+			ResultType:           string(generated.ReconciliationResultTypeMANUALLYMATCHED),
+			ConfidenceScore:      float64(score),
+			MatchSignals:         signals,
+			RemovedTransactionID: removed,
+		}
+		return nil
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// reconciliationThreshold is the user's saved auto-verify threshold, 70 when unavailable.
+func (s *ReconService) reconciliationThreshold(ctx context.Context, userID string) int {
+	threshold := 70
+	if s.userService != nil {
+		if t, err := s.userService.GetReconciliationThreshold(ctx, userID); err == nil {
+			threshold = t
+		}
+	}
+	return threshold
+}
+
+// ReprocessUpload clears the results the matcher produced for an upload and enqueues a
+// fresh reconciliation job. Transactions those results auto-verified are unlinked and
+// ones they auto-created are removed with their balance effect reversed, so the new run
+// can redo them. Results the user already accepted, rejected or matched are kept.
+func (s *ReconService) ReprocessUpload(c echo.Context, payload *ReprocessUploadReq, clerkId string) (*ReprocessUploadRes, error) {
+	if s.tm == nil {
+		return nil, fmt.Errorf("tx manager not configured")
+	}
+	if s.taskService == nil {
+		return nil, fmt.Errorf("task service not configured")
+	}
+	ctx := c.Request().Context()
+	log := middleware.GetLogger(c)
+
+	upload, err := s.repo.GetUploadByID(ctx, &GetUploadByIDReq{UploadId: payload.UploadId}, clerkId)
+	if err != nil {
+		return nil, err
+	}
+	threshold := s.reconciliationThreshold(ctx, clerkId)
+	if payload.ReconciliationThreshold != nil {
+		threshold = *payload.ReconciliationThreshold
+	}
+	out := &ReprocessUploadRes{
+		UploadId:                payload.UploadId,
+		ProcessingStatus:        string(generated.UploadProcessingStatusUPLOADED),
+		ReconciliationThreshold: threshold,
+	}
+
+	err = s.tm.WithTx(ctx, func(ctx context.Context) error {
+		// Claiming the upload inside the transaction keeps two reprocess calls from
+		// both getting past the status check.
+		claimed, err := s.repo.ClaimUploadForReprocess(ctx, payload.UploadId, clerkId)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return errs.NewBadRequestError("Reconciliation is already running for this upload", false, nil, nil, nil)
+		}
+		results, err := s.repo.ListSystemResults(ctx, payload.UploadId)
+		if err != nil {
+			return err
+		}
+		var discard []uuid.UUID
+		for _, r := range results {
+			// Only touch transactions still linked to this result's statement row.
+			if r.AppTransactionID == nil || r.StatementTransactionID == nil ||
+				r.AppStatementTxnID == nil || *r.AppStatementTxnID != *r.StatementTransactionID {
+				continue
+			}
+			switch {
+			case r.AppReconciliationStatus == string(generated.TransactionReconciliationStatusAUTOVERIFIED):
+				if err := s.repo.RevertTransactionReconciliation(ctx, *r.AppTransactionID); err != nil {
+					return err
+				}
+				out.RevertedTransactions++
+			case r.ResultType == string(generated.ReconciliationResultTypeMISSINGINAPP) &&
+				r.AppSource == string(generated.TransactionSourceSTATEMENTAUTO) &&
+				r.AppReconciliationStatus != string(generated.TransactionReconciliationStatusUSERVERIFIED):
+				discard = append(discard, *r.AppTransactionID)
+			}
+		}
+
+		discarded, err := s.repo.DiscardAutoCreatedTransactions(ctx, discard, clerkId)
+		if err != nil {
+			return err
+		}
+		out.RemovedTransactions = len(discarded)
+		deltas := make(map[uuid.UUID]*accountDelta)
+		for _, t := range discarded {
+			addTxnDeltas(deltas, t.AccountID, t.ToAccountID, generated.TxnType(t.Type), t.Amount, -1)
+		}
+		if err := s.applyAccountDeltas(ctx, clerkId, deltas); err != nil {
+			return err
+		}
+
+		out.ClearedResults, err = s.repo.DeleteSystemResults(ctx, payload.UploadId)
+		if err != nil {
+			return err
+		}
+		return s.repo.UpdateUploadProcessingStatus(ctx, payload.UploadId, generated.UploadProcessingStatusUPLOADED, uuid.Nil)
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+
+	jobPayload := tasks.BankReconciliationPayload{
+		UploadID:                payload.UploadId,
+		AccountID:               upload.AccountID,
+		UserID:                  clerkId,
+		ReconciliationThreshold: threshold,
+	}
+	if err := s.taskService.EnqueueBankReconciliation(ctx, jobPayload, log); err != nil {
+		if statusErr := s.repo.UpdateUploadProcessingStatus(ctx, payload.UploadId, generated.UploadProcessingStatusFAILED, uuid.Nil); statusErr != nil {
+			log.Error().Err(statusErr).Msg("[recon] failed to mark upload FAILED")
+		}
+		return nil, fmt.Errorf("failed to enqueue reconciliation task: %w", err)
+	}
+	log.Info().
+		Str("upload_id", payload.UploadId.String()).
+		Int64("cleared_results", out.ClearedResults).
+		Int("reverted", out.RevertedTransactions).
+		Int("removed", out.RemovedTransactions).
+		Msg("[recon] reprocess enqueued")
+	return out, nil
+}
+
 func (s *ReconService) DeleteUpload(c echo.Context, payload *DeleteUploadReq, clerkId string) error {
 	if s.tm == nil {
 		return fmt.Errorf("tx manager not configured")
@@ -105,6 +505,74 @@ func (s *ReconService) DeleteUpload(c echo.Context, payload *DeleteUploadReq, cl
 	}, log)
 }
 
+// resolveStatementProfile picks the layout for an upload: the user's custom mapping
+// for the account's bank, then the built-in profile for Bank.Code, then auto-detection.
+func (s *ReconService) resolveStatementProfile(ctx context.Context, userID string, accountID uuid.UUID) (StatementProfile, error) {
+	bankID, bankCode, err := s.repo.GetAccountBank(ctx, accountID, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return StatementProfile{}, errs.NewNotFoundError("Account not found", false, nil)
+		}
+		return StatementProfile{}, err
+	}
+	custom, err := s.repo.GetCustomStatementProfile(ctx, userID, bankID)
+	if err != nil {
+		return StatementProfile{}, err
+	}
+	if custom != nil {
+		return *custom, nil
+	}
+	return LookupStatementProfile(bankCode), nil
+}
+
+// GetAccountCoverage reports which date ranges the account's uploads cover, where no
+// statement was uploaded, and where uploads overlap.
+func (s *ReconService) GetAccountCoverage(c echo.Context, payload *GetAccountCoverageReq, clerkId string) (*AccountCoverageRes, error) {
+	ctx := c.Request().Context()
+	if _, _, err := s.repo.GetAccountBank(ctx, payload.AccountId, clerkId); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.NewNotFoundError("Account not found", false, nil)
+		}
+		return nil, err
+	}
+	uploads, err := s.repo.ListAccountCoverage(ctx, payload.AccountId, clerkId)
+	if err != nil {
+		return nil, err
+	}
+	return buildCoverage(payload.AccountId, uploads), nil
+}
+
+func (s *ReconService) ListStatementProfiles(c echo.Context, payload *ListStatementProfilesReq, clerkId string) (*StatementProfilesRes, error) {
+	custom, err := s.repo.ListCustomStatementProfiles(c.Request().Context(), clerkId)
+	if err != nil {
+		return nil, err
+	}
+	return &StatementProfilesRes{BuiltIn: BuiltInStatementProfiles(), Custom: custom}, nil
+}
+
+func (s *ReconService) SaveStatementProfile(c echo.Context, payload *SaveStatementProfileReq, clerkId string) (*CustomStatementProfile, error) {
+	profile := StatementProfile{
+		Code:          "CUSTOM",
+		Name:          payload.Name,
+		Columns:       payload.Columns,
+		Headers:       payload.Headers,
+		DateFormats:   payload.DateFormats,
+		Convention:    payload.Convention,
+		DebitMarkers:  payload.DebitMarkers,
+		CreditMarkers: payload.CreditMarkers,
+		HeaderRows:    payload.HeaderRows,
+		FooterRows:    payload.FooterRows,
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, errs.NewBadRequestError(err.Error(), false, nil, nil, nil)
+	}
+	return s.repo.SaveCustomStatementProfile(c.Request().Context(), clerkId, payload.BankId, profile)
+}
+
+func (s *ReconService) DeleteStatementProfile(c echo.Context, payload *DeleteStatementProfileReq, clerkId string) error {
+	return s.repo.DeleteCustomStatementProfile(c.Request().Context(), clerkId, payload.BankId)
+}
+
 func (s *ReconService) ParseAndProcessStatement(c echo.Context, payload *ParseExcelReq) (*UploadStatementRes, error) {
 	log := middleware.GetLogger(c)
 	ctx := c.Request().Context()
@@ -121,126 +589,414 @@ func (s *ReconService) ParseAndProcessStatement(c echo.Context, payload *ParseEx
 	}
 	defer r.Close()
 
+	profile, err := s.resolveStatementProfile(ctx, payload.UserId, payload.AccountId)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Str("profile", profile.Code).Msg("Resolved statement format profile")
+
+	accountType, err := s.repo.GetAccountType(ctx, payload.AccountId, payload.UserId)
+	if err != nil {
+		return nil, err
+	}
+	var cycle *CardCycle
+	if isCreditCardAccount(accountType) {
+		cycle = &CardCycle{}
+		profile.cycle = cycle
+	}
+
+	var rows []ParsedTxns
+	var parseErrors []ParseError
+	var deferred *StatementFile
+
 	ext := strings.ToLower(filepath.Ext(f.Filename))
+	head := make([]byte, 1024)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		log.Error().Err(err).Msg("Error reading statement file")
+		return nil, err
+	}
+	if !statementContentMatches(ext, head[:n]) {
+		return nil, errs.NewBadRequestError(fmt.Sprintf("The file's content is not a valid %s statement", ext), false, nil, nil, nil)
+	}
 	switch ext {
 	case ".xlsx":
-		rows, parseErrors, err := parseXlsxRows(r, payload.AccountId, uuid.Nil)
-		if err != nil {
-			return nil, err
+		rows, parseErrors, err = parseXlsxRows(r, profile, payload.AccountId, uuid.Nil, xlsxOptions{Password: payload.Password, Sheet: payload.Sheet})
+	case ".csv":
+		rows, parseErrors, err = parseCsvRows(r, profile, payload.AccountId, uuid.Nil)
+	case ".pdf":
+		rows, parseErrors, deferred, err = s.parsePdfRows(r, profile, payload.AccountId, payload.Password, log)
+	case ".ofx", ".qfx":
+		rows, parseErrors, err = parseOfxRows(r, payload.AccountId, uuid.Nil)
+	case ".xml":
+		rows, parseErrors, err = parseCamtRows(r, payload.AccountId, uuid.Nil)
+	case ".sta", ".mt940":
+		rows, parseErrors, err = parseMt940Rows(r, payload.AccountId, uuid.Nil)
+	case ".xls":
+		return nil, errs.NewBadRequestError("Unsupported statement format: .xls. Please upload .xlsx, .csv, .pdf, .ofx/.qfx, CAMT.053 .xml or MT940 .sta", false, nil, nil, nil)
+	default:
+		return nil, errs.NewBadRequestError("Unsupported statement format. Please upload .xlsx, .csv, .pdf, .ofx/.qfx, CAMT.053 .xml or MT940 .sta", false, nil, nil, nil)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("ext", ext).Msg("Failed to parse statement file")
+		if httpErr := statementOpenError(err); httpErr != nil {
+			return nil, httpErr
 		}
-		if len(rows) == 0 && len(parseErrors) == 0 {
-			return &UploadStatementRes{
-				UploadId: uuid.Nil,
-				JobId:    uuid.Nil,
-				Status:   "PARSED",
-				Summary:  UploadSummary{},
-				Txns:     []ParsedTxns{},
-			}, nil
+		return nil, errs.NewBadRequestError(fmt.Sprintf("Failed to read statement file: %s", err.Error()), false, nil, nil, nil)
+	}
+	enrichReferenceNumbers(rows)
+	if cycle != nil {
+		cycle.applyOverrides(payload)
+		if cycle.empty() {
+			cycle = nil
 		}
-		insertedHashes := make(map[string]struct{})
-		var uploadID uuid.UUID
+	}
 
-		err = s.tm.WithTx(ctx, func(ctx context.Context) error {
-			uploadID, err = s.repo.CreateUpload(ctx, payload.UserId, payload.AccountId, payload.FileName, "", "", 0, payload.StatementPeriodStart, payload.StatementPeriodEnd)
-			if err != nil {
-				log.Error().Err(err).Msg("Failed to create bank statement upload")
+	if deferred == nil && len(rows) == 0 && len(parseErrors) == 0 {
+		return &UploadStatementRes{
+			UploadId: uuid.Nil,
+			JobId:    uuid.Nil,
+			Status:   "PARSED",
+			Summary:  UploadSummary{},
+			Txns:     []ParsedTxns{},
+		}, nil
+	}
+	insertedHashes := make(map[string]struct{})
+	var uploadID uuid.UUID
+	overlapDuplicates := 0
+
+	err = s.tm.WithTx(ctx, func(ctx context.Context) error {
+		uploadID, err = s.repo.CreateUpload(ctx, payload.UserId, payload.AccountId, payload.FileName, "", "", 0, payload.StatementPeriodStart, payload.StatementPeriodEnd)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to create bank statement upload")
+			return err
+		}
+		if cycle != nil {
+			if err := s.repo.UpdateUploadCardCycle(ctx, uploadID, *cycle); err != nil {
+				log.Error().Err(err).Msg("Failed to store credit card billing cycle")
 				return err
 			}
-			for i := range rows {
-				rows[i].UploadId = uploadID
-				rows[i].AccountId = payload.AccountId
-			}
-
-			insertedHashes, err = s.repo.InsertStatementTransactions(ctx, rows)
-			if err != nil {
-				log.Error().Err(err).Msg("Failed to insert statement transactions")
+		}
+		if deferred != nil {
+			// The LLM read is paid for now, so a user without credits is turned away before
+			// the file is kept; the job reuses this reservation.
+			if err := s.reserveStatementParse(ctx, payload.UserId, uploadID); err != nil {
 				return err
 			}
-			return nil
-		}, log)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to create bank statement upload")
-			return nil, err
+			return s.repo.SaveStatementFile(ctx, uploadID, deferred.MimeType, deferred.Content)
 		}
-		MarkDuplicatesFromInsertedSet(rows, insertedHashes)
-		summary := SummaryFromRows(rows, parseErrors)
+		insertedHashes, overlapDuplicates, err = s.insertParsedRows(ctx, uploadID, payload.AccountId, rows, log)
+		return err
+	}, log)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create bank statement upload")
+		return nil, err
+	}
 
-		if err := s.repo.UpdateParseSummary(ctx, uploadID, summary); err != nil {
-			log.Error().Err(err).Msg("Failed to update parse summary")
+	res := &UploadStatementRes{
+		UploadId:  uploadID,
+		JobId:     uuid.Nil,
+		Status:    "PARSED",
+		Txns:      rows,
+		CardCycle: cycle,
+	}
+	if deferred != nil {
+		// The reconciliation job reads the rows first; the upload's summary fills in then.
+		res.Status = "EXTRACTING"
+		res.Txns = []ParsedTxns{}
+	} else {
+		var totalDue *float64
+		if cycle != nil {
+			totalDue = cycle.TotalDue
 		}
+		res.Summary, res.ClosingBalance, res.BalanceDrift = s.recordParseSummary(ctx, uploadID, payload.AccountId, payload.UserId, rows, parseErrors, insertedHashes, overlapDuplicates, totalDue, log)
+	}
 
-		threshold := 70
-		if s.userService != nil {
-			if t, err := s.userService.GetReconciliationThreshold(ctx, payload.UserId); err == nil {
-				threshold = t
-			}
+	threshold := s.reconciliationThreshold(ctx, payload.UserId)
+
+	// Enqueue the background reconciliation job (non-fatal if it fails)
+	if s.taskService != nil {
+		jobPayload := tasks.BankReconciliationPayload{
+			UploadID:                uploadID,
+			AccountID:               payload.AccountId,
+			UserID:                  payload.UserId,
+			ReconciliationThreshold: threshold,
 		}
+		if err := s.taskService.EnqueueBankReconciliation(ctx, jobPayload, log); err != nil {
+			log.Error().Err(err).Msg("Failed to enqueue reconciliation task")
+		}
+	}
 
-		// Enqueue the background reconciliation job (non-fatal if it fails)
-		jobID := uuid.Nil
-		if s.taskService != nil {
-			jobPayload := tasks.BankReconciliationPayload{
-				UploadID:                uploadID,
-				AccountID:               payload.AccountId,
-				UserID:                  payload.UserId,
-				ReconciliationThreshold: threshold,
-			}
-			if err := s.taskService.EnqueueBankReconciliation(ctx, jobPayload, log); err != nil {
-				log.Error().Err(err).Msg("Failed to enqueue reconciliation task")
-			}
+	return res, nil
+}
+
+// insertParsedRows stores the rows parsed off an upload, flagging the ones an earlier
+// upload of the same account already holds. Call it inside a transaction.
+func (s *ReconService) insertParsedRows(ctx context.Context, uploadID, accountID uuid.UUID, rows []ParsedTxns, log *zerolog.Logger) (map[string]struct{}, int, error) {
+	for i := range rows {
+		rows[i].UploadId = uploadID
+		rows[i].AccountId = accountID
+	}
+
+	// Rows in a range an earlier upload already covers are deduped on their content,
+	// since another export of the same period may word the narration differently.
+	overlapDuplicates := 0
+	if from, to, ok := parsedRowsDateRange(rows); ok {
+		stored, err := s.repo.ListStoredStatementRows(ctx, accountID, truncateDay(from), truncateDay(to).AddDate(0, 0, 1))
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to fetch overlapping statement rows")
+			return nil, 0, err
 		}
+		overlapDuplicates = MarkOverlapDuplicates(rows, stored)
+	}
 
-		return &UploadStatementRes{
-			UploadId: uploadID,
-			JobId:    jobID,
-			Status:   "PARSED",
-			Summary:  summary,
-			Txns:     rows,
-		}, nil
+	insertedHashes, err := s.repo.InsertStatementTransactions(ctx, rows)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to insert statement transactions")
+		return nil, 0, err
+	}
+	return insertedHashes, overlapDuplicates, nil
+}
 
-	case ".csv":
-		return nil, errs.NewBadRequestError("CSV parsing not implemented; please upload .xlsx", false, nil, nil, nil)
+// recordParseSummary stores the parse summary and running-balance check of an upload
+// whose rows were just inserted. totalDue stands in for the closing balance of a card
+// statement without a balance column.
+func (s *ReconService) recordParseSummary(ctx context.Context, uploadID, accountID uuid.UUID, userID string, rows []ParsedTxns, parseErrors []ParseError, insertedHashes map[string]struct{}, overlapDuplicates int, totalDue *float64, log *zerolog.Logger) (UploadSummary, *float64, *float64) {
+	MarkDuplicatesFromInsertedSet(rows, insertedHashes)
+	summary := SummaryFromRows(rows, parseErrors)
+	summary.OverlapDuplicateRows = overlapDuplicates
+
+	balanceErrors, closingBalance := checkRunningBalance(rows)
+	summary.BalanceMismatches = len(balanceErrors)
+	summary.Errors = append(summary.Errors, balanceErrors...)
+	if closingBalance == nil && totalDue != nil {
+		// Card statements rarely print a running balance; the total due is the closing one.
+		owed := -*totalDue
+		closingBalance = &owed
+	}
 
-	case ".xls":
-		return nil, errs.NewBadRequestError("Unsupported statement format: .xls. Please upload .xlsx or .csv", false, nil, nil, nil)
+	if err := s.repo.UpdateParseSummary(ctx, uploadID, summary); err != nil {
+		log.Error().Err(err).Msg("Failed to update parse summary")
+	}
+
+	var balanceDrift *float64
+	if closingBalance != nil {
+		currentBalance, err := s.repo.GetAccountCurrentBalance(ctx, accountID, userID)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to fetch account balance for drift check")
+		} else if currentBalance != nil {
+			drift := math.Round((*closingBalance-*currentBalance)*100) / 100
+			balanceDrift = &drift
+		}
+		if err := s.repo.UpdateUploadBalanceCheck(ctx, uploadID, closingBalance, balanceDrift); err != nil {
+			log.Error().Err(err).Msg("Failed to store statement balance check")
+		}
+	}
+	return summary, closingBalance, balanceDrift
+}
+
+// reconChunkSize caps how many statement rows, and the app transactions around them,
+// a reconciliation job holds in memory at once.
+const reconChunkSize = 500
+
+// reconRun is the state a reconciliation job carries from one chunk to the next.
+type reconRun struct {
+	payload          tasks.BankReconciliationPayload
+	maxAppDate       *time.Time
+	stmtFrom, stmtTo time.Time
+	claimed          map[uuid.UUID]struct{}
+	chunksCommitted  int
+	// card is set when the upload belongs to a credit card account.
+	card *cardLedger
+	// scoring also sets how far either side of a chunk's dates app transactions are fetched.
+	scoring ScoringProfile
+}
+
+// RunReconciliationJob reconciles an upload in date-ordered chunks, committing each
+// chunk's auto-creates, balances, results and progress before loading the next. A job
+// that fails leaves the upload FAILED rather than PROCESSING, with any statement file
+// it had still to extract dropped; a retry resumes it from its checkpoint. Each run
+// also drops statement files left unextracted for a day.
+func (s *ReconService) RunReconciliationJob(ctx context.Context, payload tasks.BankReconciliationPayload, log *zerolog.Logger) ([]uuid.UUID, error) {
+	s.dropExpiredStatementFiles(ctx, log)
+	createdIDs, err := s.runReconciliationJob(ctx, payload, log)
+	if err != nil {
+		s.failUpload(context.WithoutCancel(ctx), payload.UploadID, log)
+	}
+	return createdIDs, err
+}
+
+// failUpload marks an upload whose reconciliation job failed and drops its statement
+// file, so the text of a decrypted PDF is not kept for an upload that went nowhere.
+func (s *ReconService) failUpload(ctx context.Context, uploadID uuid.UUID, log *zerolog.Logger) {
+	if err := s.repo.UpdateUploadProcessingStatus(ctx, uploadID, generated.UploadProcessingStatusFAILED, uuid.Nil); err != nil {
+		log.Error().Err(err).Str("upload_id", uploadID.String()).Msg("[recon] failed to mark upload FAILED")
+	}
+	if err := s.repo.DeleteStatementFile(ctx, uploadID); err != nil {
+		log.Error().Err(err).Str("upload_id", uploadID.String()).Msg("[recon] failed to drop statement file")
+	}
+}
 
-	default:
-		return nil, errs.NewBadRequestError("Unsupported statement format. Please upload .xlsx or .csv", false, nil, nil, nil)
+func (s *ReconService) dropExpiredStatementFiles(ctx context.Context, log *zerolog.Logger) {
+	dropped, err := s.repo.DeleteExpiredStatementFiles(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("[recon] failed to drop expired statement files")
+		return
+	}
+	if dropped > 0 {
+		log.Info().Int64("dropped", dropped).Msg("[recon] dropped expired statement files")
 	}
 }
 
-func (s *ReconService) RunReconciliationJob(ctx context.Context, payload tasks.BankReconciliationPayload, log *zerolog.Logger) ([]uuid.UUID, error) {
+func (s *ReconService) runReconciliationJob(ctx context.Context, payload tasks.BankReconciliationPayload, log *zerolog.Logger) ([]uuid.UUID, error) {
 	utils.LogMem("start", log)
 
+	run := &reconRun{payload: payload}
+	var createdIDs []uuid.UUID
+	if payload.JobID != "" {
+		checkpoint, err := s.repo.StartJobCheckpoint(ctx, payload.JobID, payload.UploadID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record job checkpoint: %w", err)
+		}
+		switch checkpoint.Stage {
+		case jobStageCompleted:
+			log.Info().Str("job_id", payload.JobID).Int("attempt", checkpoint.Attempts).Msg("[recon] job already completed, skipping replay")
+			return nil, nil
+		case jobStageCommitted:
+			log.Info().Str("job_id", payload.JobID).Int("attempt", checkpoint.Attempts).Msg("[recon] job results already committed, finishing replay")
+			return checkpoint.CreatedTxnIDs, s.completeReconciliationJob(ctx, payload, log)
+		}
+		run.chunksCommitted = checkpoint.ChunksCommitted
+		createdIDs = checkpoint.CreatedTxnIDs
+		if checkpoint.Attempts > 1 {
+			log.Info().Str("job_id", payload.JobID).Int("attempt", checkpoint.Attempts).
+				Int("chunks_committed", checkpoint.ChunksCommitted).Msg("[recon] resuming job after last committed chunk")
+		}
+	}
+
 	if err := s.repo.UpdateUploadProcessingStatus(ctx, payload.UploadID, generated.UploadProcessingStatusPROCESSING, uuid.Nil); err != nil {
 		log.Error().Err(err).Msg("[recon] failed to mark upload PROCESSING")
 	}
 
-	stmtTxns, err := s.repo.GetStatementTransactionsForProcessing(ctx, payload.UploadID)
+	if run.chunksCommitted == 0 {
+		if err := s.extractStatementFile(ctx, payload, log); err != nil {
+			return nil, err
+		}
+		// Counters start from the results the user already decided on; a reprocess keeps those.
+		decidedLinks, err := s.repo.ListDecidedLinks(ctx, payload.UploadID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch decided results: %w", err)
+		}
+		decided := summarizeDecidedLinks(decidedLinks)
+		totalStmt, err := s.repo.CountStatementTransactionsForProcessing(ctx, payload.UploadID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count statement transactions: %w", err)
+		}
+		if err := s.repo.ResetUploadProgress(ctx, payload.UploadID, totalStmt, decided.matched, decided.unmatched, decided.missing, len(decided.stmtIDs)); err != nil {
+			return nil, fmt.Errorf("failed to reset upload progress: %w", err)
+		}
+		log.Info().Int("stmt_count", totalStmt).Int("decided_rows", len(decided.stmtIDs)).Msg("[recon] counted statement transactions")
+	}
+
+	accountType, err := s.repo.GetAccountType(ctx, payload.AccountID, payload.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch statement transactions: %w", err)
+		return nil, fmt.Errorf("failed to fetch account type: %w", err)
+	}
+	if isCreditCardAccount(accountType) {
+		run.card, err = s.loadCardLedger(ctx, payload.UserID, payload.AccountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load card payment account: %w", err)
+		}
+		log.Info().Bool("payment_account_known", run.card.paymentAccountID != nil).Msg("[recon] reconciling a credit card statement")
 	}
-	if len(stmtTxns) == 0 {
-		log.Info().Str("upload_id", payload.UploadID.String()).Msg("[recon] no statement transactions to process")
-		return nil, s.repo.UpdateUploadProcessingStatus(ctx, payload.UploadID, generated.UploadProcessingStatusCOMPLETED, uuid.Nil)
+
+	run.scoring, err = s.scoringProfile(ctx, payload.UserID, payload.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load scoring profile: %w", err)
 	}
-	utils.LogMem("after_stmt_fetch", log)
-	log.Info().Int("stmt_count", len(stmtTxns)).Msg("[recon] fetched statement transactions")
 
 	maxAppDate, err := s.repo.GetMaxAppTransactionDate(ctx, payload.AccountID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get max app transaction date: %w", err)
 	}
+	run.maxAppDate = maxAppDate
+	if maxAppDate != nil {
+		minDate, maxDate, err := s.repo.GetStatementDateRange(ctx, payload.UploadID)
+		if errors.Is(err, ErrNoDateRange) {
+			log.Warn().Str("upload_id", payload.UploadID.String()).
+				Msg("[recon] no date range for statement rows, skipping app-txn fetch")
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get statement date range: %w", err)
+		} else {
+			run.stmtFrom, run.stmtTo = minDate, maxDate
+		}
+	}
+
+	// App transactions already referenced by a result, whether decided by the user or
+	// written by an earlier chunk, are never matched or flagged again.
+	claimedIDs, err := s.repo.ListClaimedAppTransactionIDs(ctx, payload.UploadID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch claimed app transactions: %w", err)
+	}
+	run.claimed = make(map[uuid.UUID]struct{}, len(claimedIDs))
+	for _, id := range claimedIDs {
+		run.claimed[id] = struct{}{}
+	}
+
+	for {
+		rows, err := s.repo.GetStatementTransactionsForProcessing(ctx, payload.UploadID, reconChunkSize+1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch statement transactions: %w", err)
+		}
+		if len(rows) == 0 {
+			break
+		}
+		// The extra row only tells us where the next chunk starts.
+		var nextFrom *time.Time
+		if len(rows) > reconChunkSize {
+			nextFrom = rows[reconChunkSize].TransactionDate
+			rows = rows[:reconChunkSize]
+		}
+		log.Info().Int("chunk", run.chunksCommitted+1).Int("stmt_count", len(rows)).Msg("[recon] processing chunk")
+
+		chunkIDs, err := s.reconcileChunk(ctx, run, rows, nextFrom, log)
+		if errors.Is(err, errJobAlreadyCommitted) {
+			log.Info().Str("job_id", payload.JobID).Msg("[recon] another attempt advanced this job first, discarding ours")
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		createdIDs = append(createdIDs, chunkIDs...)
+		run.chunksCommitted++
+		utils.LogMem("after_chunk_commit", log)
+
+		if nextFrom == nil {
+			break
+		}
+	}
+
+	if err := s.completeReconciliationJob(ctx, payload, log); err != nil {
+		log.Error().Err(err).Msg("[recon] failed to mark upload COMPLETED")
+	}
+	utils.LogMem("done", log)
+	return createdIDs, nil
+}
+
+// reconcileChunk matches one chunk of statement rows against the app transactions
+// around it and commits everything it writes in a single transaction. nextFrom is the
+// date the following chunk starts on, or nil for the last chunk.
+func (s *ReconService) reconcileChunk(ctx context.Context, run *reconRun, stmtTxns []StatementTransaction, nextFrom *time.Time, log *zerolog.Logger) ([]uuid.UUID, error) {
+	payload := run.payload
+	window := run.scoring.DateWindowDays
+	chunkFrom, chunkTo := *stmtTxns[0].TransactionDate, *stmtTxns[len(stmtTxns)-1].TransactionDate
 
 	var tailRows, overlapRows []StatementTransaction
-	if maxAppDate == nil {
+	if run.maxAppDate == nil {
 		tailRows = stmtTxns
 	} else {
 		for _, st := range stmtTxns {
-			if st.TransactionDate == nil {
-				continue
-			}
-			if st.TransactionDate.After(*maxAppDate) {
+			if st.TransactionDate.After(*run.maxAppDate) {
 				tailRows = append(tailRows, st)
 			} else {
 				overlapRows = append(overlapRows, st)
@@ -252,117 +1008,87 @@ func (s *ReconService) RunReconciliationJob(ctx context.Context, payload tasks.B
 		Int("overlap_rows", len(overlapRows)).
 		Msg("[recon] partitioned statement rows")
 
+	// Unmatched app transactions are flagged from where the previous chunk stopped up to
+	// just before the next chunk's window, which may still match them.
 	var appTxns []AppTransaction
-	if len(overlapRows) > 0 {
-		minDate, maxDate, err := s.repo.GetStatementDateRange(ctx, payload.UploadID)
-		if errors.Is(err, ErrNoDateRange) {
-			log.Warn().Str("upload_id", payload.UploadID.String()).
-				Msg("[recon] no date range for overlap rows, skipping app-txn fetch")
-		} else if err != nil {
-			return nil, fmt.Errorf("failed to get statement date range: %w", err)
-		} else {
-			from := minDate.AddDate(0, 0, -2)
-			to := maxDate.AddDate(0, 0, 2)
-			appTxns, err = s.repo.GetAppTransactionsInDateRange(ctx, payload.AccountID, from, to)
-			if err != nil {
-				return nil, fmt.Errorf("failed to fetch app transactions: %w", err)
+	var flagFrom, flagTo time.Time
+	if run.maxAppDate != nil && !run.stmtFrom.IsZero() {
+		flagFrom = chunkFrom.AddDate(0, 0, -window)
+		if run.chunksCommitted == 0 {
+			flagFrom = run.stmtFrom
+		}
+		flagTo = run.stmtTo
+		if nextFrom != nil {
+			flagTo = nextFrom.AddDate(0, 0, -window-1)
+		}
+		from := chunkFrom.AddDate(0, 0, -window)
+		if flagFrom.Before(from) {
+			from = flagFrom
+		}
+		to := chunkTo.AddDate(0, 0, window)
+		if flagTo.After(to) {
+			to = flagTo
+		}
+		fetched, err := s.repo.GetAppTransactionsInDateRange(ctx, payload.AccountID, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch app transactions: %w", err)
+		}
+		appTxns = fetched[:0]
+		for _, at := range fetched {
+			if _, ok := run.claimed[at.ID]; !ok {
+				appTxns = append(appTxns, at)
 			}
-			log.Info().Int("app_txn_count", len(appTxns)).Msg("[recon] fetched app transactions")
-			utils.LogMem("after_app_fetch", log)
 		}
+		log.Info().Int("app_txn_count", len(appTxns)).Msg("[recon] fetched app transactions")
+		utils.LogMem("after_app_fetch", log)
 	}
 
-	dateMap := make(map[string][]AppTransaction, len(appTxns))
-	exactMap := make(map[string]*AppTransaction, len(appTxns))
-	for i := range appTxns {
-		at := &appTxns[i]
-		dateKey := at.TransactionDate.Format("2006-01-02") + "|" + at.Type
-		dateMap[dateKey] = append(dateMap[dateKey], *at)
-		exactKey := fmt.Sprintf("%.2f|%s|%s", at.Amount, at.Type, at.TransactionDate.Format("2006-01-02"))
-		exactMap[exactKey] = at
+	assignments := assignMatches(overlapRows, appTxns, run.scoring)
+	splitGroups := findSplitMatches(overlapRows, appTxns, assignments, run.scoring)
+	splitByStmt := make(map[int]int, len(splitGroups))
+	for gi, g := range splitGroups {
+		for _, si := range g.stmtIdxs {
+			splitByStmt[si] = gi
+		}
 	}
 	utils.LogMem("after_indexing", log)
+	log.Info().Int("split_groups", len(splitGroups)).Msg("[recon] detected split matches")
 
 	results := make([]ReconciliationResult, 0, len(overlapRows)+len(tailRows))
 	var highConfMatches []ReconciliationResult
 
-	for _, st := range overlapRows {
-		if st.TransactionDate == nil {
-			continue
-		}
-		stmtDate := st.TransactionDate.Format("2006-01-02")
-		stmtDesc := ""
-		if st.Description != nil {
-			stmtDesc = *st.Description
-		}
-		stmtRef := ""
-		if st.ReferenceNumber != nil {
-			stmtRef = *st.ReferenceNumber
-		}
-
-		exactKey := fmt.Sprintf("%.2f|%s|%s", st.Amount, st.Type, stmtDate)
-		if match, ok := exactMap[exactKey]; ok {
-			appID := match.ID
-			res := ReconciliationResult{
-				UploadID:               payload.UploadID,
-				StatementTransactionID: st.ID,
-				AppTransactionID:       &appID,
-				ResultType:             string(generated.ReconciliationResultTypeHIGHCONFIDENCEMATCH),
-				ConfidenceScore:        95,
-				MatchSignals: MatchSignals{
-					DateDiffDays: 0, AmountDiff: 0, AmountDiffPct: 0,
-					DescriptionSimilarity: utils.TokenJaccard(stmtDesc, match.Description),
-					ReferenceMatch:        stmtRef != "" && stmtRef == match.ReferenceNumber,
-					DateScore:             40, AmountScore: 35, DescriptionScore: 0, ReferenceScore: 0,
-				},
-				MatchStatus: "auto_accepted",
-			}
-			results = append(results, res)
-			highConfMatches = append(highConfMatches, res)
-			delete(exactMap, exactKey)
-			continue
+	for i, st := range overlapRows {
+		res := ReconciliationResult{
+			UploadID:               payload.UploadID,
+			StatementTransactionID: st.ID,
+			MatchStatus:            "pending",
 		}
 
-		var bestMatch *AppTransaction
-		var bestScore int
-		var bestSignals MatchSignals
-
-		for dayDelta := -2; dayDelta <= 2; dayDelta++ {
-			candidate := st.TransactionDate.AddDate(0, 0, dayDelta)
-			key := candidate.Format("2006-01-02") + "|" + st.Type
-			for i := range dateMap[key] {
-				at := &dateMap[key][i]
-				signals, score := scoreMatch(st.Amount, stmtDesc, stmtRef, *st.TransactionDate, at)
-				if score > bestScore {
-					bestScore = score
-					bestMatch = at
-					bestSignals = signals
-				}
+		if gi, inGroup := splitByStmt[i]; inGroup {
+			g := splitGroups[gi]
+			if g.stmtIdxs[0] == i {
+				results = append(results, splitGroupResult(payload.UploadID, g, overlapRows, appTxns))
 			}
+			continue
 		}
 
-		res := ReconciliationResult{
-			UploadID:               payload.UploadID,
-			StatementTransactionID: st.ID,
-			MatchSignals:           bestSignals,
-			MatchStatus:            "pending",
+		m, ok := assignments[i]
+		if ok {
+			appID := appTxns[m.appIdx].ID
+			res.AppTransactionID = &appID
+			res.MatchSignals = m.signals
+			res.ConfidenceScore = float64(m.score)
 		}
 
 		switch {
-		case bestMatch != nil && bestScore >= payload.ReconciliationThreshold:
-			appID := bestMatch.ID
-			res.AppTransactionID = &appID
+		case ok && (m.exact || m.score >= payload.ReconciliationThreshold):
 			res.ResultType = string(generated.ReconciliationResultTypeHIGHCONFIDENCEMATCH)
-			res.ConfidenceScore = float64(bestScore)
 			res.MatchStatus = "auto_accepted"
 			results = append(results, res)
 			highConfMatches = append(highConfMatches, res)
 
-		case bestMatch != nil && bestScore > 0:
-			appID := bestMatch.ID
-			res.AppTransactionID = &appID
+		case ok:
 			res.ResultType = string(generated.ReconciliationResultTypeLOWCONFIDENCEMATCH)
-			res.ConfidenceScore = float64(bestScore)
 			results = append(results, res)
 
 		default:
@@ -374,15 +1100,53 @@ func (s *ReconService) RunReconciliationJob(ctx context.Context, payload tasks.B
 	utils.LogMem("after_scoring", log)
 	log.Info().Int("results_so_far", len(results)).Msg("[recon] scoring complete")
 
+	merchants, err := s.repo.FindMerchantsByKeys(ctx, narrationMerchantKeys(tailRows, overlapRows))
+	if err != nil {
+		log.Error().Err(err).Msg("[recon] failed to look up narration merchants")
+		return nil, err
+	}
+
+	overlapByID := make(map[uuid.UUID]StatementTransaction, len(overlapRows))
+	for _, st := range overlapRows {
+		overlapByID[st.ID] = st
+	}
+
+	// On a card, bill payments the app already has as a savings debit are linked to it
+	// as a transfer instead of being auto-created. A debit that does not name the card is
+	// only linked for review, and becomes the transfer once the user accepts it.
+	var cardPayments map[uuid.UUID]cardPayment
+	if run.card != nil {
+		missing := append([]StatementTransaction{}, tailRows...)
+		for _, res := range results {
+			if res.ResultType == string(generated.ReconciliationResultTypeMISSINGINAPP) && res.AppTransactionID == nil {
+				if st, ok := overlapByID[res.StatementTransactionID]; ok {
+					missing = append(missing, st)
+				}
+			}
+		}
+		cardPayments, err = s.matchCardPayments(ctx, run, missing)
+		if err != nil {
+			return nil, fmt.Errorf("failed to match card bill payments: %w", err)
+		}
+		log.Info().Int("linked_bill_payments", len(cardPayments)).Msg("[recon] matched card bill payments")
+	}
+
 	autoCreateParams := make([]generated.CreateTxnBatchParams, 0)
 	autoCreateResultIdxs := make([]int, 0)
+	var transfers []CardPaymentSource
+	autoCreate := func(st StatementTransaction, resultIdx int) {
+		if payment, ok := cardPayments[st.ID]; ok {
+			results[resultIdx] = cardTransferResult(run.scoring, results[resultIdx], st, payment)
+			if payment.confirmed {
+				transfers = append(transfers, payment.src)
+			}
+			return
+		}
+		autoCreateParams = append(autoCreateParams, stmtTxnToCreateParams(payload.UserID, payload.AccountID, st, merchants, run.card))
+		autoCreateResultIdxs = append(autoCreateResultIdxs, resultIdx)
+	}
 
 	for _, st := range tailRows {
-		if st.TransactionDate == nil {
-			continue
-		}
-		params := stmtTxnToCreateParams(payload.UserID, payload.AccountID, st)
-		autoCreateParams = append(autoCreateParams, params)
 		results = append(results, ReconciliationResult{
 			UploadID:               payload.UploadID,
 			StatementTransactionID: st.ID,
@@ -391,95 +1155,279 @@ func (s *ReconService) RunReconciliationJob(ctx context.Context, payload tasks.B
 			ConfidenceScore:        100,
 			MatchStatus:            "pending",
 		})
-		autoCreateResultIdxs = append(autoCreateResultIdxs, len(results)-1)
+		autoCreate(st, len(results)-1)
 	}
 
 	for i, res := range results {
 		if res.ResultType == string(generated.ReconciliationResultTypeMISSINGINAPP) && res.AppTransactionID == nil {
-			for _, st := range overlapRows {
-				if st.ID == res.StatementTransactionID && st.TransactionDate != nil {
-					params := stmtTxnToCreateParams(payload.UserID, payload.AccountID, st)
-					autoCreateParams = append(autoCreateParams, params)
-					autoCreateResultIdxs = append(autoCreateResultIdxs, i)
-					break
-				}
+			if st, ok := overlapByID[res.StatementTransactionID]; ok {
+				autoCreate(st, i)
 			}
 		}
 	}
 
+	// Auto-creates, balance deltas, verification marks, results, progress and the
+	// checkpoint commit together, so a retry resumes from the last whole chunk.
 	var createdIDs []uuid.UUID
-	if len(autoCreateParams) > 0 {
-		newIDs, err := s.repo.CreateAutoTransactionsBatch(ctx, autoCreateParams)
-		if err != nil {
-			return nil, fmt.Errorf("failed to auto-create transactions: %w", err)
+	err = s.tm.WithTx(ctx, func(ctx context.Context) error {
+		if len(autoCreateParams) > 0 {
+			newIDs, err := s.repo.CreateAutoTransactionsBatch(ctx, autoCreateParams)
+			if err != nil {
+				return fmt.Errorf("failed to auto-create transactions: %w", err)
+			}
+			createdIDs = newIDs
+			for i, resultIdx := range autoCreateResultIdxs {
+				if i < len(newIDs) && resultIdx >= 0 && resultIdx < len(results) {
+					id := newIDs[i]
+					results[resultIdx].AppTransactionID = &id
+				}
+			}
+			log.Info().Int("auto_created", len(newIDs)).Msg("[recon] auto-created transactions")
+		}
+
+		deltas := make(map[uuid.UUID]*accountDelta)
+		for _, p := range autoCreateParams {
+			addTxnDeltas(deltas, utils.UUIDToUUID(p.AccountID), utils.UUIDToUUIDPtr(p.ToAccountID), p.Type, utils.NumericToFloat64(p.Amount), 1)
+		}
+		for _, src := range transfers {
+			converted, err := s.repo.MarkTransactionTransfer(ctx, src.ID, payload.AccountID)
+			if err != nil {
+				return fmt.Errorf("failed to mark bill payment %s as a transfer: %w", src.ID, err)
+			}
+			if converted {
+				// The debit stops counting as an expense and now pays down the card.
+				addTxnDeltas(deltas, src.AccountID, nil, generated.TxnTypeDEBIT, src.Amount, -1)
+				addTxnDeltas(deltas, src.AccountID, &payload.AccountID, generated.TxnTypeDEBIT, src.Amount, 1)
+			}
 		}
-		createdIDs = newIDs
-		for i, resultIdx := range autoCreateResultIdxs {
-			if i < len(newIDs) && resultIdx >= 0 && resultIdx < len(results) {
-				id := newIDs[i]
-				results[resultIdx].AppTransactionID = &id
+		if err := s.applyAccountDeltas(ctx, payload.UserID, deltas); err != nil {
+			return fmt.Errorf("failed to update balances after auto-create: %w", err)
+		}
+		utils.LogMem("after_auto_create", log)
+
+		for _, res := range highConfMatches {
+			if res.AppTransactionID != nil {
+				if err := s.repo.MarkTransactionAutoVerified(ctx, *res.AppTransactionID, res.StatementTransactionID); err != nil {
+					return fmt.Errorf("failed to mark transaction %s auto-verified: %w", res.AppTransactionID, err)
+				}
 			}
 		}
-		log.Info().Int("auto_created", len(newIDs)).Msg("[recon] auto-created transactions")
-
-		if s.balanceUpdater != nil {
-			var totalIncome, totalExpense, totalBalance float64
-			for _, p := range autoCreateParams {
-				amt := utils.NumericToFloat64(p.Amount)
-				switch p.Type {
-				case generated.TxnTypeCREDIT, generated.TxnTypeINCOME,
-					generated.TxnTypeREFUND, generated.TxnTypeINVESTMENT:
-					totalIncome += amt
-					totalBalance += amt
-				case generated.TxnTypeDEBIT, generated.TxnTypeSUBSCRIPTION:
-					totalExpense += amt
-					totalBalance -= amt
+		utils.LogMem("after_auto_verify", log)
+
+		if !flagFrom.IsZero() {
+			notInStatement := notInStatementResults(payload.UploadID, appTxns, results, flagFrom, flagTo)
+			results = append(results, notInStatement...)
+			log.Info().Int("not_in_statement", len(notInStatement)).Msg("[recon] flagged app transactions missing from statement")
+		}
+
+		if err := s.repo.InsertReconciliationResults(ctx, results); err != nil {
+			return fmt.Errorf("failed to insert reconciliation results: %w", err)
+		}
+		utils.LogMem("after_batch_insert", log)
+		log.Info().Int("total_results", len(results)).Msg("[recon] inserted chunk reconciliation results")
+
+		var matched, unmatched, missing int
+		for _, res := range results {
+			switch generated.ReconciliationResultType(res.ResultType) {
+			case generated.ReconciliationResultTypeHIGHCONFIDENCEMATCH, generated.ReconciliationResultTypeLOWCONFIDENCEMATCH:
+				matched++
+			case generated.ReconciliationResultTypeSPLITMATCH:
+				for _, m := range res.Members {
+					if m.StatementTransactionID != nil {
+						matched++
+					}
 				}
+			case generated.ReconciliationResultTypeMISSINGINAPP:
+				unmatched++
+			case generated.ReconciliationResultTypeNOTINSTATEMENT:
+				missing++
+			}
+		}
+		if err := s.repo.AddUploadChunkProgress(ctx, payload.UploadID, len(stmtTxns), matched, unmatched, missing); err != nil {
+			return fmt.Errorf("failed to update upload progress: %w", err)
+		}
+
+		if payload.JobID != "" {
+			// Blocks behind a concurrent attempt for the same job; if that one advanced, roll ours back.
+			advanced, err := s.repo.AdvanceJobCheckpoint(ctx, payload.JobID, run.chunksCommitted, createdIDs, nextFrom == nil)
+			if err != nil {
+				return fmt.Errorf("failed to advance job checkpoint: %w", err)
 			}
-			if err := s.balanceUpdater.ApplyBatch(ctx, payload.UserID, payload.AccountID, totalIncome, totalExpense, totalBalance); err != nil {
-				log.Error().Err(err).Msg("[recon] failed to update balances after auto-create")
+			if !advanced {
+				return errJobAlreadyCommitted
 			}
 		}
+		return nil
+	}, log)
+	if err != nil {
+		return nil, err
 	}
-	utils.LogMem("after_auto_create", log)
 
-	for _, res := range highConfMatches {
+	for _, res := range results {
 		if res.AppTransactionID != nil {
-			if err := s.repo.MarkTransactionAutoVerified(ctx, *res.AppTransactionID, res.StatementTransactionID); err != nil {
-				log.Error().Err(err).Str("app_txn_id", res.AppTransactionID.String()).Msg("[recon] failed to mark transaction auto-verified")
+			run.claimed[*res.AppTransactionID] = struct{}{}
+		}
+		for _, m := range res.Members {
+			if m.AppTransactionID != nil {
+				run.claimed[*m.AppTransactionID] = struct{}{}
 			}
 		}
 	}
-	utils.LogMem("after_auto_verify", log)
+	return createdIDs, nil
+}
 
-	if err := s.repo.InsertReconciliationResults(ctx, results); err != nil {
-		return nil, fmt.Errorf("failed to insert reconciliation results: %w", err)
-	}
-	utils.LogMem("after_batch_insert", log)
-	log.Info().Int("total_results", len(results)).Msg("[recon] inserted all reconciliation results")
+// Stages a reconciliation job checkpoint moves through.
+const (
+	jobStageStarted   = "STARTED"
+	jobStageCommitted = "COMMITTED"
+	jobStageCompleted = "COMPLETED"
+)
+
+var errJobAlreadyCommitted = errors.New("reconciliation job already advanced by another attempt")
 
+// completeReconciliationJob marks the upload COMPLETED and closes the job's checkpoint.
+func (s *ReconService) completeReconciliationJob(ctx context.Context, payload tasks.BankReconciliationPayload, log *zerolog.Logger) error {
 	if err := s.repo.UpdateUploadProcessingStatus(ctx, payload.UploadID, generated.UploadProcessingStatusCOMPLETED, uuid.Nil); err != nil {
-		log.Error().Err(err).Msg("[recon] failed to mark upload COMPLETED")
+		return err
 	}
-	utils.LogMem("done", log)
-	return createdIDs, nil
+	if payload.JobID == "" {
+		return nil
+	}
+	if err := s.repo.CompleteJobCheckpoint(ctx, payload.JobID); err != nil {
+		log.Error().Err(err).Str("job_id", payload.JobID).Msg("[recon] failed to mark job checkpoint COMPLETED")
+	}
+	return nil
+}
+
+// decidedSummary is what a reprocess run must leave alone, plus how those kept results
+// contribute to the upload's counters.
+type decidedSummary struct {
+	stmtIDs                     map[uuid.UUID]struct{}
+	appIDs                      map[uuid.UUID]struct{}
+	matched, unmatched, missing int
+}
+
+func summarizeDecidedLinks(links []DecidedLink) decidedSummary {
+	sum := decidedSummary{
+		stmtIDs: make(map[uuid.UUID]struct{}),
+		appIDs:  make(map[uuid.UUID]struct{}),
+	}
+	stmtMatched := make(map[uuid.UUID]bool)
+	for _, l := range links {
+		if l.AppTransactionID != nil {
+			if _, seen := sum.appIDs[*l.AppTransactionID]; !seen && l.StatementTransactionID == nil &&
+				l.ResultType == string(generated.ReconciliationResultTypeNOTINSTATEMENT) {
+				sum.missing++
+			}
+			sum.appIDs[*l.AppTransactionID] = struct{}{}
+		}
+		if l.StatementTransactionID == nil {
+			continue
+		}
+		sum.stmtIDs[*l.StatementTransactionID] = struct{}{}
+		switch generated.ReconciliationResultType(l.ResultType) {
+		case generated.ReconciliationResultTypeMANUALLYMATCHED:
+			stmtMatched[*l.StatementTransactionID] = true
+		case generated.ReconciliationResultTypeHIGHCONFIDENCEMATCH, generated.ReconciliationResultTypeLOWCONFIDENCEMATCH,
+			generated.ReconciliationResultTypeSPLITMATCH:
+			stmtMatched[*l.StatementTransactionID] = stmtMatched[*l.StatementTransactionID] || l.UserAction == "accepted"
+		default:
+			if _, ok := stmtMatched[*l.StatementTransactionID]; !ok {
+				stmtMatched[*l.StatementTransactionID] = false
+			}
+		}
+	}
+	for _, ok := range stmtMatched {
+		if ok {
+			sum.matched++
+		} else {
+			sum.unmatched++
+		}
+	}
+	return sum
+}
+
+// notInStatementResults flags app transactions dated inside the statement period that
+// no statement row matched. Excluded transactions are skipped; cash ones never reach
+// here because GetAppTransactionsInDateRange filters them out.
+func notInStatementResults(uploadID uuid.UUID, appTxns []AppTransaction, results []ReconciliationResult, from, to time.Time) []ReconciliationResult {
+	claimed := make(map[uuid.UUID]struct{}, len(results))
+	for _, res := range results {
+		if res.AppTransactionID != nil {
+			claimed[*res.AppTransactionID] = struct{}{}
+		}
+		for _, m := range res.Members {
+			if m.AppTransactionID != nil {
+				claimed[*m.AppTransactionID] = struct{}{}
+			}
+		}
+	}
+
+	fromDay, toDay := from.Format("2006-01-02"), to.Format("2006-01-02")
+	var out []ReconciliationResult
+	for _, at := range appTxns {
+		if at.IsExcluded {
+			continue
+		}
+		if _, ok := claimed[at.ID]; ok {
+			continue
+		}
+		day := at.TransactionDate.Format("2006-01-02")
+		if day < fromDay || day > toDay {
+			continue
+		}
+		appID := at.ID
+		out = append(out, ReconciliationResult{
+			UploadID:         uploadID,
+			AppTransactionID: &appID,
+			ResultType:       string(generated.ReconciliationResultTypeNOTINSTATEMENT),
+			ConfidenceScore:  0,
+			MatchStatus:      "pending",
+		})
+	}
+	return out
+}
+
+// splitGroupResult builds the single SPLIT_MATCH result for a group. It is anchored
+// on the group's first statement row and first app transaction; every participant,
+// anchors included, is listed in Members.
+func splitGroupResult(uploadID uuid.UUID, g splitGroup, stmts []StatementTransaction, appTxns []AppTransaction) ReconciliationResult {
+	anchorApp := appTxns[g.appIdxs[0]].ID
+	res := ReconciliationResult{
+		UploadID:               uploadID,
+		StatementTransactionID: stmts[g.stmtIdxs[0]].ID,
+		AppTransactionID:       &anchorApp,
+		ResultType:             string(generated.ReconciliationResultTypeSPLITMATCH),
+		ConfidenceScore:        float64(g.score),
+		MatchSignals:           g.signals,
+		MatchStatus:            "pending",
+	}
+	for _, si := range g.stmtIdxs {
+		id := stmts[si].ID
+		res.Members = append(res.Members, MatchMember{StatementTransactionID: &id, Amount: stmts[si].Amount})
+	}
+	for _, ai := range g.appIdxs {
+		id := appTxns[ai].ID
+		res.Members = append(res.Members, MatchMember{AppTransactionID: &id, Amount: appTxns[ai].Amount})
+	}
+	return res
 }
 
-func scoreMatch(stmtAmount float64, stmtDesc, stmtRef string, stmtDate time.Time, at *AppTransaction) (MatchSignals, int) {
-	signals := MatchSignals{}
+// scoreMatch rates how well an app transaction fits a statement row under a scoring
+// profile. The parsed narration adds a reference match on the UTR/RRN and lifts the
+// description score when the counterparty or VPA appears in the app transaction's
+// description.
+func scoreMatch(p ScoringProfile, stmtAmount float64, stmtDesc string, narr Narration, stmtDate time.Time, at *AppTransaction) (MatchSignals, int) {
+	signals := MatchSignals{PaymentMethod: narr.PaymentMethod}
 
 	daysDiff := int(stmtDate.Sub(at.TransactionDate).Hours() / 24)
 	if daysDiff < 0 {
 		daysDiff = -daysDiff
 	}
 	signals.DateDiffDays = daysDiff
-	switch {
-	case daysDiff == 0:
-		signals.DateScore = 40
-	case daysDiff == 1:
-		signals.DateScore = 30
-	case daysDiff == 2:
-		signals.DateScore = 20
+	if daysDiff <= p.DateWindowDays {
+		// Steps down a quarter of the weight per day on the default two-day window.
+		signals.DateScore = weightShare(p.DateWeight, p.DateWindowDays+2-daysDiff, p.DateWindowDays+2)
 	}
 
 	amountDiff := stmtAmount - at.Amount
@@ -494,27 +1442,34 @@ func scoreMatch(stmtAmount float64, stmtDesc, stmtRef string, stmtDate time.Time
 	signals.AmountDiffPct = amountDiffPct
 	switch {
 	case amountDiff == 0:
-		signals.AmountScore = 35
+		signals.AmountScore = p.AmountWeight
 		signals.AmountDiff = 0
-	case amountDiffPct <= 1:
-		signals.AmountScore = 25
-	case amountDiffPct <= 3:
-		signals.AmountScore = 15
+	case amountDiffPct <= p.AmountTolerancePct/3:
+		signals.AmountScore = weightShare(p.AmountWeight, 5, 7)
+	case amountDiffPct <= p.AmountTolerancePct:
+		signals.AmountScore = weightShare(p.AmountWeight, 3, 7)
 	}
 
 	similarity := utils.TokenJaccard(stmtDesc, at.Description)
 	signals.DescriptionSimilarity = similarity
 	switch {
 	case similarity >= 0.7:
-		signals.DescriptionScore = 15
+		signals.DescriptionScore = p.DescriptionWeight
 	case similarity >= 0.5:
-		signals.DescriptionScore = 10
+		signals.DescriptionScore = weightShare(p.DescriptionWeight, 2, 3)
 	case similarity >= 0.3:
-		signals.DescriptionScore = 5
+		signals.DescriptionScore = weightShare(p.DescriptionWeight, 1, 3)
 	}
 
-	if stmtRef != "" && at.ReferenceNumber != "" && stmtRef == at.ReferenceNumber {
-		signals.ReferenceScore = 10
+	if narrationMentions(narr, at.Description) {
+		signals.CounterpartyMatch = true
+		if floor := weightShare(p.DescriptionWeight, 2, 3); signals.DescriptionScore < floor {
+			signals.DescriptionScore = floor
+		}
+	}
+
+	if referencesMatch(narr.Reference, stmtDesc, at.ReferenceNumber) {
+		signals.ReferenceScore = p.ReferenceWeight
 		signals.ReferenceMatch = true
 	}
 
@@ -522,24 +1477,105 @@ func scoreMatch(stmtAmount float64, stmtDesc, stmtRef string, stmtDate time.Time
 	return signals, total
 }
 
-func stmtTxnToCreateParams(userID string, accountID uuid.UUID, st StatementTransaction) generated.CreateTxnBatchParams {
+// txnBalanceDeltas is the lifetime income/expense and account balance change a single
+// transaction causes when it is created.
+func txnBalanceDeltas(txnType generated.TxnType, amount float64) (income, expense, balance float64) {
+	switch txnType {
+	case generated.TxnTypeCREDIT, generated.TxnTypeINCOME,
+		generated.TxnTypeREFUND, generated.TxnTypeINVESTMENT:
+		return amount, 0, amount
+	case generated.TxnTypeDEBIT, generated.TxnTypeSUBSCRIPTION:
+		return 0, amount, -amount
+	}
+	return 0, 0, 0
+}
+
+// accountDelta is the lifetime income/expense and balance change one account takes.
+type accountDelta struct {
+	income, expense, balance float64
+}
+
+// addTxnDeltas adds sign times the change creating a transaction causes to deltas. A
+// transfer moves money between the user's own accounts, so it shifts both balances
+// and is neither income nor expense.
+func addTxnDeltas(deltas map[uuid.UUID]*accountDelta, accountID uuid.UUID, toAccountID *uuid.UUID, txnType generated.TxnType, amount, sign float64) {
+	get := func(id uuid.UUID) *accountDelta {
+		d := deltas[id]
+		if d == nil {
+			d = &accountDelta{}
+			deltas[id] = d
+		}
+		return d
+	}
+	if toAccountID != nil && *toAccountID != accountID {
+		get(accountID).balance -= sign * amount
+		get(*toAccountID).balance += sign * amount
+		return
+	}
+	income, expense, balance := txnBalanceDeltas(txnType, amount)
+	d := get(accountID)
+	d.income += sign * income
+	d.expense += sign * expense
+	d.balance += sign * balance
+}
+
+func (s *ReconService) applyAccountDeltas(ctx context.Context, userID string, deltas map[uuid.UUID]*accountDelta) error {
+	if s.balanceUpdater == nil {
+		return nil
+	}
+	for accountID, d := range deltas {
+		if err := s.balanceUpdater.ApplyBatch(ctx, userID, accountID, d.income, d.expense, d.balance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stmtTxnToCreateParams builds an auto-created transaction from a statement row, taking
+// the reference, payment method and merchant from its narration where it has them. On
+// a credit card a bill payment becomes a transfer from the account that pays the card,
+// when it is known, and any other credit is a refund rather than income.
+func stmtTxnToCreateParams(userID string, accountID uuid.UUID, st StatementTransaction, merchants map[string]uuid.UUID, card *cardLedger) generated.CreateTxnBatchParams {
+	desc, narr := statementNarration(st)
+	txnType := generated.TxnType(st.Type)
+	fromAccount, toAccount := accountID, (*uuid.UUID)(nil)
+	if card != nil && st.Type == string(CREDIT) {
+		switch {
+		case !isCardBillPayment(desc, narr):
+			txnType = generated.TxnTypeREFUND
+		case card.paymentAccountID != nil:
+			fromAccount, toAccount = *card.paymentAccountID, &accountID
+			txnType = generated.TxnTypeDEBIT
+		}
+	}
+	var reference, paymentMethod *string
+	if narr.Reference != "" {
+		reference = &narr.Reference
+	}
+	if narr.PaymentMethod != "" {
+		paymentMethod = &narr.PaymentMethod
+	}
+	var merchantID *uuid.UUID
+	if id, ok := merchants[merchantKey(narr.Merchant)]; ok {
+		merchantID = &id
+	}
 	source := generated.NullTransactionSource{
 		TransactionSource: generated.TransactionSourceSTATEMENTAUTO,
 		Valid:             true,
 	}
 	return generated.CreateTxnBatchParams{
 		UserID:          userID,
-		AccountID:       utils.UUIDToPgtype(accountID),
-		ToAccountID:     utils.UUIDPtrToPgtype(nil),
+		AccountID:       utils.UUIDToPgtype(fromAccount),
+		ToAccountID:     utils.UUIDPtrToPgtype(toAccount),
 		CategoryID:      utils.UUIDPtrToPgtype(nil),
-		MerchantID:      utils.UUIDPtrToPgtype(nil),
-		Type:            generated.TxnType(st.Type),
+		MerchantID:      utils.UUIDPtrToPgtype(merchantID),
+		Type:            txnType,
 		Amount:          utils.Float64PtrToNum(&st.Amount),
 		Description:     utils.StringPtrToText(st.Description),
 		Tags:            utils.StringPtrToText(nil),
 		SmsID:           utils.UUIDPtrToPgtype(nil),
-		PaymentMethod:   utils.StringPtrToText(nil),
-		ReferenceNumber: utils.StringPtrToText(st.ReferenceNumber),
+		PaymentMethod:   utils.StringPtrToText(paymentMethod),
+		ReferenceNumber: utils.StringPtrToText(reference),
 		IsRecurring:     utils.BoolPtrToBool(nil),
 		Notes:           utils.StringPtrToText(nil),
 		TransactionDate: utils.TimestampToPgtype(*st.TransactionDate),
@@ -547,85 +1583,3 @@ func stmtTxnToCreateParams(userID string, accountID uuid.UUID, st StatementTrans
 		StatementTxnID:  utils.UUIDToPgtype(st.ID),
 	}
 }
-
-func parseXlsxRows(r interface {
-	Read(p []byte) (n int, err error)
-}, accountID uuid.UUID, uploadID uuid.UUID,
-) ([]ParsedTxns, []ParseError, error) {
-	ef, err := excelize.OpenReader(r)
-	if err != nil {
-		return nil, nil, err
-	}
-	defer ef.Close()
-
-	sheets := ef.GetSheetList()
-	if len(sheets) == 0 {
-		return nil, nil, fmt.Errorf("no sheet found")
-	}
-	sheet := sheets[0]
-
-	rowsIter, err := ef.Rows(sheet)
-	if err != nil {
-		return nil, nil, err
-	}
-	defer rowsIter.Close()
-
-	var out []ParsedTxns
-	var parseErrors []ParseError
-	rowNum := 0
-
-	for rowsIter.Next() {
-		row, err := rowsIter.Columns()
-		if err != nil {
-			return nil, nil, err
-		}
-		rowNum++
-
-		if rowNum == 1 {
-			continue
-		}
-		if len(row) <= colDrCr {
-			parseErrors = append(parseErrors, ParseError{Row: rowNum, Error: "insufficient columns", Data: map[string]interface{}{"cells": len(row)}})
-			continue
-		}
-
-		txnDate, err := ParseExcelDate(row, colTxnDate)
-		if err != nil {
-			parseErrors = append(parseErrors, ParseError{Row: rowNum, Error: "invalid transaction date", Data: map[string]interface{}{"value": SafeCell(row, colTxnDate)}})
-			continue
-		}
-
-		amount, err := ParseExcelAmount(row, colAmount)
-		if err != nil {
-			parseErrors = append(parseErrors, ParseError{Row: rowNum, Error: "invalid amount", Data: map[string]interface{}{"value": SafeCell(row, colAmount)}})
-			continue
-		}
-
-		drCr := strings.TrimSpace(strings.ToUpper(SafeCell(row, colDrCr)))
-		if drCr != "DR" && drCr != "CR" {
-			parseErrors = append(parseErrors, ParseError{Row: rowNum, Error: "invalid Dr/Cr", Data: map[string]interface{}{"value": SafeCell(row, colDrCr)}})
-			continue
-		}
-
-		desc := strings.TrimSpace(SafeCell(row, colDescription))
-		hash := RowHash(txnDate, amount, drCr, desc)
-		txnType := DEBIT
-		if drCr == "CR" {
-			txnType = CREDIT
-		}
-
-		out = append(out, ParsedTxns{
-			UploadId:        uploadID,
-			AccountId:       accountID,
-			TxnDate:         txnDate,
-			Description:     utils.PtrString(desc),
-			Amount:          amount,
-			Type:            txnType,
-			ReferenceNumber: utils.PtrString(strings.TrimSpace(SafeCell(row, colChqRefNo))),
-			RawRowHash:      &hash,
-			RowNumber:       uint32(rowNum),
-		})
-	}
-
-	return out, parseErrors, nil
-}
@@ -0,0 +1,799 @@
+package reconciliation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/user"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/errs"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/tasks"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// fakeTx runs fn directly and records whether it asked for a rollback.
+type fakeTx struct {
+	rolledBack bool
+}
+
+func (f *fakeTx) WithTx(c context.Context, fn func(c context.Context) error, _ *zerolog.Logger) error {
+	if err := fn(c); err != nil {
+		f.rolledBack = true
+		return err
+	}
+	return nil
+}
+
+// fakeReconRepo records what the service writes; methods a test does not reach are
+// left to the embedded nil interface.
+type fakeReconRepo struct {
+	reconRepository
+	statuses      map[uuid.UUID]generated.UploadProcessingStatus
+	checkpointErr error
+
+	// results are an upload's reconciliation results; decided ones are those the user
+	// has acted on.
+	results   []fakeResult
+	running   bool
+	reverted  []uuid.UUID
+	discarded []uuid.UUID
+
+	// files are the uploads with a statement file still to extract; sweeps counts the
+	// expired-file cleanups run.
+	files  map[uuid.UUID]bool
+	sweeps int
+}
+
+type fakeResult struct {
+	SystemResult
+	decided bool
+}
+
+func newFakeReconRepo() *fakeReconRepo {
+	return &fakeReconRepo{
+		statuses: make(map[uuid.UUID]generated.UploadProcessingStatus),
+		files:    make(map[uuid.UUID]bool),
+	}
+}
+
+func (f *fakeReconRepo) UpdateUploadProcessingStatus(_ context.Context, uploadID uuid.UUID, status generated.UploadProcessingStatus, _ uuid.UUID) error {
+	f.statuses[uploadID] = status
+	return nil
+}
+
+func (f *fakeReconRepo) DeleteStatementFile(_ context.Context, uploadID uuid.UUID) error {
+	delete(f.files, uploadID)
+	return nil
+}
+
+func (f *fakeReconRepo) DeleteExpiredStatementFiles(context.Context) (int64, error) {
+	f.sweeps++
+	return 0, nil
+}
+
+func (f *fakeReconRepo) StartJobCheckpoint(context.Context, string, uuid.UUID) (*JobCheckpoint, error) {
+	if f.checkpointErr != nil {
+		return nil, f.checkpointErr
+	}
+	return &JobCheckpoint{Attempts: 1}, nil
+}
+
+func (f *fakeReconRepo) GetUploadByID(_ context.Context, payload *GetUploadByIDReq, _ string) (*UploadDetail, error) {
+	return &UploadDetail{UploadListItem: UploadListItem{ID: payload.UploadId}}, nil
+}
+
+func (f *fakeReconRepo) ClaimUploadForReprocess(context.Context, uuid.UUID, string) (bool, error) {
+	return !f.running, nil
+}
+
+func (f *fakeReconRepo) ListSystemResults(context.Context, uuid.UUID) ([]SystemResult, error) {
+	var out []SystemResult
+	for _, r := range f.results {
+		if !r.decided {
+			out = append(out, r.SystemResult)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeReconRepo) RevertTransactionReconciliation(_ context.Context, txnID uuid.UUID) error {
+	f.reverted = append(f.reverted, txnID)
+	return nil
+}
+
+func (f *fakeReconRepo) DiscardAutoCreatedTransactions(_ context.Context, txnIDs []uuid.UUID, _ string) ([]DiscardedTxn, error) {
+	f.discarded = append(f.discarded, txnIDs...)
+	return make([]DiscardedTxn, len(txnIDs)), nil
+}
+
+func (f *fakeReconRepo) DeleteSystemResults(context.Context, uuid.UUID) (int64, error) {
+	var kept []fakeResult
+	for _, r := range f.results {
+		if r.decided {
+			kept = append(kept, r)
+		}
+	}
+	cleared := int64(len(f.results) - len(kept))
+	f.results = kept
+	return cleared, nil
+}
+
+// fakeTasks records the reconciliation jobs the service enqueues.
+type fakeTasks struct {
+	reconTaskService
+	enqueued []tasks.BankReconciliationPayload
+}
+
+func (f *fakeTasks) EnqueueBankReconciliation(_ context.Context, payload tasks.BankReconciliationPayload, _ *zerolog.Logger) error {
+	f.enqueued = append(f.enqueued, payload)
+	return nil
+}
+
+var testLog = zerolog.Nop()
+
+func testEchoContext() echo.Context {
+	return echo.New().NewContext(httptest.NewRequest("POST", "/", nil), httptest.NewRecorder())
+}
+
+func TestRunReconciliationJobFailureMarksUpload(t *testing.T) {
+	repo := newFakeReconRepo()
+	repo.checkpointErr = errors.New("connection reset")
+	svc := &ReconService{repo: repo, tm: &fakeTx{}}
+	uploadID := uuid.New()
+	repo.files[uploadID] = true
+
+	_, err := svc.RunReconciliationJob(context.Background(), tasks.BankReconciliationPayload{JobID: "job-1", UploadID: uploadID}, &testLog)
+	if err == nil {
+		t.Fatal("RunReconciliationJob() error = nil, want the checkpoint error")
+	}
+	if got := repo.statuses[uploadID]; got != generated.UploadProcessingStatusFAILED {
+		t.Errorf("upload status = %q, want FAILED", got)
+	}
+	if repo.files[uploadID] {
+		t.Error("failed upload kept its statement file")
+	}
+	if repo.sweeps != 1 {
+		t.Errorf("expired statement files swept %d times, want 1", repo.sweeps)
+	}
+}
+
+// chunkRepo serves an upload's statement rows to a reconciliation job and records the
+// chunks it commits.
+type chunkRepo struct {
+	*fakeReconRepo
+	checkpoint JobCheckpoint
+	rows       []StatementTransaction
+	processed  map[uuid.UUID]bool
+	progress   int
+	fileReads  int
+	// raced makes the first checkpoint advance lose to another attempt.
+	raced     bool
+	advances  []int
+	finals    []bool
+	completed bool
+}
+
+func (f *chunkRepo) StartJobCheckpoint(context.Context, string, uuid.UUID) (*JobCheckpoint, error) {
+	cp := f.checkpoint
+	return &cp, nil
+}
+
+func (f *chunkRepo) GetStatementFile(context.Context, uuid.UUID) (*StatementFile, error) {
+	f.fileReads++
+	return nil, nil
+}
+
+func (f *chunkRepo) ListDecidedLinks(context.Context, uuid.UUID) ([]DecidedLink, error) {
+	return nil, nil
+}
+
+func (f *chunkRepo) CountStatementTransactionsForProcessing(context.Context, uuid.UUID) (int, error) {
+	return len(f.rows) - len(f.processed), nil
+}
+
+func (f *chunkRepo) ResetUploadProgress(_ context.Context, _ uuid.UUID, _, _, _, _, processed int) error {
+	f.progress = processed
+	return nil
+}
+
+func (f *chunkRepo) GetAccountType(context.Context, uuid.UUID, string) (string, error) {
+	return "SAVINGS", nil
+}
+
+func (f *chunkRepo) GetScoringProfile(context.Context, string, uuid.UUID) (*ScoringProfile, error) {
+	return nil, nil
+}
+
+func (f *chunkRepo) GetMaxAppTransactionDate(context.Context, uuid.UUID) (*time.Time, error) {
+	return nil, nil
+}
+
+func (f *chunkRepo) ListClaimedAppTransactionIDs(context.Context, uuid.UUID) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (f *chunkRepo) GetStatementTransactionsForProcessing(_ context.Context, _ uuid.UUID, limit int32) ([]StatementTransaction, error) {
+	var out []StatementTransaction
+	for _, st := range f.rows {
+		if !f.processed[st.ID] && len(out) < int(limit) {
+			out = append(out, st)
+		}
+	}
+	return out, nil
+}
+
+func (f *chunkRepo) FindMerchantsByKeys(context.Context, []string) (map[string]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (f *chunkRepo) CreateAutoTransactionsBatch(_ context.Context, params []generated.CreateTxnBatchParams) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, len(params))
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+	return ids, nil
+}
+
+func (f *chunkRepo) InsertReconciliationResults(_ context.Context, results []ReconciliationResult) error {
+	for _, res := range results {
+		f.processed[res.StatementTransactionID] = true
+	}
+	return nil
+}
+
+func (f *chunkRepo) AddUploadChunkProgress(_ context.Context, _ uuid.UUID, processed, _, _, _ int) error {
+	f.progress += processed
+	return nil
+}
+
+func (f *chunkRepo) AdvanceJobCheckpoint(_ context.Context, _ string, chunksCommitted int, _ []uuid.UUID, final bool) (bool, error) {
+	if f.raced {
+		return false, nil
+	}
+	f.advances = append(f.advances, chunksCommitted)
+	f.finals = append(f.finals, final)
+	return true, nil
+}
+
+func (f *chunkRepo) CompleteJobCheckpoint(context.Context, string) error {
+	f.completed = true
+	return nil
+}
+
+func TestRunReconciliationJobChunks(t *testing.T) {
+	total := 2*reconChunkSize + 1
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := make([]StatementTransaction, total)
+	for i := range rows {
+		date := base.AddDate(0, 0, i/10)
+		desc := fmt.Sprintf("UPI/SHOP/%d", i)
+		rows[i] = StatementTransaction{ID: uuid.New(), TransactionDate: &date, Description: &desc, Amount: 100, Type: string(DEBIT)}
+	}
+	priorIDs := func(n int) []uuid.UUID {
+		ids := make([]uuid.UUID, n)
+		for i := range ids {
+			ids[i] = uuid.New()
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name          string
+		checkpoint    JobCheckpoint
+		committedRows int
+		raced         bool
+		wantAdvances  []int
+		wantCreated   int
+		wantFileReads int
+		wantStatus    generated.UploadProcessingStatus
+	}{
+		{
+			name:          "fresh job commits one chunk at a time",
+			checkpoint:    JobCheckpoint{Stage: jobStageStarted, Attempts: 1},
+			wantAdvances:  []int{0, 1, 2},
+			wantCreated:   total,
+			wantFileReads: 1,
+			wantStatus:    generated.UploadProcessingStatusCOMPLETED,
+		},
+		{
+			name:          "retry resumes after the last committed chunk",
+			checkpoint:    JobCheckpoint{Stage: jobStageStarted, Attempts: 2, ChunksCommitted: 1, CreatedTxnIDs: priorIDs(reconChunkSize)},
+			committedRows: reconChunkSize,
+			wantAdvances:  []int{1, 2},
+			wantCreated:   total,
+			wantStatus:    generated.UploadProcessingStatusCOMPLETED,
+		},
+		{
+			name:          "replay of a committed job only completes it",
+			checkpoint:    JobCheckpoint{Stage: jobStageCommitted, Attempts: 2, ChunksCommitted: 3, CreatedTxnIDs: priorIDs(total)},
+			committedRows: total,
+			wantCreated:   total,
+			wantStatus:    generated.UploadProcessingStatusCOMPLETED,
+		},
+		{
+			name:          "replay of a completed job does nothing",
+			checkpoint:    JobCheckpoint{Stage: jobStageCompleted, Attempts: 2, ChunksCommitted: 3},
+			committedRows: total,
+		},
+		{
+			name:          "attempt that loses the checkpoint race stops",
+			checkpoint:    JobCheckpoint{Stage: jobStageStarted, Attempts: 1},
+			raced:         true,
+			wantFileReads: 1,
+			wantStatus:    generated.UploadProcessingStatusPROCESSING,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &chunkRepo{
+				fakeReconRepo: newFakeReconRepo(),
+				checkpoint:    tt.checkpoint,
+				rows:          rows,
+				processed:     make(map[uuid.UUID]bool),
+				progress:      tt.committedRows,
+				raced:         tt.raced,
+			}
+			for _, st := range rows[:tt.committedRows] {
+				repo.processed[st.ID] = true
+			}
+			svc := &ReconService{repo: repo, tm: &fakeTx{}}
+			payload := tasks.BankReconciliationPayload{JobID: "job-1", UploadID: uuid.New(), AccountID: uuid.New(), UserID: "user_1", ReconciliationThreshold: 80}
+
+			created, err := svc.RunReconciliationJob(context.Background(), payload, &testLog)
+			if err != nil {
+				t.Fatalf("RunReconciliationJob() error = %v", err)
+			}
+			if !slices.Equal(repo.advances, tt.wantAdvances) {
+				t.Errorf("checkpoint advanced with %v chunks committed, want %v", repo.advances, tt.wantAdvances)
+			}
+			for i, final := range repo.finals {
+				if final != (i == len(repo.finals)-1) {
+					t.Errorf("chunk %d final = %v", i, final)
+				}
+			}
+			if len(created) != tt.wantCreated {
+				t.Errorf("created %d transactions, want %d", len(created), tt.wantCreated)
+			}
+			if repo.fileReads != tt.wantFileReads {
+				t.Errorf("statement file read %d times, want %d", repo.fileReads, tt.wantFileReads)
+			}
+			if got := repo.statuses[payload.UploadID]; got != tt.wantStatus {
+				t.Errorf("upload status = %q, want %q", got, tt.wantStatus)
+			}
+			if repo.completed != (tt.wantStatus == generated.UploadProcessingStatusCOMPLETED) {
+				t.Errorf("checkpoint completed = %v, want it closed only with the upload", repo.completed)
+			}
+			if tt.wantStatus == generated.UploadProcessingStatusCOMPLETED && (repo.progress != total || len(repo.processed) != total) {
+				t.Errorf("processed %d of %d rows with progress %d, want every row counted once", len(repo.processed), total, repo.progress)
+			}
+		})
+	}
+}
+
+// fakeCredits hands out LLM parse credits while any are left.
+type fakeCredits struct {
+	userThresholdProvider
+	left int
+}
+
+func (f *fakeCredits) ReserveLlmCredit(context.Context, string, string, *uuid.UUID) (*user.LlmCreditReservation, error) {
+	if f.left == 0 {
+		return nil, user.ErrLlmCreditsExhausted
+	}
+	f.left--
+	return &user.LlmCreditReservation{Id: uuid.New(), Remaining: f.left}, nil
+}
+
+func TestReserveStatementParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		left     int
+		wantCode int
+	}{
+		{name: "credit left", left: 1},
+		{name: "no credits left", left: 0, wantCode: http.StatusPaymentRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credits := &fakeCredits{left: tt.left}
+			svc := &ReconService{userService: credits}
+
+			err := svc.reserveStatementParse(context.Background(), "user_1", uuid.New())
+			var httpErr *errs.HTTPError
+			switch {
+			case tt.wantCode == 0 && err != nil:
+				t.Fatalf("reserveStatementParse() error = %v", err)
+			case tt.wantCode != 0 && (!errors.As(err, &httpErr) || httpErr.Status != tt.wantCode):
+				t.Fatalf("reserveStatementParse() error = %v, want HTTP %d", err, tt.wantCode)
+			}
+			if tt.wantCode == 0 && credits.left != tt.left-1 {
+				t.Errorf("credits left = %d, want %d", credits.left, tt.left-1)
+			}
+		})
+	}
+}
+
+func TestReprocessUpload(t *testing.T) {
+	uploadID := uuid.New()
+	verifiedTxn, autoTxn, decidedTxn := uuid.New(), uuid.New(), uuid.New()
+	verifiedRow, autoRow, decidedRow := uuid.New(), uuid.New(), uuid.New()
+	results := func() []fakeResult {
+		return []fakeResult{
+			{SystemResult: SystemResult{
+				ID:                      uuid.New(),
+				ResultType:              string(generated.ReconciliationResultTypeHIGHCONFIDENCEMATCH),
+				StatementTransactionID:  &verifiedRow,
+				AppTransactionID:        &verifiedTxn,
+				AppReconciliationStatus: string(generated.TransactionReconciliationStatusAUTOVERIFIED),
+				AppStatementTxnID:       &verifiedRow,
+			}},
+			{SystemResult: SystemResult{
+				ID:                     uuid.New(),
+				ResultType:             string(generated.ReconciliationResultTypeMISSINGINAPP),
+				StatementTransactionID: &autoRow,
+				AppTransactionID:       &autoTxn,
+				AppSource:              string(generated.TransactionSourceSTATEMENTAUTO),
+				AppStatementTxnID:      &autoRow,
+			}},
+			{decided: true, SystemResult: SystemResult{
+				ID:                      uuid.New(),
+				ResultType:              string(generated.ReconciliationResultTypeLOWCONFIDENCEMATCH),
+				StatementTransactionID:  &decidedRow,
+				AppTransactionID:        &decidedTxn,
+				AppReconciliationStatus: string(generated.TransactionReconciliationStatusAUTOVERIFIED),
+				AppStatementTxnID:       &decidedRow,
+			}},
+		}
+	}
+
+	t.Run("decided results are kept and system links reverted", func(t *testing.T) {
+		repo := newFakeReconRepo()
+		repo.results = results()
+		taskSvc := &fakeTasks{}
+		svc := &ReconService{repo: repo, tm: &fakeTx{}, taskService: taskSvc}
+
+		res, err := svc.ReprocessUpload(testEchoContext(), &ReprocessUploadReq{UploadId: uploadID}, "user_1")
+		if err != nil {
+			t.Fatalf("ReprocessUpload() error = %v", err)
+		}
+		if len(repo.reverted) != 1 || repo.reverted[0] != verifiedTxn {
+			t.Errorf("reverted %v, want only %s", repo.reverted, verifiedTxn)
+		}
+		if len(repo.discarded) != 1 || repo.discarded[0] != autoTxn {
+			t.Errorf("discarded %v, want only %s", repo.discarded, autoTxn)
+		}
+		if len(repo.results) != 1 || !repo.results[0].decided {
+			t.Errorf("results left = %+v, want only the decided one", repo.results)
+		}
+		if res.ClearedResults != 2 || res.RevertedTransactions != 1 || res.RemovedTransactions != 1 {
+			t.Errorf("ReprocessUpload() = %+v, want 2 cleared, 1 reverted, 1 removed", res)
+		}
+		if got := repo.statuses[uploadID]; got != generated.UploadProcessingStatusUPLOADED {
+			t.Errorf("upload status = %q, want UPLOADED", got)
+		}
+		if len(taskSvc.enqueued) != 1 || taskSvc.enqueued[0].UploadID != uploadID {
+			t.Errorf("enqueued %+v, want one job for the upload", taskSvc.enqueued)
+		}
+	})
+
+	t.Run("upload still running is refused", func(t *testing.T) {
+		repo := newFakeReconRepo()
+		repo.results = results()
+		repo.running = true
+		taskSvc := &fakeTasks{}
+		tx := &fakeTx{}
+		svc := &ReconService{repo: repo, tm: tx, taskService: taskSvc}
+
+		if _, err := svc.ReprocessUpload(testEchoContext(), &ReprocessUploadReq{UploadId: uploadID}, "user_1"); err == nil {
+			t.Fatal("ReprocessUpload() error = nil, want the upload to be refused")
+		}
+		if !tx.rolledBack {
+			t.Error("refused reprocess did not roll back")
+		}
+		if len(repo.reverted) != 0 || len(repo.discarded) != 0 || len(repo.results) != 3 {
+			t.Errorf("refused reprocess changed results: reverted %v, discarded %v, %d results left", repo.reverted, repo.discarded, len(repo.results))
+		}
+		if len(taskSvc.enqueued) != 0 {
+			t.Errorf("refused reprocess enqueued %d jobs", len(taskSvc.enqueued))
+		}
+	})
+}
+
+// reviewRepo serves the app transactions a review decision touches and records what
+// was done to each.
+type reviewRepo struct {
+	reconRepository
+	targets   []ReviewTxn
+	updateErr error
+
+	verified, rejected, restored, reverted []uuid.UUID
+}
+
+func (f *reviewRepo) GetReviewTargets(context.Context, []uuid.UUID, string, uuid.UUID) ([]ReviewTxn, error) {
+	return f.targets, nil
+}
+
+func (f *reviewRepo) BulkUpdateResultStatus(_ context.Context, resultIDs []uuid.UUID, userAction, _ string, _ uuid.UUID) ([]UpdateResultStatusRes, error) {
+	if f.updateErr != nil {
+		return nil, f.updateErr
+	}
+	out := make([]UpdateResultStatusRes, len(resultIDs))
+	for i, id := range resultIDs {
+		out[i] = UpdateResultStatusRes{ID: id, UserAction: userAction}
+	}
+	return out, nil
+}
+
+func (f *reviewRepo) MarkTransactionUserVerified(_ context.Context, txnID uuid.UUID, _ *uuid.UUID) error {
+	f.verified = append(f.verified, txnID)
+	return nil
+}
+
+func (f *reviewRepo) RejectAutoCreatedTransaction(_ context.Context, txnID uuid.UUID, _ string) (bool, error) {
+	f.rejected = append(f.rejected, txnID)
+	return true, nil
+}
+
+func (f *reviewRepo) RestoreAutoCreatedTransaction(_ context.Context, txnID uuid.UUID, _ *uuid.UUID) (bool, error) {
+	f.restored = append(f.restored, txnID)
+	return true, nil
+}
+
+func (f *reviewRepo) RevertTransactionReconciliation(_ context.Context, txnID uuid.UUID) error {
+	f.reverted = append(f.reverted, txnID)
+	return nil
+}
+
+// fakeBalances sums the balance changes applied to each account.
+type fakeBalances struct {
+	balance map[uuid.UUID]float64
+}
+
+func (f *fakeBalances) ApplyBatch(_ context.Context, _ string, accountID uuid.UUID, _, _, balanceDelta float64) error {
+	f.balance[accountID] += balanceDelta
+	return nil
+}
+
+func TestBulkUpdateResultStatus(t *testing.T) {
+	account, card, txn := uuid.New(), uuid.New(), uuid.New()
+	target := func(resultType generated.ReconciliationResultType, source generated.TransactionSource) ReviewTxn {
+		return ReviewTxn{
+			ResultID:         uuid.New(),
+			ResultType:       string(resultType),
+			AppTransactionID: txn,
+			AccountID:        account,
+			UploadAccountID:  account,
+			Type:             string(generated.TxnTypeDEBIT),
+			Amount:           100,
+			Source:           string(source),
+		}
+	}
+	autoCreated := target(generated.ReconciliationResultTypeMISSINGINAPP, generated.TransactionSourceSTATEMENTAUTO)
+	deletedAutoCreated := autoCreated
+	deletedAutoCreated.Deleted = true
+	savingsLeg := target(generated.ReconciliationResultTypeHIGHCONFIDENCEMATCH, generated.TransactionSourceMANUAL)
+	savingsLeg.UploadAccountID = card
+
+	tests := []struct {
+		name         string
+		action       string
+		target       ReviewTxn
+		updateErr    error
+		wantVerified bool
+		wantRejected bool
+		wantRestored bool
+		wantReverted bool
+		wantBalance  float64
+		wantErr      bool
+	}{
+		{
+			name:         "accepting a match verifies the transaction",
+			action:       "accepted",
+			target:       target(generated.ReconciliationResultTypeHIGHCONFIDENCEMATCH, generated.TransactionSourceSMS),
+			wantVerified: true,
+		},
+		{
+			name:         "rejecting an auto-created row deletes it and reverses its debit",
+			action:       "rejected",
+			target:       autoCreated,
+			wantRejected: true,
+			wantBalance:  100,
+		},
+		{
+			name:         "accepting a rejected auto-created row restores it",
+			action:       "accepted",
+			target:       deletedAutoCreated,
+			wantRestored: true,
+			wantBalance:  -100,
+		},
+		{
+			name:         "rejecting an auto-verified match reverts it",
+			action:       "rejected",
+			target:       target(generated.ReconciliationResultTypeHIGHCONFIDENCEMATCH, generated.TransactionSourceSMS),
+			wantReverted: true,
+		},
+		{
+			name:   "rows not in the statement are left alone",
+			action: "rejected",
+			target: target(generated.ReconciliationResultTypeNOTINSTATEMENT, generated.TransactionSourceSMS),
+		},
+		{
+			name:   "the savings leg of a card bill payment is left alone",
+			action: "rejected",
+			target: savingsLeg,
+		},
+		{
+			name:      "failed status update applies nothing",
+			action:    "accepted",
+			target:    target(generated.ReconciliationResultTypeHIGHCONFIDENCEMATCH, generated.TransactionSourceSMS),
+			updateErr: errors.New("update failed"),
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &reviewRepo{targets: []ReviewTxn{tt.target}, updateErr: tt.updateErr}
+			balances := &fakeBalances{balance: make(map[uuid.UUID]float64)}
+			tx := &fakeTx{}
+			svc := &ReconService{repo: repo, tm: tx, balanceUpdater: balances}
+
+			res, err := svc.BulkUpdateResultStatus(testEchoContext(), &BulkUpdateResultStatusReq{
+				UploadId:   uuid.New(),
+				ResultIds:  []uuid.UUID{tt.target.ResultID},
+				UserAction: tt.action,
+			}, "user_1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BulkUpdateResultStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tx.rolledBack != tt.wantErr {
+				t.Errorf("rolled back = %v, want %v", tx.rolledBack, tt.wantErr)
+			}
+			if !tt.wantErr && len(res.Updated) != 1 {
+				t.Errorf("updated %d results, want 1", len(res.Updated))
+			}
+			for _, c := range []struct {
+				what string
+				got  []uuid.UUID
+				want bool
+			}{
+				{"verified", repo.verified, tt.wantVerified},
+				{"rejected", repo.rejected, tt.wantRejected},
+				{"restored", repo.restored, tt.wantRestored},
+				{"reverted", repo.reverted, tt.wantReverted},
+			} {
+				if (len(c.got) == 1 && c.got[0] == txn) != c.want || len(c.got) > 1 {
+					t.Errorf("%s %v, want %v", c.what, c.got, c.want)
+				}
+			}
+			if balances.balance[account] != tt.wantBalance {
+				t.Errorf("balance change = %v, want %v", balances.balance[account], tt.wantBalance)
+			}
+		})
+	}
+}
+
+// manualMatchRepo serves one result and the app transaction picked for it, on top of
+// reviewRepo's records.
+type manualMatchRepo struct {
+	reviewRepo
+	result   MatchableResult
+	picked   AppTransaction
+	matched  *uuid.UUID
+	signals  MatchSignals
+	uncaught []uuid.UUID
+}
+
+func (f *manualMatchRepo) GetResultForMatch(context.Context, uuid.UUID, string) (*MatchableResult, error) {
+	r := f.result
+	return &r, nil
+}
+
+func (f *manualMatchRepo) GetAppTransactionForMatch(context.Context, uuid.UUID, string) (*AppTransaction, error) {
+	at := f.picked
+	return &at, nil
+}
+
+func (f *manualMatchRepo) GetScoringProfile(context.Context, string, uuid.UUID) (*ScoringProfile, error) {
+	return nil, nil
+}
+
+func (f *manualMatchRepo) UpdateResultManualMatch(_ context.Context, _, appTxnID uuid.UUID, _ float64, signals MatchSignals) error {
+	f.matched, f.signals = &appTxnID, signals
+	return nil
+}
+
+func (f *manualMatchRepo) DeleteNotInStatementResult(_ context.Context, _, appTxnID uuid.UUID) error {
+	f.uncaught = append(f.uncaught, appTxnID)
+	return nil
+}
+
+func TestConfirmManualMatch(t *testing.T) {
+	account := uuid.New()
+	stmtDate := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	desc := "UPI/SWIGGY/412345678901"
+	st := StatementTransaction{ID: uuid.New(), TransactionDate: &stmtDate, Description: &desc, Amount: 250, Type: string(DEBIT)}
+	autoCreated := uuid.New()
+	picked := AppTransaction{ID: uuid.New(), AccountID: account, Amount: 250, TransactionDate: stmtDate.AddDate(0, 0, 1), Type: string(DEBIT), Description: "Swiggy"}
+
+	missing := MatchableResult{
+		ID:               uuid.New(),
+		AccountID:        account,
+		ResultType:       string(generated.ReconciliationResultTypeMISSINGINAPP),
+		Statement:        st,
+		AppTransactionID: &autoCreated,
+		AppSource:        string(generated.TransactionSourceSTATEMENTAUTO),
+		AppType:          string(generated.TxnTypeDEBIT),
+		AppAmount:        250,
+	}
+	lowConfidence := missing
+	lowConfidence.ResultType = string(generated.ReconciliationResultTypeLOWCONFIDENCEMATCH)
+	lowConfidence.AppSource = string(generated.TransactionSourceSMS)
+	matched := missing
+	matched.ResultType = string(generated.ReconciliationResultTypeHIGHCONFIDENCEMATCH)
+	otherAccount := picked
+	otherAccount.AccountID = uuid.New()
+
+	tests := []struct {
+		name         string
+		result       MatchableResult
+		picked       AppTransaction
+		wantRemoved  bool
+		wantReverted bool
+		wantBalance  float64
+		wantBadReq   bool
+	}{
+		{name: "auto-created row is swapped out and its debit reversed", result: missing, picked: picked, wantRemoved: true, wantBalance: 250},
+		{name: "low confidence link is reverted", result: lowConfidence, picked: picked, wantReverted: true},
+		{name: "high confidence match cannot be relinked", result: matched, picked: picked, wantBadReq: true},
+		{name: "transaction on another account", result: missing, picked: otherAccount, wantBadReq: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &manualMatchRepo{result: tt.result, picked: tt.picked}
+			balances := &fakeBalances{balance: make(map[uuid.UUID]float64)}
+			tx := &fakeTx{}
+			svc := &ReconService{repo: repo, tm: tx, balanceUpdater: balances}
+
+			res, err := svc.ConfirmManualMatch(testEchoContext(), &ConfirmManualMatchReq{ResultId: tt.result.ID, AppTransactionId: tt.picked.ID}, "user_1")
+			if tt.wantBadReq {
+				var httpErr *errs.HTTPError
+				if !errors.As(err, &httpErr) || httpErr.Status != http.StatusBadRequest {
+					t.Fatalf("ConfirmManualMatch() error = %v, want a bad request", err)
+				}
+				if !tx.rolledBack || repo.matched != nil {
+					t.Errorf("refused match was applied: rolled back %v, matched %v", tx.rolledBack, repo.matched)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ConfirmManualMatch() error = %v", err)
+			}
+			if repo.matched == nil || *repo.matched != picked.ID || len(repo.verified) != 1 || repo.verified[0] != picked.ID {
+				t.Errorf("matched %v and verified %v, want %s", repo.matched, repo.verified, picked.ID)
+			}
+			if repo.signals.AmountDiff != 0 || repo.signals.DateDiffDays != 1 {
+				t.Errorf("signals %+v, want an exact amount and a one-day gap recorded", repo.signals)
+			}
+			if (res.RemovedTransactionID != nil) != tt.wantRemoved {
+				t.Errorf("removed %v, want removed = %v", res.RemovedTransactionID, tt.wantRemoved)
+			}
+			if (len(repo.reverted) == 1) != tt.wantReverted {
+				t.Errorf("reverted %v, want reverted = %v", repo.reverted, tt.wantReverted)
+			}
+			if balances.balance[account] != tt.wantBalance {
+				t.Errorf("balance change = %v, want %v", balances.balance[account], tt.wantBalance)
+			}
+			if len(repo.uncaught) != 1 {
+				t.Errorf("cleared %d not-in-statement results for the picked transaction, want 1", len(repo.uncaught))
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package reconciliation
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,6 +28,12 @@ func SafeCell(row []string, i int) string {
 	return ""
 }
 
+// indianDateLayouts are the day-first layouts common in Indian bank exports.
+var indianDateLayouts = []string{
+	"02/01/2006", "02/01/06", "02-01-06",
+	"02-Jan-2006", "02-Jan-06", "2 Jan 2006", "02 Jan 2006", "02 Jan 06",
+}
+
 func ParseExcelDate(row []string, col int) (time.Time, error) {
 	v := strings.TrimSpace(SafeCell(row, col))
 	if v == "" {
@@ -46,6 +54,11 @@ func ParseExcelDate(row []string, col int) (time.Time, error) {
 	if t, err := time.Parse("2006-01-02", v); err == nil {
 		return t, nil
 	}
+	for _, layout := range indianDateLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
 	var f float64
 	if _, err := fmt.Sscanf(v, "%f", &f); err == nil && f > 0 {
 		t, _ := excelize.ExcelDateToTime(f, false)
@@ -58,15 +71,61 @@ func ParseExcelDate(row []string, col int) (time.Time, error) {
 }
 
 func ParseExcelAmount(row []string, col int) (float64, error) {
-	v := strings.TrimSpace(SafeCell(row, col))
-	v = strings.ReplaceAll(v, ",", "")
-	var f float64
-	if _, err := fmt.Sscanf(v, "%f", &f); err != nil {
-		return 0, fmt.Errorf("invalid amount: %s", v)
+	return ParseAmountValue(SafeCell(row, col))
+}
+
+// ParseAmountValue parses a statement amount, tolerating thousands separators,
+// currency markers and accounting-style negatives such as "(1,200.00)".
+func ParseAmountValue(v string) (float64, error) {
+	raw := v
+	v = strings.TrimSpace(v)
+	for _, marker := range []string{",", "₹", "INR", "Rs.", "Rs", " "} {
+		v = strings.ReplaceAll(v, marker, "")
+	}
+	negative := false
+	if strings.HasPrefix(v, "(") && strings.HasSuffix(v, ")") {
+		negative = true
+		v = strings.TrimSuffix(strings.TrimPrefix(v, "("), ")")
+	}
+	if strings.HasSuffix(v, "-") {
+		negative = true
+		v = strings.TrimSuffix(v, "-")
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount: %s", raw)
+	}
+	if negative {
+		f = -f
 	}
 	return f, nil
 }
 
+// ParseBalanceValue parses a running-balance cell. A trailing "Dr" marks an
+// overdrawn (negative) balance, as some banks print "5,000.00 Cr" / "120.00 Dr".
+func ParseBalanceValue(v string) (float64, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, fmt.Errorf("empty balance")
+	}
+	upper := strings.ToUpper(v)
+	sign := 1.0
+	for _, suffix := range []string{"(CR)", "(DR)", "CR", "DR"} {
+		if strings.HasSuffix(upper, suffix) {
+			if strings.Contains(suffix, "DR") {
+				sign = -1
+			}
+			v = strings.TrimSpace(v[:len(v)-len(suffix)])
+			break
+		}
+	}
+	f, err := ParseAmountValue(v)
+	if err != nil {
+		return 0, err
+	}
+	return sign * f, nil
+}
+
 func MarkDuplicatesFromInsertedSet(rows []ParsedTxns, insertedHashes map[string]struct{}) {
 	for i := range rows {
 		h := ""
@@ -74,8 +133,78 @@ func MarkDuplicatesFromInsertedSet(rows []ParsedTxns, insertedHashes map[string]
 			h = *rows[i].RawRowHash
 		}
 		_, inserted := insertedHashes[h]
-		rows[i].IsDuplicate = utils.PtrBool(!inserted)
+		overlap := rows[i].IsDuplicate != nil && *rows[i].IsDuplicate
+		rows[i].IsDuplicate = utils.PtrBool(!inserted || overlap)
+	}
+}
+
+// MarkOverlapDuplicates flags parsed rows that another upload of the same account already
+// holds. Rows are compared on day, amount, type and reference number, so two exports of
+// the same period that word the narration differently still dedupe; each stored row
+// absorbs at most one parsed row. Rows whose hash is already stored are left to the
+// insert's hash conflict but still absorb their stored twin. Returns how many were flagged.
+func MarkOverlapDuplicates(rows []ParsedTxns, stored []StoredStatementRow) int {
+	if len(stored) == 0 {
+		return 0
+	}
+	hashes := make(map[string]struct{}, len(stored))
+	for _, st := range stored {
+		hashes[st.RawRowHash] = struct{}{}
+	}
+	remaining := make(map[string]int, len(stored))
+	for _, st := range stored {
+		remaining[overlapKey(st.TransactionDate, st.Amount, st.Type, st.ReferenceNumber)]++
 	}
+	for i := range rows {
+		if rows[i].RawRowHash == nil {
+			continue
+		}
+		if _, ok := hashes[*rows[i].RawRowHash]; ok {
+			remaining[overlapKey(rows[i].TxnDate, rows[i].Amount, string(rows[i].Type), rows[i].ReferenceNumber)]--
+		}
+	}
+
+	flagged := 0
+	for i := range rows {
+		if rows[i].RawRowHash == nil {
+			continue
+		}
+		if _, ok := hashes[*rows[i].RawRowHash]; ok {
+			continue
+		}
+		key := overlapKey(rows[i].TxnDate, rows[i].Amount, string(rows[i].Type), rows[i].ReferenceNumber)
+		if remaining[key] > 0 {
+			remaining[key]--
+			rows[i].IsDuplicate = utils.PtrBool(true)
+			flagged++
+		}
+	}
+	return flagged
+}
+
+// parsedRowsDateRange returns the earliest and latest dates among the parsed rows.
+func parsedRowsDateRange(rows []ParsedTxns) (from, to time.Time, ok bool) {
+	for _, r := range rows {
+		if r.TxnDate.IsZero() {
+			continue
+		}
+		if !ok || r.TxnDate.Before(from) {
+			from = r.TxnDate
+		}
+		if !ok || r.TxnDate.After(to) {
+			to = r.TxnDate
+		}
+		ok = true
+	}
+	return from, to, ok
+}
+
+func overlapKey(date time.Time, amount float64, txnType string, ref *string) string {
+	refKey := ""
+	if ref != nil {
+		refKey = strings.ToUpper(strings.TrimSpace(*ref))
+	}
+	return date.UTC().Format("2006-01-02") + "|" + strconv.FormatInt(toPaise(amount), 10) + "|" + txnType + "|" + refKey
 }
 
 func SummaryFromRows(rows []ParsedTxns, parseErrors []ParseError) UploadSummary {
@@ -94,3 +223,30 @@ func SummaryFromRows(rows []ParsedTxns, parseErrors []ParseError) UploadSummary
 		Errors:        parseErrors,
 	}
 }
+
+// statementContentMatches reports whether the first bytes of an uploaded statement look
+// like the format its extension names. Uploads are accepted with generic content types
+// such as text/plain or application/octet-stream, so the type alone proves nothing.
+func statementContentMatches(ext string, head []byte) bool {
+	switch ext {
+	case ".xlsx":
+		// A zip archive, or the compound file an encrypted workbook is wrapped in.
+		return bytes.HasPrefix(head, []byte("PK\x03\x04")) || bytes.HasPrefix(head, []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1"))
+	case ".pdf":
+		return bytes.Contains(head, []byte("%PDF-"))
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return false
+	}
+	text := bytes.TrimSpace(bytes.TrimPrefix(head, []byte("\xEF\xBB\xBF")))
+	switch ext {
+	case ".xml":
+		return bytes.HasPrefix(text, []byte("<"))
+	case ".ofx", ".qfx":
+		upper := bytes.ToUpper(text)
+		return bytes.Contains(upper, []byte("OFXHEADER")) || bytes.Contains(upper, []byte("<OFX"))
+	case ".sta", ".mt940":
+		return bytes.Contains(text, []byte(":20:")) || bytes.HasPrefix(text, []byte("{1:"))
+	}
+	return true
+}
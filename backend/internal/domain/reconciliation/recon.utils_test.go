@@ -0,0 +1,202 @@
+package reconciliation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAmountValue(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{"1200.50", 1200.50, false},
+		{"1,200.50", 1200.50, false},
+		{"  ₹ 1,00,000.00 ", 100000, false},
+		{"INR 250", 250, false},
+		{"Rs. 99.99", 99.99, false},
+		{"Rs 10", 10, false},
+		{"(1,200.00)", -1200, false},
+		{"500.00-", -500, false},
+		{"-75.25", -75.25, false},
+		{"0", 0, false},
+		{"", 0, true},
+		{"abc", 0, true},
+		{"12.3.4", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmountValue(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAmountValue(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAmountValue(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseBalanceValue(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{"5,000.00", 5000, false},
+		{"5,000.00 Cr", 5000, false},
+		{"5,000.00CR", 5000, false},
+		{"120.00 Dr", -120, false},
+		{"120.00 dr", -120, false},
+		{"120.00 (Dr)", -120, false},
+		{"(120.00)", -120, false},
+		{"₹ 42.10 (Cr)", 42.10, false},
+		{"", 0, true},
+		{"   ", 0, true},
+		{"Dr", 0, true},
+		{"n/a", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBalanceValue(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseBalanceValue(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBalanceValue(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func overlapRow(hash string, day int, amount float64, txnType TxnType, ref string) ParsedTxns {
+	r := balanceRow(0, day, txnType, amount, nil)
+	r.RawRowHash = &hash
+	if ref != "" {
+		r.ReferenceNumber = &ref
+	}
+	return r
+}
+
+func storedRow(hash string, day int, amount float64, txnType TxnType, ref string) StoredStatementRow {
+	st := StoredStatementRow{
+		TransactionDate: time.Date(2024, time.April, day, 0, 0, 0, 0, time.UTC),
+		Amount:          amount,
+		Type:            string(txnType),
+		RawRowHash:      hash,
+	}
+	if ref != "" {
+		st.ReferenceNumber = &ref
+	}
+	return st
+}
+
+func TestMarkOverlapDuplicates(t *testing.T) {
+	tests := []struct {
+		name        string
+		rows        []ParsedTxns
+		stored      []StoredStatementRow
+		wantFlagged []bool
+	}{
+		{
+			name:        "nothing stored",
+			rows:        []ParsedTxns{overlapRow("a", 1, 100, DEBIT, "")},
+			wantFlagged: []bool{false},
+		},
+		{
+			name:        "same transaction worded differently",
+			rows:        []ParsedTxns{overlapRow("new", 1, 100, DEBIT, "")},
+			stored:      []StoredStatementRow{storedRow("old", 1, 100, DEBIT, "")},
+			wantFlagged: []bool{true},
+		},
+		{
+			name:        "reference compared case-insensitively",
+			rows:        []ParsedTxns{overlapRow("new", 1, 100, DEBIT, " ref123 ")},
+			stored:      []StoredStatementRow{storedRow("old", 1, 100, DEBIT, "REF123")},
+			wantFlagged: []bool{true},
+		},
+		{
+			name:        "different reference",
+			rows:        []ParsedTxns{overlapRow("new", 1, 100, DEBIT, "REF1")},
+			stored:      []StoredStatementRow{storedRow("old", 1, 100, DEBIT, "REF2")},
+			wantFlagged: []bool{false},
+		},
+		{
+			name:        "different type",
+			rows:        []ParsedTxns{overlapRow("new", 1, 100, CREDIT, "")},
+			stored:      []StoredStatementRow{storedRow("old", 1, 100, DEBIT, "")},
+			wantFlagged: []bool{false},
+		},
+		{
+			name:        "each stored row absorbs one parsed row",
+			rows:        []ParsedTxns{overlapRow("x", 1, 100, DEBIT, ""), overlapRow("y", 1, 100, DEBIT, "")},
+			stored:      []StoredStatementRow{storedRow("old", 1, 100, DEBIT, "")},
+			wantFlagged: []bool{true, false},
+		},
+		{
+			name:        "row with a stored hash absorbs its twin",
+			rows:        []ParsedTxns{overlapRow("new", 1, 100, DEBIT, ""), overlapRow("old", 1, 100, DEBIT, "")},
+			stored:      []StoredStatementRow{storedRow("old", 1, 100, DEBIT, "")},
+			wantFlagged: []bool{false, false},
+		},
+		{
+			name: "row without a hash is ignored",
+			rows: []ParsedTxns{
+				func() ParsedTxns { r := overlapRow("", 1, 100, DEBIT, ""); r.RawRowHash = nil; return r }(),
+			},
+			stored:      []StoredStatementRow{storedRow("old", 1, 100, DEBIT, "")},
+			wantFlagged: []bool{false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantCount := 0
+			for _, f := range tt.wantFlagged {
+				if f {
+					wantCount++
+				}
+			}
+			if got := MarkOverlapDuplicates(tt.rows, tt.stored); got != wantCount {
+				t.Errorf("MarkOverlapDuplicates() = %d, want %d", got, wantCount)
+			}
+			for i, r := range tt.rows {
+				flagged := r.IsDuplicate != nil && *r.IsDuplicate
+				if flagged != tt.wantFlagged[i] {
+					t.Errorf("row %d flagged = %v, want %v", i, flagged, tt.wantFlagged[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStatementContentMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		ext  string
+		head string
+		want bool
+	}{
+		{"xlsx zip", ".xlsx", "PK\x03\x04\x14\x00", true},
+		{"xlsx encrypted", ".xlsx", "\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1\x00", true},
+		{"xlsx renamed csv", ".xlsx", "Date,Amount\n", false},
+		{"pdf", ".pdf", "%PDF-1.7\n", true},
+		{"pdf after leading junk", ".pdf", "\r\n%PDF-1.4", true},
+		{"pdf renamed text", ".pdf", "hello", false},
+		{"xml with bom", ".xml", "\xEF\xBB\xBF  <?xml version=\"1.0\"?>", true},
+		{"xml plain text", ".xml", "Date,Amount", false},
+		{"ofx sgml header", ".ofx", "OFXHEADER:100\nDATA:OFXSGML", true},
+		{"qfx lower case tag", ".qfx", "<?xml?>\n<ofx>", true},
+		{"ofx unrelated", ".ofx", "<html>", false},
+		{"mt940 transaction reference", ".sta", ":20:STARTUMS\n:25:123", true},
+		{"mt940 swift block", ".mt940", "{1:F01BANK}", true},
+		{"mt940 plain text", ".mt940", "Date,Amount", false},
+		{"binary text file", ".csv", "Date\x00Amount", false},
+		{"csv", ".csv", "Date,Amount\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statementContentMatches(tt.ext, []byte(tt.head)); got != tt.want {
+				t.Errorf("statementContentMatches(%q, %q) = %v, want %v", tt.ext, tt.head, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package reconciliation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/errs"
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+)
+
+// Error codes returned with the bad request when an XLSX statement cannot be opened.
+const (
+	ErrCodeStatementPasswordRequired  = "STATEMENT_PASSWORD_REQUIRED"
+	ErrCodeStatementPasswordIncorrect = "STATEMENT_PASSWORD_INCORRECT"
+	ErrCodeStatementSheetNotFound     = "STATEMENT_SHEET_NOT_FOUND"
+)
+
+var (
+	errStatementPasswordRequired  = errors.New("statement file is password protected")
+	errStatementPasswordIncorrect = errors.New("statement file password is incorrect")
+)
+
+var oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// errStatementSheetNotFound names the sheet that was asked for and the ones the workbook has.
+type errStatementSheetNotFound struct {
+	sheet  string
+	sheets []string
+}
+
+func (e *errStatementSheetNotFound) Error() string {
+	return fmt.Sprintf("sheet %q not found; available sheets: %s", e.sheet, strings.Join(e.sheets, ", "))
+}
+
+// xlsxOptions are the per-upload knobs for opening a workbook. Password is only ever
+// handed to excelize and must not be stored or logged.
+type xlsxOptions struct {
+	Password string
+	Sheet    string
+}
+
+// parseXlsxRows parses the chosen sheet, or every sheet that yields transactions when
+// none is chosen. Row numbers continue across sheets so they stay unique in the upload.
+func parseXlsxRows(r io.Reader, profile StatementProfile, accountID uuid.UUID, uploadID uuid.UUID, opts xlsxOptions) ([]ParsedTxns, []ParseError, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	// An encrypted XLSX is wrapped in an OLE compound file rather than being a zip.
+	if opts.Password == "" && bytes.HasPrefix(data, oleSignature) {
+		return nil, nil, errStatementPasswordRequired
+	}
+
+	ef, err := excelize.OpenReader(bytes.NewReader(data), excelize.Options{Password: opts.Password})
+	if err != nil {
+		return nil, nil, xlsxOpenError(err, opts.Password)
+	}
+	defer ef.Close()
+
+	sheets := ef.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, nil, fmt.Errorf("no sheet found")
+	}
+
+	if opts.Sheet != "" {
+		for _, name := range sheets {
+			if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(opts.Sheet)) {
+				rows, parseErrors, _, err := parseXlsxSheet(ef, name, profile, accountID, uploadID)
+				return rows, parseErrors, err
+			}
+		}
+		return nil, nil, &errStatementSheetNotFound{sheet: opts.Sheet, sheets: sheets}
+	}
+
+	var (
+		rows        []ParsedTxns
+		parseErrors []ParseError
+		firstErr    error
+		offset      int
+	)
+	for _, name := range sheets {
+		sheetRows, sheetErrors, count, err := parseXlsxSheet(ef, name, profile, accountID, uploadID)
+		if err != nil || len(sheetRows) == 0 {
+			// Cover sheets, summaries and blank tabs hold no transaction table.
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for i := range sheetRows {
+			sheetRows[i].RowNumber += uint32(offset)
+		}
+		for i := range sheetErrors {
+			sheetErrors[i].Row += offset
+			if len(sheets) > 1 {
+				if sheetErrors[i].Data == nil {
+					sheetErrors[i].Data = map[string]interface{}{}
+				}
+				sheetErrors[i].Data["sheet"] = name
+			}
+		}
+		rows = append(rows, sheetRows...)
+		parseErrors = append(parseErrors, sheetErrors...)
+		offset += count
+	}
+	if len(rows) == 0 && firstErr != nil {
+		return nil, nil, firstErr
+	}
+	return rows, parseErrors, nil
+}
+
+// parseXlsxSheet runs one sheet through the profile-driven parser and reports how many
+// rows it read.
+func parseXlsxSheet(ef *excelize.File, sheet string, profile StatementProfile, accountID, uploadID uuid.UUID) ([]ParsedTxns, []ParseError, int, error) {
+	rowsIter, err := ef.Rows(sheet)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer rowsIter.Close()
+
+	count := 0
+	rows, parseErrors, err := parseStatementRows(func() ([]string, error) {
+		if !rowsIter.Next() {
+			if err := rowsIter.Error(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		count++
+		return rowsIter.Columns()
+	}, profile, accountID, uploadID)
+	return rows, parseErrors, count, err
+}
+
+// xlsxOpenError turns excelize's encryption failures into errors the upload maps to
+// specific bad-request codes.
+func xlsxOpenError(err error, password string) error {
+	switch {
+	case errors.Is(err, excelize.ErrWorkbookPassword):
+		return errStatementPasswordIncorrect
+	case errors.Is(err, excelize.ErrWorkbookFileFormat):
+		// excelize reports an encrypted workbook it could not decrypt as a format error.
+		if password == "" {
+			return errStatementPasswordRequired
+		}
+		return errStatementPasswordIncorrect
+	}
+	return err
+}
+
+// statementOpenError maps workbook open failures to a bad request carrying a specific code,
+// or returns nil for any other error.
+func statementOpenError(err error) *errs.HTTPError {
+	code := ""
+	switch {
+	case errors.Is(err, errStatementPasswordRequired):
+		code = ErrCodeStatementPasswordRequired
+	case errors.Is(err, errStatementPasswordIncorrect):
+		code = ErrCodeStatementPasswordIncorrect
+	default:
+		var sheetErr *errStatementSheetNotFound
+		if !errors.As(err, &sheetErr) {
+			return nil
+		}
+		code = ErrCodeStatementSheetNotFound
+	}
+	msg := err.Error()
+	return errs.NewBadRequestError(strings.ToUpper(msg[:1])+msg[1:], false, &code, nil, nil)
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/user"
+	aiservices "github.com/KaranMali2001/finance-tracker-v2-backend/internal/services/aiServices"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/tasks"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -17,6 +19,7 @@ import (
 type reconQuerier interface {
 	WithTx(tx pgx.Tx) *generated.Queries
 	CreateBankStatementUpload(ctx context.Context, arg generated.CreateBankStatementUploadParams) (pgtype.UUID, error)
+	ClaimUploadForReprocess(ctx context.Context, arg generated.ClaimUploadForReprocessParams) (pgtype.UUID, error)
 	ListBankStatementUploadsByUser(ctx context.Context, userID string) ([]generated.ListBankStatementUploadsByUserRow, error)
 	GetBankStatementUploadByID(ctx context.Context, arg generated.GetBankStatementUploadByIDParams) (generated.GetBankStatementUploadByIDRow, error)
 	DeleteBankStatementUploadByID(ctx context.Context, arg generated.DeleteBankStatementUploadByIDParams) error
@@ -28,7 +31,8 @@ type reconQuerier interface {
 	ListStatementTransactionsByUploadID(ctx context.Context, arg generated.ListStatementTransactionsByUploadIDParams) ([]generated.ListStatementTransactionsByUploadIDRow, error)
 	UpdateUploadSummary(ctx context.Context, arg generated.UpdateUploadSummaryParams) error
 	GetStatementDateRange(ctx context.Context, uploadID pgtype.UUID) (generated.GetStatementDateRangeRow, error)
-	GetStatementTransactionsForProcessing(ctx context.Context, uploadID pgtype.UUID) ([]generated.GetStatementTransactionsForProcessingRow, error)
+	GetStatementTransactionsForProcessing(ctx context.Context, arg generated.GetStatementTransactionsForProcessingParams) ([]generated.GetStatementTransactionsForProcessingRow, error)
+	CountStatementTransactionsForProcessing(ctx context.Context, uploadID pgtype.UUID) (int64, error)
 	GetMaxAppTransactionDate(ctx context.Context, accountID pgtype.UUID) (interface{}, error)
 	GetAppTransactionsInDateRange(ctx context.Context, arg generated.GetAppTransactionsInDateRangeParams) ([]generated.GetAppTransactionsInDateRangeRow, error)
 	CreateTxnBatch(ctx context.Context, arg []generated.CreateTxnBatchParams) *generated.CreateTxnBatchBatchResults
@@ -38,6 +42,54 @@ type reconQuerier interface {
 	CountReconciliationResultsByUploadID(ctx context.Context, uploadID pgtype.UUID) (int64, error)
 	GetReconciliationResultsByUploadID(ctx context.Context, arg generated.GetReconciliationResultsByUploadIDParams) ([]generated.GetReconciliationResultsByUploadIDRow, error)
 	BulkUpdateReconciliationResultStatus(ctx context.Context, arg generated.BulkUpdateReconciliationResultStatusParams) ([]generated.BulkUpdateReconciliationResultStatusRow, error)
+	GetAccountBank(ctx context.Context, arg generated.GetAccountBankParams) (generated.GetAccountBankRow, error)
+	GetStatementFormatProfile(ctx context.Context, arg generated.GetStatementFormatProfileParams) (generated.StatementFormatProfile, error)
+	ListStatementFormatProfiles(ctx context.Context, userID string) ([]generated.StatementFormatProfile, error)
+	UpsertStatementFormatProfile(ctx context.Context, arg generated.UpsertStatementFormatProfileParams) (generated.StatementFormatProfile, error)
+	DeleteStatementFormatProfile(ctx context.Context, arg generated.DeleteStatementFormatProfileParams) error
+	GetAccountCurrentBalance(ctx context.Context, arg generated.GetAccountCurrentBalanceParams) (pgtype.Numeric, error)
+	UpdateUploadBalanceCheck(ctx context.Context, arg generated.UpdateUploadBalanceCheckParams) error
+	ResetUploadProgress(ctx context.Context, arg generated.ResetUploadProgressParams) error
+	AddUploadChunkProgress(ctx context.Context, arg generated.AddUploadChunkProgressParams) error
+	ListClaimedAppTransactionIDs(ctx context.Context, uploadID pgtype.UUID) ([]pgtype.UUID, error)
+	FindMerchantsByKeys(ctx context.Context, dollar_1 []string) ([]generated.FindMerchantsByKeysRow, error)
+	InsertReconciliationGroup(ctx context.Context, arg generated.InsertReconciliationGroupParams) (pgtype.UUID, error)
+	InsertReconciliationMatchMemberBatch(ctx context.Context, arg []generated.InsertReconciliationMatchMemberBatchParams) *generated.InsertReconciliationMatchMemberBatchBatchResults
+	ListReconciliationMatchMembers(ctx context.Context, dollar_1 []pgtype.UUID) ([]generated.ListReconciliationMatchMembersRow, error)
+	GetReconciliationReviewTargets(ctx context.Context, arg generated.GetReconciliationReviewTargetsParams) ([]generated.GetReconciliationReviewTargetsRow, error)
+	MarkTransactionUserVerified(ctx context.Context, arg generated.MarkTransactionUserVerifiedParams) error
+	RevertTransactionReconciliation(ctx context.Context, id pgtype.UUID) error
+	RejectAutoCreatedTransaction(ctx context.Context, arg generated.RejectAutoCreatedTransactionParams) (int64, error)
+	RestoreAutoCreatedTransaction(ctx context.Context, arg generated.RestoreAutoCreatedTransactionParams) (int64, error)
+	GetReconciliationResultForMatch(ctx context.Context, arg generated.GetReconciliationResultForMatchParams) (generated.GetReconciliationResultForMatchRow, error)
+	GetAppTransactionForMatch(ctx context.Context, arg generated.GetAppTransactionForMatchParams) (generated.GetAppTransactionForMatchRow, error)
+	UpdateReconciliationResultManualMatch(ctx context.Context, arg generated.UpdateReconciliationResultManualMatchParams) error
+	DeleteNotInStatementResult(ctx context.Context, arg generated.DeleteNotInStatementResultParams) error
+	ListSystemReconciliationResults(ctx context.Context, uploadID pgtype.UUID) ([]generated.ListSystemReconciliationResultsRow, error)
+	DeleteSystemReconciliationResults(ctx context.Context, uploadID pgtype.UUID) (int64, error)
+	ListDecidedReconciliationLinks(ctx context.Context, uploadID pgtype.UUID) ([]generated.ListDecidedReconciliationLinksRow, error)
+	DiscardAutoCreatedTransactions(ctx context.Context, arg generated.DiscardAutoCreatedTransactionsParams) ([]generated.DiscardAutoCreatedTransactionsRow, error)
+	StartReconciliationJobCheckpoint(ctx context.Context, arg generated.StartReconciliationJobCheckpointParams) (generated.StartReconciliationJobCheckpointRow, error)
+	AdvanceReconciliationJobCheckpoint(ctx context.Context, arg generated.AdvanceReconciliationJobCheckpointParams) (int64, error)
+	ListAccountStatementCoverage(ctx context.Context, arg generated.ListAccountStatementCoverageParams) ([]generated.ListAccountStatementCoverageRow, error)
+	ListAccountStatementRowsInRange(ctx context.Context, arg generated.ListAccountStatementRowsInRangeParams) ([]generated.ListAccountStatementRowsInRangeRow, error)
+	CompleteReconciliationJobCheckpoint(ctx context.Context, jobID string) error
+	GetAccountType(ctx context.Context, arg generated.GetAccountTypeParams) (string, error)
+	GetCardIdentity(ctx context.Context, arg generated.GetCardIdentityParams) (generated.GetCardIdentityRow, error)
+	UpdateUploadCardCycle(ctx context.Context, arg generated.UpdateUploadCardCycleParams) error
+	SaveStatementUploadFile(ctx context.Context, arg generated.SaveStatementUploadFileParams) error
+	GetStatementUploadFile(ctx context.Context, uploadID pgtype.UUID) (generated.GetStatementUploadFileRow, error)
+	DeleteStatementUploadFile(ctx context.Context, uploadID pgtype.UUID) error
+	DeleteExpiredStatementUploadFiles(ctx context.Context) (int64, error)
+	ListCardPaymentAccounts(ctx context.Context, arg generated.ListCardPaymentAccountsParams) ([]generated.ListCardPaymentAccountsRow, error)
+	ListCardPaymentSources(ctx context.Context, arg generated.ListCardPaymentSourcesParams) ([]generated.ListCardPaymentSourcesRow, error)
+	MarkTransactionTransfer(ctx context.Context, arg generated.MarkTransactionTransferParams) (pgtype.UUID, error)
+	GetScoringProfile(ctx context.Context, arg generated.GetScoringProfileParams) (generated.ReconciliationScoringProfile, error)
+	ListScoringProfiles(ctx context.Context, userID string) ([]generated.ReconciliationScoringProfile, error)
+	UpsertScoringProfile(ctx context.Context, arg generated.UpsertScoringProfileParams) (generated.ReconciliationScoringProfile, error)
+	SaveScoringCalibration(ctx context.Context, arg generated.SaveScoringCalibrationParams) (generated.ReconciliationScoringProfile, error)
+	ListCalibrationDecisions(ctx context.Context, arg generated.ListCalibrationDecisionsParams) ([]generated.ListCalibrationDecisionsRow, error)
+	CountUncalibratedDecisions(ctx context.Context, userID string) (int64, error)
 }
 
 // reconRepository is the interface ReconService depends on.
@@ -45,12 +97,16 @@ type reconRepository interface {
 	CreateUpload(ctx context.Context, userID string, accountID uuid.UUID, fileName, fileURL, fileType string, fileSize int, periodStart, periodEnd time.Time) (uuid.UUID, error)
 	ListUploadsByUser(ctx context.Context, userID string) ([]UploadListItem, error)
 	GetUploadByID(ctx context.Context, payload *GetUploadByIDReq, clerkId string) (*UploadDetail, error)
+	ClaimUploadForReprocess(ctx context.Context, uploadID uuid.UUID, clerkId string) (bool, error)
 	DeleteUpload(ctx context.Context, uploadID uuid.UUID, userID string) error
 	InsertStatementTransactions(ctx context.Context, rows []ParsedTxns) (map[string]struct{}, error)
 	GetUploadDetail(ctx context.Context, uploadID uuid.UUID, userID string, limit, offset int32) (*UploadFullDetailPaginated, error)
 	UpdateParseSummary(ctx context.Context, uploadID uuid.UUID, summary UploadSummary) error
 	GetStatementDateRange(ctx context.Context, uploadID uuid.UUID) (minDate, maxDate time.Time, err error)
-	GetStatementTransactionsForProcessing(ctx context.Context, uploadID uuid.UUID) ([]StatementTransaction, error)
+	GetStatementTransactionsForProcessing(ctx context.Context, uploadID uuid.UUID, limit int32) ([]StatementTransaction, error)
+	CountStatementTransactionsForProcessing(ctx context.Context, uploadID uuid.UUID) (int, error)
+	ListClaimedAppTransactionIDs(ctx context.Context, uploadID uuid.UUID) ([]uuid.UUID, error)
+	FindMerchantsByKeys(ctx context.Context, keys []string) (map[string]uuid.UUID, error)
 	GetMaxAppTransactionDate(ctx context.Context, accountID uuid.UUID) (*time.Time, error)
 	GetAppTransactionsInDateRange(ctx context.Context, accountID uuid.UUID, from, to time.Time) ([]AppTransaction, error)
 	CreateAutoTransactionsBatch(ctx context.Context, params []generated.CreateTxnBatchParams) ([]uuid.UUID, error)
@@ -59,11 +115,60 @@ type reconRepository interface {
 	MarkTransactionAutoVerified(ctx context.Context, txnID, stmtTxnID uuid.UUID) error
 	GetResultsByUploadID(ctx context.Context, uploadID uuid.UUID, limit, offset int32) (*PaginatedReconciliationResults, error)
 	BulkUpdateResultStatus(ctx context.Context, resultIDs []uuid.UUID, userAction string, clerkID string, uploadID uuid.UUID) ([]UpdateResultStatusRes, error)
+	GetAccountBank(ctx context.Context, accountID uuid.UUID, userID string) (uuid.UUID, string, error)
+	GetCustomStatementProfile(ctx context.Context, userID string, bankID uuid.UUID) (*StatementProfile, error)
+	ListCustomStatementProfiles(ctx context.Context, userID string) ([]CustomStatementProfile, error)
+	SaveCustomStatementProfile(ctx context.Context, userID string, bankID uuid.UUID, profile StatementProfile) (*CustomStatementProfile, error)
+	DeleteCustomStatementProfile(ctx context.Context, userID string, bankID uuid.UUID) error
+	GetAccountCurrentBalance(ctx context.Context, accountID uuid.UUID, userID string) (*float64, error)
+	UpdateUploadBalanceCheck(ctx context.Context, uploadID uuid.UUID, closingBalance, drift *float64) error
+	ResetUploadProgress(ctx context.Context, uploadID uuid.UUID, total, matched, unmatched, missing, processed int) error
+	AddUploadChunkProgress(ctx context.Context, uploadID uuid.UUID, processed, matched, unmatched, missing int) error
+	GetReviewTargets(ctx context.Context, resultIDs []uuid.UUID, clerkID string, uploadID uuid.UUID) ([]ReviewTxn, error)
+	MarkTransactionUserVerified(ctx context.Context, txnID uuid.UUID, stmtTxnID *uuid.UUID) error
+	RevertTransactionReconciliation(ctx context.Context, txnID uuid.UUID) error
+	RejectAutoCreatedTransaction(ctx context.Context, txnID uuid.UUID, clerkID string) (bool, error)
+	RestoreAutoCreatedTransaction(ctx context.Context, txnID uuid.UUID, stmtTxnID *uuid.UUID) (bool, error)
+	GetResultForMatch(ctx context.Context, resultID uuid.UUID, clerkID string) (*MatchableResult, error)
+	GetAppTransactionForMatch(ctx context.Context, txnID uuid.UUID, clerkID string) (*AppTransaction, error)
+	UpdateResultManualMatch(ctx context.Context, resultID, appTxnID uuid.UUID, score float64, signals MatchSignals) error
+	DeleteNotInStatementResult(ctx context.Context, uploadID, appTxnID uuid.UUID) error
+	ListSystemResults(ctx context.Context, uploadID uuid.UUID) ([]SystemResult, error)
+	DeleteSystemResults(ctx context.Context, uploadID uuid.UUID) (int64, error)
+	ListDecidedLinks(ctx context.Context, uploadID uuid.UUID) ([]DecidedLink, error)
+	DiscardAutoCreatedTransactions(ctx context.Context, txnIDs []uuid.UUID, clerkID string) ([]DiscardedTxn, error)
+	StartJobCheckpoint(ctx context.Context, jobID string, uploadID uuid.UUID) (*JobCheckpoint, error)
+	AdvanceJobCheckpoint(ctx context.Context, jobID string, chunksCommitted int, createdIDs []uuid.UUID, final bool) (bool, error)
+	ListAccountCoverage(ctx context.Context, accountID uuid.UUID, userID string) ([]CoverageUpload, error)
+	ListStoredStatementRows(ctx context.Context, accountID uuid.UUID, from, to time.Time) ([]StoredStatementRow, error)
+	CompleteJobCheckpoint(ctx context.Context, jobID string) error
+	GetAccountType(ctx context.Context, accountID uuid.UUID, userID string) (string, error)
+	GetCardIdentity(ctx context.Context, cardID uuid.UUID, userID string) (*CardIdentity, error)
+	UpdateUploadCardCycle(ctx context.Context, uploadID uuid.UUID, cycle CardCycle) error
+	SaveStatementFile(ctx context.Context, uploadID uuid.UUID, mimeType string, content []byte) error
+	GetStatementFile(ctx context.Context, uploadID uuid.UUID) (*StatementFile, error)
+	DeleteStatementFile(ctx context.Context, uploadID uuid.UUID) error
+	DeleteExpiredStatementFiles(ctx context.Context) (int64, error)
+	ListCardPaymentAccounts(ctx context.Context, userID string, cardID uuid.UUID) ([]CardPaymentAccount, error)
+	ListCardPaymentSources(ctx context.Context, userID string, cardID uuid.UUID, from, to time.Time) ([]CardPaymentSource, error)
+	MarkTransactionTransfer(ctx context.Context, txnID, toAccountID uuid.UUID) (bool, error)
+	GetScoringProfile(ctx context.Context, userID string, accountID uuid.UUID) (*ScoringProfile, error)
+	ListScoringProfiles(ctx context.Context, userID string) ([]ScoringProfileRes, error)
+	SaveScoringProfile(ctx context.Context, userID string, accountID *uuid.UUID, profile ScoringProfile, autoCalibrate bool) (*ScoringProfileRes, error)
+	SaveScoringCalibration(ctx context.Context, userID string, accountID *uuid.UUID, profile ScoringProfile, stats CalibrationStats) error
+	ListCalibrationDecisions(ctx context.Context, userID string, limit int32) ([]CalibrationDecision, error)
+	CountUncalibratedDecisions(ctx context.Context, userID string) (int64, error)
+}
+
+// txRunner runs fn inside one database transaction; *database.TxManager implements it.
+type txRunner interface {
+	WithTx(c context.Context, fn func(c context.Context) error, log *zerolog.Logger) error
 }
 
 // reconTaskService is the narrow interface ReconService needs from tasks.TaskService.
 type reconTaskService interface {
 	EnqueueBankReconciliation(ctx context.Context, payload tasks.BankReconciliationPayload, logger *zerolog.Logger) error
+	EnqueueReconCalibration(ctx context.Context, payload tasks.ReconCalibrationPayload, logger *zerolog.Logger) error
 }
 
 // balanceApplier is the narrow interface ReconService needs from shared.BalanceUpdater.
@@ -75,6 +180,14 @@ type balanceApplier interface {
 // *user.UserService satisfies this implicitly.
 type userThresholdProvider interface {
 	GetReconciliationThreshold(ctx context.Context, clerkId string) (int, error)
+	ReserveLlmCredit(ctx context.Context, clerkId, feature string, referenceID *uuid.UUID) (*user.LlmCreditReservation, error)
+	RefundLlmCredit(ctx context.Context, clerkId string, reservationID uuid.UUID) error
+}
+
+// statementTableExtractor reads statement tables the rule-based parsers cannot.
+// *aiservices.GeminiService satisfies this implicitly.
+type statementTableExtractor interface {
+	ParseStatementTable(ctx context.Context, file []byte, mimeType string, log *zerolog.Logger) ([]aiservices.StatementTableRow, error)
 }
 
 // Compile-time check: *generated.Queries must satisfy reconQuerier.
@@ -0,0 +1,340 @@
+package sms
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/transaction"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/middleware"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// pendingSmsTxn is a transaction a batch will create, with the SMS in the batch that
+// report the same payment. Both are indexes into the batch's messages.
+type pendingSmsTxn struct {
+	result  int
+	req     *transaction.CreateTxnReq
+	repeats []int
+}
+
+// accountLookup is a cached GetAccountIdByNumber result.
+type accountLookup struct {
+	id  *uuid.UUID
+	err error
+}
+
+// CreateSmsBatch stores a device's backlog of SMS in one insert and handles each the way
+// CreateSms does, with a fixed number of queries rather than several per message:
+// accounts are looked up once per number, duplicates are checked against one fetch of
+// the stored transactions, the new transactions are inserted together and the outcomes
+// are written in one update. The ones left for the LLM go in one job. A client message
+// ID the user already sent, before or earlier in the same batch, is reported as a
+// duplicate and not processed again.
+func (s *SmsService) CreateSmsBatch(c echo.Context, payload *CreateSmsBatchReq, clerkId string) (*CreateSmsBatchRes, error) {
+	log := middleware.GetLogger(c)
+	ctx := c.Request().Context()
+
+	results := make([]CreateSmsBatchItemRes, len(payload.Messages))
+	first := make(map[string]int, len(payload.Messages))
+	items := make([]CreateSmsBatchItem, 0, len(payload.Messages))
+	for i, msg := range payload.Messages {
+		results[i] = CreateSmsBatchItemRes{ClientMessageId: msg.ClientMessageId}
+		if _, seen := first[msg.ClientMessageId]; seen {
+			continue
+		}
+		first[msg.ClientMessageId] = i
+		items = append(items, msg)
+	}
+
+	rows, err := s.r.CreateSmsBatch(ctx, items, clerkId)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]*SmsLogs, len(rows))
+	for i := range rows {
+		if rows[i].ClientMessageId != nil {
+			stored[*rows[i].ClientMessageId] = &rows[i]
+		}
+	}
+	var existingIDs []string
+	for _, item := range items {
+		if _, ok := stored[item.ClientMessageId]; !ok {
+			existingIDs = append(existingIDs, item.ClientMessageId)
+		}
+	}
+	existing := make(map[string]*SmsLogs, len(existingIDs))
+	if len(existingIDs) > 0 {
+		found, err := s.r.GetSmsesByClientMessageIds(ctx, clerkId, existingIDs)
+		if err != nil {
+			return nil, err
+		}
+		for i := range found {
+			if found[i].ClientMessageId != nil {
+				existing[*found[i].ClientMessageId] = &found[i]
+			}
+		}
+	}
+
+	learned := make(map[string][]smsTemplate)
+	accounts := make(map[string]accountLookup)
+	accountID := func(suffix string) (*uuid.UUID, error) {
+		l, ok := accounts[suffix]
+		if !ok {
+			l.id, l.err = s.r.GetAccountIdByNumber(ctx, clerkId, suffix)
+			accounts[suffix] = l
+		}
+		return l.id, l.err
+	}
+	outcomes := make([]*smsOutcome, len(payload.Messages))
+	var txnReqs []*transaction.CreateTxnReq
+	for i, msg := range payload.Messages {
+		smsLog, ok := stored[msg.ClientMessageId]
+		if !ok || first[msg.ClientMessageId] != i {
+			results[i].Status = SmsBatchDuplicate
+			results[i].Sms = existing[msg.ClientMessageId]
+			continue
+		}
+		results[i].Status = SmsBatchCreated
+		results[i].Sms = smsLog
+		smsID, err := uuid.Parse(smsLog.Id)
+		if err != nil {
+			continue
+		}
+
+		sender := senderHeader(msg.Sender)
+		templates, ok := learned[sender]
+		if !ok {
+			templates = s.learnedTemplates(ctx, clerkId, msg.Sender, log)
+			learned[sender] = templates
+		}
+		out := s.processSms(log, smsID, &msg.CreateSmsReq, templates, accountID)
+		outcomes[i] = &out
+		if out.txn != nil {
+			txnReqs = append(txnReqs, out.txn)
+		}
+	}
+
+	// A failed fetch falls through to creating the transactions, as a failed lookup does
+	// for a single SMS.
+	candidates, err := s.r.ListDuplicateCandidates(ctx, clerkId, txnReqs)
+	if err != nil {
+		log.Error().Err(err).Msg("[sms] failed to look for duplicate transactions")
+	}
+	var pending []*pendingSmsTxn
+	for i, out := range outcomes {
+		if out == nil || out.txn == nil {
+			continue
+		}
+		if dupID := findStoredRepeat(candidates, out.txn); dupID != nil {
+			out.duplicateOf = dupID
+			continue
+		}
+		if p := findPendingRepeat(pending, out.txn); p != nil {
+			p.repeats = append(p.repeats, i)
+			continue
+		}
+		pending = append(pending, &pendingSmsTxn{result: i, req: out.txn})
+	}
+
+	s.createBatchTxns(c, pending, outcomes, results, clerkId)
+	s.applyBatchOutcomes(ctx, log, outcomes, results, clerkId)
+
+	var llmIDs []uuid.UUID
+	for _, out := range outcomes {
+		if out != nil && out.needsLlm {
+			llmIDs = append(llmIDs, out.smsID)
+		}
+	}
+	s.enqueueLlmParse(ctx, log, clerkId, llmIDs)
+
+	res := &CreateSmsBatchRes{Results: results}
+	for i := range results {
+		switch results[i].Status {
+		case SmsBatchCreated:
+			res.Created++
+		case SmsBatchDuplicate:
+			res.Duplicates++
+		case SmsBatchFailed:
+			res.Failed++
+		}
+	}
+	return res, nil
+}
+
+// createBatchTxns creates the transactions of a batch in one insert and points the SMS
+// that repeat them at the new transaction. When that fails they are created one at a
+// time, so one bad transaction does not hold back the rest; an SMS whose transaction
+// still fails is reported as failed. It stays stored with the status it had, and
+// sending it again is a duplicate.
+func (s *SmsService) createBatchTxns(c echo.Context, pending []*pendingSmsTxn, outcomes []*smsOutcome, results []CreateSmsBatchItemRes, clerkId string) {
+	if len(pending) == 0 {
+		return
+	}
+	log := middleware.GetLogger(c)
+
+	reqs := make([]*transaction.CreateTxnReq, len(pending))
+	for i, p := range pending {
+		reqs[i] = p.req
+	}
+	txns, err := s.txnSvc.CreateTxnBatch(c, reqs, clerkId)
+	if err != nil {
+		log.Error().Err(err).Msg("[sms] failed to create batch transactions, creating them one at a time")
+		txns = make([]*transaction.Transaction, len(pending))
+		for i, p := range pending {
+			txn, err := s.txnSvc.CreateTxn(c, p.req, clerkId)
+			if err != nil {
+				log.Error().Err(err).Msg("[sms] failed to create transaction from SMS")
+				msg := err.Error()
+				for _, r := range append([]int{p.result}, p.repeats...) {
+					results[r].Status = SmsBatchFailed
+					results[r].Error = &msg
+				}
+				continue
+			}
+			txns[i] = txn
+		}
+	}
+
+	for i, p := range pending {
+		if txns[i] == nil {
+			continue
+		}
+		outcomes[p.result].status = "success"
+		txnID, err := uuid.Parse(txns[i].Id)
+		if err != nil {
+			continue
+		}
+		for _, r := range p.repeats {
+			outcomes[r].duplicateOf = &txnID
+		}
+	}
+}
+
+// applyBatchOutcomes writes what processing a batch decided to its SMS in one update,
+// and records the hits and misses of each learned template that parsed any of them.
+func (s *SmsService) applyBatchOutcomes(ctx context.Context, log *zerolog.Logger, outcomes []*smsOutcome, results []CreateSmsBatchItemRes, clerkId string) {
+	hits := make(map[uuid.UUID]int)
+	misses := make(map[uuid.UUID]int)
+	index := make(map[string]int, len(outcomes))
+	var updates []SmsOutcomeUpdate
+	for i, out := range outcomes {
+		if out == nil {
+			continue
+		}
+		switch {
+		case out.learnedMiss:
+			misses[out.learnedID]++
+		case out.learnedID != uuid.Nil:
+			hits[out.learnedID]++
+		}
+		u := SmsOutcomeUpdate{
+			SmsId:            out.smsID,
+			Status:           out.status,
+			Template:         out.template,
+			AvailableBalance: out.availableBalance,
+		}
+		if out.duplicateOf != nil {
+			u.Status = "duplicate"
+			u.MergedTxnId = out.duplicateOf
+			u.ParsedTxn = out.txn
+		}
+		if u.Status == "" && u.Template == "" {
+			continue
+		}
+		updates = append(updates, u)
+		index[out.smsID.String()] = i
+	}
+
+	for id, n := range hits {
+		if err := s.r.RecordSmsTemplateHit(ctx, id, n); err != nil {
+			log.Error().Err(err).Msg("[sms] failed to record template hit")
+		}
+	}
+	s.recordTemplateMisses(ctx, log, misses)
+	if len(updates) == 0 {
+		return
+	}
+	updated, err := s.r.UpdateSmsBatchOutcomes(ctx, clerkId, updates)
+	if err != nil {
+		log.Error().Err(err).Int("sms_count", len(updates)).Msg("[sms] failed to record batch outcomes")
+		return
+	}
+	for i := range updated {
+		if r, ok := index[updated[i].Id]; ok {
+			results[r].Sms = &updated[i]
+		}
+	}
+}
+
+// findStoredRepeat returns the stored transaction req repeats, matched the way
+// FindDuplicateSmsTxn matches: reference number first, then the closest in time.
+func findStoredRepeat(candidates []SmsTxnCandidate, req *transaction.CreateTxnReq) *uuid.UUID {
+	var best *SmsTxnCandidate
+	var bestByRef bool
+	var bestGap time.Duration
+	for i := range candidates {
+		c := &candidates[i]
+		byRef, ok := repeatsSmsTxn(&c.Txn, req)
+		if !ok {
+			continue
+		}
+		gap := c.Txn.TransactionDate.Sub(*req.TransactionDate).Abs()
+		if best == nil || (byRef && !bestByRef) || (byRef == bestByRef && gap < bestGap) {
+			best, bestByRef, bestGap = c, byRef, gap
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return &best.Id
+}
+
+// findPendingRepeat returns the transaction a batch is about to create that req repeats,
+// matched the way FindDuplicateSmsTxn matches stored ones.
+func findPendingRepeat(pending []*pendingSmsTxn, req *transaction.CreateTxnReq) *pendingSmsTxn {
+	var byAmount *pendingSmsTxn
+	for _, p := range pending {
+		byRef, ok := repeatsSmsTxn(p.req, req)
+		if !ok {
+			continue
+		}
+		if byRef {
+			return p
+		}
+		if byAmount == nil {
+			byAmount = p
+		}
+	}
+	return byAmount
+}
+
+// repeatsSmsTxn reports whether req repeats txn: the same type on the same account
+// within smsDuplicateWindow and the same reference number. When either has no
+// reference number, the same amount counts, but only against a transaction created from
+// an SMS, so two payments with different references or a manual entry are never merged.
+// byRef is set when the reference numbers matched.
+func repeatsSmsTxn(txn, req *transaction.CreateTxnReq) (byRef, ok bool) {
+	if txn.Type != req.Type || txn.AccountId != req.AccountId || !withinSmsDuplicateWindow(txn, req) {
+		return false, false
+	}
+	if hasSmsRef(txn) && hasSmsRef(req) {
+		same := *txn.ReferenceNumber == *req.ReferenceNumber
+		return same, same
+	}
+	return false, txn.SmsId != nil && math.Abs(txn.Amount-req.Amount) < 0.01
+}
+
+func hasSmsRef(req *transaction.CreateTxnReq) bool {
+	return req.ReferenceNumber != nil && *req.ReferenceNumber != ""
+}
+
+func withinSmsDuplicateWindow(a, b *transaction.CreateTxnReq) bool {
+	if a.TransactionDate == nil || b.TransactionDate == nil {
+		return false
+	}
+	d := a.TransactionDate.Sub(*b.TransactionDate)
+	return d >= -smsDuplicateWindow && d <= smsDuplicateWindow
+}
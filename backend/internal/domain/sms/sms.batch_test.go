@@ -0,0 +1,205 @@
+package sms
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/transaction"
+	"github.com/google/uuid"
+)
+
+var (
+	testAccount      = uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	otherTestAccount = uuid.MustParse("22222222-2222-2222-2222-222222222222")
+	testReceivedAt   = time.Date(2024, time.April, 5, 10, 0, 0, 0, time.UTC)
+)
+
+// smsTxn builds a transaction parsed from an SMS received minutes after testReceivedAt.
+func smsTxn(account uuid.UUID, txnType transaction.TxnType, amount float64, ref string, minutes int) *transaction.CreateTxnReq {
+	req := manualTxn(account, txnType, amount, ref, minutes)
+	smsID := uuid.New()
+	req.SmsId = &smsID
+	return req
+}
+
+// manualTxn builds a transaction the user entered, dated minutes after testReceivedAt.
+func manualTxn(account uuid.UUID, txnType transaction.TxnType, amount float64, ref string, minutes int) *transaction.CreateTxnReq {
+	at := testReceivedAt.Add(time.Duration(minutes) * time.Minute)
+	req := &transaction.CreateTxnReq{AccountId: account, Type: txnType, Amount: amount, TransactionDate: &at}
+	if ref != "" {
+		req.ReferenceNumber = &ref
+	}
+	return req
+}
+
+func TestRepeatsSmsTxn(t *testing.T) {
+	withRef := smsTxn(testAccount, transaction.TxnTypeDebit, 250, "412345678901", 0)
+	withoutRef := smsTxn(testAccount, transaction.TxnTypeDebit, 250, "", 0)
+	tests := []struct {
+		name      string
+		txn       *transaction.CreateTxnReq
+		req       *transaction.CreateTxnReq
+		wantByRef bool
+		wantOk    bool
+	}{
+		{"same reference", withRef, smsTxn(testAccount, transaction.TxnTypeDebit, 250, "412345678901", 1), true, true},
+		{"same reference different amount", withRef, smsTxn(testAccount, transaction.TxnTypeDebit, 25, "412345678901", 1), true, true},
+		{"same reference on a manual entry", manualTxn(testAccount, transaction.TxnTypeDebit, 250, "412345678901", 0), smsTxn(testAccount, transaction.TxnTypeDebit, 250, "412345678901", 1), true, true},
+		{"same amount, new SMS has no reference", withRef, smsTxn(testAccount, transaction.TxnTypeDebit, 250, "", 5), false, true},
+		{"same amount, stored SMS has no reference", withoutRef, smsTxn(testAccount, transaction.TxnTypeDebit, 250, "412345678901", 5), false, true},
+		{"same amount, different reference", withRef, smsTxn(testAccount, transaction.TxnTypeDebit, 250, "499999999999", 5), false, false},
+		{"same amount on a manual entry", manualTxn(testAccount, transaction.TxnTypeDebit, 250, "", 0), smsTxn(testAccount, transaction.TxnTypeDebit, 250, "", 1), false, false},
+		{"at the edge of the window", withoutRef, smsTxn(testAccount, transaction.TxnTypeDebit, 250, "", -10), false, true},
+		{"outside the window", withRef, smsTxn(testAccount, transaction.TxnTypeDebit, 250, "412345678901", 11), false, false},
+		{"different account", withRef, smsTxn(otherTestAccount, transaction.TxnTypeDebit, 250, "412345678901", 0), false, false},
+		{"different type", withRef, smsTxn(testAccount, transaction.TxnTypeCredit, 250, "412345678901", 0), false, false},
+		{"different amount", withoutRef, smsTxn(testAccount, transaction.TxnTypeDebit, 251, "", 0), false, false},
+		{"undated", withRef, &transaction.CreateTxnReq{AccountId: testAccount, Type: transaction.TxnTypeDebit, Amount: 250}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			byRef, ok := repeatsSmsTxn(tt.txn, tt.req)
+			if byRef != tt.wantByRef || ok != tt.wantOk {
+				t.Errorf("repeatsSmsTxn() = (%v, %v), want (%v, %v)", byRef, ok, tt.wantByRef, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestFindPendingRepeat(t *testing.T) {
+	tests := []struct {
+		name    string
+		pending []*transaction.CreateTxnReq
+		req     *transaction.CreateTxnReq
+		want    int
+	}{
+		{
+			name: "nothing pending",
+			req:  smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", 0),
+			want: -1,
+		},
+		{
+			name: "reference match beats an earlier amount match",
+			pending: []*transaction.CreateTxnReq{
+				smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", 0),
+				smsTxn(testAccount, transaction.TxnTypeDebit, 100, "412345678901", 3),
+			},
+			req:  smsTxn(testAccount, transaction.TxnTypeDebit, 100, "412345678901", 4),
+			want: 1,
+		},
+		{
+			name: "first amount match",
+			pending: []*transaction.CreateTxnReq{
+				smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", 0),
+				smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", 2),
+			},
+			req:  smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", 3),
+			want: 0,
+		},
+		{
+			name: "two payments of the same amount with different references",
+			pending: []*transaction.CreateTxnReq{
+				smsTxn(testAccount, transaction.TxnTypeDebit, 20, "412345678901", 0),
+			},
+			req:  smsTxn(testAccount, transaction.TxnTypeDebit, 20, "412345678999", 5),
+			want: -1,
+		},
+		{
+			name: "same amount on another account",
+			pending: []*transaction.CreateTxnReq{
+				smsTxn(otherTestAccount, transaction.TxnTypeDebit, 100, "", 0),
+			},
+			req:  smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", 0),
+			want: -1,
+		},
+		{
+			name: "same amount an hour later",
+			pending: []*transaction.CreateTxnReq{
+				smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", 0),
+			},
+			req:  smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", 60),
+			want: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending := make([]*pendingSmsTxn, len(tt.pending))
+			for i, req := range tt.pending {
+				pending[i] = &pendingSmsTxn{result: i, req: req}
+			}
+			got := findPendingRepeat(pending, tt.req)
+			switch {
+			case tt.want < 0 && got != nil:
+				t.Errorf("findPendingRepeat() = result %d, want nil", got.result)
+			case tt.want >= 0 && (got == nil || got.result != tt.want):
+				t.Errorf("findPendingRepeat() = %+v, want result %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindStoredRepeat(t *testing.T) {
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	candidate := func(i int, req *transaction.CreateTxnReq) SmsTxnCandidate {
+		return SmsTxnCandidate{Id: ids[i], Txn: *req}
+	}
+	tests := []struct {
+		name       string
+		candidates []SmsTxnCandidate
+		req        *transaction.CreateTxnReq
+		want       int
+	}{
+		{
+			name: "no candidates",
+			req:  smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", 0),
+			want: -1,
+		},
+		{
+			name: "reference match beats a closer amount match",
+			candidates: []SmsTxnCandidate{
+				candidate(0, smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", 0)),
+				candidate(1, smsTxn(testAccount, transaction.TxnTypeDebit, 100, "412345678901", -8)),
+			},
+			req:  smsTxn(testAccount, transaction.TxnTypeDebit, 100, "412345678901", 0),
+			want: 1,
+		},
+		{
+			name: "closest amount match",
+			candidates: []SmsTxnCandidate{
+				candidate(0, smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", -6)),
+				candidate(1, smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", 2)),
+				candidate(2, smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", -4)),
+			},
+			req:  smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", 0),
+			want: 1,
+		},
+		{
+			name: "manual entry is not absorbed on amount",
+			candidates: []SmsTxnCandidate{
+				candidate(0, manualTxn(testAccount, transaction.TxnTypeDebit, 100, "", 0)),
+			},
+			req:  smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", 1),
+			want: -1,
+		},
+		{
+			name: "candidates for other accounts and amounts",
+			candidates: []SmsTxnCandidate{
+				candidate(0, smsTxn(otherTestAccount, transaction.TxnTypeDebit, 100, "", 0)),
+				candidate(1, smsTxn(testAccount, transaction.TxnTypeDebit, 90, "", 0)),
+			},
+			req:  smsTxn(testAccount, transaction.TxnTypeDebit, 100, "", 0),
+			want: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findStoredRepeat(tt.candidates, tt.req)
+			switch {
+			case tt.want < 0 && got != nil:
+				t.Errorf("findStoredRepeat() = %s, want nil", got)
+			case tt.want >= 0 && (got == nil || *got != ids[tt.want]):
+				t.Errorf("findStoredRepeat() = %v, want %s", got, ids[tt.want])
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/transaction"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/errs"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/middleware"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// smsDuplicateWindow is how far apart the SMS for one payment arrive: the bank's debit
+// alert, the UPI app's confirmation and a card alert.
+const smsDuplicateWindow = 10 * time.Minute
+
+// duplicateTxnParams looks for a transaction the one parsed from an SMS received at
+// the given time would repeat.
+func duplicateTxnParams(clerkId string, req *transaction.CreateTxnReq, at time.Time) generated.FindDuplicateSmsTxnParams {
+	ref := req.ReferenceNumber
+	if ref != nil && *ref == "" {
+		ref = nil
+	}
+	return generated.FindDuplicateSmsTxnParams{
+		UserID:          clerkId,
+		Type:            generated.TxnType(req.Type),
+		ReferenceNumber: utils.StringPtrToText(ref),
+		AccountID:       utils.UUIDToPgtype(req.AccountId),
+		Amount:          utils.Float64PtrToNum(&req.Amount),
+		FromDate:        utils.TimestampToPgtype(at.Add(-smsDuplicateWindow)),
+		ToDate:          utils.TimestampToPgtype(at.Add(smsDuplicateWindow)),
+		At:              utils.TimestampToPgtype(at),
+	}
+}
+
+// SplitSms undoes a duplicate merge: the SMS gets its own transaction from what was
+// parsed out of it and is no longer linked to the one it was merged into. Both happen in
+// one database transaction, so a failed create leaves the merge as it was.
+func (s *SmsService) SplitSms(c echo.Context, payload *SplitSmsReq, clerkId string) (*SplitSmsRes, error) {
+	log := middleware.GetLogger(c)
+	ctx := c.Request().Context()
+
+	smsLog, err := s.r.GetSmsById(ctx, &GetSmsByIdReq{SmsId: payload.SmsId}, clerkId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.NewNotFoundError("SMS not found", false, nil)
+		}
+		return nil, err
+	}
+	if smsLog.MergedTransactionId == nil || smsLog.ParsedTxn == nil {
+		return nil, errs.NewBadRequestError("SMS is not merged into another transaction", false, nil, nil, nil)
+	}
+
+	var split *SmsLogs
+	var txn *transaction.Transaction
+	err = s.tm.WithTx(ctx, func(ctx context.Context) error {
+		split, err = s.r.SplitSmsMerge(ctx, payload.SmsId, clerkId, "success")
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errs.NewBadRequestError("SMS is not merged into another transaction", false, nil, nil, nil)
+			}
+			return err
+		}
+		txnReq := *smsLog.ParsedTxn
+		txnReq.SmsId = &payload.SmsId
+		txn, err = s.txnSvc.CreateTxnTx(ctx, &txnReq, clerkId)
+		return err
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+
+	if txnID, err := uuid.Parse(txn.Id); err == nil {
+		if err := s.txnSvc.EnqueueAutoLink(ctx, clerkId, []uuid.UUID{txnID}, log); err != nil {
+			log.Error().Err(err).Msg("[sms] failed to enqueue auto-link after split")
+		}
+	}
+	return &SplitSmsRes{Sms: split, Transaction: txn}, nil
+}
@@ -0,0 +1,255 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/transaction"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/errs"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// fakeTx runs fn directly and records whether it asked for a rollback.
+type fakeTx struct {
+	rolledBack bool
+}
+
+func (f *fakeTx) WithTx(c context.Context, fn func(c context.Context) error, _ *zerolog.Logger) error {
+	if err := fn(c); err != nil {
+		f.rolledBack = true
+		return err
+	}
+	return nil
+}
+
+// fakeSmsRepo keeps one SMS in memory; methods a test does not reach are left to the
+// embedded nil interface.
+type fakeSmsRepo struct {
+	smsRepository
+	sms       SmsLogs
+	accountID *uuid.UUID
+	dupID     *uuid.UUID
+	mergedTo  *uuid.UUID
+	status    string
+
+	// templates are the sender's active learned templates; hits and misses count what
+	// was recorded against them.
+	templates    []SmsTemplate
+	hits, misses int
+}
+
+func (f *fakeSmsRepo) CreateSms(context.Context, *CreateSmsReq, string) (*SmsLogs, error) {
+	s := f.sms
+	return &s, nil
+}
+
+func (f *fakeSmsRepo) GetSmsById(context.Context, *GetSmsByIdReq, string) (*SmsLogs, error) {
+	s := f.sms
+	return &s, nil
+}
+
+func (f *fakeSmsRepo) ListActiveSmsTemplates(context.Context, string, string) ([]SmsTemplate, error) {
+	return f.templates, nil
+}
+
+func (f *fakeSmsRepo) RecordSmsTemplateHit(_ context.Context, _ uuid.UUID, hits int) error {
+	f.hits += hits
+	return nil
+}
+
+func (f *fakeSmsRepo) RecordSmsTemplateMiss(_ context.Context, id uuid.UUID, misses int) (*SmsTemplate, error) {
+	f.misses += misses
+	return &SmsTemplate{Id: id, Status: SmsTemplateCandidate, Misses: f.misses}, nil
+}
+
+func (f *fakeSmsRepo) UpdateSmsTemplateParse(context.Context, uuid.UUID, string, *float64) (*SmsLogs, error) {
+	s := f.sms
+	return &s, nil
+}
+
+func (f *fakeSmsRepo) GetAccountIdByNumber(context.Context, string, string) (*uuid.UUID, error) {
+	if f.accountID == nil {
+		return nil, pgx.ErrNoRows
+	}
+	return f.accountID, nil
+}
+
+func (f *fakeSmsRepo) FindDuplicateTxn(context.Context, string, *transaction.CreateTxnReq, time.Time) (*uuid.UUID, error) {
+	return f.dupID, nil
+}
+
+func (f *fakeSmsRepo) MergeSmsIntoTxn(_ context.Context, _ uuid.UUID, txnID uuid.UUID, parsed *transaction.CreateTxnReq, status string) (*SmsLogs, error) {
+	f.mergedTo, f.status = &txnID, status
+	f.sms.MergedTransactionId, f.sms.ParsedTxn, f.sms.ParsingStatus = &txnID, parsed, status
+	s := f.sms
+	return &s, nil
+}
+
+func (f *fakeSmsRepo) UpdateSmsParsingStatus(_ context.Context, _ uuid.UUID, status string, _ *string) (*SmsLogs, error) {
+	f.status = status
+	f.sms.ParsingStatus = status
+	s := f.sms
+	return &s, nil
+}
+
+func (f *fakeSmsRepo) SplitSmsMerge(context.Context, uuid.UUID, string, string) (*SmsLogs, error) {
+	if f.sms.MergedTransactionId == nil {
+		return nil, pgx.ErrNoRows
+	}
+	f.sms.MergedTransactionId, f.sms.ParsedTxn, f.sms.ParsingStatus = nil, nil, "success"
+	s := f.sms
+	return &s, nil
+}
+
+// fakeTxnCreator records the transactions the SMS service creates.
+type fakeTxnCreator struct {
+	smsTxnCreator
+	created []transaction.CreateTxnReq
+	err     error
+	linked  []uuid.UUID
+}
+
+func (f *fakeTxnCreator) create(req *transaction.CreateTxnReq) (*transaction.Transaction, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.created = append(f.created, *req)
+	return &transaction.Transaction{Id: uuid.NewString()}, nil
+}
+
+func (f *fakeTxnCreator) CreateTxn(_ echo.Context, req *transaction.CreateTxnReq, _ string) (*transaction.Transaction, error) {
+	return f.create(req)
+}
+
+func (f *fakeTxnCreator) CreateTxnTx(_ context.Context, req *transaction.CreateTxnReq, _ string) (*transaction.Transaction, error) {
+	return f.create(req)
+}
+
+func (f *fakeTxnCreator) EnqueueAutoLink(_ context.Context, _ string, ids []uuid.UUID, _ *zerolog.Logger) error {
+	f.linked = append(f.linked, ids...)
+	return nil
+}
+
+func newTestSmsService(repo *fakeSmsRepo, txns *fakeTxnCreator, tx *fakeTx) *SmsService {
+	return &SmsService{r: repo, tm: tx, txnSvc: txns, parser: NewSmsParser()}
+}
+
+func testEchoContext() echo.Context {
+	return echo.New().NewContext(httptest.NewRequest("POST", "/", nil), httptest.NewRecorder())
+}
+
+func TestCreateSmsMergesRepeat(t *testing.T) {
+	account := uuid.New()
+	amount, suffix, ref := 20.0, "4321", "412345678901"
+	payload := &CreateSmsReq{
+		Sender:          "XY-PAYAPP",
+		RawMessage:      "Payment done",
+		ReceivedAt:      testReceivedAt,
+		ParseStatus:     "success",
+		Amount:          &amount,
+		AccountNumber:   &suffix,
+		ReferenceNumber: &ref,
+	}
+	existing := uuid.New()
+	tests := []struct {
+		name        string
+		dupID       *uuid.UUID
+		wantMerged  bool
+		wantCreated int
+		wantStatus  string
+	}{
+		{name: "repeat is linked to the existing transaction", dupID: &existing, wantMerged: true, wantStatus: "duplicate"},
+		{name: "new payment gets its own transaction", wantCreated: 1, wantStatus: "success"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSmsRepo{sms: SmsLogs{Id: uuid.NewString()}, accountID: &account, dupID: tt.dupID}
+			txns := &fakeTxnCreator{}
+			svc := newTestSmsService(repo, txns, &fakeTx{})
+
+			got, err := svc.CreateSms(testEchoContext(), payload, "user_1")
+			if err != nil {
+				t.Fatalf("CreateSms() error = %v", err)
+			}
+			if len(txns.created) != tt.wantCreated {
+				t.Errorf("created %d transactions, want %d", len(txns.created), tt.wantCreated)
+			}
+			if tt.wantMerged {
+				if repo.mergedTo == nil || *repo.mergedTo != existing {
+					t.Errorf("merged into %v, want %s", repo.mergedTo, existing)
+				}
+				if got.ParsedTxn == nil || got.ParsedTxn.Amount != amount || got.ParsedTxn.AccountId != account {
+					t.Errorf("merged SMS kept parsed txn %+v, want the parse so it can be split later", got.ParsedTxn)
+				}
+			} else if repo.mergedTo != nil {
+				t.Errorf("merged into %s, want a new transaction", repo.mergedTo)
+			}
+			if got.ParsingStatus != tt.wantStatus {
+				t.Errorf("ParsingStatus = %q, want %q", got.ParsingStatus, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSplitSms(t *testing.T) {
+	account := uuid.New()
+	merged := uuid.New()
+	smsID := uuid.New()
+	parsed := smsTxn(account, transaction.TxnTypeDebit, 20, "412345678901", 0)
+	mergedSms := SmsLogs{Id: smsID.String(), ParsingStatus: "duplicate", MergedTransactionId: &merged, ParsedTxn: parsed}
+
+	tests := []struct {
+		name         string
+		sms          SmsLogs
+		createErr    error
+		wantErr      bool
+		wantBadReq   bool
+		wantRollback bool
+	}{
+		{name: "merged SMS gets its own transaction", sms: mergedSms},
+		{name: "SMS that was never merged", sms: SmsLogs{Id: smsID.String(), ParsingStatus: "success"}, wantErr: true, wantBadReq: true},
+		{name: "failed create undoes the split", sms: mergedSms, createErr: errors.New("insert failed"), wantErr: true, wantRollback: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSmsRepo{sms: tt.sms}
+			txns := &fakeTxnCreator{err: tt.createErr}
+			tx := &fakeTx{}
+			svc := newTestSmsService(repo, txns, tx)
+
+			res, err := svc.SplitSms(testEchoContext(), &SplitSmsReq{SmsId: smsID}, "user_1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SplitSms() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var httpErr *errs.HTTPError
+			if tt.wantBadReq && !errors.As(err, &httpErr) {
+				t.Errorf("SplitSms() error = %v, want a bad request", err)
+			}
+			if tx.rolledBack != tt.wantRollback {
+				t.Errorf("rolled back = %v, want %v", tx.rolledBack, tt.wantRollback)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(txns.created) != 1 {
+				t.Fatalf("created %d transactions, want 1", len(txns.created))
+			}
+			created := txns.created[0]
+			if created.SmsId == nil || *created.SmsId != smsID || created.Amount != parsed.Amount || created.AccountId != account {
+				t.Errorf("created %+v, want the SMS's own parse", created)
+			}
+			if res.Sms.MergedTransactionId != nil {
+				t.Errorf("split SMS is still merged into %s", res.Sms.MergedTransactionId)
+			}
+			if len(txns.linked) != 1 {
+				t.Errorf("auto-link enqueued for %d transactions, want 1", len(txns.linked))
+			}
+		})
+	}
+}
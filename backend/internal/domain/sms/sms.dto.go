@@ -3,6 +3,7 @@ package sms
 import (
 	"time"
 
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/transaction"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
@@ -22,6 +23,27 @@ type SmsLogs struct {
 	CreatedAt          time.Time `json:"created_at,omitempty"`
 	UpdatedAt          time.Time `json:"updated_at,omitempty"`
 	LastRetryAt        time.Time `json:"last_retry_at,omitempty"`
+	ParsedTemplate     *string   `json:"parsed_template,omitempty"`
+	AvailableBalance   *float64  `json:"available_balance,omitempty"`
+
+	MergedTransactionId *uuid.UUID                `json:"merged_transaction_id,omitempty"`
+	ParsedTxn           *transaction.CreateTxnReq `json:"parsed_txn,omitempty"`
+	ClientMessageId     *string                   `json:"client_message_id,omitempty"`
+}
+
+// SmsTemplate is an SMS template learned from LLM parses, with how it has fared.
+type SmsTemplate struct {
+	Id         uuid.UUID  `json:"id"`
+	Sender     string     `json:"sender"`
+	Pattern    string     `json:"pattern"`
+	Type       string     `json:"type"`
+	Status     string     `json:"status"`
+	Hits       int        `json:"hits"`
+	Misses     int        `json:"misses"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	PromotedAt *time.Time `json:"promoted_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
 }
 type GetSmsByIdReq struct {
 	SmsId uuid.UUID `param:"id" validate:"required"`
@@ -38,12 +60,81 @@ type CreateSmsReq struct {
 	Merchant        *string  `json:"merchant,omitempty"`
 	ReferenceNumber *string  `json:"reference_number,omitempty"`
 }
+
+// deviceParsed reports whether the device read the amount and account out of the SMS.
+func (r *CreateSmsReq) deviceParsed() bool {
+	return r.ParseStatus == "success" && r.Amount != nil && r.AccountNumber != nil
+}
+
+// CreateSmsBatchItem is one SMS from a device's backlog. The client message ID is the
+// device's own ID for the message; sending it again is a no-op.
+type CreateSmsBatchItem struct {
+	ClientMessageId string `json:"client_message_id" validate:"required,max=100"`
+	CreateSmsReq
+}
+
+type CreateSmsBatchReq struct {
+	Messages []CreateSmsBatchItem `json:"messages" validate:"required,min=1,max=500,dive"`
+}
+
+// Outcomes of one message in a batch upload.
+const (
+	SmsBatchCreated   = "created"
+	SmsBatchDuplicate = "duplicate"
+	SmsBatchFailed    = "failed"
+)
+
+type CreateSmsBatchItemRes struct {
+	ClientMessageId string   `json:"client_message_id"`
+	Status          string   `json:"status"`
+	Sms             *SmsLogs `json:"sms,omitempty"`
+	Error           *string  `json:"error,omitempty"`
+}
+
+type CreateSmsBatchRes struct {
+	Created    int                     `json:"created"`
+	Duplicates int                     `json:"duplicates"`
+	Failed     int                     `json:"failed"`
+	Results    []CreateSmsBatchItemRes `json:"results"`
+}
+
+// SmsTxnCandidate is a stored transaction an SMS in a batch could repeat.
+type SmsTxnCandidate struct {
+	Id  uuid.UUID
+	Txn transaction.CreateTxnReq
+}
+
+// SmsOutcomeUpdate is what a batch writes back to one of its SMS. Empty fields keep
+// what the SMS already has.
+type SmsOutcomeUpdate struct {
+	SmsId            uuid.UUID
+	Status           string
+	Template         string
+	AvailableBalance *float64
+	MergedTxnId      *uuid.UUID
+	ParsedTxn        *transaction.CreateTxnReq
+}
+
+type SplitSmsReq struct {
+	SmsId uuid.UUID `param:"id" validate:"required"`
+}
+
+type SplitSmsRes struct {
+	Sms         *SmsLogs                 `json:"sms"`
+	Transaction *transaction.Transaction `json:"transaction"`
+}
 type DeleteSmsReq struct {
 	SmsId uuid.UUID `param:"id" validate:"required"`
 }
 
 type GetSmsesReq struct{}
 
+type GetSmsTemplatesReq struct{}
+
+type DeleteSmsTemplateReq struct {
+	TemplateId uuid.UUID `param:"id" validate:"required"`
+}
+
 func (u *GetSmsesReq) Validate() error {
 	return nil
 }
@@ -52,6 +143,10 @@ func (u *CreateSmsReq) Validate() error {
 	return validator.New().Struct(u)
 }
 
+func (u *CreateSmsBatchReq) Validate() error {
+	return validator.New().Struct(u)
+}
+
 func (u *GetSmsByIdReq) Validate() error {
 	return validator.New().Struct(u)
 }
@@ -59,3 +154,15 @@ func (u *GetSmsByIdReq) Validate() error {
 func (u *DeleteSmsReq) Validate() error {
 	return validator.New().Struct(u)
 }
+
+func (u *GetSmsTemplatesReq) Validate() error {
+	return nil
+}
+
+func (u *DeleteSmsTemplateReq) Validate() error {
+	return validator.New().Struct(u)
+}
+
+func (u *SplitSmsReq) Validate() error {
+	return validator.New().Struct(u)
+}
@@ -115,3 +115,95 @@ func (h *SmsHandler) CreateSms(c echo.Context) error {
 		&CreateSmsReq{},
 	)(c)
 }
+
+// CreateSmsBatch godoc
+// @Summary Upload a batch of SMS
+// @Description Stores up to 500 SMS from a device's backlog in one request. Each message carries the device's client_message_id; one already sent is reported as a duplicate instead of being stored again, so a failed sync can be retried as is. The response has a result for each message, in the order sent.
+// @Tags SMS
+// @Accept json
+// @Produce json
+// @Name CreateSmsBatch
+// @Param sms body CreateSmsBatchReq true "SMS batch request"
+// @Success 200 {object} CreateSmsBatchRes
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /sms/batch [post]
+func (h *SmsHandler) CreateSmsBatch(c echo.Context) error {
+	return handler.Handle(
+		h.base,
+		func(c echo.Context, payload *CreateSmsBatchReq) (*CreateSmsBatchRes, error) {
+			return h.service.CreateSmsBatch(c, payload, middleware.GetUserID(c))
+		},
+		http.StatusOK,
+		&CreateSmsBatchReq{},
+	)(c)
+}
+
+// GetSmsTemplates godoc
+// @Summary Get learned SMS templates
+// @Description Lists the SMS templates learned from LLM parses for the authenticated user, with their hits, misses and when they were last seen. Active templates parse SMS from their sender without calling the LLM.
+// @Tags SMS
+// @Produce json
+// @Name GetSmsTemplates
+// @Success 200 {array} SmsTemplate
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /sms/templates [get]
+func (h *SmsHandler) GetSmsTemplates(c echo.Context) error {
+	return handler.Handle(
+		h.base,
+		func(c echo.Context, payload *GetSmsTemplatesReq) ([]SmsTemplate, error) {
+			return h.service.GetSmsTemplates(c, payload, middleware.GetUserID(c))
+		},
+		http.StatusOK,
+		&GetSmsTemplatesReq{},
+	)(c)
+}
+
+// DeleteSmsTemplate godoc
+// @Summary Delete a learned SMS template
+// @Description Deletes a learned SMS template; SMS it would have parsed go back to the LLM fallback
+// @Tags SMS
+// @Produce json
+// @Name DeleteSmsTemplate
+// @Param id path string true "Template ID" format(uuid)
+// @Success 204
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /sms/templates/{id} [delete]
+func (h *SmsHandler) DeleteSmsTemplate(c echo.Context) error {
+	return handler.HandleNoContent(
+		h.base,
+		func(c echo.Context, payload *DeleteSmsTemplateReq) error {
+			return h.service.DeleteSmsTemplate(c, payload, middleware.GetUserID(c))
+		},
+		http.StatusNoContent,
+		&DeleteSmsTemplateReq{},
+	)(c)
+}
+
+// SplitSms godoc
+// @Summary Split a merged SMS into its own transaction
+// @Description Undoes a duplicate merge: an SMS that was linked to an existing transaction gets its own transaction from what was parsed out of it
+// @Tags SMS
+// @Produce json
+// @Name SplitSms
+// @Param id path string true "SMS ID" format(uuid)
+// @Success 201 {object} SplitSmsRes
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Not Found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /sms/{id}/split [post]
+func (h *SmsHandler) SplitSms(c echo.Context) error {
+	return handler.Handle(
+		h.base,
+		func(c echo.Context, payload *SplitSmsReq) (*SplitSmsRes, error) {
+			return h.service.SplitSms(c, payload, middleware.GetUserID(c))
+		},
+		http.StatusCreated,
+		&SplitSmsReq{},
+	)(c)
+}
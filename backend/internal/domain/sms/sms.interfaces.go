@@ -2,25 +2,45 @@ package sms
 
 import (
 	"context"
+	"time"
 
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/transaction"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
 )
 
 // smsQuerier is the narrow slice of generated.Queries that SmsRepository needs.
+// WithTx is included because the repository creates tx-scoped queriers internally.
 type smsQuerier interface {
+	WithTx(tx pgx.Tx) *generated.Queries
 	GetSmses(ctx context.Context, userID string) ([]generated.SmsLog, error)
 	GetSmsById(ctx context.Context, arg generated.GetSmsByIdParams) (generated.SmsLog, error)
 	DeleteSms(ctx context.Context, arg generated.DeleteSmsParams) error
 	CreateSms(ctx context.Context, arg generated.CreateSmsParams) (generated.SmsLog, error)
+	CreateSmsBatch(ctx context.Context, arg generated.CreateSmsBatchParams) ([]generated.SmsLog, error)
+	GetSmsesByClientMessageIds(ctx context.Context, arg generated.GetSmsesByClientMessageIdsParams) ([]generated.SmsLog, error)
 	UpdateSmsParsingStatus(ctx context.Context, arg generated.UpdateSmsParsingStatusParams) (generated.SmsLog, error)
+	UpdateSmsTemplateParse(ctx context.Context, arg generated.UpdateSmsTemplateParseParams) (generated.SmsLog, error)
+	ListSmsTemplates(ctx context.Context, userID string) ([]generated.SmsTemplate, error)
+	ListActiveSmsTemplates(ctx context.Context, arg generated.ListActiveSmsTemplatesParams) ([]generated.SmsTemplate, error)
+	RecordSmsTemplateHit(ctx context.Context, arg generated.RecordSmsTemplateHitParams) (generated.SmsTemplate, error)
+	RecordSmsTemplateMiss(ctx context.Context, arg generated.RecordSmsTemplateMissParams) (generated.SmsTemplate, error)
+	DeleteSmsTemplate(ctx context.Context, arg generated.DeleteSmsTemplateParams) error
+	FindDuplicateSmsTxn(ctx context.Context, arg generated.FindDuplicateSmsTxnParams) (pgtype.UUID, error)
+	ListSmsDuplicateCandidates(ctx context.Context, arg generated.ListSmsDuplicateCandidatesParams) ([]generated.ListSmsDuplicateCandidatesRow, error)
+	MergeSmsIntoTxn(ctx context.Context, arg generated.MergeSmsIntoTxnParams) (generated.SmsLog, error)
+	UpdateSmsBatchOutcomes(ctx context.Context, arg generated.UpdateSmsBatchOutcomesParams) ([]generated.SmsLog, error)
+	SplitSmsMerge(ctx context.Context, arg generated.SplitSmsMergeParams) (generated.SmsLog, error)
 }
 
 // accountQuerier is the narrow slice of generated.Queries that SmsRepository needs for account lookup.
 type accountQuerier interface {
 	GetAccountByNumber(ctx context.Context, arg generated.GetAccountByNumberParams) (generated.Account, error)
+	ListAccountsByNumberSuffix(ctx context.Context, arg generated.ListAccountsByNumberSuffixParams) ([]generated.Account, error)
 }
 
 // smsRepository is the interface SmsService depends on.
@@ -29,23 +49,46 @@ type smsRepository interface {
 	GetSmsById(ctx context.Context, payload *GetSmsByIdReq, clerkId string) (*SmsLogs, error)
 	DeleteSms(ctx context.Context, payload *DeleteSmsReq, clerkId string) error
 	CreateSms(ctx context.Context, payload *CreateSmsReq, clerkId string) (*SmsLogs, error)
+	CreateSmsBatch(ctx context.Context, items []CreateSmsBatchItem, clerkId string) ([]SmsLogs, error)
+	GetSmsesByClientMessageIds(ctx context.Context, clerkId string, clientMessageIds []string) ([]SmsLogs, error)
 	GetAccountIdByNumber(ctx context.Context, clerkId, accountNumber string) (*uuid.UUID, error)
 	UpdateSmsParsingStatus(ctx context.Context, smsID uuid.UUID, status string, errMsg *string) (*SmsLogs, error)
+	UpdateSmsTemplateParse(ctx context.Context, smsID uuid.UUID, template string, availableBalance *float64) (*SmsLogs, error)
+	ListSmsTemplates(ctx context.Context, clerkId string) ([]SmsTemplate, error)
+	ListActiveSmsTemplates(ctx context.Context, clerkId, sender string) ([]SmsTemplate, error)
+	RecordSmsTemplateHit(ctx context.Context, templateID uuid.UUID, hits int) error
+	RecordSmsTemplateMiss(ctx context.Context, templateID uuid.UUID, misses int) (*SmsTemplate, error)
+	DeleteSmsTemplate(ctx context.Context, payload *DeleteSmsTemplateReq, clerkId string) error
+	FindDuplicateTxn(ctx context.Context, clerkId string, req *transaction.CreateTxnReq, receivedAt time.Time) (*uuid.UUID, error)
+	ListDuplicateCandidates(ctx context.Context, clerkId string, reqs []*transaction.CreateTxnReq) ([]SmsTxnCandidate, error)
+	MergeSmsIntoTxn(ctx context.Context, smsID, txnID uuid.UUID, parsed *transaction.CreateTxnReq, status string) (*SmsLogs, error)
+	UpdateSmsBatchOutcomes(ctx context.Context, clerkId string, updates []SmsOutcomeUpdate) ([]SmsLogs, error)
+	SplitSmsMerge(ctx context.Context, smsID uuid.UUID, clerkId, status string) (*SmsLogs, error)
+}
+
+// txRunner runs fn inside one database transaction; *database.TxManager implements it.
+type txRunner interface {
+	WithTx(c context.Context, fn func(c context.Context) error, log *zerolog.Logger) error
 }
 
 // smsTxnCreator is the subset of transaction.TxnService used by SmsService.
 type smsTxnCreator interface {
 	CreateTxn(c echo.Context, payload *transaction.CreateTxnReq, clerkId string) (*transaction.Transaction, error)
+	CreateTxnTx(ctx context.Context, payload *transaction.CreateTxnReq, clerkId string) (*transaction.Transaction, error)
+	EnqueueAutoLink(ctx context.Context, clerkId string, txnIDs []uuid.UUID, log *zerolog.Logger) error
+	CreateTxnBatch(c echo.Context, payloads []*transaction.CreateTxnReq, clerkId string) ([]*transaction.Transaction, error)
 }
 
 // smsLlmTaskEnqueuer is the subset of tasks.TaskService used by SmsService.
 type smsLlmTaskEnqueuer interface {
 	EnqueueLlmSmsParse(ctx context.Context, smsID uuid.UUID, clerkID string) error
+	EnqueueLlmSmsParseBatch(ctx context.Context, smsIDs []uuid.UUID, clerkID string) error
 }
 
 // smsUserProvider is the subset of user.UserService needed by the SMS module for device auth.
 type smsUserProvider interface {
 	GetUseLlmParsing(ctx context.Context, clerkId string) (bool, error)
+	GetLlmCreditsLeft(ctx context.Context, clerkId string) (int, error)
 	GetClerkIdByApiKey(c echo.Context, apiKey string) (string, error)
 }
 
@@ -3,22 +3,35 @@ package sms
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/transaction"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/user"
 	aiservices "github.com/KaranMali2001/finance-tracker-v2-backend/internal/services/aiServices"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/utils"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rs/zerolog"
 )
 
+// ErrSmsNotAwaitingLlm is returned by RunLlmParse for an SMS that is not waiting on the
+// LLM: the rule-based parse read it, or an earlier attempt already handled it.
+var ErrSmsNotAwaitingLlm = errors.New("sms is not waiting for an LLM parse")
+
 // llmSmsQuerier is the narrow slice of generated.Queries the LLM service needs.
 type llmSmsQuerier interface {
 	GetSmsById(ctx context.Context, arg generated.GetSmsByIdParams) (generated.SmsLog, error)
 	UpdateSmsLlmResult(ctx context.Context, arg generated.UpdateSmsLlmResultParams) (generated.SmsLog, error)
 	GetAccountByNumber(ctx context.Context, arg generated.GetAccountByNumberParams) (generated.Account, error)
+	ListSmsTemplatesBySender(ctx context.Context, arg generated.ListSmsTemplatesBySenderParams) ([]generated.SmsTemplate, error)
+	CreateSmsTemplate(ctx context.Context, arg generated.CreateSmsTemplateParams) (generated.SmsTemplate, error)
+	RecordSmsTemplateHit(ctx context.Context, arg generated.RecordSmsTemplateHitParams) (generated.SmsTemplate, error)
+	RecordSmsTemplateMiss(ctx context.Context, arg generated.RecordSmsTemplateMissParams) (generated.SmsTemplate, error)
+	FindDuplicateSmsTxn(ctx context.Context, arg generated.FindDuplicateSmsTxnParams) (pgtype.UUID, error)
+	MergeSmsIntoTxn(ctx context.Context, arg generated.MergeSmsIntoTxnParams) (generated.SmsLog, error)
 }
 
 // geminiSmsParser is satisfied by *aiservices.GeminiService.
@@ -31,17 +44,29 @@ type smsTxnCreatorCtx interface {
 	CreateTxnCtx(ctx context.Context, payload *transaction.CreateTxnReq, clerkId string) (*transaction.Transaction, error)
 }
 
+// llmCreditReserver is satisfied by *user.UserService.
+type llmCreditReserver interface {
+	ReserveLlmCredit(ctx context.Context, clerkId, feature string, referenceID *uuid.UUID) (*user.LlmCreditReservation, error)
+	RefundLlmCredit(ctx context.Context, clerkId string, reservationID uuid.UUID) error
+}
+
 // SmsLlmService runs the LLM fallback parse flow for a failed SMS log.
 type SmsLlmService struct {
-	q      llmSmsQuerier
-	gemini geminiSmsParser
-	txnSvc smsTxnCreatorCtx
+	q       llmSmsQuerier
+	gemini  geminiSmsParser
+	txnSvc  smsTxnCreatorCtx
+	credits llmCreditReserver
 }
 
-func NewSmsLlmService(q llmSmsQuerier, gemini geminiSmsParser, txnSvc smsTxnCreatorCtx) *SmsLlmService {
-	return &SmsLlmService{q: q, gemini: gemini, txnSvc: txnSvc}
+func NewSmsLlmService(q llmSmsQuerier, gemini geminiSmsParser, txnSvc smsTxnCreatorCtx, credits llmCreditReserver) *SmsLlmService {
+	return &SmsLlmService{q: q, gemini: gemini, txnSvc: txnSvc, credits: credits}
 }
 
+// RunLlmParse parses an SMS with the LLM, paying one of the user's LLM parse credits.
+// Only an SMS the LLM has not read yet is sent: one the rule-based parse failed, or an
+// earlier attempt failed or left unfinished; others return ErrSmsNotAwaitingLlm. A
+// retry reuses the credit an unfinished attempt reserved. With no credits left the SMS
+// keeps what the rule-based parse made of it and user.ErrLlmCreditsExhausted is returned.
 func (s *SmsLlmService) RunLlmParse(ctx context.Context, smsID uuid.UUID, clerkID string, log *zerolog.Logger) error {
 	smsLog, err := s.q.GetSmsById(ctx, generated.GetSmsByIdParams{
 		ID:     utils.UUIDToPgtype(smsID),
@@ -50,6 +75,23 @@ func (s *SmsLlmService) RunLlmParse(ctx context.Context, smsID uuid.UUID, clerkI
 	if err != nil {
 		return fmt.Errorf("sms not found: %w", err)
 	}
+	if !awaitingLlm(smsLog) {
+		return ErrSmsNotAwaitingLlm
+	}
+
+	reservation, err := s.credits.ReserveLlmCredit(ctx, clerkID, user.LlmFeatureSmsParse, &smsID)
+	if err != nil {
+		if errors.Is(err, user.ErrLlmCreditsExhausted) {
+			_, _ = s.q.UpdateSmsLlmResult(ctx, generated.UpdateSmsLlmResultParams{
+				ID:                utils.UUIDToPgtype(smsID),
+				LlmParseAttempted: pgtype.Bool{Bool: false, Valid: true},
+				LlmParsed:         pgtype.Bool{Bool: false, Valid: true},
+				ParsingStatus:     smsLog.ParsingStatus,
+				ErrorMessage:      pgtype.Text{String: err.Error(), Valid: true},
+			})
+		}
+		return err
+	}
 
 	_, err = s.q.UpdateSmsLlmResult(ctx, generated.UpdateSmsLlmResultParams{
 		ID:                utils.UUIDToPgtype(smsID),
@@ -65,6 +107,9 @@ func (s *SmsLlmService) RunLlmParse(ctx context.Context, smsID uuid.UUID, clerkI
 
 	parsed, err := s.gemini.ParseSmsTxn(ctx, smsLog.RawMessage, log)
 	if err != nil {
+		if rerr := s.credits.RefundLlmCredit(context.WithoutCancel(ctx), clerkID, reservation.Id); rerr != nil {
+			log.Error().Err(rerr).Msg("[sms-llm] failed to refund LLM parse credit")
+		}
 		errMsg := err.Error()
 		_, _ = s.q.UpdateSmsLlmResult(ctx, generated.UpdateSmsLlmResultParams{
 			ID:                utils.UUIDToPgtype(smsID),
@@ -104,6 +149,7 @@ func (s *SmsLlmService) RunLlmParse(ctx context.Context, smsID uuid.UUID, clerkI
 			ParsingStatus:     pgtype.Text{String: "llm_success_no_account", Valid: true},
 		})
 		log.Warn().Str("account_number", *parsed.AccountNum).Msg("[sms-llm] account not found, skipping transaction")
+		s.learnTemplate(ctx, smsLog, parsed, log)
 		return nil
 	}
 
@@ -113,15 +159,35 @@ func (s *SmsLlmService) RunLlmParse(ctx context.Context, smsID uuid.UUID, clerkI
 		txnType = transaction.TxnTypeCredit
 	}
 
-	if _, err := s.txnSvc.CreateTxnCtx(ctx, &transaction.CreateTxnReq{
+	txnReq := &transaction.CreateTxnReq{
 		AccountId:       accountID,
 		Type:            txnType,
 		Amount:          parsed.Amount,
 		Description:     parsed.Description,
 		ReferenceNumber: parsed.ReferenceNumber,
+		TransactionDate: &smsLog.ReceivedAt.Time,
 		SmsId:           &smsID,
-	}, clerkID); err != nil {
-		log.Error().Err(err).Msg("[sms-llm] failed to create transaction")
+	}
+	status := "llm_success"
+	dupID, err := s.q.FindDuplicateSmsTxn(ctx, duplicateTxnParams(clerkID, txnReq, smsLog.ReceivedAt.Time))
+	if err == nil {
+		status = "llm_duplicate"
+		parsedTxn, _ := json.Marshal(txnReq)
+		if _, err := s.q.MergeSmsIntoTxn(ctx, generated.MergeSmsIntoTxnParams{
+			ID:                  utils.UUIDToPgtype(smsID),
+			MergedTransactionID: dupID,
+			ParsedTxn:           parsedTxn,
+			ParsingStatus:       pgtype.Text{String: status, Valid: true},
+		}); err != nil {
+			log.Error().Err(err).Msg("[sms-llm] failed to link SMS to existing transaction")
+		}
+	} else {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Error().Err(err).Msg("[sms-llm] failed to look for a duplicate transaction")
+		}
+		if _, err := s.txnSvc.CreateTxnCtx(ctx, txnReq, clerkID); err != nil {
+			log.Error().Err(err).Msg("[sms-llm] failed to create transaction")
+		}
 	}
 
 	_, _ = s.q.UpdateSmsLlmResult(ctx, generated.UpdateSmsLlmResultParams{
@@ -129,9 +195,22 @@ func (s *SmsLlmService) RunLlmParse(ctx context.Context, smsID uuid.UUID, clerkI
 		LlmParseAttempted: pgtype.Bool{Bool: true, Valid: true},
 		LlmParsed:         pgtype.Bool{Bool: true, Valid: true},
 		LlmResponse:       pgtype.Text{String: responseStr, Valid: true},
-		ParsingStatus:     pgtype.Text{String: "llm_success", Valid: true},
+		ParsingStatus:     pgtype.Text{String: status, Valid: true},
 	})
 
-	log.Info().Str("sms_id", smsID.String()).Msg("[sms-llm] successfully parsed and created transaction")
+	log.Info().Str("sms_id", smsID.String()).Str("parsing_status", status).Msg("[sms-llm] successfully parsed SMS")
+	s.learnTemplate(ctx, smsLog, parsed, log)
 	return nil
 }
+
+// awaitingLlm reports whether an SMS still needs an LLM parse.
+func awaitingLlm(smsLog generated.SmsLog) bool {
+	if smsLog.LlmParsed.Bool {
+		return false
+	}
+	switch smsLog.ParsingStatus.String {
+	case "failed", "llm_failed", "llm_processing":
+		return true
+	}
+	return false
+}
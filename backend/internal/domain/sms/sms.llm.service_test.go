@@ -0,0 +1,113 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/user"
+	aiservices "github.com/KaranMali2001/finance-tracker-v2-backend/internal/services/aiServices"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+)
+
+// fakeLlmQuerier keeps one SMS log and applies the LLM results written to it.
+type fakeLlmQuerier struct {
+	llmSmsQuerier
+	sms generated.SmsLog
+}
+
+func (f *fakeLlmQuerier) GetSmsById(context.Context, generated.GetSmsByIdParams) (generated.SmsLog, error) {
+	return f.sms, nil
+}
+
+func (f *fakeLlmQuerier) UpdateSmsLlmResult(_ context.Context, arg generated.UpdateSmsLlmResultParams) (generated.SmsLog, error) {
+	f.sms.LlmParsed, f.sms.ParsingStatus = arg.LlmParsed, arg.ParsingStatus
+	return f.sms, nil
+}
+
+// fakeGemini fails every parse with err.
+type fakeGemini struct {
+	err error
+}
+
+func (f *fakeGemini) ParseSmsTxn(context.Context, string, *zerolog.Logger) (*aiservices.ParsedTxn, error) {
+	return nil, f.err
+}
+
+// fakeCredits hands out one reservation and records refunds and whether their context
+// was still live.
+type fakeCredits struct {
+	reserved   int
+	refunded   int
+	refundLive bool
+}
+
+func (f *fakeCredits) ReserveLlmCredit(context.Context, string, string, *uuid.UUID) (*user.LlmCreditReservation, error) {
+	f.reserved++
+	return &user.LlmCreditReservation{Id: uuid.New()}, nil
+}
+
+func (f *fakeCredits) RefundLlmCredit(ctx context.Context, _ string, _ uuid.UUID) error {
+	f.refunded++
+	f.refundLive = ctx.Err() == nil
+	return nil
+}
+
+func TestAwaitingLlm(t *testing.T) {
+	tests := []struct {
+		status    string
+		llmParsed bool
+		want      bool
+	}{
+		{"failed", false, true},
+		{"llm_failed", false, true},
+		{"llm_processing", false, true},
+		{"llm_processing", true, false},
+		{"success", false, false},
+		{"llm_success", true, false},
+	}
+	for _, tt := range tests {
+		smsLog := generated.SmsLog{
+			ParsingStatus: pgtype.Text{String: tt.status, Valid: true},
+			LlmParsed:     pgtype.Bool{Bool: tt.llmParsed, Valid: true},
+		}
+		if got := awaitingLlm(smsLog); got != tt.want {
+			t.Errorf("awaitingLlm(%q, llm_parsed=%v) = %v, want %v", tt.status, tt.llmParsed, got, tt.want)
+		}
+	}
+}
+
+func TestRunLlmParseRetriesAfterGeminiError(t *testing.T) {
+	smsID := uuid.New()
+	q := &fakeLlmQuerier{sms: generated.SmsLog{
+		ID:            utils.UUIDToPgtype(smsID),
+		ParsingStatus: pgtype.Text{String: "failed", Valid: true},
+	}}
+	credits := &fakeCredits{}
+	svc := NewSmsLlmService(q, &fakeGemini{err: errors.New("model overloaded")}, nil, credits)
+	log := zerolog.Nop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := svc.RunLlmParse(ctx, smsID, "user_1", &log); err == nil {
+		t.Fatal("RunLlmParse() error = nil, want the Gemini error")
+	}
+	if q.sms.ParsingStatus.String != "llm_failed" {
+		t.Errorf("ParsingStatus = %q, want llm_failed", q.sms.ParsingStatus.String)
+	}
+	if credits.refunded != 1 || !credits.refundLive {
+		t.Errorf("refunded %d times, live context %v; want one refund on a live context", credits.refunded, credits.refundLive)
+	}
+
+	err := svc.RunLlmParse(context.Background(), smsID, "user_1", &log)
+	if errors.Is(err, ErrSmsNotAwaitingLlm) {
+		t.Fatal("retry after a Gemini error was skipped as not awaiting the LLM")
+	}
+	if credits.reserved != 2 {
+		t.Errorf("reserved %d credits, want the retry to reserve again after the refund", credits.reserved)
+	}
+}
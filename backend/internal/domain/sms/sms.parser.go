@@ -0,0 +1,266 @@
+package sms
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/transaction"
+	"github.com/google/uuid"
+)
+
+// smsTemplate is one message format a bank sends. Its pattern runs against the message
+// with whitespace collapsed, and names what it captures with the groups amount,
+// account, counterparty, vpa, ref and balance; only amount is required. Templates
+// learned from LLM parses carry their sms_templates id.
+type smsTemplate struct {
+	id      uuid.UUID
+	name    string
+	senders []string
+	txnType transaction.TxnType
+	pattern *regexp.Regexp
+}
+
+// Fragments shared by the templates below.
+const (
+	tplAmount  = `(?:Rs\.?|INR|₹)\s*(?P<amount>[\d,]+(?:\.\d{1,2})?)`
+	tplAccount = `[*xX]*(?P<account>\d{3,6})`
+	tplRef     = `(?P<ref>\d{6,})`
+	tplVpa     = `(?P<vpa>[\w.\-]+@[\w.\-]+?)`
+)
+
+func newSmsTemplate(name string, txnType transaction.TxnType, pattern string, senders ...string) smsTemplate {
+	return smsTemplate{
+		name:    name,
+		senders: senders,
+		txnType: txnType,
+		pattern: regexp.MustCompile(`(?i)` + pattern),
+	}
+}
+
+// builtinSmsTemplates are keyed by the sender header banks register their SMS under.
+// Templates without senders are generic and run once the keyed ones miss, for a bank
+// sender or a message the device could not parse.
+var builtinSmsTemplates = []smsTemplate{
+	newSmsTemplate("hdfc_upi_sent", transaction.TxnTypeDebit,
+		`Sent `+tplAmount+` From HDFC Bank A/C `+tplAccount+` To (?P<counterparty>.+?) On \S+ Ref `+tplRef,
+		"HDFCBK"),
+	newSmsTemplate("hdfc_vpa_debit", transaction.TxnTypeDebit,
+		tplAmount+` (?:has been )?debited from (?:HDFC Bank )?a/c `+tplAccount+` on \S+ to VPA `+tplVpa+` ?\(UPI Ref No\.? ?`+tplRef,
+		"HDFCBK"),
+	newSmsTemplate("hdfc_upi_received", transaction.TxnTypeCredit,
+		`Money Received ?-? ?`+tplAmount+` in (?:your )?HDFC Bank A/c `+tplAccount+` on \S+ by a/c linked to VPA `+tplVpa+` ?\(UPI Ref No\.? ?`+tplRef,
+		"HDFCBK"),
+	newSmsTemplate("hdfc_deposit", transaction.TxnTypeCredit,
+		tplAmount+` deposited in (?:HDFC Bank )?A/c `+tplAccount+` on \S+ for (?P<counterparty>.+?)\.(?: Avl|$)`,
+		"HDFCBK"),
+	newSmsTemplate("hdfc_card_spent", transaction.TxnTypeDebit,
+		`(?:Spent|Txn) `+tplAmount+` (?:On|Using|at) HDFC Bank Card `+tplAccount+` (?:At|on) (?P<counterparty>.+?) On \S+`,
+		"HDFCBK"),
+
+	newSmsTemplate("icici_debit", transaction.TxnTypeDebit,
+		`ICICI Bank Acc(?:oun)?t `+tplAccount+` (?:is )?debited (?:for|with) `+tplAmount+` on \S+(?:;| and) (?P<counterparty>.+?) credited\.(?: UPI:`+tplRef+`)?`,
+		"ICICIB", "ICICIT"),
+	newSmsTemplate("icici_credit", transaction.TxnTypeCredit,
+		`Acc(?:oun)?t `+tplAccount+` (?:is )?credited (?:with|for) `+tplAmount+` on \S+ (?:from|by) (?P<counterparty>.+?)\.(?: UPI:`+tplRef+`)?`,
+		"ICICIB", "ICICIT"),
+	newSmsTemplate("icici_card_spent", transaction.TxnTypeDebit,
+		tplAmount+` spent (?:using|on) ICICI Bank Card `+tplAccount+` on \S+ (?:on|at) (?P<counterparty>.+?)\.`,
+		"ICICIB", "ICICIT"),
+
+	newSmsTemplate("sbi_upi_debit", transaction.TxnTypeDebit,
+		`A/C `+tplAccount+` debited by (?:Rs\.? ?)?(?P<amount>[\d,]+(?:\.\d{1,2})?) on date \S+ trf to (?P<counterparty>.+?) Ref ?no `+tplRef,
+		"SBIUPI", "SBIINB", "SBIPSG", "ATMSBI", "CBSSBI"),
+	newSmsTemplate("sbi_upi_credit", transaction.TxnTypeCredit,
+		`A/c ?`+tplAccount+`[ -]?credited by (?:Rs\.? ?)?(?P<amount>[\d,]+(?:\.\d{1,2})?) on (?:date )?\S+ (?:transfer from|by) ?(?P<counterparty>[^(]*?) ?\(?Ref ?no `+tplRef,
+		"SBIUPI", "SBIINB", "SBIPSG", "ATMSBI", "CBSSBI"),
+
+	newSmsTemplate("axis_debit", transaction.TxnTypeDebit,
+		tplAmount+` debited A/c no\.? `+tplAccount+` \S+,? \S+(?: UPI/P2[AM]/`+tplRef+`/(?P<counterparty>[^/]+?)(?: Not you|$))?`,
+		"AXISBK"),
+	newSmsTemplate("axis_credit", transaction.TxnTypeCredit,
+		tplAmount+` credited A/c no\.? `+tplAccount+` \S+,? \S+(?: UPI/P2[AM]/`+tplRef+`/(?P<counterparty>[^/]+?)(?: Not you|$))?`,
+		"AXISBK"),
+
+	newSmsTemplate("kotak_upi_sent", transaction.TxnTypeDebit,
+		`Sent `+tplAmount+` from Kotak Bank AC `+tplAccount+` to `+tplVpa+` on \S+?\. ?UPI Ref:? ?`+tplRef,
+		"KOTAKB"),
+	newSmsTemplate("kotak_upi_received", transaction.TxnTypeCredit,
+		`Received `+tplAmount+` in your Kotak Bank AC `+tplAccount+` from `+tplVpa+` on \S+?\. ?UPI Ref:? ?`+tplRef,
+		"KOTAKB"),
+
+	newSmsTemplate("generic_debit", transaction.TxnTypeDebit,
+		tplAmount+` (?:has been |is |was )?(?:debited|spent|withdrawn|deducted)\b.*?\b(?:A/?c|Acct|Account|Card)(?: no\.?)? ?[:\-]? ?[*xX]+(?P<account>\d{3,6})`),
+	newSmsTemplate("generic_debit_account_first", transaction.TxnTypeDebit,
+		`\b(?:A/?c|Acct|Account)(?: no\.?)? ?[*xX]+(?P<account>\d{3,6}) (?:is |has been )?debited (?:for|with|by) `+tplAmount),
+	newSmsTemplate("generic_credit", transaction.TxnTypeCredit,
+		tplAmount+` (?:has been |is |was )?(?:credited|deposited|received)\b.*?\b(?:A/?c|Acct|Account)(?: no\.?)? ?[:\-]? ?[*xX]+(?P<account>\d{3,6})`),
+	newSmsTemplate("generic_credit_account_first", transaction.TxnTypeCredit,
+		`\b(?:A/?c|Acct|Account)(?: no\.?)? ?[*xX]+(?P<account>\d{3,6}) (?:is |has been )?credited (?:for|with|by) `+tplAmount),
+}
+
+// smsBankSenders are the sender headers of banks without templates of their own, whose
+// SMS the generic templates read even when the device parsed them.
+var smsBankSenders = []string{
+	"PNBSMS", "BOBTXN", "BOBSMS", "CANBNK", "UNIONB", "BOIIND", "CENTBK", "IOBCHN",
+	"IDFCFB", "INDUSB", "YESBNK", "FEDBNK", "RBLBNK", "AUBANK", "IDBIBK", "KVBANK",
+	"SCBANK", "CITIBK", "HSBCIN", "DBSBNK",
+}
+
+// Fields banks print in many formats, read from anywhere in the message when the
+// matching template does not capture them itself.
+var (
+	smsBalancePattern = regexp.MustCompile(`(?i)\b(?:Avl|Avbl|Aval|Available|Clear|Clr)\.? ?(?:Bal(?:ance)?|Bal\.?|Lmt|Limit)\.?(?: is| of)? ?[:\-]? ?(?:Rs\.?|INR|₹)? ?(?P<balance>-?[\d,]+(?:\.\d{1,2})?)`)
+	smsRefPattern     = regexp.MustCompile(`(?i)\b(?:UPI Ref(?:erence)?(?: No)?|Ref(?:erence)?(?: ?No)?|Txn ?(?:Id|No)|UTR(?: No)?|UPI)\.?[:\- ]? ?(?P<ref>\d{9,})`)
+	smsVpaPattern     = regexp.MustCompile(`(?i)\b(?P<vpa>[\w.\-]{2,}@[a-z]{2,})\b`)
+)
+
+// ParsedSms is what a template read out of an SMS.
+type ParsedSms struct {
+	Template         string
+	Type             transaction.TxnType
+	Amount           float64
+	AccountSuffix    *string
+	Merchant         *string
+	Vpa              *string
+	ReferenceNumber  *string
+	AvailableBalance *float64
+
+	learnedID uuid.UUID
+}
+
+// description is the counterparty the transaction is recorded against.
+func (p *ParsedSms) description() *string {
+	if p.Merchant != nil {
+		return p.Merchant
+	}
+	return p.Vpa
+}
+
+// SmsParser matches bank SMS against the templates for their sender.
+type SmsParser struct {
+	bySender map[string][]smsTemplate
+	generic  []smsTemplate
+	banks    map[string]struct{}
+}
+
+func NewSmsParser() *SmsParser {
+	p := &SmsParser{bySender: make(map[string][]smsTemplate), banks: make(map[string]struct{})}
+	for _, t := range builtinSmsTemplates {
+		if len(t.senders) == 0 {
+			p.generic = append(p.generic, t)
+			continue
+		}
+		for _, sender := range t.senders {
+			p.bySender[sender] = append(p.bySender[sender], t)
+			p.banks[sender] = struct{}{}
+		}
+	}
+	for _, sender := range smsBankSenders {
+		p.banks[sender] = struct{}{}
+	}
+	return p
+}
+
+// senderHeader strips the operator prefix and the DLT suffix off a sender ID, so
+// "VM-HDFCBK", "AD-HDFCBK-S" and "HDFCBK" all read "HDFCBK".
+func senderHeader(sender string) string {
+	parts := strings.Split(strings.ToUpper(strings.TrimSpace(sender)), "-")
+	if len(parts) > 1 && len(parts[0]) == 2 {
+		return parts[1]
+	}
+	return parts[0]
+}
+
+// Parse returns what the first matching template reads out of an SMS, or nil when no
+// template matches. The sender's built-in templates are tried first, then the ones
+// learned for it, then the generic ones. The generic ones only read a known bank's
+// SMS, or one the device could not parse; otherwise the device's reading stands.
+func (p *SmsParser) Parse(sender, rawMessage string, learned []smsTemplate, deviceParsed bool) *ParsedSms {
+	msg := normalizeSms(rawMessage)
+	header := senderHeader(sender)
+	generic := p.generic
+	if _, bank := p.banks[header]; !bank && deviceParsed {
+		generic = nil
+	}
+	for _, templates := range [][]smsTemplate{p.bySender[header], learned, generic} {
+		for _, t := range templates {
+			if parsed := t.parse(msg); parsed != nil {
+				return parsed
+			}
+		}
+	}
+	return nil
+}
+
+func normalizeSms(rawMessage string) string {
+	return strings.Join(strings.Fields(rawMessage), " ")
+}
+
+func (t smsTemplate) parse(msg string) *ParsedSms {
+	groups := namedGroups(t.pattern, msg)
+	if groups == nil {
+		return nil
+	}
+	amount, err := parseSmsAmount(groups["amount"])
+	if err != nil || amount <= 0 {
+		return nil
+	}
+	parsed := &ParsedSms{
+		Template:        t.name,
+		Type:            t.txnType,
+		Amount:          amount,
+		AccountSuffix:   optionalGroup(groups, "account"),
+		Merchant:        optionalGroup(groups, "counterparty"),
+		Vpa:             optionalGroup(groups, "vpa"),
+		ReferenceNumber: optionalGroup(groups, "ref"),
+		learnedID:       t.id,
+	}
+	if parsed.Merchant != nil && strings.Contains(*parsed.Merchant, "@") {
+		if parsed.Vpa == nil {
+			parsed.Vpa = parsed.Merchant
+		}
+		parsed.Merchant = nil
+	}
+	if parsed.Vpa == nil {
+		parsed.Vpa = optionalGroup(namedGroups(smsVpaPattern, msg), "vpa")
+	}
+	if parsed.ReferenceNumber == nil {
+		parsed.ReferenceNumber = optionalGroup(namedGroups(smsRefPattern, msg), "ref")
+	}
+	balance := groups["balance"]
+	if balance == "" {
+		balance = namedGroups(smsBalancePattern, msg)["balance"]
+	}
+	if b, err := parseSmsAmount(balance); err == nil {
+		parsed.AvailableBalance = &b
+	}
+	return parsed
+}
+
+// namedGroups returns the named groups of the first match, or nil when there is none.
+func namedGroups(re *regexp.Regexp, s string) map[string]string {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return nil
+	}
+	groups := make(map[string]string, len(m))
+	for i, name := range re.SubexpNames() {
+		if name != "" && m[i] != "" {
+			groups[name] = m[i]
+		}
+	}
+	return groups
+}
+
+func optionalGroup(groups map[string]string, name string) *string {
+	v := strings.Trim(groups[name], " .,;:-")
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func parseSmsAmount(s string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+}
@@ -0,0 +1,181 @@
+package sms
+
+import (
+	"testing"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/transaction"
+	"github.com/google/uuid"
+)
+
+func TestSenderHeader(t *testing.T) {
+	tests := []struct {
+		sender string
+		want   string
+	}{
+		{"HDFCBK", "HDFCBK"},
+		{"VM-HDFCBK", "HDFCBK"},
+		{"ad-hdfcbk-s", "HDFCBK"},
+		{" JD-ICICIB ", "ICICIB"},
+		{"SBIUPI-T", "SBIUPI"},
+	}
+	for _, tt := range tests {
+		if got := senderHeader(tt.sender); got != tt.want {
+			t.Errorf("senderHeader(%q) = %q, want %q", tt.sender, got, tt.want)
+		}
+	}
+}
+
+func TestSmsParserParse(t *testing.T) {
+	tests := []struct {
+		name         string
+		sender       string
+		msg          string
+		deviceParsed bool
+		wantTemplate string
+		wantType     transaction.TxnType
+		wantAmount   float64
+		wantAccount  string
+		wantMerchant string
+		wantVpa      string
+		wantRef      string
+		wantBalance  *float64
+	}{
+		{
+			name:         "hdfc upi sent",
+			sender:       "VM-HDFCBK",
+			msg:          "Sent Rs.1,250.00\nFrom HDFC Bank A/C *4321 To SWIGGY On 05/04/24 Ref 412345678901 Not You? Call 18002586161",
+			wantTemplate: "hdfc_upi_sent",
+			wantType:     transaction.TxnTypeDebit,
+			wantAmount:   1250,
+			wantAccount:  "4321",
+			wantMerchant: "SWIGGY",
+			wantRef:      "412345678901",
+		},
+		{
+			name:         "hdfc upi received moves the vpa out of the merchant",
+			sender:       "AD-HDFCBK-S",
+			msg:          "Money Received - INR 500.00 in your HDFC Bank A/c xx4321 on 05-04-24 by a/c linked to VPA ravi@okaxis (UPI Ref No 412345678902)",
+			wantTemplate: "hdfc_upi_received",
+			wantType:     transaction.TxnTypeCredit,
+			wantAmount:   500,
+			wantAccount:  "4321",
+			wantVpa:      "ravi@okaxis",
+			wantRef:      "412345678902",
+		},
+		{
+			name:         "icici debit with balance",
+			sender:       "JD-ICICIB",
+			msg:          "ICICI Bank Acct XX987 debited for Rs 99.00 on 05-Apr-24; AMAZON PAY credited. UPI:412345678903. Avl Bal Rs 10,500.50",
+			wantTemplate: "icici_debit",
+			wantType:     transaction.TxnTypeDebit,
+			wantAmount:   99,
+			wantAccount:  "987",
+			wantMerchant: "AMAZON PAY",
+			wantRef:      "412345678903",
+			wantBalance:  floatPtr(10500.50),
+		},
+		{
+			name:         "unknown sender falls back to the generic templates",
+			sender:       "XY-MYBANK",
+			msg:          "INR 2,000 debited from your A/c XX5678 on 05-04-24. UPI Ref 412345678904. Avl Bal INR 8,000",
+			wantTemplate: "generic_debit",
+			wantType:     transaction.TxnTypeDebit,
+			wantAmount:   2000,
+			wantAccount:  "5678",
+			wantRef:      "412345678904",
+			wantBalance:  floatPtr(8000),
+		},
+		{
+			name:         "unknown sender the device read keeps the device's reading",
+			sender:       "XY-MYBANK",
+			msg:          "INR 2,000 debited from your A/c XX5678 on 05-04-24. UPI Ref 412345678904. Avl Bal INR 8,000",
+			deviceParsed: true,
+		},
+		{
+			name:         "bank without templates of its own gets the generic ones",
+			sender:       "VM-PNBSMS",
+			msg:          "INR 2,000 debited from your A/c XX5678 on 05-04-24. UPI Ref 412345678904. Avl Bal INR 8,000",
+			deviceParsed: true,
+			wantTemplate: "generic_debit",
+			wantType:     transaction.TxnTypeDebit,
+			wantAmount:   2000,
+			wantAccount:  "5678",
+			wantRef:      "412345678904",
+			wantBalance:  floatPtr(8000),
+		},
+		{
+			name:   "promotional message",
+			sender: "VM-HDFCBK",
+			msg:    "Get a personal loan of up to Rs 10,00,000 in 10 seconds. T&C apply.",
+		},
+	}
+	p := NewSmsParser()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.Parse(tt.sender, tt.msg, nil, tt.deviceParsed)
+			if tt.wantTemplate == "" {
+				if got != nil {
+					t.Fatalf("Parse() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("Parse() = nil")
+			}
+			if got.Template != tt.wantTemplate {
+				t.Errorf("Template = %q, want %q", got.Template, tt.wantTemplate)
+			}
+			if got.Type != tt.wantType {
+				t.Errorf("Type = %s, want %s", got.Type, tt.wantType)
+			}
+			if got.Amount != tt.wantAmount {
+				t.Errorf("Amount = %v, want %v", got.Amount, tt.wantAmount)
+			}
+			checkOptional(t, "AccountSuffix", got.AccountSuffix, tt.wantAccount)
+			checkOptional(t, "Merchant", got.Merchant, tt.wantMerchant)
+			checkOptional(t, "Vpa", got.Vpa, tt.wantVpa)
+			checkOptional(t, "ReferenceNumber", got.ReferenceNumber, tt.wantRef)
+			switch {
+			case tt.wantBalance == nil && got.AvailableBalance != nil:
+				t.Errorf("AvailableBalance = %v, want nil", *got.AvailableBalance)
+			case tt.wantBalance != nil && (got.AvailableBalance == nil || *got.AvailableBalance != *tt.wantBalance):
+				t.Errorf("AvailableBalance = %v, want %v", got.AvailableBalance, *tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestSmsParserLearnedOrder(t *testing.T) {
+	learned, err := learnedSmsTemplate(uuid.New(), `^Paid Rs\.? ?(?P<amount>[\d,]+(?:\.\d{1,2})?) from A/c X+(?P<account>\d{3,6})$`, transaction.TxnTypeDebit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewSmsParser()
+
+	got := p.Parse("XY-MYBANK", "Paid Rs. 40 from A/c XX1234", []smsTemplate{learned}, true)
+	if got == nil || got.Template != learned.name || got.learnedID != learned.id {
+		t.Errorf("learned template was not used: %+v", got)
+	}
+
+	// A built-in template for the sender wins over a learned one.
+	msg := "Sent Rs.10.00 From HDFC Bank A/C *4321 To SHOP On 05/04/24 Ref 412345678901"
+	catchAll, err := learnedSmsTemplate(uuid.New(), `(?P<amount>\d+)`, transaction.TxnTypeCredit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Parse("HDFCBK", msg, []smsTemplate{catchAll}, true); got == nil || got.Template != "hdfc_upi_sent" {
+		t.Errorf("Parse() = %+v, want the hdfc_upi_sent template", got)
+	}
+}
+
+func checkOptional(t *testing.T, field string, got *string, want string) {
+	t.Helper()
+	switch {
+	case want == "" && got != nil:
+		t.Errorf("%s = %q, want nil", field, *got)
+	case want != "" && (got == nil || *got != want):
+		t.Errorf("%s = %v, want %q", field, got, want)
+	}
+}
+
+func floatPtr(v float64) *float64 { return &v }
@@ -2,23 +2,37 @@ package sms
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"time"
 
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/transaction"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/utils"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type SmsRepository struct {
 	q  smsQuerier
 	aq accountQuerier
+	tm *database.TxManager
 }
 
-func NewSmsRepository(q smsQuerier, aq accountQuerier) *SmsRepository {
-	return &SmsRepository{q: q, aq: aq}
+func NewSmsRepository(q smsQuerier, aq accountQuerier, tm *database.TxManager) *SmsRepository {
+	return &SmsRepository{q: q, aq: aq, tm: tm}
 }
 
 func SmsFromDB(s generated.SmsLog) *SmsLogs {
+	var parsedTxn *transaction.CreateTxnReq
+	if len(s.ParsedTxn) > 0 {
+		parsedTxn = &transaction.CreateTxnReq{}
+		if err := json.Unmarshal(s.ParsedTxn, parsedTxn); err != nil {
+			parsedTxn = nil
+		}
+	}
 	return &SmsLogs{
 		Id:                 utils.UUIDToString(s.ID),
 		Sender:             s.Sender,
@@ -33,6 +47,12 @@ func SmsFromDB(s generated.SmsLog) *SmsLogs {
 		CreatedAt:          s.CreatedAt.Time,
 		UpdatedAt:          s.UpdatedAt.Time,
 		LastRetryAt:        s.LastRetryAt.Time,
+		ParsedTemplate:     utils.TextToStringPtr(s.ParsedTemplate),
+		AvailableBalance:   utils.NumericToFloat64Ptr(s.AvailableBalance),
+
+		MergedTransactionId: utils.UUIDToUUIDPtr(s.MergedTransactionID),
+		ParsedTxn:           parsedTxn,
+		ClientMessageId:     utils.TextToStringPtr(s.ClientMessageID),
 	}
 }
 
@@ -82,15 +102,74 @@ func (s *SmsRepository) CreateSms(c context.Context, payload *CreateSmsReq, cler
 	return SmsFromDB(sms), nil
 }
 
+// CreateSmsBatch inserts a batch of SMS in one statement. Messages whose client message
+// ID the user already sent are skipped, so fewer rows than items may come back.
+func (s *SmsRepository) CreateSmsBatch(c context.Context, items []CreateSmsBatchItem, clerkId string) ([]SmsLogs, error) {
+	params := generated.CreateSmsBatchParams{
+		UserID:           clerkId,
+		Senders:          make([]string, len(items)),
+		RawMessages:      make([]string, len(items)),
+		ReceivedAts:      make([]pgtype.Timestamp, len(items)),
+		ClientMessageIds: make([]string, len(items)),
+	}
+	for i, item := range items {
+		params.Senders[i] = item.Sender
+		params.RawMessages[i] = item.RawMessage
+		params.ReceivedAts[i] = utils.TimestampToPgtype(item.ReceivedAt)
+		params.ClientMessageIds[i] = item.ClientMessageId
+	}
+	rows, err := s.q.CreateSmsBatch(c, params)
+	if err != nil {
+		return nil, err
+	}
+	smsLogs := make([]SmsLogs, len(rows))
+	for i, row := range rows {
+		smsLogs[i] = *SmsFromDB(row)
+	}
+	return smsLogs, nil
+}
+
+func (s *SmsRepository) GetSmsesByClientMessageIds(c context.Context, clerkId string, clientMessageIds []string) ([]SmsLogs, error) {
+	rows, err := s.q.GetSmsesByClientMessageIds(c, generated.GetSmsesByClientMessageIdsParams{
+		UserID:           clerkId,
+		ClientMessageIds: clientMessageIds,
+	})
+	if err != nil {
+		return nil, err
+	}
+	smsLogs := make([]SmsLogs, len(rows))
+	for i, row := range rows {
+		smsLogs[i] = *SmsFromDB(row)
+	}
+	return smsLogs, nil
+}
+
+// GetAccountIdByNumber finds the account an SMS names. Banks print only the last few
+// digits, so when no account number matches exactly, the one account ending in them
+// is used; pgx.ErrNoRows is returned when none or several do.
 func (s *SmsRepository) GetAccountIdByNumber(ctx context.Context, clerkId, accountNumber string) (*uuid.UUID, error) {
 	acc, err := s.aq.GetAccountByNumber(ctx, generated.GetAccountByNumberParams{
 		UserID:        clerkId,
 		AccountNumber: accountNumber,
 	})
+	if err == nil {
+		id := utils.UUIDToUUID(acc.ID)
+		return &id, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	accounts, err := s.aq.ListAccountsByNumberSuffix(ctx, generated.ListAccountsByNumberSuffixParams{
+		UserID: clerkId,
+		Suffix: accountNumber,
+	})
 	if err != nil {
 		return nil, err
 	}
-	id := utils.UUIDToUUID(acc.ID)
+	if len(accounts) != 1 {
+		return nil, pgx.ErrNoRows
+	}
+	id := utils.UUIDToUUID(accounts[0].ID)
 	return &id, nil
 }
 
@@ -105,3 +184,214 @@ func (s *SmsRepository) UpdateSmsParsingStatus(ctx context.Context, smsID uuid.U
 	}
 	return SmsFromDB(sms), nil
 }
+
+func (s *SmsRepository) UpdateSmsTemplateParse(ctx context.Context, smsID uuid.UUID, template string, availableBalance *float64) (*SmsLogs, error) {
+	sms, err := s.q.UpdateSmsTemplateParse(ctx, generated.UpdateSmsTemplateParseParams{
+		ID:               utils.UUIDToPgtype(smsID),
+		ParsedTemplate:   pgtype.Text{String: template, Valid: true},
+		AvailableBalance: utils.Float64PtrToNum(availableBalance),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return SmsFromDB(sms), nil
+}
+
+func SmsTemplateFromDB(t generated.SmsTemplate) SmsTemplate {
+	return SmsTemplate{
+		Id:         utils.UUIDToUUID(t.ID),
+		Sender:     t.Sender,
+		Pattern:    t.Pattern,
+		Type:       string(t.TxnType),
+		Status:     t.Status,
+		Hits:       int(t.Hits),
+		Misses:     int(t.Misses),
+		LastSeenAt: utils.TimestampToTimePtr(t.LastSeenAt),
+		PromotedAt: utils.TimestampToTimePtr(t.PromotedAt),
+		CreatedAt:  t.CreatedAt.Time,
+		UpdatedAt:  t.UpdatedAt.Time,
+	}
+}
+
+func (s *SmsRepository) ListSmsTemplates(ctx context.Context, clerkId string) ([]SmsTemplate, error) {
+	rows, err := s.q.ListSmsTemplates(ctx, clerkId)
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]SmsTemplate, len(rows))
+	for i, row := range rows {
+		templates[i] = SmsTemplateFromDB(row)
+	}
+	return templates, nil
+}
+
+func (s *SmsRepository) ListActiveSmsTemplates(ctx context.Context, clerkId, sender string) ([]SmsTemplate, error) {
+	rows, err := s.q.ListActiveSmsTemplates(ctx, generated.ListActiveSmsTemplatesParams{
+		UserID: clerkId,
+		Sender: sender,
+	})
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]SmsTemplate, len(rows))
+	for i, row := range rows {
+		templates[i] = SmsTemplateFromDB(row)
+	}
+	return templates, nil
+}
+
+func (s *SmsRepository) RecordSmsTemplateHit(ctx context.Context, templateID uuid.UUID, hits int) error {
+	_, err := s.q.RecordSmsTemplateHit(ctx, generated.RecordSmsTemplateHitParams{
+		Hits:         int32(hits),
+		PromoteAfter: smsTemplatePromoteAfter,
+		ID:           utils.UUIDToPgtype(templateID),
+	})
+	return err
+}
+
+// RecordSmsTemplateMiss counts parses a learned template got wrong and returns it, with
+// an active one demoted once it has missed too often.
+func (s *SmsRepository) RecordSmsTemplateMiss(ctx context.Context, templateID uuid.UUID, misses int) (*SmsTemplate, error) {
+	row, err := s.q.RecordSmsTemplateMiss(ctx, generated.RecordSmsTemplateMissParams{
+		Misses:    int32(misses),
+		MaxMisses: smsTemplateMaxMisses,
+		ID:        utils.UUIDToPgtype(templateID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	t := SmsTemplateFromDB(row)
+	return &t, nil
+}
+
+func (s *SmsRepository) DeleteSmsTemplate(ctx context.Context, payload *DeleteSmsTemplateReq, clerkId string) error {
+	return s.q.DeleteSmsTemplate(ctx, generated.DeleteSmsTemplateParams{
+		ID:     utils.UUIDToPgtype(payload.TemplateId),
+		UserID: clerkId,
+	})
+}
+
+// FindDuplicateTxn returns the transaction the one parsed from an SMS would repeat, or
+// nil when there is none.
+func (s *SmsRepository) FindDuplicateTxn(ctx context.Context, clerkId string, req *transaction.CreateTxnReq, receivedAt time.Time) (*uuid.UUID, error) {
+	id, err := s.q.FindDuplicateSmsTxn(ctx, duplicateTxnParams(clerkId, req, receivedAt))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	txnID := utils.UUIDToUUID(id)
+	return &txnID, nil
+}
+
+// ListDuplicateCandidates returns the stored transactions the ones parsed from a batch
+// of SMS could repeat, in one query rather than one lookup per SMS.
+func (s *SmsRepository) ListDuplicateCandidates(ctx context.Context, clerkId string, reqs []*transaction.CreateTxnReq) ([]SmsTxnCandidate, error) {
+	params := generated.ListSmsDuplicateCandidatesParams{
+		UserID:     clerkId,
+		WindowSecs: int32(smsDuplicateWindow / time.Second),
+	}
+	for _, req := range reqs {
+		if req.TransactionDate == nil {
+			continue
+		}
+		params.AccountIds = append(params.AccountIds, utils.UUIDToPgtype(req.AccountId))
+		params.ReceivedAts = append(params.ReceivedAts, utils.TimestampToPgtype(*req.TransactionDate))
+	}
+	if len(params.AccountIds) == 0 {
+		return nil, nil
+	}
+	rows, err := s.q.ListSmsDuplicateCandidates(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]SmsTxnCandidate, len(rows))
+	for i, row := range rows {
+		date := row.TransactionDate.Time
+		candidates[i] = SmsTxnCandidate{
+			Id: utils.UUIDToUUID(row.ID),
+			Txn: transaction.CreateTxnReq{
+				AccountId:       utils.UUIDToUUID(row.AccountID),
+				Type:            transaction.TxnType(row.Type),
+				Amount:          utils.NumericToFloat64(row.Amount),
+				ReferenceNumber: utils.TextToStringPtr(row.ReferenceNumber),
+				TransactionDate: &date,
+				SmsId:           utils.UUIDToUUIDPtr(row.SmsID),
+			},
+		}
+	}
+	return candidates, nil
+}
+
+// UpdateSmsBatchOutcomes writes what processing a batch of SMS decided in one statement
+// and returns the updated rows.
+func (s *SmsRepository) UpdateSmsBatchOutcomes(ctx context.Context, clerkId string, updates []SmsOutcomeUpdate) ([]SmsLogs, error) {
+	params := generated.UpdateSmsBatchOutcomesParams{
+		UserID:               clerkId,
+		Ids:                  make([]pgtype.UUID, len(updates)),
+		ParsingStatuses:      make([]string, len(updates)),
+		ParsedTemplates:      make([]string, len(updates)),
+		AvailableBalances:    make([]pgtype.Numeric, len(updates)),
+		MergedTransactionIds: make([]pgtype.UUID, len(updates)),
+		ParsedTxns:           make([][]byte, len(updates)),
+	}
+	for i, u := range updates {
+		params.Ids[i] = utils.UUIDToPgtype(u.SmsId)
+		params.ParsingStatuses[i] = u.Status
+		params.ParsedTemplates[i] = u.Template
+		params.AvailableBalances[i] = utils.Float64PtrToNum(u.AvailableBalance)
+		if u.MergedTxnId != nil {
+			params.MergedTransactionIds[i] = utils.UUIDToPgtype(*u.MergedTxnId)
+		}
+		if u.ParsedTxn != nil {
+			parsedTxn, err := json.Marshal(u.ParsedTxn)
+			if err != nil {
+				return nil, err
+			}
+			params.ParsedTxns[i] = parsedTxn
+		}
+	}
+	rows, err := s.q.UpdateSmsBatchOutcomes(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	smsLogs := make([]SmsLogs, len(rows))
+	for i, row := range rows {
+		smsLogs[i] = *SmsFromDB(row)
+	}
+	return smsLogs, nil
+}
+
+func (s *SmsRepository) MergeSmsIntoTxn(ctx context.Context, smsID, txnID uuid.UUID, parsed *transaction.CreateTxnReq, status string) (*SmsLogs, error) {
+	parsedTxn, err := json.Marshal(parsed)
+	if err != nil {
+		return nil, err
+	}
+	sms, err := s.q.MergeSmsIntoTxn(ctx, generated.MergeSmsIntoTxnParams{
+		ID:                  utils.UUIDToPgtype(smsID),
+		MergedTransactionID: utils.UUIDToPgtype(txnID),
+		ParsedTxn:           parsedTxn,
+		ParsingStatus:       pgtype.Text{String: status, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return SmsFromDB(sms), nil
+}
+
+func (s *SmsRepository) SplitSmsMerge(ctx context.Context, smsID uuid.UUID, clerkId, status string) (*SmsLogs, error) {
+	queries := s.q
+	if tx := s.tm.GetTx(ctx); tx != nil {
+		queries = s.q.WithTx(tx)
+	}
+	sms, err := queries.SplitSmsMerge(ctx, generated.SplitSmsMergeParams{
+		ID:            utils.UUIDToPgtype(smsID),
+		UserID:        clerkId,
+		ParsingStatus: pgtype.Text{String: status, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return SmsFromDB(sms), nil
+}
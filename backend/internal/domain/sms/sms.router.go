@@ -1,6 +1,7 @@
 package sms
 
 import (
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/middleware"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/server"
 	"github.com/labstack/echo/v4"
@@ -16,6 +17,7 @@ type Deps struct {
 	Server     *server.Server
 	Queries    smsQuerier
 	AccQueries accountQuerier
+	TxnManager *database.TxManager
 	UserSvc    smsUserProvider
 
 	TxnSvc     smsTxnCreator
@@ -23,8 +25,8 @@ type Deps struct {
 }
 
 func NewSmsModule(deps Deps) *Module {
-	repo := NewSmsRepository(deps.Queries, deps.AccQueries)
-	service := NewSmsService(repo, deps.TxnSvc, deps.LlmTaskSvc, deps.UserSvc)
+	repo := NewSmsRepository(deps.Queries, deps.AccQueries, deps.TxnManager)
+	service := NewSmsService(repo, deps.TxnManager, deps.TxnSvc, deps.LlmTaskSvc, deps.UserSvc)
 	handler := NewSmsHandler(deps.Server, service)
 
 	return &Module{
@@ -39,7 +41,11 @@ func (m *Module) RegisterRoutes(g *echo.Group) {
 	deviceAuth := middleware.NewDeviceAuthMiddleware(m.userSvc).RequireDeviceAuth
 
 	g.GET("/sms", m.handler.GetSmses, clerkAuth)
+	g.GET("/sms/templates", m.handler.GetSmsTemplates, clerkAuth)
+	g.DELETE("/sms/templates/:id", m.handler.DeleteSmsTemplate, clerkAuth)
 	g.GET("/sms/:id", m.handler.GetSmsById, clerkAuth)
 	g.POST("/sms", m.handler.CreateSms, deviceAuth)
+	g.POST("/sms/batch", m.handler.CreateSmsBatch, deviceAuth)
+	g.POST("/sms/:id/split", m.handler.SplitSms, clerkAuth)
 	g.DELETE("/sms/:id", m.handler.DeleteSms, clerkAuth)
 }
@@ -1,24 +1,30 @@
 package sms
 
 import (
+	"context"
 	"errors"
+	"time"
 
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/transaction"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/middleware"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
 )
 
 type SmsService struct {
 	r          smsRepository
+	tm         txRunner
 	txnSvc     smsTxnCreator
 	llmTaskSvc smsLlmTaskEnqueuer
 	userSvc    smsUserProvider
+	parser     *SmsParser
 }
 
-func NewSmsService(r smsRepository, txnSvc smsTxnCreator, llmTaskSvc smsLlmTaskEnqueuer, userSvc smsUserProvider) *SmsService {
-	return &SmsService{r: r, txnSvc: txnSvc, llmTaskSvc: llmTaskSvc, userSvc: userSvc}
+func NewSmsService(r smsRepository, tm *database.TxManager, txnSvc smsTxnCreator, llmTaskSvc smsLlmTaskEnqueuer, userSvc smsUserProvider) *SmsService {
+	return &SmsService{r: r, tm: tm, txnSvc: txnSvc, llmTaskSvc: llmTaskSvc, userSvc: userSvc, parser: NewSmsParser()}
 }
 
 func (s *SmsService) GetSmses(c echo.Context, payload *GetSmsesReq, clerkId string) ([]SmsLogs, error) {
@@ -29,10 +35,21 @@ func (s *SmsService) GetSmsById(c echo.Context, payload *GetSmsByIdReq, clerkId
 	return s.r.GetSmsById(c.Request().Context(), payload, clerkId)
 }
 
+func (s *SmsService) GetSmsTemplates(c echo.Context, payload *GetSmsTemplatesReq, clerkId string) ([]SmsTemplate, error) {
+	return s.r.ListSmsTemplates(c.Request().Context(), clerkId)
+}
+
+func (s *SmsService) DeleteSmsTemplate(c echo.Context, payload *DeleteSmsTemplateReq, clerkId string) error {
+	return s.r.DeleteSmsTemplate(c.Request().Context(), payload, clerkId)
+}
+
 func (s *SmsService) DeleteSms(c echo.Context, payload *DeleteSmsReq, clerkId string) error {
 	return s.r.DeleteSms(c.Request().Context(), payload, clerkId)
 }
 
+// CreateSms stores an SMS and records its transaction. The server-side templates,
+// built-in and learned, are tried first, then what the device parsed; a message
+// neither could read goes to the LLM fallback when the user has it turned on.
 func (s *SmsService) CreateSms(c echo.Context, payload *CreateSmsReq, clerkId string) (*SmsLogs, error) {
 	log := middleware.GetLogger(c)
 	ctx := c.Request().Context()
@@ -41,67 +58,216 @@ func (s *SmsService) CreateSms(c echo.Context, payload *CreateSmsReq, clerkId st
 	if err != nil {
 		return nil, err
 	}
+	smsID, err := uuid.Parse(smsLog.Id)
+	if err != nil {
+		return smsLog, nil
+	}
 
-	if payload.ParseStatus == "success" && payload.Amount != nil && payload.AccountNumber != nil {
-		accountID, err := s.r.GetAccountIdByNumber(ctx, clerkId, *payload.AccountNumber)
+	learned := s.learnedTemplates(ctx, clerkId, payload.Sender, log)
+	out := s.processSms(log, smsID, payload, learned, func(suffix string) (*uuid.UUID, error) {
+		return s.r.GetAccountIdByNumber(ctx, clerkId, suffix)
+	})
+	if out.txn != nil {
+		// Several SMS often report one payment; a later one is linked to the transaction the
+		// first created. A failed lookup falls through to creating the transaction.
+		dupID, err := s.r.FindDuplicateTxn(ctx, clerkId, out.txn, payload.ReceivedAt)
 		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				log.Warn().Str("account_number", *payload.AccountNumber).Msg("[sms] account not found, skipping transaction creation")
-				return smsLog, nil
-			}
-			log.Error().Err(err).Msg("[sms] failed to look up account by number")
-			return smsLog, nil
+			log.Error().Err(err).Msg("[sms] failed to look for a duplicate transaction")
 		}
+		out.duplicateOf = dupID
+	}
+	return s.applySmsOutcome(c, log, smsLog, &out, clerkId), nil
+}
+
+// smsOutcome is what processing a stored SMS decided. Nothing is written until the
+// caller applies it, so a batch can write the outcomes of all its SMS together.
+type smsOutcome struct {
+	smsID uuid.UUID
+	// status is the parsing_status to set; empty keeps the stored one.
+	status           string
+	template         string
+	availableBalance *float64
+	learnedID        uuid.UUID
+	// learnedMiss is set when the learned template read the SMS differently from the
+	// device, and counts against the template instead of for it.
+	learnedMiss bool
+	// txn is the transaction the SMS describes. It is created unless duplicateOf is set,
+	// in which case the SMS is linked to that transaction instead.
+	txn         *transaction.CreateTxnReq
+	duplicateOf *uuid.UUID
+	needsLlm    bool
+}
 
-		smsID, _ := uuid.Parse(smsLog.Id)
+// processSms parses a stored SMS and decides what to record for it. accountID resolves
+// the account number the SMS names; it is the only lookup processing makes.
+func (s *SmsService) processSms(log *zerolog.Logger, smsID uuid.UUID, payload *CreateSmsReq, learned []smsTemplate, accountID func(suffix string) (*uuid.UUID, error)) smsOutcome {
+	out := smsOutcome{smsID: smsID}
+	if parsed := s.parser.Parse(payload.Sender, payload.RawMessage, learned, payload.deviceParsed()); parsed != nil {
+		log.Info().Str("template", parsed.Template).Msg("[sms] parsed by server template")
+		out.template = parsed.Template
+		out.availableBalance = parsed.AvailableBalance
+		out.learnedID = parsed.learnedID
+		out.learnedMiss = parsed.learnedID != uuid.Nil && !parsed.agreesWithDevice(payload)
+		resolveSmsTxn(log, &out, parsed, payload.ReceivedAt, accountID)
+		return out
+	}
+
+	if payload.deviceParsed() {
 		txnType := transaction.TxnTypeDebit
 		if payload.TransactionType != nil && *payload.TransactionType == "credit" {
 			txnType = transaction.TxnTypeCredit
 		}
-
-		txnReq := &transaction.CreateTxnReq{
-			AccountId:       *accountID,
+		resolveSmsTxn(log, &out, &ParsedSms{
 			Type:            txnType,
 			Amount:          *payload.Amount,
+			AccountSuffix:   payload.AccountNumber,
+			Merchant:        payload.Merchant,
 			ReferenceNumber: payload.ReferenceNumber,
-			Description:     payload.Merchant,
-			SmsId:           &smsID,
-			TransactionDate: &payload.ReceivedAt,
+		}, payload.ReceivedAt, accountID)
+		return out
+	}
+
+	if payload.ParseStatus == "failed" {
+		out.status = "failed"
+		out.needsLlm = true
+	}
+	return out
+}
+
+// resolveSmsTxn sets the transaction a parsed SMS describes against the account it
+// names. A template parse whose account the user has not added is kept as
+// success_no_account so it is not sent to the LLM.
+func resolveSmsTxn(log *zerolog.Logger, out *smsOutcome, parsed *ParsedSms, receivedAt time.Time, accountID func(suffix string) (*uuid.UUID, error)) {
+	var id *uuid.UUID
+	var err error
+	if parsed.AccountSuffix != nil {
+		id, err = accountID(*parsed.AccountSuffix)
+	}
+	if id == nil {
+		switch {
+		case err != nil && !errors.Is(err, pgx.ErrNoRows):
+			log.Error().Err(err).Msg("[sms] failed to look up account by number")
+		case parsed.AccountSuffix != nil:
+			log.Warn().Str("account_number", *parsed.AccountSuffix).Msg("[sms] account not found, skipping transaction creation")
 		}
-		if _, err := s.txnSvc.CreateTxn(c, txnReq, clerkId); err != nil {
-			log.Error().Err(err).Msg("[sms] failed to create transaction from SMS")
-			return smsLog, nil
+		if parsed.Template != "" {
+			out.status = "success_no_account"
+		}
+		return
+	}
+
+	smsID := out.smsID
+	out.txn = &transaction.CreateTxnReq{
+		AccountId:       *id,
+		Type:            parsed.Type,
+		Amount:          parsed.Amount,
+		ReferenceNumber: parsed.ReferenceNumber,
+		Description:     parsed.description(),
+		SmsId:           &smsID,
+		TransactionDate: &receivedAt,
+	}
+}
+
+// applySmsOutcome records what processing a single SMS decided: the template parse,
+// then either the link to the transaction it repeats or a new transaction.
+func (s *SmsService) applySmsOutcome(c echo.Context, log *zerolog.Logger, smsLog *SmsLogs, out *smsOutcome, clerkId string) *SmsLogs {
+	ctx := c.Request().Context()
+	switch {
+	case out.learnedMiss:
+		s.recordTemplateMisses(ctx, log, map[uuid.UUID]int{out.learnedID: 1})
+	case out.learnedID != uuid.Nil:
+		if err := s.r.RecordSmsTemplateHit(ctx, out.learnedID, 1); err != nil {
+			log.Error().Err(err).Msg("[sms] failed to record template hit")
 		}
-		if updated, err := s.r.UpdateSmsParsingStatus(ctx, smsID, "success", nil); err != nil {
-			log.Error().Err(err).Msg("[sms] failed to mark parsing_status=success")
+	}
+	if out.template != "" {
+		if updated, err := s.r.UpdateSmsTemplateParse(ctx, out.smsID, out.template, out.availableBalance); err != nil {
+			log.Error().Err(err).Msg("[sms] failed to record template parse")
 		} else {
 			smsLog = updated
 		}
-		return smsLog, nil
 	}
 
-	if payload.ParseStatus == "failed" {
-		smsID, err := uuid.Parse(smsLog.Id)
-		if err != nil {
-			return smsLog, nil
-		}
-		failedStatus := "failed"
-		if updated, err := s.r.UpdateSmsParsingStatus(ctx, smsID, failedStatus, nil); err != nil {
-			log.Error().Err(err).Msg("[sms] failed to mark parsing_status=failed")
+	switch {
+	case out.duplicateOf != nil:
+		log.Info().Str("transaction_id", out.duplicateOf.String()).Msg("[sms] duplicate of an existing transaction, linking instead of creating")
+		if updated, err := s.r.MergeSmsIntoTxn(ctx, out.smsID, *out.duplicateOf, out.txn, "duplicate"); err != nil {
+			log.Error().Err(err).Msg("[sms] failed to link SMS to existing transaction")
 		} else {
 			smsLog = updated
 		}
-		if s.llmTaskSvc != nil && s.userSvc != nil {
-			useLlm, err := s.userSvc.GetUseLlmParsing(ctx, clerkId)
-			if err != nil {
-				log.Error().Err(err).Msg("[sms] failed to fetch use_llm_parsing flag")
-			} else if useLlm {
-				if err := s.llmTaskSvc.EnqueueLlmSmsParse(ctx, smsID, clerkId); err != nil {
-					log.Error().Err(err).Msg("[sms] failed to enqueue LLM parse task")
-				}
-			}
+	case out.txn != nil:
+		if _, err := s.txnSvc.CreateTxn(c, out.txn, clerkId); err != nil {
+			log.Error().Err(err).Msg("[sms] failed to create transaction from SMS")
+			return smsLog
 		}
+		smsLog = s.markSmsStatus(ctx, log, smsLog, out.smsID, "success")
+	case out.status != "":
+		smsLog = s.markSmsStatus(ctx, log, smsLog, out.smsID, out.status)
+	}
+	if out.needsLlm {
+		s.enqueueLlmParse(ctx, log, clerkId, []uuid.UUID{out.smsID})
+	}
+	return smsLog
+}
+
+// recordTemplateMisses counts the SMS each learned template read differently from the
+// device.
+func (s *SmsService) recordTemplateMisses(ctx context.Context, log *zerolog.Logger, misses map[uuid.UUID]int) {
+	for id, n := range misses {
+		t, err := s.r.RecordSmsTemplateMiss(ctx, id, n)
+		if err != nil {
+			log.Error().Err(err).Msg("[sms] failed to record template miss")
+			continue
+		}
+		if t.Status != SmsTemplateActive {
+			log.Warn().Str("template_id", id.String()).Int("misses", t.Misses).Msg("[sms] learned template demoted")
+		}
+	}
+}
+
+func (s *SmsService) markSmsStatus(ctx context.Context, log *zerolog.Logger, smsLog *SmsLogs, smsID uuid.UUID, status string) *SmsLogs {
+	updated, err := s.r.UpdateSmsParsingStatus(ctx, smsID, status, nil)
+	if err != nil {
+		log.Error().Err(err).Msgf("[sms] failed to mark parsing_status=%s", status)
+		return smsLog
 	}
+	return updated
+}
 
-	return smsLog, nil
+// enqueueLlmParse sends SMS to the LLM fallback when the user has it turned on, as one
+// job however many there are. Only as many as the user has LLM parse credits left for
+// are sent; the rest keep the rule-based result.
+func (s *SmsService) enqueueLlmParse(ctx context.Context, log *zerolog.Logger, clerkId string, smsIDs []uuid.UUID) {
+	if len(smsIDs) == 0 || s.llmTaskSvc == nil || s.userSvc == nil {
+		return
+	}
+	useLlm, err := s.userSvc.GetUseLlmParsing(ctx, clerkId)
+	if err != nil {
+		log.Error().Err(err).Msg("[sms] failed to fetch use_llm_parsing flag")
+		return
+	}
+	if !useLlm {
+		return
+	}
+	left, err := s.userSvc.GetLlmCreditsLeft(ctx, clerkId)
+	if err != nil {
+		log.Error().Err(err).Msg("[sms] failed to fetch LLM parse credits")
+		return
+	}
+	if left < len(smsIDs) {
+		log.Warn().Int("credits_left", left).Int("sms_count", len(smsIDs)).Msg("[sms] not enough LLM parse credits, keeping the rule-based result")
+		smsIDs = smsIDs[:max(left, 0)]
+	}
+	if len(smsIDs) == 0 {
+		return
+	}
+	if len(smsIDs) == 1 {
+		err = s.llmTaskSvc.EnqueueLlmSmsParse(ctx, smsIDs[0], clerkId)
+	} else {
+		err = s.llmTaskSvc.EnqueueLlmSmsParseBatch(ctx, smsIDs, clerkId)
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("[sms] failed to enqueue LLM parse task")
+	}
 }
@@ -0,0 +1,339 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/transaction"
+	aiservices "github.com/KaranMali2001/finance-tracker-v2-backend/internal/services/aiServices"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/utils"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+)
+
+// smsTemplatePromoteAfter is how many LLM parses a learned template must agree with,
+// and none disagree with, before it parses SMS in place of the LLM.
+const smsTemplatePromoteAfter = 3
+
+// smsTemplateMaxMisses is how many parses an active template may get wrong, against
+// the LLM or the device, before it is demoted and the LLM reads its sender again.
+const smsTemplateMaxMisses = 1
+
+// Statuses of a learned template.
+const (
+	SmsTemplateCandidate = "candidate"
+	SmsTemplateActive    = "active"
+)
+
+var (
+	smsNumberPattern   = regexp.MustCompile(`\d[\d,]*(?:\.\d+)?`)
+	smsDigitRunPattern = regexp.MustCompile(`\d+`)
+	smsMonthPattern    = regexp.MustCompile(`(?i)^(?:jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\W*$`)
+)
+
+// learnedSmsTemplate compiles a template stored in sms_templates.
+func learnedSmsTemplate(id uuid.UUID, pattern string, txnType transaction.TxnType) (smsTemplate, error) {
+	re, err := regexp.Compile(`(?i)` + pattern)
+	if err != nil {
+		return smsTemplate{}, err
+	}
+	return smsTemplate{id: id, name: "learned:" + id.String(), txnType: txnType, pattern: re}, nil
+}
+
+// agrees reports whether a template read an SMS the way the LLM did: the same amount
+// and direction, account digits where one ends with the other, and the same reference
+// when both have one.
+func (p *ParsedSms) agrees(llm *aiservices.ParsedTxn) bool {
+	if math.Abs(p.Amount-llm.Amount) >= 0.01 || string(p.Type) != strings.ToUpper(llm.Type) {
+		return false
+	}
+	if llm.AccountNum == nil || p.AccountSuffix == nil {
+		return false
+	}
+	want, got := smsDigits(*llm.AccountNum), *p.AccountSuffix
+	if want == "" || !(strings.HasSuffix(want, got) || strings.HasSuffix(got, want)) {
+		return false
+	}
+	if llm.ReferenceNumber != nil && p.ReferenceNumber != nil && !strings.EqualFold(*llm.ReferenceNumber, *p.ReferenceNumber) {
+		return false
+	}
+	return true
+}
+
+// agreesWithDevice reports whether a template read an SMS the way the device did. A
+// device that did not parse it cannot disagree.
+func (p *ParsedSms) agreesWithDevice(payload *CreateSmsReq) bool {
+	if !payload.deviceParsed() {
+		return true
+	}
+	txnType := string(transaction.TxnTypeDebit)
+	if payload.TransactionType != nil && *payload.TransactionType == "credit" {
+		txnType = string(transaction.TxnTypeCredit)
+	}
+	return p.agrees(&aiservices.ParsedTxn{
+		Amount:          *payload.Amount,
+		Type:            txnType,
+		AccountNum:      payload.AccountNumber,
+		ReferenceNumber: payload.ReferenceNumber,
+	})
+}
+
+func smsDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// smsSpan is where a field the LLM extracted sits in the message.
+type smsSpan struct {
+	start, end int
+	group      string
+	pattern    string
+}
+
+// learnSmsPattern lines up the fields of an LLM parse with the message they came from
+// and turns the message into a pattern: each field becomes a named group, and the text
+// around them stays literal except for words carrying digits or a month, which vary
+// from one message to the next. It fails unless the amount and account are both found.
+func learnSmsPattern(msg string, llm *aiservices.ParsedTxn) (string, bool) {
+	if llm.Amount <= 0 || llm.AccountNum == nil {
+		return "", false
+	}
+	var spans []smsSpan
+	add := func(sp smsSpan) bool {
+		if overlaps(sp, spans) {
+			return false
+		}
+		spans = append(spans, sp)
+		return true
+	}
+
+	amount, ok := findSmsAmount(msg, llm.Amount)
+	if !ok || !add(amount) {
+		return "", false
+	}
+	account, ok := findSmsAccount(msg, smsDigits(*llm.AccountNum), spans)
+	if !ok || !add(account) {
+		return "", false
+	}
+	if llm.ReferenceNumber != nil && *llm.ReferenceNumber != "" {
+		if sp, ok := findSmsText(msg, *llm.ReferenceNumber, "ref", `[A-Za-z0-9]+`); ok {
+			add(sp)
+		}
+	}
+	if llm.Description != nil && len(strings.TrimSpace(*llm.Description)) >= 3 {
+		if sp, ok := findSmsText(msg, strings.TrimSpace(*llm.Description), "counterparty", `.+?`); ok {
+			add(sp)
+		}
+	}
+	sort.Slice(spans, func(a, b int) bool { return spans[a].start < spans[b].start })
+
+	var b strings.Builder
+	b.WriteString("^")
+	pos := 0
+	for _, sp := range spans {
+		b.WriteString(generalizeSmsLiteral(msg[pos:sp.start]))
+		fmt.Fprintf(&b, "(?P<%s>%s)", sp.group, sp.pattern)
+		pos = sp.end
+	}
+	b.WriteString(generalizeSmsLiteral(msg[pos:]))
+	b.WriteString("$")
+	return b.String(), true
+}
+
+// findSmsAmount finds the number equal to the amount, preferring one printed after a
+// currency marker.
+func findSmsAmount(msg string, amount float64) (smsSpan, bool) {
+	var found []smsSpan
+	for _, loc := range smsNumberPattern.FindAllStringIndex(msg, -1) {
+		v, err := parseSmsAmount(msg[loc[0]:loc[1]])
+		if err != nil || math.Abs(v-amount) >= 0.01 {
+			continue
+		}
+		sp := smsSpan{start: loc[0], end: loc[1], group: "amount", pattern: `[\d,]+(?:\.\d{1,2})?`}
+		before := strings.ToUpper(strings.TrimRight(msg[:loc[0]], " ."))
+		if strings.HasSuffix(before, "RS") || strings.HasSuffix(before, "INR") || strings.HasSuffix(before, "₹") {
+			return sp, true
+		}
+		found = append(found, sp)
+	}
+	if len(found) == 0 {
+		return smsSpan{}, false
+	}
+	return found[0], true
+}
+
+// findSmsAccount finds the masked account digits: a run of three to six digits that
+// ends with the LLM's account number, or that it ends with, preferring one behind a
+// mask character.
+func findSmsAccount(msg, digits string, taken []smsSpan) (smsSpan, bool) {
+	if len(digits) > 4 {
+		digits = digits[len(digits)-4:]
+	}
+	if digits == "" {
+		return smsSpan{}, false
+	}
+	var found []smsSpan
+	for _, loc := range smsDigitRunPattern.FindAllStringIndex(msg, -1) {
+		run := msg[loc[0]:loc[1]]
+		if len(run) < 3 || len(run) > 6 || !(strings.HasSuffix(run, digits) || strings.HasSuffix(digits, run)) {
+			continue
+		}
+		sp := smsSpan{start: loc[0], end: loc[1], group: "account", pattern: `\d{3,6}`}
+		if overlaps(sp, taken) {
+			continue
+		}
+		if loc[0] > 0 && strings.ContainsRune("xX*", rune(msg[loc[0]-1])) {
+			return sp, true
+		}
+		found = append(found, sp)
+	}
+	if len(found) == 0 {
+		return smsSpan{}, false
+	}
+	return found[0], true
+}
+
+func overlaps(sp smsSpan, spans []smsSpan) bool {
+	for _, other := range spans {
+		if sp.start < other.end && other.start < sp.end {
+			return true
+		}
+	}
+	return false
+}
+
+func findSmsText(msg, text, group, pattern string) (smsSpan, bool) {
+	loc := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(text)).FindStringIndex(msg)
+	if loc == nil {
+		return smsSpan{}, false
+	}
+	return smsSpan{start: loc[0], end: loc[1], group: group, pattern: pattern}, true
+}
+
+// generalizeSmsLiteral quotes the text between two fields word by word, letting any
+// word with a digit or a month name in it match any other word.
+func generalizeSmsLiteral(s string) string {
+	words := strings.Split(s, " ")
+	for i, w := range words {
+		switch {
+		case w == "":
+		case strings.IndexFunc(w, unicode.IsDigit) >= 0, smsMonthPattern.MatchString(w):
+			words[i] = `\S+`
+		default:
+			words[i] = regexp.QuoteMeta(w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// learnTemplate checks an LLM parse against the templates learned for its sender. Each
+// one that matches the message gets a hit when it read it the same way and a miss when
+// it did not; when none agreed, the message becomes a new candidate template. Failures
+// are only logged, since the parse itself already succeeded.
+func (s *SmsLlmService) learnTemplate(ctx context.Context, smsLog generated.SmsLog, parsed *aiservices.ParsedTxn, log *zerolog.Logger) {
+	txnType := transaction.TxnType(strings.ToUpper(parsed.Type))
+	if txnType != transaction.TxnTypeDebit && txnType != transaction.TxnTypeCredit {
+		return
+	}
+	sender := senderHeader(smsLog.Sender)
+	msg := normalizeSms(smsLog.RawMessage)
+
+	rows, err := s.q.ListSmsTemplatesBySender(ctx, generated.ListSmsTemplatesBySenderParams{
+		UserID: smsLog.UserID,
+		Sender: sender,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("[sms-llm] failed to load learned templates")
+		return
+	}
+	agreed := false
+	for _, row := range rows {
+		t, err := learnedSmsTemplate(utils.UUIDToUUID(row.ID), row.Pattern, transaction.TxnType(row.TxnType))
+		if err != nil {
+			continue
+		}
+		got := t.parse(msg)
+		if got == nil {
+			continue
+		}
+		if !got.agrees(parsed) {
+			updated, err := s.q.RecordSmsTemplateMiss(ctx, generated.RecordSmsTemplateMissParams{
+				Misses:    1,
+				MaxMisses: smsTemplateMaxMisses,
+				ID:        row.ID,
+			})
+			if err != nil {
+				log.Error().Err(err).Msg("[sms-llm] failed to record template miss")
+			} else if row.Status == SmsTemplateActive && updated.Status != SmsTemplateActive {
+				log.Warn().Str("sender", sender).Str("template_id", t.id.String()).Msg("[sms-llm] learned template demoted")
+			}
+			continue
+		}
+		agreed = true
+		updated, err := s.q.RecordSmsTemplateHit(ctx, generated.RecordSmsTemplateHitParams{
+			Hits:         1,
+			PromoteAfter: smsTemplatePromoteAfter,
+			ID:           row.ID,
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("[sms-llm] failed to record template hit")
+			continue
+		}
+		if row.Status != SmsTemplateActive && updated.Status == SmsTemplateActive {
+			log.Info().Str("sender", sender).Str("template_id", t.id.String()).Msg("[sms-llm] learned template promoted")
+		}
+	}
+	if agreed {
+		return
+	}
+
+	pattern, ok := learnSmsPattern(msg, parsed)
+	if !ok {
+		return
+	}
+	// The pattern must read the message back the way the LLM did to be worth keeping.
+	candidate, err := learnedSmsTemplate(uuid.Nil, pattern, txnType)
+	if err != nil {
+		return
+	}
+	if got := candidate.parse(msg); got == nil || !got.agrees(parsed) {
+		return
+	}
+	if _, err := s.q.CreateSmsTemplate(ctx, generated.CreateSmsTemplateParams{
+		UserID:  smsLog.UserID,
+		Sender:  sender,
+		Pattern: pattern,
+		TxnType: generated.TxnType(txnType),
+	}); err != nil {
+		log.Error().Err(err).Msg("[sms-llm] failed to save candidate template")
+	}
+}
+
+// learnedTemplates compiles the active templates learned for the sender of an SMS.
+func (s *SmsService) learnedTemplates(ctx context.Context, clerkId, sender string, log *zerolog.Logger) []smsTemplate {
+	rows, err := s.r.ListActiveSmsTemplates(ctx, clerkId, senderHeader(sender))
+	if err != nil {
+		log.Error().Err(err).Msg("[sms] failed to load learned templates")
+		return nil
+	}
+	templates := make([]smsTemplate, 0, len(rows))
+	for _, row := range rows {
+		t, err := learnedSmsTemplate(row.Id, row.Pattern, transaction.TxnType(row.Type))
+		if err != nil {
+			log.Warn().Err(err).Str("template_id", row.Id.String()).Msg("[sms] skipping learned template that does not compile")
+			continue
+		}
+		templates = append(templates, t)
+	}
+	return templates
+}
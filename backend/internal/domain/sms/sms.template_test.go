@@ -0,0 +1,212 @@
+package sms
+
+import (
+	"testing"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/transaction"
+	aiservices "github.com/KaranMali2001/finance-tracker-v2-backend/internal/services/aiServices"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/utils"
+	"github.com/google/uuid"
+)
+
+func TestLearnSmsPattern(t *testing.T) {
+	tests := []struct {
+		name         string
+		msg          string
+		llm          aiservices.ParsedTxn
+		next         string
+		wantAmount   float64
+		wantAccount  string
+		wantMerchant string
+		wantRef      string
+		wantOk       bool
+	}{
+		{
+			name: "learned pattern reads the next message of the same shape",
+			msg:  "Dear Customer, Rs.1,250.00 paid from A/c XX4321 to SWIGGY on 05-Apr-24. Ref 412345678901",
+			llm: aiservices.ParsedTxn{
+				Amount:          1250,
+				Type:            "debit",
+				AccountNum:      utils.PtrString("4321"),
+				Description:     utils.PtrString("SWIGGY"),
+				ReferenceNumber: utils.PtrString("412345678901"),
+			},
+			next:         "Dear Customer, Rs.99.50 paid from A/c XX4321 to ZOMATO LTD on 12-May-24. Ref 498765432100",
+			wantAmount:   99.50,
+			wantAccount:  "4321",
+			wantMerchant: "ZOMATO LTD",
+			wantRef:      "498765432100",
+			wantOk:       true,
+		},
+		{
+			name: "amount that matches the account digits is taken from behind the currency",
+			msg:  "A/c *500 debited INR 500 today",
+			llm: aiservices.ParsedTxn{
+				Amount:     500,
+				Type:       "debit",
+				AccountNum: utils.PtrString("500"),
+			},
+			next:        "A/c *500 debited INR 75 today",
+			wantAmount:  75,
+			wantAccount: "500",
+			wantOk:      true,
+		},
+		{
+			name: "amount not in the message",
+			msg:  "Rs.100 paid from A/c XX4321",
+			llm:  aiservices.ParsedTxn{Amount: 200, Type: "debit", AccountNum: utils.PtrString("4321")},
+		},
+		{
+			name: "account not in the message",
+			msg:  "Rs.100 paid from A/c XX4321",
+			llm:  aiservices.ParsedTxn{Amount: 100, Type: "debit", AccountNum: utils.PtrString("9999")},
+		},
+		{
+			name: "no account",
+			msg:  "Rs.100 paid",
+			llm:  aiservices.ParsedTxn{Amount: 100, Type: "debit"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern, ok := learnSmsPattern(tt.msg, &tt.llm)
+			if ok != tt.wantOk {
+				t.Fatalf("learnSmsPattern() ok = %v, want %v (pattern %q)", ok, tt.wantOk, pattern)
+			}
+			if !ok {
+				return
+			}
+			tpl, err := learnedSmsTemplate(uuid.New(), pattern, transaction.TxnTypeDebit)
+			if err != nil {
+				t.Fatalf("learned pattern %q does not compile: %v", pattern, err)
+			}
+			if tpl.parse(tt.msg) == nil {
+				t.Errorf("pattern %q does not match the message it was learned from", pattern)
+			}
+			got := tpl.parse(tt.next)
+			if got == nil {
+				t.Fatalf("pattern %q does not match %q", pattern, tt.next)
+			}
+			if got.Amount != tt.wantAmount {
+				t.Errorf("Amount = %v, want %v", got.Amount, tt.wantAmount)
+			}
+			checkOptional(t, "AccountSuffix", got.AccountSuffix, tt.wantAccount)
+			checkOptional(t, "Merchant", got.Merchant, tt.wantMerchant)
+			checkOptional(t, "ReferenceNumber", got.ReferenceNumber, tt.wantRef)
+		})
+	}
+}
+
+func TestParsedSmsAgrees(t *testing.T) {
+	parsed := ParsedSms{
+		Type:            transaction.TxnTypeDebit,
+		Amount:          250,
+		AccountSuffix:   utils.PtrString("4321"),
+		ReferenceNumber: utils.PtrString("412345678901"),
+	}
+	tests := []struct {
+		name string
+		llm  aiservices.ParsedTxn
+		want bool
+	}{
+		{"same reading", aiservices.ParsedTxn{Amount: 250, Type: "debit", AccountNum: utils.PtrString("XXXX4321"), ReferenceNumber: utils.PtrString("412345678901")}, true},
+		{"shorter account digits", aiservices.ParsedTxn{Amount: 250, Type: "DEBIT", AccountNum: utils.PtrString("321")}, true},
+		{"no reference from the llm", aiservices.ParsedTxn{Amount: 250, Type: "debit", AccountNum: utils.PtrString("4321")}, true},
+		{"different amount", aiservices.ParsedTxn{Amount: 25, Type: "debit", AccountNum: utils.PtrString("4321")}, false},
+		{"different direction", aiservices.ParsedTxn{Amount: 250, Type: "credit", AccountNum: utils.PtrString("4321")}, false},
+		{"different account", aiservices.ParsedTxn{Amount: 250, Type: "debit", AccountNum: utils.PtrString("1234")}, false},
+		{"no account from the llm", aiservices.ParsedTxn{Amount: 250, Type: "debit"}, false},
+		{"different reference", aiservices.ParsedTxn{Amount: 250, Type: "debit", AccountNum: utils.PtrString("4321"), ReferenceNumber: utils.PtrString("999")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parsed.agrees(&tt.llm); got != tt.want {
+				t.Errorf("agrees() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsedSmsAgreesWithDevice(t *testing.T) {
+	parsed := ParsedSms{Type: transaction.TxnTypeDebit, Amount: 250, AccountSuffix: utils.PtrString("4321")}
+	amount, other := 250.0, 25.0
+	tests := []struct {
+		name   string
+		device CreateSmsReq
+		want   bool
+	}{
+		{"same reading", CreateSmsReq{ParseStatus: "success", Amount: &amount, AccountNumber: utils.PtrString("4321"), TransactionType: utils.PtrString("debit")}, true},
+		{"device did not parse it", CreateSmsReq{ParseStatus: "failed"}, true},
+		{"different amount", CreateSmsReq{ParseStatus: "success", Amount: &other, AccountNumber: utils.PtrString("4321")}, false},
+		{"different direction", CreateSmsReq{ParseStatus: "success", Amount: &amount, AccountNumber: utils.PtrString("4321"), TransactionType: utils.PtrString("credit")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parsed.agreesWithDevice(&tt.device); got != tt.want {
+				t.Errorf("agreesWithDevice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateSmsCountsLearnedTemplateMisses(t *testing.T) {
+	pattern, ok := learnSmsPattern("Dear Customer, Rs.1,250.00 paid from A/c XX4321 to SWIGGY on 05-Apr-24. Ref 412345678901", &aiservices.ParsedTxn{
+		Amount:          1250,
+		Type:            "debit",
+		AccountNum:      utils.PtrString("4321"),
+		Description:     utils.PtrString("SWIGGY"),
+		ReferenceNumber: utils.PtrString("412345678901"),
+	})
+	if !ok {
+		t.Fatal("learnSmsPattern() failed")
+	}
+	template := SmsTemplate{Id: uuid.New(), Pattern: pattern, Type: string(transaction.TxnTypeDebit), Status: SmsTemplateActive}
+	msg := "Dear Customer, Rs.99.50 paid from A/c XX4321 to ZOMATO on 12-May-24. Ref 498765432100"
+	read, misread := 99.50, 9950.0
+
+	tests := []struct {
+		name       string
+		device     *float64
+		wantHits   int
+		wantMisses int
+	}{
+		{name: "device agrees", device: &read, wantHits: 1},
+		{name: "device did not parse it", wantHits: 1},
+		{name: "device read another amount", device: &misread, wantMisses: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := &CreateSmsReq{Sender: "XY-PAYAPP", RawMessage: msg, ReceivedAt: testReceivedAt, ParseStatus: "failed"}
+			if tt.device != nil {
+				payload.ParseStatus, payload.Amount, payload.AccountNumber = "success", tt.device, utils.PtrString("4321")
+			}
+			repo := &fakeSmsRepo{sms: SmsLogs{Id: uuid.NewString()}, templates: []SmsTemplate{template}}
+			svc := newTestSmsService(repo, &fakeTxnCreator{}, &fakeTx{})
+
+			if _, err := svc.CreateSms(testEchoContext(), payload, "user_1"); err != nil {
+				t.Fatalf("CreateSms() error = %v", err)
+			}
+			if repo.hits != tt.wantHits || repo.misses != tt.wantMisses {
+				t.Errorf("recorded %d hits and %d misses, want %d and %d", repo.hits, repo.misses, tt.wantHits, tt.wantMisses)
+			}
+		})
+	}
+}
+
+func TestGeneralizeSmsLiteral(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{" paid from A/c ", ` paid from A/c `},
+		{" on 05-Apr-24. Ref ", ` on \S+ Ref `},
+		{" on Apr 5 at ", ` on \S+ \S+ at `},
+		{"(UPI) ", `\(UPI\) `},
+	}
+	for _, tt := range tests {
+		if got := generalizeSmsLiteral(tt.in); got != tt.want {
+			t.Errorf("generalizeSmsLiteral(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
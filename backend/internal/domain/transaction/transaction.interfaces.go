@@ -17,6 +17,7 @@ import (
 type txnQuerier interface {
 	WithTx(tx pgx.Tx) *generated.Queries
 	CreateTxn(ctx context.Context, arg generated.CreateTxnParams) (generated.Transaction, error)
+	CreateTxnBatch(ctx context.Context, arg []generated.CreateTxnBatchParams) *generated.CreateTxnBatchBatchResults
 	GetTxnsWithFilters(ctx context.Context, arg generated.GetTxnsWithFiltersParams) ([]generated.GetTxnsWithFiltersRow, error)
 	SoftDeleteTxns(ctx context.Context, arg generated.SoftDeleteTxnsParams) ([]generated.Transaction, error)
 	UpdateTxn(ctx context.Context, arg generated.UpdateTxnParams) (pgtype.UUID, error)
@@ -25,6 +26,7 @@ type txnQuerier interface {
 // txnRepository is the interface TxnService depends on.
 type txnRepository interface {
 	CreateTxns(ctx context.Context, clerkId string, payload *CreateTxnReq) (*Transaction, error)
+	CreateTxnsBatch(ctx context.Context, clerkId string, payloads []*CreateTxnReq) ([]*Transaction, error)
 	GetTxnsWithFilters(ctx context.Context, clerkId string, filters *GetTxnsWithFiltersReq) ([]*Transaction, error)
 	SoftDeleteTxns(ctx context.Context, clerkId string, payload *SoftDeleteTxnsReq) ([]*Transaction, error)
 	UpdateTxn(ctx context.Context, clerkId string, payload *UpdateTxnReq) (*Transaction, error)
@@ -36,6 +38,8 @@ type txnRepository interface {
 type userProvider interface {
 	GetUserByClerkId(ctx context.Context, clerkId string) (*user.User, error)
 	UpdateUserInternal(ctx context.Context, payload *user.UpdateUserInternal, clerkId string) (*user.User, error)
+	ReserveLlmCredit(ctx context.Context, clerkId, feature string, referenceID *uuid.UUID) (*user.LlmCreditReservation, error)
+	RefundLlmCredit(ctx context.Context, clerkId string, reservationID uuid.UUID) error
 }
 
 // staticProvider is the local interface for cross-module static dependency.
@@ -137,6 +137,7 @@ func (h *TxnHandler) UpdateTxn(c echo.Context) error {
 // @Success 200 {object} ParsedTxnRes
 // @Failure 400 {object} map[string]string "Bad Request"
 // @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 402 {object} map[string]string "LLM_CREDITS_EXHAUSTED: no LLM parse credits left this month"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /transaction/image-parse [post]
 func (h *TxnHandler) ParseTxn(c echo.Context) error {
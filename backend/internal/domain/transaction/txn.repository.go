@@ -76,6 +76,69 @@ func (r *TxnRepository) CreateTxns(c context.Context, clerkId string, payload *C
 	return txnFromDb(&dbTxn), nil
 }
 
+// CreateTxnsBatch inserts several transactions in one round trip and returns them in
+// the order given.
+func (r *TxnRepository) CreateTxnsBatch(c context.Context, clerkId string, payloads []*CreateTxnReq) ([]*Transaction, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(c); tx != nil {
+		queries = queries.WithTx(tx)
+	}
+	params := make([]generated.CreateTxnBatchParams, len(payloads))
+	for i, payload := range payloads {
+		params[i] = generated.CreateTxnBatchParams{
+			UserID:          clerkId,
+			AccountID:       utils.UUIDToPgtype(payload.AccountId),
+			CategoryID:      utils.UUIDPtrToPgtype(payload.CategoryId),
+			MerchantID:      utils.UUIDPtrToPgtype(payload.MerchantId),
+			Type:            generated.TxnType(payload.Type),
+			Amount:          utils.Float64PtrToNum(&payload.Amount),
+			Description:     utils.StringPtrToText(payload.Description),
+			Tags:            utils.StringPtrToText(payload.Tags),
+			SmsID:           utils.UUIDPtrToPgtype(payload.SmsId),
+			PaymentMethod:   utils.StringPtrToText(payload.PaymentMethod),
+			ReferenceNumber: utils.StringPtrToText(payload.ReferenceNumber),
+			IsRecurring:     utils.ToPgBool(&payload.IsRecurring),
+			Notes:           utils.StringPtrToText(payload.Notes),
+			TransactionDate: utils.TimestampPtrToPgtype(payload.TransactionDate),
+			// The column default CreateTxn leaves in place.
+			Source: generated.NullTransactionSource{TransactionSource: generated.TransactionSourceMANUAL, Valid: true},
+		}
+	}
+
+	txns := make([]*Transaction, len(payloads))
+	var batchErr error
+	queries.CreateTxnBatch(c, params).QueryRow(func(i int, row generated.CreateTxnBatchRow, err error) {
+		if err != nil {
+			if batchErr == nil {
+				batchErr = err
+			}
+			return
+		}
+		payload := payloads[i]
+		txns[i] = &Transaction{
+			Id:              utils.UUIDToString(row.ID),
+			UserId:          clerkId,
+			AccountId:       payload.AccountId.String(),
+			CategoryId:      utils.UUIDToStringPtr(utils.UUIDPtrToPgtype(payload.CategoryId)),
+			MerchantId:      utils.UUIDToStringPtr(utils.UUIDPtrToPgtype(payload.MerchantId)),
+			Type:            TxnType(row.Type),
+			Amount:          payload.Amount,
+			Description:     utils.TextToStringPtr(row.Description),
+			Notes:           payload.Notes,
+			Tags:            payload.Tags,
+			SmsId:           utils.UUIDToStringPtr(utils.UUIDPtrToPgtype(payload.SmsId)),
+			PaymentMethod:   payload.PaymentMethod,
+			ReferenceNumber: payload.ReferenceNumber,
+			IsRecurring:     payload.IsRecurring,
+			TransactionDate: utils.TimestampToTimePtr(row.TransactionDate),
+		}
+	})
+	if batchErr != nil {
+		return nil, batchErr
+	}
+	return txns, nil
+}
+
 func (r *TxnRepository) GetTxnsWithFilters(c context.Context, clerkId string, filters *GetTxnsWithFiltersReq) ([]*Transaction, error) {
 	queries := r.queries
 	if tx := r.tm.GetTx(c); tx != nil {
@@ -2,6 +2,7 @@ package transaction
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime"
@@ -45,15 +46,9 @@ func (s *TxnService) CreateTxn(c echo.Context, payload *CreateTxnReq, clerkId st
 	log.Info().Msgf("Creating New Transaction for User %v", clerkId)
 	var result *Transaction
 	err := s.tm.WithTx(c.Request().Context(), func(c context.Context) error {
-		txn, err := s.r.CreateTxns(c, clerkId, payload)
-		if err != nil {
-			return err
-		}
+		txn, err := s.CreateTxnTx(c, payload, clerkId)
 		result = txn
-		if err := s.balanceUpdater.Apply(c, clerkId, payload.AccountId, string(txn.Type), txn.Amount); err != nil {
-			return err
-		}
-		return nil
+		return err
 	}, log)
 	if err != nil {
 		return nil, err
@@ -75,15 +70,9 @@ func (s *TxnService) CreateTxnCtx(ctx context.Context, payload *CreateTxnReq, cl
 	log := zerolog.Ctx(ctx)
 	var result *Transaction
 	err := s.tm.WithTx(ctx, func(c context.Context) error {
-		txn, err := s.r.CreateTxns(c, clerkId, payload)
-		if err != nil {
-			return err
-		}
+		txn, err := s.CreateTxnTx(c, payload, clerkId)
 		result = txn
-		if err := s.balanceUpdater.Apply(c, clerkId, payload.AccountId, string(txn.Type), txn.Amount); err != nil {
-			return err
-		}
-		return nil
+		return err
 	}, log)
 	if err != nil {
 		return nil, err
@@ -99,6 +88,76 @@ func (s *TxnService) CreateTxnCtx(ctx context.Context, payload *CreateTxnReq, cl
 	return result, nil
 }
 
+// CreateTxnTx creates a transaction and applies it to the balances inside the database
+// transaction ctx carries. The caller queues auto-linking once that commits.
+func (s *TxnService) CreateTxnTx(ctx context.Context, payload *CreateTxnReq, clerkId string) (*Transaction, error) {
+	txn, err := s.r.CreateTxns(ctx, clerkId, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.balanceUpdater.Apply(ctx, clerkId, payload.AccountId, string(txn.Type), txn.Amount); err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+// EnqueueAutoLink queues linking the given transactions to the user's investments.
+func (s *TxnService) EnqueueAutoLink(ctx context.Context, clerkId string, txnIDs []uuid.UUID, log *zerolog.Logger) error {
+	return s.autoLinker.EnqueueAutoLinkCtx(ctx, clerkId, txnIDs, log)
+}
+
+// CreateTxnBatch inserts several transactions in one round trip, applies them to the
+// balances once per account and type, and queues a single auto-link job for all of
+// them. Nothing is created if any of them fails.
+func (s *TxnService) CreateTxnBatch(c echo.Context, payloads []*CreateTxnReq, clerkId string) ([]*Transaction, error) {
+	log := middleware.GetLogger(c)
+	ctx := c.Request().Context()
+	log.Info().Msgf("Creating %d Transactions for User %v", len(payloads), clerkId)
+	type balanceKey struct {
+		accountID uuid.UUID
+		txnType   TxnType
+	}
+	var results []*Transaction
+	err := s.tm.WithTx(ctx, func(c context.Context) error {
+		var err error
+		results, err = s.r.CreateTxnsBatch(c, clerkId, payloads)
+		if err != nil {
+			return err
+		}
+		totals := make(map[balanceKey]float64)
+		var keys []balanceKey
+		for _, payload := range payloads {
+			k := balanceKey{payload.AccountId, payload.Type}
+			if _, ok := totals[k]; !ok {
+				keys = append(keys, k)
+			}
+			totals[k] += payload.Amount
+		}
+		for _, k := range keys {
+			if err := s.balanceUpdater.Apply(c, clerkId, k.accountID, string(k.txnType), totals[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, log)
+	if err != nil {
+		return nil, err
+	}
+
+	txnIDs := make([]uuid.UUID, 0, len(results))
+	for _, txn := range results {
+		if id, err := uuid.Parse(txn.Id); err == nil {
+			txnIDs = append(txnIDs, id)
+		}
+	}
+	if len(txnIDs) > 0 {
+		if err := s.autoLinker.EnqueueAutoLinkCtx(ctx, clerkId, txnIDs, log); err != nil {
+			log.Error().Err(err).Msg("failed to enqueue auto-link after batch transaction creation")
+		}
+	}
+	return results, nil
+}
+
 func (s *TxnService) GetTxnsWithFilters(c echo.Context, payload *GetTxnsWithFiltersReq, clerkId string) ([]*Transaction, error) {
 	return s.r.GetTxnsWithFilters(c.Request().Context(), clerkId, payload)
 }
@@ -212,9 +271,20 @@ func (s *TxnService) ParseTxnImage(c echo.Context, payload *ParseTxnImgReq, cler
 		log.Error().Err(err).Msg("error while reading the File")
 		return nil, err
 	}
+	reservation, err := s.userRepo.ReserveLlmCredit(c.Request().Context(), clerkId, user.LlmFeatureTxnImageParse, nil)
+	if err != nil {
+		if errors.Is(err, user.ErrLlmCreditsExhausted) {
+			return nil, user.NewLlmCreditsExhaustedError()
+		}
+		log.Error().Err(err).Msg("error while reserving an LLM parse credit")
+		return nil, err
+	}
 	parseTxn, err := s.geminiSvc.ParseTxn(c.Request().Context(), imageData, categoryMap, merchantMap, mimeType, log)
 	if err != nil {
 		log.Error().Err(err).Msg("error while parsing txn through gemini")
+		if rerr := s.userRepo.RefundLlmCredit(context.WithoutCancel(c.Request().Context()), clerkId, reservation.Id); rerr != nil {
+			log.Error().Err(rerr).Msg("error while refunding the LLM parse credit")
+		}
 		return nil, err
 	}
 	log.Debug().Msgf("Parsed Txn before updating the User %v", parseTxn)
@@ -0,0 +1,51 @@
+package user
+
+import (
+	"context"
+	"errors"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/errs"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Features an LLM parse credit is spent on, as recorded in the ledger.
+const (
+	LlmFeatureSmsParse       = "sms_parse"
+	LlmFeatureTxnImageParse  = "txn_image_parse"
+	LlmFeatureStatementParse = "statement_parse"
+)
+
+// LlmCreditsExhaustedCode is the error code returned to a user out of LLM parse credits.
+const LlmCreditsExhaustedCode = "LLM_CREDITS_EXHAUSTED"
+
+// ErrLlmCreditsExhausted is returned when a reservation finds no credit left this month.
+var ErrLlmCreditsExhausted = errors.New("no LLM parse credits left this month")
+
+// NewLlmCreditsExhaustedError is the HTTP error for a request that needed a credit the
+// user does not have.
+func NewLlmCreditsExhaustedError() *errs.HTTPError {
+	code := LlmCreditsExhaustedCode
+	return errs.NewPaymentRequiredError("You have used all your LLM parse credits for this month", false, &code)
+}
+
+func (s *UserService) GetLlmCredits(c echo.Context, payload *GetLlmCreditsReq, clerkId string) (*LlmCredits, error) {
+	return s.repository.GetLlmCredits(c.Request().Context(), clerkId)
+}
+
+// GetLlmCreditsLeft returns how many LLM parses the user can still make this month.
+func (s *UserService) GetLlmCreditsLeft(ctx context.Context, clerkId string) (int, error) {
+	credits, err := s.repository.GetLlmCredits(ctx, clerkId)
+	if err != nil {
+		return 0, err
+	}
+	return credits.Remaining, nil
+}
+
+func (s *UserService) ReserveLlmCredit(ctx context.Context, clerkId, feature string, referenceID *uuid.UUID) (*LlmCreditReservation, error) {
+	return s.repository.ReserveLlmCredit(ctx, clerkId, feature, referenceID)
+}
+
+func (s *UserService) RefundLlmCredit(ctx context.Context, clerkId string, reservationID uuid.UUID) error {
+	return s.repository.RefundLlmCredit(ctx, clerkId, reservationID)
+}
@@ -0,0 +1,194 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// fakeLedger keeps one user's credits the way the llm_credit.sql queries do: a
+// reservation takes a credit while any are left, unless its reference already has one
+// not refunded, and a reservation is refunded once.
+type fakeLedger struct {
+	userQuerier
+	user     string
+	balance  int32
+	entries  []generated.LlmCreditLedger
+	refunded map[uuid.UUID]bool
+	err      error
+}
+
+func (f *fakeLedger) ReserveLlmCredit(_ context.Context, arg generated.ReserveLlmCreditParams) (generated.LlmCreditLedger, error) {
+	if f.err != nil {
+		return generated.LlmCreditLedger{}, f.err
+	}
+	if arg.ReferenceID.Valid {
+		for i := len(f.entries) - 1; i >= 0; i-- {
+			e := f.entries[i]
+			if e.UserID == arg.UserID && e.Feature == arg.Feature && e.ReferenceID == arg.ReferenceID &&
+				e.Delta < 0 && !f.refunded[utils.UUIDToUUID(e.ID)] {
+				return e, nil
+			}
+		}
+	}
+	if arg.UserID != f.user || f.balance <= 0 {
+		return generated.LlmCreditLedger{}, pgx.ErrNoRows
+	}
+	f.balance--
+	entry := generated.LlmCreditLedger{
+		ID:           utils.UUIDToPgtype(uuid.New()),
+		UserID:       arg.UserID,
+		Feature:      arg.Feature,
+		Delta:        -1,
+		BalanceAfter: f.balance,
+		ReferenceID:  arg.ReferenceID,
+	}
+	f.entries = append(f.entries, entry)
+	return entry, nil
+}
+
+func (f *fakeLedger) RefundLlmCredit(_ context.Context, arg generated.RefundLlmCreditParams) (generated.LlmCreditLedger, error) {
+	if f.err != nil {
+		return generated.LlmCreditLedger{}, f.err
+	}
+	id := utils.UUIDToUUID(arg.ReservationID)
+	for _, e := range f.entries {
+		if e.ID != arg.ReservationID || e.UserID != arg.UserID || e.Delta >= 0 || f.refunded[id] {
+			continue
+		}
+		f.refunded[id] = true
+		f.balance++
+		refund := generated.LlmCreditLedger{
+			ID:           utils.UUIDToPgtype(uuid.New()),
+			UserID:       e.UserID,
+			Feature:      e.Feature,
+			Delta:        1,
+			BalanceAfter: f.balance,
+			ReferenceID:  e.ReferenceID,
+			RefundOf:     e.ID,
+		}
+		f.entries = append(f.entries, refund)
+		return refund, nil
+	}
+	return generated.LlmCreditLedger{}, pgx.ErrNoRows
+}
+
+func newCreditRepository(balance int32) (*UserRepository, *fakeLedger) {
+	ledger := &fakeLedger{user: "user_1", balance: balance, refunded: make(map[uuid.UUID]bool)}
+	return NewUserRepository(ledger, &database.TxManager{}), ledger
+}
+
+func TestReserveLlmCredit(t *testing.T) {
+	ctx := context.Background()
+	repo, ledger := newCreditRepository(2)
+	ref := uuid.New()
+
+	first, err := repo.ReserveLlmCredit(ctx, "user_1", LlmFeatureStatementParse, &ref)
+	if err != nil {
+		t.Fatalf("first reservation: %v", err)
+	}
+	if first.Remaining != 1 {
+		t.Errorf("first reservation Remaining = %d, want 1", first.Remaining)
+	}
+	if got := ledger.entries[0]; got.Feature != LlmFeatureStatementParse || utils.UUIDToUUID(got.ReferenceID) != ref {
+		t.Errorf("ledger entry = %+v, want feature %q and reference %s", got, LlmFeatureStatementParse, ref)
+	}
+
+	second, err := repo.ReserveLlmCredit(ctx, "user_1", LlmFeatureSmsParse, nil)
+	if err != nil {
+		t.Fatalf("second reservation: %v", err)
+	}
+	if second.Remaining != 0 || second.Id == first.Id {
+		t.Errorf("second reservation = %+v, want a new reservation with 0 remaining", second)
+	}
+	if ledger.entries[1].ReferenceID.Valid {
+		t.Errorf("reservation without a reference stored %v", ledger.entries[1].ReferenceID)
+	}
+
+	if _, err := repo.ReserveLlmCredit(ctx, "user_1", LlmFeatureSmsParse, nil); !errors.Is(err, ErrLlmCreditsExhausted) {
+		t.Errorf("reservation with no credits left: err = %v, want ErrLlmCreditsExhausted", err)
+	}
+	if _, err := repo.ReserveLlmCredit(ctx, "someone_else", LlmFeatureSmsParse, nil); !errors.Is(err, ErrLlmCreditsExhausted) {
+		t.Errorf("reservation for another user: err = %v, want ErrLlmCreditsExhausted", err)
+	}
+
+	dbErr := errors.New("connection reset")
+	ledger.err = dbErr
+	if _, err := repo.ReserveLlmCredit(ctx, "user_1", LlmFeatureSmsParse, nil); !errors.Is(err, dbErr) {
+		t.Errorf("reservation on a failing database: err = %v, want %v", err, dbErr)
+	}
+}
+
+func TestReserveLlmCreditReusesOpenReservation(t *testing.T) {
+	ctx := context.Background()
+	repo, ledger := newCreditRepository(3)
+	sms := uuid.New()
+
+	first, err := repo.ReserveLlmCredit(ctx, "user_1", LlmFeatureSmsParse, &sms)
+	if err != nil {
+		t.Fatalf("first reservation: %v", err)
+	}
+	retry, err := repo.ReserveLlmCredit(ctx, "user_1", LlmFeatureSmsParse, &sms)
+	if err != nil {
+		t.Fatalf("retried reservation: %v", err)
+	}
+	if retry.Id != first.Id || ledger.balance != 2 {
+		t.Errorf("retry = %+v with balance %d, want reservation %s reused and balance 2", retry, ledger.balance, first.Id)
+	}
+
+	if err := repo.RefundLlmCredit(ctx, "user_1", first.Id); err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	again, err := repo.ReserveLlmCredit(ctx, "user_1", LlmFeatureSmsParse, &sms)
+	if err != nil {
+		t.Fatalf("reservation after refund: %v", err)
+	}
+	if again.Id == first.Id || ledger.balance != 2 {
+		t.Errorf("reservation after refund = %+v with balance %d, want a new reservation and balance 2", again, ledger.balance)
+	}
+}
+
+func TestRefundLlmCredit(t *testing.T) {
+	ctx := context.Background()
+	repo, ledger := newCreditRepository(1)
+
+	res, err := repo.ReserveLlmCredit(ctx, "user_1", LlmFeatureTxnImageParse, nil)
+	if err != nil {
+		t.Fatalf("reservation: %v", err)
+	}
+	if err := repo.RefundLlmCredit(ctx, "user_1", res.Id); err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if ledger.balance != 1 {
+		t.Errorf("balance after refund = %d, want 1", ledger.balance)
+	}
+	if got := ledger.entries[len(ledger.entries)-1]; got.Delta != 1 || utils.UUIDToUUID(got.RefundOf) != res.Id {
+		t.Errorf("refund entry = %+v, want +1 against reservation %s", got, res.Id)
+	}
+
+	// Refunding again, someone else's reservation or an unknown one does nothing.
+	if err := repo.RefundLlmCredit(ctx, "user_1", res.Id); err != nil {
+		t.Errorf("second refund: err = %v, want nil", err)
+	}
+	if err := repo.RefundLlmCredit(ctx, "someone_else", res.Id); err != nil {
+		t.Errorf("refund by another user: err = %v, want nil", err)
+	}
+	if err := repo.RefundLlmCredit(ctx, "user_1", uuid.New()); err != nil {
+		t.Errorf("refund of an unknown reservation: err = %v, want nil", err)
+	}
+	if ledger.balance != 1 {
+		t.Errorf("balance after repeated refunds = %d, want 1", ledger.balance)
+	}
+
+	dbErr := errors.New("connection reset")
+	ledger.err = dbErr
+	if err := repo.RefundLlmCredit(ctx, "user_1", res.Id); !errors.Is(err, dbErr) {
+		t.Errorf("refund on a failing database: err = %v, want %v", err, dbErr)
+	}
+}
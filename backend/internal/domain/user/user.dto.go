@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 type UpdateUserReq struct {
@@ -44,3 +45,24 @@ type GenerateApiKeyReq struct{}
 func (u *GenerateApiKeyReq) Validate() error {
 	return nil
 }
+
+// LlmCredits is what the user's plan grants each month and how much of it is left.
+type LlmCredits struct {
+	Plan           string    `json:"plan"`
+	MonthlyCredits int       `json:"monthly_credits"`
+	Remaining      int       `json:"remaining"`
+	ResetsAt       time.Time `json:"resets_at"`
+}
+
+// LlmCreditReservation is a credit taken ahead of an LLM call, to be refunded if the
+// call fails.
+type LlmCreditReservation struct {
+	Id        uuid.UUID
+	Remaining int
+}
+
+type GetLlmCreditsReq struct{}
+
+func (u *GetLlmCreditsReq) Validate() error {
+	return nil
+}
@@ -65,3 +65,18 @@ func (h *UserHandler) GenerateApiKey(c echo.Context) error {
 		&GenerateApiKeyReq{},
 	)(c)
 }
+
+// GetLlmCredits godoc
+// @Summary Get LLM parse credits
+// @Description Returns the authenticated user's plan, the LLM parse credits it grants each month, how many are left and when they reset. SMS and transaction image parsing with the LLM each use one credit.
+// @Tags User
+// @Produce json
+// @Success 200 {object} LlmCredits
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /user/llm-credits [get]
+func (h *UserHandler) GetLlmCredits(c echo.Context) error {
+	return handler.Handle(h.base, func(c echo.Context, payload *GetLlmCreditsReq) (*LlmCredits, error) {
+		return h.userService.GetLlmCredits(c, payload, middleware.GetUserID(c))
+	}, http.StatusOK, &GetLlmCreditsReq{})(c)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -16,6 +17,9 @@ type userQuerier interface {
 	GetAuthUser(ctx context.Context, clerkID string) (generated.User, error)
 	UpdateUserInternal(ctx context.Context, arg generated.UpdateUserInternalParams) (generated.User, error)
 	GetUserByApiKey(ctx context.Context, apiKey pgtype.Text) (generated.User, error)
+	GetLlmCredits(ctx context.Context, clerkID string) (generated.GetLlmCreditsRow, error)
+	ReserveLlmCredit(ctx context.Context, arg generated.ReserveLlmCreditParams) (generated.LlmCreditLedger, error)
+	RefundLlmCredit(ctx context.Context, arg generated.RefundLlmCreditParams) (generated.LlmCreditLedger, error)
 }
 
 // userRepository is the interface UserService depends on.
@@ -25,6 +29,9 @@ type userRepository interface {
 	UpdateUserInternal(ctx context.Context, payload *UpdateUserInternal, clerkId string) (*User, error)
 	GetReconciliationThreshold(ctx context.Context, clerkId string) (int, error)
 	GetUserByApiKey(ctx context.Context, apiKey string) (*User, error)
+	GetLlmCredits(ctx context.Context, clerkId string) (*LlmCredits, error)
+	ReserveLlmCredit(ctx context.Context, clerkId, feature string, referenceID *uuid.UUID) (*LlmCreditReservation, error)
+	RefundLlmCredit(ctx context.Context, clerkId string, reservationID uuid.UUID) error
 }
 
 // Compile-time check: *generated.Queries must satisfy userQuerier.
@@ -2,10 +2,13 @@ package user
 
 import (
 	"context"
+	"errors"
 
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/database/generated"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type UserRepository struct {
@@ -112,3 +115,58 @@ func (r *UserRepository) GetUserByApiKey(c context.Context, apiKey string) (*Use
 	}
 	return userFromDb(&user), nil
 }
+
+func (r *UserRepository) GetLlmCredits(ctx context.Context, clerkId string) (*LlmCredits, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = r.queries.WithTx(tx)
+	}
+	row, err := queries.GetLlmCredits(ctx, clerkId)
+	if err != nil {
+		return nil, err
+	}
+	return &LlmCredits{
+		Plan:           row.LlmPlan,
+		MonthlyCredits: int(row.MonthlyCredits),
+		Remaining:      int(row.Remaining),
+		ResetsAt:       utils.TimestampToTime(row.ResetsAt),
+	}, nil
+}
+
+// ReserveLlmCredit takes one credit for an LLM call, returning ErrLlmCreditsExhausted
+// when the user has none left this month.
+func (r *UserRepository) ReserveLlmCredit(ctx context.Context, clerkId, feature string, referenceID *uuid.UUID) (*LlmCreditReservation, error) {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = r.queries.WithTx(tx)
+	}
+	entry, err := queries.ReserveLlmCredit(ctx, generated.ReserveLlmCreditParams{
+		UserID:      clerkId,
+		Feature:     feature,
+		ReferenceID: utils.UUIDPtrToPgtype(referenceID),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrLlmCreditsExhausted
+		}
+		return nil, err
+	}
+	return &LlmCreditReservation{Id: utils.UUIDToUUID(entry.ID), Remaining: int(entry.BalanceAfter)}, nil
+}
+
+// RefundLlmCredit gives a reservation back. Refunding one already refunded, or one
+// from a month that has since reset, does nothing.
+func (r *UserRepository) RefundLlmCredit(ctx context.Context, clerkId string, reservationID uuid.UUID) error {
+	queries := r.queries
+	if tx := r.tm.GetTx(ctx); tx != nil {
+		queries = r.queries.WithTx(tx)
+	}
+	_, err := queries.RefundLlmCredit(ctx, generated.RefundLlmCreditParams{
+		ReservationID: utils.UUIDToPgtype(reservationID),
+		UserID:        clerkId,
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	return nil
+}
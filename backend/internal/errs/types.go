@@ -54,6 +54,21 @@ func NewNotFoundError(message string, override bool, code *string) *HTTPError {
 	}
 }
 
+func NewPaymentRequiredError(message string, override bool, code *string) *HTTPError {
+	formattedCode := MakeUpperCaseWithUnderscores(http.StatusText(http.StatusPaymentRequired))
+
+	if code != nil {
+		formattedCode = *code
+	}
+
+	return &HTTPError{
+		Code:     formattedCode,
+		Message:  message,
+		Status:   http.StatusPaymentRequired,
+		Override: override,
+	}
+}
+
 func NewInternalServerError() *HTTPError {
 	return &HTTPError{
 		Code:     MakeUpperCaseWithUnderscores(http.StatusText(http.StatusInternalServerError)),
@@ -285,20 +285,35 @@ var (
 		".webp": true,
 	}
 
-	// AllowedExcelTypes contains the allowed MIME types for Excel uploads
+	// AllowedExcelTypes contains the allowed MIME types for statement uploads (spreadsheets, PDF, OFX/QFX, CAMT.053, MT940).
+	// OFX, CAMT.053 and MT940 have no type clients agree on and usually arrive as a generic
+	// XML, text or binary one, so the reconciliation service checks the content against the
+	// extension before parsing.
 	AllowedExcelTypes = map[string]bool{
 		"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": true, // .xlsx
 		"application/vnd.ms-excel": true, // .xls
 		"text/csv":                 true, // .csv
 		"application/csv":          true, // .csv (non-standard but common)
+		"application/pdf":          true, // .pdf
+		"application/x-ofx":        true, // .ofx
+		"application/vnd.intu.qfx": true, // .qfx
+		"application/xml":          true, // .xml (CAMT.053)
+		"text/xml":                 true, // .xml (CAMT.053)
+		"text/plain":               true, // .sta / .mt940
 		// Some clients may upload Excel with a generic content type.
 		"application/octet-stream": true,
 	}
-	// AllowedExcelExtensions contains the allowed file extensions for Excel uploads
+	// AllowedExcelExtensions contains the allowed file extensions for statement uploads
 	AllowedExcelExtensions = map[string]bool{
-		".xlsx": true,
-		".xls":  true,
-		".csv":  true,
+		".xlsx":  true,
+		".xls":   true,
+		".csv":   true,
+		".pdf":   true,
+		".ofx":   true,
+		".qfx":   true,
+		".xml":   true,
+		".sta":   true,
+		".mt940": true,
 	}
 )
 
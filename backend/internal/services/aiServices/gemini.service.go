@@ -105,6 +105,59 @@ Return JSON with these fields (use null for unknown values):
 	return parseResponse(text)
 }
 
+// StatementTableRow is one transaction row the model read from a statement table.
+type StatementTableRow struct {
+	Date        string   `json:"date"`
+	Description string   `json:"description"`
+	Reference   *string  `json:"reference_number,omitempty"`
+	Amount      float64  `json:"amount"`
+	Type        string   `json:"type"`
+	Balance     *float64 `json:"balance,omitempty"`
+}
+
+// ParseStatementTable reads every transaction row of a bank or credit card statement
+// whose layout the rule-based parsers could not handle.
+func (gs *GeminiService) ParseStatementTable(ctx context.Context, file []byte, mimeType string, log *zerolog.Logger) ([]StatementTableRow, error) {
+	prompt := `Extract every transaction row from the transaction table(s) of this bank or credit card statement and return ONLY a valid JSON array with no extra text.
+
+Each element must be:
+{
+  "date": <transaction (posting) date in ISO 8601 format YYYY-MM-DD>,
+  "description": <narration / particulars exactly as printed, joined into one line>,
+  "reference_number": <cheque / reference / UTR number column value, or null>,
+  "amount": <positive numeric amount without currency symbols or thousands separators>,
+  "type": <"DEBIT" for withdrawals, purchases and charges; "CREDIT" for deposits, refunds and payments received>,
+  "balance": <running balance after the row as a number (negative if overdrawn), or null>
+}
+
+Instructions:
+1. Include every row across all pages in the order printed; skip opening/closing balance lines, totals, headers and footers.
+2. Never invent rows or values; use null when a field is not printed.
+3. Return [] if the document has no transaction table.`
+
+	content := []*genai.Content{
+		{
+			Parts: []*genai.Part{
+				{InlineData: &genai.Blob{MIMEType: mimeType, Data: file}},
+				genai.NewPartFromText(prompt),
+			},
+			Role: genai.RoleUser,
+		},
+	}
+	resp, err := gs.GeminiClient.Models.GenerateContent(ctx, gs.Model, content, nil)
+	if err != nil {
+		return nil, err
+	}
+	text := resp.Text()
+	log.Info().Int("response_len", len(text)).Msg("[recon] Gemini statement table response received")
+
+	var rows []StatementTableRow
+	if err := json.Unmarshal([]byte(extractJSON(text)), &rows); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal statement table: %w", err)
+	}
+	return rows, nil
+}
+
 func (gs *GeminiService) buildPrompt(categories map[string]string, merchants map[string]string) string {
 	var catList strings.Builder
 	for catId, catName := range categories {
@@ -8,6 +8,8 @@ import (
 	"github.com/rs/zerolog"
 )
 
+const TaskReconCalibration TaskType = "reconciliation:calibrate"
+
 // BankReconciliationPayload is the job payload for reconciliation:process tasks.
 type BankReconciliationPayload struct {
 	JobID                   string    `json:"job_id"`
@@ -20,3 +22,13 @@ type BankReconciliationPayload struct {
 func (ts *TaskService) EnqueueBankReconciliation(ctx context.Context, payload BankReconciliationPayload, logger *zerolog.Logger) error {
 	return ts.EnqueueTask(ctx, jobs.JobTypeBANKRECONCILIATION, TaskBankReconciliation, payload, payload.UserID, logger)
 }
+
+// ReconCalibrationPayload is the job payload for reconciliation:calibrate tasks.
+type ReconCalibrationPayload struct {
+	JobID  string `json:"job_id"`
+	UserID string `json:"user_id"`
+}
+
+func (ts *TaskService) EnqueueReconCalibration(ctx context.Context, payload ReconCalibrationPayload, logger *zerolog.Logger) error {
+	return ts.EnqueueTask(ctx, jobs.JobTypeRECONCALIBRATION, TaskReconCalibration, payload, payload.UserID, logger)
+}
@@ -19,3 +19,17 @@ func (ts *TaskService) EnqueueLlmSmsParse(ctx context.Context, smsID uuid.UUID,
 	log := zerolog.Ctx(ctx)
 	return ts.EnqueueTask(ctx, jobs.JobTypeLLMSMSPARSE, TaskLlmSmsParse, LlmSmsParsePayload{SmsID: smsID, UserID: clerkID}, clerkID, log)
 }
+
+const TaskLlmSmsParseBatch TaskType = "sms:llm_parse_batch"
+
+// LlmSmsParseBatchPayload runs the LLM fallback over the SMS a batch upload could not parse.
+type LlmSmsParseBatchPayload struct {
+	JobID  string      `json:"job_id"`
+	SmsIDs []uuid.UUID `json:"sms_ids"`
+	UserID string      `json:"user_id"`
+}
+
+func (ts *TaskService) EnqueueLlmSmsParseBatch(ctx context.Context, smsIDs []uuid.UUID, clerkID string) error {
+	log := zerolog.Ctx(ctx)
+	return ts.EnqueueTask(ctx, jobs.JobTypeLLMSMSPARSE, TaskLlmSmsParseBatch, LlmSmsParseBatchPayload{SmsIDs: smsIDs, UserID: clerkID}, clerkID, log)
+}
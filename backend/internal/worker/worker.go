@@ -3,12 +3,16 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/dispatcher"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/investment"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/jobs"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/reconciliation"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/sms"
+	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/domain/user"
 	"github.com/KaranMali2001/finance-tracker-v2-backend/internal/tasks"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
@@ -20,6 +24,7 @@ type emailSender interface {
 
 type reconRunner interface {
 	RunReconciliationJob(ctx context.Context, payload tasks.BankReconciliationPayload, log *zerolog.Logger) ([]uuid.UUID, error)
+	RunCalibrationJob(ctx context.Context, payload tasks.ReconCalibrationPayload, log *zerolog.Logger) (*reconciliation.CalibrationStats, error)
 }
 
 type investRunner interface {
@@ -68,10 +73,14 @@ func (w *Worker) Handle(ctx context.Context, event dispatcher.JobPayload) error
 		return w.handleWelcomeEmail(ctx, event.Payload)
 	case string(tasks.TaskBankReconciliation):
 		return w.handleBankReconciliation(ctx, event.Payload)
+	case string(tasks.TaskReconCalibration):
+		return w.handleReconCalibration(ctx, event.Payload)
 	case string(tasks.TaskInvestmentAutoLink):
 		return w.handleInvestmentAutoLink(ctx, event.Payload)
 	case string(tasks.TaskLlmSmsParse):
 		return w.handleLlmSmsParse(ctx, event.Payload)
+	case string(tasks.TaskLlmSmsParseBatch):
+		return w.handleLlmSmsParseBatch(ctx, event.Payload)
 	}
 	return fmt.Errorf("unknown job type: %s", event.Type)
 }
@@ -175,6 +184,26 @@ func (w *Worker) handleBankReconciliation(ctx context.Context, raw json.RawMessa
 	return nil
 }
 
+func (w *Worker) handleReconCalibration(ctx context.Context, raw json.RawMessage) error {
+	var payload tasks.ReconCalibrationPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal calibration payload: %w", err)
+	}
+
+	job := w.markProcessing(ctx, payload.JobID)
+
+	stats, err := w.reconService.RunCalibrationJob(ctx, payload, w.logger)
+	if err != nil {
+		w.markFailed(ctx, job, err.Error())
+		w.logger.Error().Err(err).Str("user_id", payload.UserID).Msg("[recon] calibration job failed")
+		return err
+	}
+
+	resultBytes, _ := json.Marshal(stats)
+	w.markCompleted(ctx, job, string(resultBytes))
+	return nil
+}
+
 func (w *Worker) handleInvestmentAutoLink(ctx context.Context, raw json.RawMessage) error {
 	var payload tasks.InvestmentAutoLinkPayload
 	if err := json.Unmarshal(raw, &payload); err != nil {
@@ -216,8 +245,56 @@ func (w *Worker) handleLlmSmsParse(ctx context.Context, raw json.RawMessage) err
 	w.logger.Info().Str("sms_id", payload.SmsID.String()).Str("user_id", payload.UserID).Msg("[sms-llm] starting LLM parse")
 
 	if err := w.smsLlmSvc.RunLlmParse(ctx, payload.SmsID, payload.UserID, w.logger); err != nil {
+		if errors.Is(err, user.ErrLlmCreditsExhausted) {
+			w.logger.Warn().Str("sms_id", payload.SmsID.String()).Msg("[sms-llm] no LLM parse credits left, skipping")
+			return nil
+		}
+		if errors.Is(err, sms.ErrSmsNotAwaitingLlm) {
+			w.logger.Info().Str("sms_id", payload.SmsID.String()).Msg("[sms-llm] SMS no longer waiting on the LLM, skipping")
+			return nil
+		}
 		w.logger.Error().Err(err).Str("sms_id", payload.SmsID.String()).Msg("[sms-llm] LLM parse failed")
 		return err
 	}
 	return nil
 }
+
+// handleLlmSmsParseBatch parses each SMS in turn. One SMS failing does not fail the
+// job, and RunLlmParse skips an SMS an earlier attempt already handled, so a retry
+// only pays for the ones still waiting.
+func (w *Worker) handleLlmSmsParseBatch(ctx context.Context, raw json.RawMessage) error {
+	var payload tasks.LlmSmsParseBatchPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal LLM SMS batch parse payload: %w", err)
+	}
+
+	job := w.markProcessing(ctx, payload.JobID)
+	w.logger.Info().Int("sms_count", len(payload.SmsIDs)).Str("user_id", payload.UserID).Msg("[sms-llm] starting batch LLM parse")
+
+	parsed, failed, skipped := 0, 0, 0
+	for i, smsID := range payload.SmsIDs {
+		if err := ctx.Err(); err != nil {
+			w.markFailed(ctx, job, err.Error())
+			return err
+		}
+		if err := w.smsLlmSvc.RunLlmParse(ctx, smsID, payload.UserID, w.logger); err != nil {
+			if errors.Is(err, user.ErrLlmCreditsExhausted) {
+				skipped += len(payload.SmsIDs) - i
+				w.logger.Warn().Int("skipped", skipped).Msg("[sms-llm] no LLM parse credits left, skipping the rest of the batch")
+				break
+			}
+			if errors.Is(err, sms.ErrSmsNotAwaitingLlm) {
+				skipped++
+				continue
+			}
+			w.logger.Error().Err(err).Str("sms_id", smsID.String()).Msg("[sms-llm] LLM parse failed")
+			failed++
+			continue
+		}
+		parsed++
+	}
+
+	resultBytes, _ := json.Marshal(map[string]int{"parsed": parsed, "failed": failed, "skipped": skipped})
+	w.markCompleted(ctx, job, string(resultBytes))
+	return nil
+}